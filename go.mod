@@ -9,3 +9,5 @@ require gopkg.in/yaml.v3 v3.0.1
 require golang.org/x/text v0.33.0
 
 require github.com/santhosh-tekuri/jsonschema/v6 v6.0.2
+
+require github.com/BurntSushi/toml v1.6.0
@@ -2,15 +2,19 @@
 package cli
 
 import (
+	"encoding/json"
 	"fmt"
+	"os"
 	"sort"
 	"strings"
 
+	"github.com/AndreyAkinshin/structyl/internal/config"
 	"github.com/AndreyAkinshin/structyl/internal/errors"
 	"github.com/AndreyAkinshin/structyl/internal/output"
 	"github.com/AndreyAkinshin/structyl/internal/project"
 	"github.com/AndreyAkinshin/structyl/internal/target"
 	"github.com/AndreyAkinshin/structyl/internal/toolchain"
+	"github.com/AndreyAkinshin/structyl/pkg/testhelper"
 )
 
 // Version is set at build time.
@@ -43,7 +47,7 @@ func Run(args []string) int {
 	case "-h", "--help", "help":
 		printUsage()
 		return 0
-	case "--version", "version":
+	case "--version":
 		fmt.Printf("structyl %s\n", Version)
 		return 0
 	}
@@ -61,6 +65,13 @@ func Run(args []string) int {
 	cmd = remaining[0]
 	cmdArgs := remaining[1:]
 
+	// Export the tag selection so reference test harnesses launched by
+	// target commands apply the same filter.
+	applyTagsToEnv(opts)
+	// Export the profile so that projects loaded by this process and mise
+	// tasks it runs use the same one.
+	applyProfileToEnv(opts)
+
 	updateChecker := NewUpdateChecker(opts.Quiet)
 	defer updateChecker.ShowNotification()
 
@@ -80,6 +91,8 @@ func Run(args []string) int {
 	// Release command
 	case "release":
 		return cmdRelease(cmdArgs, opts)
+	case "publish":
+		return cmdPublish(cmdArgs, opts)
 
 	// CI commands require explicit routing because they wrap mise tasks with pre/post
 	// validation. Custom ci:* variants (e.g., ci:custom) defined in config go through
@@ -106,6 +119,8 @@ func Run(args []string) int {
 	// Test utilities
 	case "test-summary":
 		return cmdTestSummary(cmdArgs)
+	case "tests":
+		return cmdTests(cmdArgs, opts)
 
 	// Utility commands
 	case "targets":
@@ -118,6 +133,11 @@ func Run(args []string) int {
 	case "completion":
 		updateChecker.Skip()
 		return cmdCompletion(cmdArgs)
+	case "version":
+		if len(cmdArgs) == 0 {
+			updateChecker.Skip()
+		}
+		return cmdVersion(cmdArgs, opts)
 
 	default:
 		// Unified command handling:
@@ -133,6 +153,8 @@ type GlobalOptions struct {
 	Docker     bool
 	NoDocker   bool
 	TargetType string
+	Tags       string
+	Profile    string
 	Quiet      bool
 	Verbose    bool
 }
@@ -176,6 +198,24 @@ func parseGlobalFlags(args []string) (*GlobalOptions, []string, error) {
 		case strings.HasPrefix(arg, "--type="):
 			opts.TargetType = strings.TrimPrefix(arg, "--type=")
 			i++
+		case arg == "--tags":
+			if i+1 >= len(args) {
+				return nil, nil, fmt.Errorf("--tags requires a value")
+			}
+			opts.Tags = args[i+1]
+			i += 2
+		case strings.HasPrefix(arg, "--tags="):
+			opts.Tags = strings.TrimPrefix(arg, "--tags=")
+			i++
+		case arg == "--profile":
+			if i+1 >= len(args) {
+				return nil, nil, fmt.Errorf("--profile requires a value")
+			}
+			opts.Profile = args[i+1]
+			i += 2
+		case strings.HasPrefix(arg, "--profile="):
+			opts.Profile = strings.TrimPrefix(arg, "--profile=")
+			i++
 		case arg == "--":
 			// Everything after -- is passed through
 			remaining = append(remaining, args[i:]...)
@@ -202,6 +242,59 @@ func parseGlobalFlags(args []string) (*GlobalOptions, []string, error) {
 	return opts, remaining, nil
 }
 
+// applyTagsToEnv exports --tags as STRUCTYL_TEST_TAGS for child processes.
+// The expression is normalized to its canonical form. An explicit flag
+// overrides any value already present in the environment.
+func applyTagsToEnv(opts *GlobalOptions) {
+	if opts.Tags == "" {
+		return
+	}
+	filter, err := testhelper.ParseTagFilter(opts.Tags)
+	if err != nil {
+		// Already validated by parseGlobalFlags.
+		return
+	}
+	_ = os.Setenv(testhelper.TagsEnvVar, filter.String())
+}
+
+// applyComparisonToEnv exports the tests.comparison section of cfg as
+// STRUCTYL_COMPARISON, so that harnesses built on pkg/testhelper compare with
+// the options of the selected profile and included files, whatever the
+// format of the configuration file.
+func applyComparisonToEnv(cfg *config.Config) {
+	if cfg.Tests == nil || cfg.Tests.Comparison == nil {
+		return
+	}
+	data, err := json.Marshal(cfg.Tests.Comparison)
+	if err != nil {
+		return
+	}
+	_ = os.Setenv(testhelper.ComparisonEnvVar, string(data))
+}
+
+// applyProfileToEnv exports --profile as STRUCTYL_PROFILE, and adds the
+// selected profile to MISE_ENV so that mise loads the mise.<profile>.toml
+// generated for it. An explicit flag overrides STRUCTYL_PROFILE.
+func applyProfileToEnv(opts *GlobalOptions) {
+	if opts.Profile != "" {
+		_ = os.Setenv(config.ProfileEnvVar, opts.Profile)
+	}
+	profile := os.Getenv(config.ProfileEnvVar)
+	if profile == "" {
+		return
+	}
+	envs := strings.Split(os.Getenv("MISE_ENV"), ",")
+	for _, env := range envs {
+		if strings.TrimSpace(env) == profile {
+			return
+		}
+	}
+	if existing := os.Getenv("MISE_ENV"); existing != "" {
+		profile = existing + "," + profile
+	}
+	_ = os.Setenv("MISE_ENV", profile)
+}
+
 // validateGlobalOptions checks that global options are valid.
 func validateGlobalOptions(opts *GlobalOptions) error {
 	// Validate target type
@@ -212,6 +305,13 @@ func validateGlobalOptions(opts *GlobalOptions) error {
 		}
 	}
 
+	// Validate tag selection expression
+	if opts.Tags != "" {
+		if _, err := testhelper.ParseTagFilter(opts.Tags); err != nil {
+			return fmt.Errorf("invalid --tags value: %v\n  example: structyl test --tags slow,!skip-ci", err)
+		}
+	}
+
 	// Validate mutual exclusivity of quiet and verbose
 	if opts.Quiet && opts.Verbose {
 		return fmt.Errorf("--quiet and --verbose are mutually exclusive")
@@ -235,6 +335,7 @@ func printUsage() {
 	// Try to load project for context-aware help
 	proj, _ := project.LoadProject()
 	if proj != nil {
+		output.RegisterSecrets(proj.Secrets...)
 		printProjectHelp(w, proj)
 	} else {
 		printGenericHelp(w)
@@ -283,9 +384,12 @@ func printProjectHelp(w *output.Writer, proj *project.Project) {
 	w.HelpCommand("ci", "Run CI pipeline (clean, restore, check, build, test)", 15)
 	w.HelpCommand("ci:release", "Run CI pipeline with release builds", 15)
 	w.HelpCommand("release <ver>", "Create a release (set version, commit, optionally push)", 15)
+	w.HelpCommand("publish", "Publish all targets to their package registries", 15)
+	w.HelpCommand("version bump", "Bump the project version (major, minor, patch, ...)", 15)
+	w.HelpCommand("version set", "Set the project version", 15)
 	w.HelpSubCommand("--push", "Push to remote with tags", 10)
 	w.HelpSubCommand("--dry-run", "Print what would be done", 10)
-	w.HelpSubCommand("--force", "Force release with uncommitted changes", 10)
+	w.HelpSubCommand("--force", "Release with uncommitted changes", 10)
 
 	w.HelpSection("Docker Commands:")
 	w.HelpCommand("docker-build [services]", "Build Docker images for services", 22)
@@ -301,6 +405,10 @@ func printProjectHelp(w *output.Writer, proj *project.Project) {
 	w.HelpSection("Utility Commands:")
 	w.HelpCommand("targets", "List all configured targets", 16)
 	w.HelpCommand("config validate", "Validate project configuration", 16)
+	w.HelpCommand("tests lint", "Check reference test suites", 16)
+	w.HelpCommand("tests scaffold", "Generate a reference test harness", 16)
+	w.HelpCommand("tests matrix", "Cross-language conformance report", 16)
+	w.HelpCommand("tests fuzz", "Differential fuzzing across targets", 16)
 	w.HelpCommand("upgrade", "Manage pinned CLI version", 16)
 	w.HelpCommand("completion", "Generate shell completion (bash, zsh, fish)", 16)
 	w.HelpCommand("version", "Show version information", 16)
@@ -337,6 +445,9 @@ func printGenericHelp(w *output.Writer) {
 	w.HelpCommand("ci", "Run CI pipeline (clean, restore, check, build, test)", 15)
 	w.HelpCommand("ci:release", "Run CI pipeline with release builds", 15)
 	w.HelpCommand("release <ver>", "Create a release (set version, commit, optionally push)", 15)
+	w.HelpCommand("publish", "Publish all targets to their package registries", 15)
+	w.HelpCommand("version bump", "Bump the project version (major, minor, patch, ...)", 15)
+	w.HelpCommand("version set", "Set the project version", 15)
 
 	w.HelpSection("Docker Commands:")
 	w.HelpCommand("docker-build [services]", "Build Docker images for services", 22)
@@ -352,6 +463,10 @@ func printGenericHelp(w *output.Writer) {
 	w.HelpSection("Utility Commands:")
 	w.HelpCommand("targets", "List all configured targets", 16)
 	w.HelpCommand("config validate", "Validate project configuration", 16)
+	w.HelpCommand("tests lint", "Check reference test suites", 16)
+	w.HelpCommand("tests scaffold", "Generate a reference test harness", 16)
+	w.HelpCommand("tests matrix", "Cross-language conformance report", 16)
+	w.HelpCommand("tests fuzz", "Differential fuzzing across targets", 16)
 	w.HelpCommand("upgrade", "Manage pinned CLI version", 16)
 	w.HelpCommand("completion", "Generate shell completion (bash, zsh, fish)", 16)
 	w.HelpCommand("version", "Show version information", 16)
@@ -373,11 +488,15 @@ func printGlobalFlags(w *output.Writer) {
 	w.HelpFlag("--docker", "Run in Docker container", widthFlagWithValue)
 	w.HelpFlag("--no-docker", "Disable Docker mode", widthFlagWithValue)
 	w.HelpFlag("--type=<type>", "Filter targets by type (\"language\" or \"auxiliary\")", widthFlagWithValue)
+	w.HelpFlag("--tags=<expr>", "Select reference test cases by tag (e.g. slow,!skip-ci)", widthFlagWithValue)
+	w.HelpFlag("--profile=<name>", "Apply a configuration profile (e.g. ci)", widthFlagWithValue)
 	w.HelpFlag("-h, --help", "Show this help", widthFlagWithValue)
 	w.HelpFlag("--version", "Show version", widthFlagWithValue)
 
 	w.HelpSection("Environment:")
 	w.HelpEnvVar("STRUCTYL_DOCKER=1", "Auto-enable Docker mode", 18)
+	w.HelpEnvVar("STRUCTYL_TEST_TAGS", "Tag selection seen by test harnesses (set by --tags)", 18)
+	w.HelpEnvVar("STRUCTYL_PROFILE", "Configuration profile (set by --profile)", 18)
 }
 
 func printExamplesForProject(w *output.Writer, targets []target.Target) {
@@ -26,6 +26,7 @@ import (
 	"errors"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 
@@ -34,6 +35,7 @@ import (
 	"github.com/AndreyAkinshin/structyl/internal/runner" //nolint:staticcheck // SA1019: Testing Docker error handling requires runner package
 	"github.com/AndreyAkinshin/structyl/internal/target"
 	"github.com/AndreyAkinshin/structyl/internal/testing/mocks"
+	"github.com/AndreyAkinshin/structyl/pkg/testhelper"
 )
 
 func TestParseGlobalFlags(t *testing.T) {
@@ -243,6 +245,123 @@ func TestParseGlobalFlags_TypeMissingValue(t *testing.T) {
 	}
 }
 
+func TestParseGlobalFlags_Tags(t *testing.T) {
+	// Note: subtests are NOT parallel because parseGlobalFlags calls applyVerbosityToOutput
+	// which modifies the global output writer.
+	tests := []struct {
+		name     string
+		args     []string
+		wantTags string
+		wantErr  bool
+	}{
+		{"separate value", []string{"test", "--tags", "slow,!skip-ci"}, "slow,!skip-ci", false},
+		{"equals form", []string{"--tags=slow", "test"}, "slow", false},
+		{"missing value", []string{"test", "--tags"}, "", true},
+		{"empty term", []string{"--tags=a,,b", "test"}, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, remaining, err := parseGlobalFlags(tt.args)
+			if tt.wantErr {
+				if err == nil {
+					t.Error("parseGlobalFlags() expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseGlobalFlags() error = %v", err)
+			}
+			if opts.Tags != tt.wantTags {
+				t.Errorf("Tags = %q, want %q", opts.Tags, tt.wantTags)
+			}
+			if len(remaining) != 1 || remaining[0] != "test" {
+				t.Errorf("remaining = %v, want [test]", remaining)
+			}
+		})
+	}
+}
+
+func TestApplyTagsToEnv_SetsCanonicalExpression(t *testing.T) {
+	t.Setenv(testhelper.TagsEnvVar, "stale")
+
+	applyTagsToEnv(&GlobalOptions{Tags: " !skip-ci , slow "})
+
+	if got := os.Getenv(testhelper.TagsEnvVar); got != "slow,!skip-ci" {
+		t.Errorf("%s = %q, want %q", testhelper.TagsEnvVar, got, "slow,!skip-ci")
+	}
+}
+
+func TestParseGlobalFlags_Profile(t *testing.T) {
+	// Note: subtests are NOT parallel because parseGlobalFlags calls applyVerbosityToOutput
+	// which modifies the global output writer.
+	tests := []struct {
+		name        string
+		args        []string
+		wantProfile string
+		wantErr     bool
+	}{
+		{"separate value", []string{"test", "--profile", "ci"}, "ci", false},
+		{"equals form", []string{"--profile=ci", "test"}, "ci", false},
+		{"missing value", []string{"test", "--profile"}, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, remaining, err := parseGlobalFlags(tt.args)
+			if tt.wantErr {
+				if err == nil {
+					t.Error("parseGlobalFlags() expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseGlobalFlags() error = %v", err)
+			}
+			if opts.Profile != tt.wantProfile {
+				t.Errorf("Profile = %q, want %q", opts.Profile, tt.wantProfile)
+			}
+			if len(remaining) != 1 || remaining[0] != "test" {
+				t.Errorf("remaining = %v, want [test]", remaining)
+			}
+		})
+	}
+}
+
+func TestApplyProfileToEnv(t *testing.T) {
+	tests := []struct {
+		name        string
+		flag        string
+		envProfile  string
+		miseEnv     string
+		wantProfile string
+		wantMiseEnv string
+	}{
+		{"flag", "ci", "", "", "ci", "ci"},
+		{"flag overrides environment", "ci", "local", "", "ci", "ci"},
+		{"environment", "", "ci", "", "ci", "ci"},
+		{"appended to MISE_ENV", "ci", "", "docker", "ci", "docker,ci"},
+		{"already in MISE_ENV", "ci", "", "ci, docker", "ci", "ci, docker"},
+		{"no profile", "", "", "docker", "", "docker"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(config.ProfileEnvVar, tt.envProfile)
+			t.Setenv("MISE_ENV", tt.miseEnv)
+
+			applyProfileToEnv(&GlobalOptions{Profile: tt.flag})
+
+			if got := os.Getenv(config.ProfileEnvVar); got != tt.wantProfile {
+				t.Errorf("%s = %q, want %q", config.ProfileEnvVar, got, tt.wantProfile)
+			}
+			if got := os.Getenv("MISE_ENV"); got != tt.wantMiseEnv {
+				t.Errorf("MISE_ENV = %q, want %q", got, tt.wantMiseEnv)
+			}
+		})
+	}
+}
+
 func TestParseGlobalFlags_UnknownFlagsPassThrough(t *testing.T) {
 	// Unknown flags are passed through to commands (not rejected at global level)
 	// This allows command-specific flags like "build --release"
@@ -666,9 +785,9 @@ func TestCmdConfig_UnknownSubcommand_ReturnsError(t *testing.T) {
 func TestCmdConfigValidate_ValidProject(t *testing.T) {
 	root := createTestProject(t)
 	withWorkingDir(t, root, func() {
-		exitCode := cmdConfigValidate()
+		exitCode := cmdConfigValidate(nil)
 		if exitCode != 0 {
-			t.Errorf("cmdConfigValidate() = %d, want 0", exitCode)
+			t.Errorf("cmdConfigValidate(nil) = %d, want 0", exitCode)
 		}
 	})
 }
@@ -676,9 +795,27 @@ func TestCmdConfigValidate_ValidProject(t *testing.T) {
 func TestCmdConfigValidate_InvalidProject(t *testing.T) {
 	tmpDir := t.TempDir()
 	withWorkingDir(t, tmpDir, func() {
-		exitCode := cmdConfigValidate()
+		exitCode := cmdConfigValidate(nil)
 		if exitCode == 0 {
-			t.Error("cmdConfigValidate() = 0, want non-zero when no project")
+			t.Error("cmdConfigValidate(nil) = 0, want non-zero when no project")
+		}
+	})
+}
+
+func TestCmdConfigShow(t *testing.T) {
+	root := createTestProject(t)
+	withWorkingDir(t, root, func() {
+		t.Setenv(config.ProfileEnvVar, "")
+		if exitCode := cmdConfigShow(nil); exitCode != 0 {
+			t.Errorf("cmdConfigShow() = %d, want 0", exitCode)
+		}
+		if exitCode := cmdConfigShow([]string{"extra"}); exitCode != 2 {
+			t.Errorf("cmdConfigShow([extra]) = %d, want 2", exitCode)
+		}
+
+		t.Setenv(config.ProfileEnvVar, "missing")
+		if exitCode := cmdConfigShow(nil); exitCode == 0 {
+			t.Error("cmdConfigShow() = 0, want non-zero for an unknown profile")
 		}
 	})
 }
@@ -908,6 +1045,39 @@ func TestCmdInit_CreatesVersionFile(t *testing.T) {
 	})
 }
 
+func TestCmdInit_RegistersVersionFilePresets(t *testing.T) {
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(root, "rs"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	cargo := "[package]\nname = \"demo\"\nversion = \"0.3.0\"\n"
+	if err := os.WriteFile(filepath.Join(root, "rs", "Cargo.toml"), []byte(cargo), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	withWorkingDir(t, root, func() {
+		if code := cmdInit([]string{"--yes"}); code != 0 {
+			t.Fatalf("cmdInit() = %d, want 0", code)
+		}
+	})
+
+	cfg, err := config.Load(filepath.Join(root, ".structyl", "config.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []config.VersionFileConfig{{Path: "rs/Cargo.toml", Key: "package.version"}}
+	if cfg.Version == nil || !reflect.DeepEqual(cfg.Version.Files, want) {
+		t.Errorf("version config = %+v, want files %+v", cfg.Version, want)
+	}
+	content, err := os.ReadFile(filepath.Join(root, ".structyl", "PROJECT_VERSION"))
+	if err != nil || string(content) != "0.3.0\n" {
+		t.Errorf("PROJECT_VERSION = %q, %v; want the version from Cargo.toml", content, err)
+	}
+}
+
 func TestCmdInit_CreatesTestsDirectory(t *testing.T) {
 	tmpDir := t.TempDir()
 
@@ -1565,6 +1735,30 @@ func TestCmdRelease_MissingVersion_ReturnsError(t *testing.T) {
 	})
 }
 
+func TestCmdRelease_AutoWithVersion_ReturnsError(t *testing.T) {
+	root := createTestProject(t)
+	withWorkingDir(t, root, func() {
+		exitCode := cmdRelease([]string{"1.0.0", "--auto"}, &GlobalOptions{})
+		if exitCode != 2 {
+			t.Errorf("cmdRelease(1.0.0 --auto) = %d, want 2 (usage error)", exitCode)
+		}
+	})
+}
+
+func TestCmdRelease_InvalidPositionalArgs_ReturnsError(t *testing.T) {
+	root := createTestProject(t)
+	withWorkingDir(t, root, func() {
+		for _, args := range [][]string{
+			{"rs", "1.0.0", "extra"},
+			{"rs", "1.0.0", "--auto"},
+		} {
+			if exitCode := cmdRelease(args, &GlobalOptions{}); exitCode != 2 {
+				t.Errorf("cmdRelease(%v) = %d, want 2 (usage error)", args, exitCode)
+			}
+		}
+	})
+}
+
 func TestCmdRelease_NoProject_ReturnsError(t *testing.T) {
 	tmpDir := t.TempDir()
 	withWorkingDir(t, tmpDir, func() {
@@ -1587,7 +1781,8 @@ func TestCmdRelease_ParsesFlags(t *testing.T) {
 			{"version only", []string{"1.0.0", "--dry-run"}},
 			{"with push", []string{"1.0.0", "--dry-run", "--push"}},
 			{"with force", []string{"1.0.0", "--dry-run", "--force"}},
-			{"all flags", []string{"1.0.0", "--dry-run", "--push", "--force"}},
+			{"with ci", []string{"1.0.0", "--dry-run", "--ci"}},
+			{"all flags", []string{"1.0.0", "--dry-run", "--push", "--ci", "--force"}},
 			{"flags before version", []string{"--dry-run", "1.0.0"}},
 		}
 
@@ -1605,6 +1800,20 @@ func TestCmdRelease_ParsesFlags(t *testing.T) {
 	})
 }
 
+func TestReleaseCI(t *testing.T) {
+	t.Parallel()
+	if releaseCI(&config.Config{}, false, &GlobalOptions{}) != nil {
+		t.Error("releaseCI() without --ci or release.require_ci should be nil")
+	}
+	if releaseCI(&config.Config{}, true, &GlobalOptions{}) == nil {
+		t.Error("releaseCI() with --ci should not be nil")
+	}
+	required := &config.Config{Release: &config.ReleaseConfig{RequireCI: true}}
+	if releaseCI(required, false, &GlobalOptions{}) == nil {
+		t.Error("releaseCI() with release.require_ci should not be nil")
+	}
+}
+
 func TestCmdRelease_InvalidVersion_ReturnsError(t *testing.T) {
 	root := createTestProject(t)
 	withWorkingDir(t, root, func() {
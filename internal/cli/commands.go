@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"golang.org/x/text/cases"
@@ -22,9 +23,9 @@ import (
 	// functionality (DockerRunner, DockerUnavailableError, CheckDockerAvailable). These types
 	// are used by docker-build/docker-clean commands and will be removed with the runner package.
 	"github.com/AndreyAkinshin/structyl/internal/runner"
-	"github.com/AndreyAkinshin/structyl/internal/schema"
 	"github.com/AndreyAkinshin/structyl/internal/target"
 	"github.com/AndreyAkinshin/structyl/internal/toolchain"
+	"github.com/AndreyAkinshin/structyl/internal/version"
 )
 
 var out = output.New()
@@ -34,7 +35,7 @@ var out = output.New()
 const (
 	widthFlagShort      = 10 // longest: "-h, --help" (10 chars)
 	widthArgPlaceholder = 12 // longest: "[services]" (10 chars) + 2 padding
-	widthFlagWithValue  = 14 // longest: "--type=<type>" (13 chars) + 1 padding
+	widthFlagWithValue  = 16 // longest: "--profile=<name>" (16 chars)
 	widthSubcommand     = 6  // longest: "sync" (4 chars) + 2 padding
 )
 
@@ -46,6 +47,8 @@ func applyVerbosityToOutput(opts *GlobalOptions) {
 
 // loadProject loads the project configuration and handles errors uniformly.
 // Returns the project and exit code 0 on success, or nil and appropriate exit code on failure.
+// The comparison options of the project are exported for the test harnesses it runs,
+// and its secret values are registered for redaction from output.
 // Exit codes: 1 for runtime errors, 2 for config errors (per errors package specification).
 func loadProject() (*project.Project, int) {
 	proj, err := project.LoadProject()
@@ -53,6 +56,8 @@ func loadProject() (*project.Project, int) {
 		out.ErrorPrefix("%v", err)
 		return nil, internalerrors.GetExitCode(err)
 	}
+	applyComparisonToEnv(proj.Config)
+	output.RegisterSecrets(proj.Secrets...)
 	return proj, 0
 }
 
@@ -120,8 +125,10 @@ func ensureMiseConfig(proj *project.Project, mode MiseRegenerateMode) error {
 }
 
 // writeMiseConfig writes the mise.toml file from project configuration.
+// It is generated without the selected profile: each profile has its own
+// mise.<profile>.toml, which mise loads through MISE_ENV.
 func writeMiseConfig(proj *project.Project) error {
-	_, err := mise.WriteMiseTomlWithToolchains(proj.Root, proj.Config, proj.Toolchains, mise.WriteAlways)
+	_, err := mise.WriteMiseTomlWithToolchains(proj.Root, proj.BaseConfig, proj.Toolchains, mise.WriteAlways)
 	if err != nil {
 		return fmt.Errorf("failed to generate mise.toml: %w", err)
 	}
@@ -362,13 +369,21 @@ func printTargetsJSON(targets []target.Target) int {
 // cmdConfig handles configuration utilities.
 func cmdConfig(args []string) int {
 	if len(args) == 0 {
-		out.ErrorPrefix("config: subcommand required (validate)")
+		out.ErrorPrefix("config: subcommand required (validate, show, convert, migrate, schema)")
 		return internalerrors.ExitConfigError
 	}
 
 	switch args[0] {
 	case "validate":
-		return cmdConfigValidate()
+		return cmdConfigValidate(args[1:])
+	case "show":
+		return cmdConfigShow(args[1:])
+	case "convert":
+		return cmdConfigConvert(args[1:])
+	case "migrate":
+		return cmdConfigMigrate(args[1:])
+	case "schema":
+		return cmdConfigSchema(args[1:])
 	case "-h", "--help":
 		printConfigUsage()
 		return 0
@@ -378,57 +393,6 @@ func cmdConfig(args []string) int {
 	}
 }
 
-func cmdConfigValidate() int {
-	proj, exitCode := loadProject()
-	if proj == nil {
-		return exitCode
-	}
-
-	// Run JSON Schema validation on raw config file.
-	// LoadProject performs Go struct parsing and semantic validation,
-	// but schema validation catches additional issues like type mismatches
-	// and constraint violations defined in the JSON Schema.
-	configPath := proj.ConfigPath()
-	configData, err := os.ReadFile(configPath)
-	if err != nil {
-		out.ErrorPrefix("failed to read config for schema validation: %v", err)
-		return internalerrors.ExitConfigError
-	}
-
-	if err := schema.ValidateConfig(configData); err != nil {
-		out.ErrorPrefix("schema validation failed: %v", err)
-		return internalerrors.ExitConfigError
-	}
-
-	printProjectWarnings(proj)
-
-	// Validate registry creation
-	registry, err := target.NewRegistry(proj.Config, proj.Root)
-	if err != nil {
-		out.ErrorPrefix("%v", err)
-		return internalerrors.ExitConfigError
-	}
-
-	// Count targets by type
-	targets := registry.All()
-	var langCount, auxCount int
-	for _, t := range targets {
-		if t.Type() == target.TypeLanguage {
-			langCount++
-		} else {
-			auxCount++
-		}
-	}
-
-	out.ValidationSuccess("Configuration is valid.")
-	out.SummaryItem("Project", proj.Config.Project.Name)
-	out.SummaryItem("Targets", fmt.Sprintf("%d (%d language, %d auxiliary)", len(targets), langCount, auxCount))
-	if len(proj.Warnings) > 0 {
-		out.SummaryItem("Warnings", fmt.Sprintf("%d", len(proj.Warnings)))
-	}
-	return 0
-}
-
 // cmdCI runs the CI pipeline.
 func cmdCI(cmd string, args []string, opts *GlobalOptions) int {
 	if wantsHelp(args) {
@@ -455,6 +419,22 @@ func cmdCI(cmd string, args []string, opts *GlobalOptions) int {
 		targetName, passthruArgs = extractTargetArg(args, registry)
 	}
 
+	// Version drift fails CI before any target runs, rather than at release time.
+	// With a git:tag source, untagged commits have dev versions that version
+	// files are not expected to match, so there is nothing to check.
+	if proj.Config.Version != nil && len(proj.Config.Version.Files) > 0 && !config.IsGitTagSource(proj.Config.Version) {
+		current, statuses, code := checkProjectVersion(proj, cmd)
+		if code != 0 {
+			return code
+		}
+		if n := countInconsistent(statuses); n > 0 {
+			printVersionCheck(proj, current, statuses)
+			out.ErrorPrefix("%s: %d file(s) do not match version %s", cmd, n, current)
+			out.Hint("Run 'structyl version check --fix' to update them.")
+			return internalerrors.ExitRuntimeError
+		}
+	}
+
 	// Ensure mise is ready (installed and mise.toml up-to-date)
 	if code := ensureMiseReady(proj); code != 0 {
 		return code
@@ -537,7 +517,7 @@ func cmdMiseSync(args []string, opts *GlobalOptions) int {
 	}
 
 	// Generate mise.toml using loaded toolchains (always regenerates)
-	created, err := mise.WriteMiseTomlWithToolchains(proj.Root, proj.Config, proj.Toolchains, mise.WriteAlways)
+	created, err := mise.WriteMiseTomlWithToolchains(proj.Root, proj.BaseConfig, proj.Toolchains, mise.WriteAlways)
 	if err != nil {
 		out.ErrorPrefix("mise sync: %v", err)
 		return internalerrors.ExitRuntimeError
@@ -642,10 +622,14 @@ func cmdRelease(args []string, opts *GlobalOptions) int {
 		printReleaseUsage()
 		return 0
 	}
+	if len(args) > 0 && args[0] == "notes" {
+		return cmdReleaseNotes(args[1:])
+	}
 
 	// Parse release-specific flags
 	releaseOpts := release.Options{}
 	var remaining []string
+	var runCI bool
 
 	for _, arg := range args {
 		switch arg {
@@ -655,25 +639,45 @@ func cmdRelease(args []string, opts *GlobalOptions) int {
 			releaseOpts.DryRun = true
 		case "--force":
 			releaseOpts.Force = true
+		case "--auto":
+			releaseOpts.Auto = true
+		case "--ci":
+			runCI = true
 		default:
 			remaining = append(remaining, arg)
 		}
 	}
 
-	if len(remaining) == 0 {
+	// Positional arguments are [target] <version>, or [target] with --auto.
+	switch {
+	case releaseOpts.Auto && len(remaining) == 1 && version.Validate(remaining[0]) != nil:
+		releaseOpts.Target = remaining[0]
+	case releaseOpts.Auto && len(remaining) > 0:
+		out.ErrorPrefix("release: --auto computes the version; do not pass %q", remaining[len(remaining)-1])
+		return internalerrors.ExitConfigError
+	case releaseOpts.Auto:
+	case len(remaining) == 0:
 		out.ErrorPrefix("release: version required")
-		out.Errorln("usage: structyl release <version> [--push] [--dry-run] [--force]")
+		out.Errorln("usage: structyl release [target] <version>|--auto [--push] [--ci] [--dry-run] [--force]")
+		return internalerrors.ExitConfigError
+	case len(remaining) == 1:
+		releaseOpts.Version = remaining[0]
+	case len(remaining) == 2:
+		releaseOpts.Target, releaseOpts.Version = remaining[0], remaining[1]
+	default:
+		out.ErrorPrefix("release: unexpected argument %q", remaining[2])
 		return internalerrors.ExitConfigError
 	}
 
-	releaseOpts.Version = remaining[0]
-
 	proj, exitCode := loadProject()
 	if proj == nil {
 		return exitCode
 	}
 
+	releaseOpts.CI = releaseCI(proj.Config, runCI, opts)
+
 	releaser := release.NewReleaser(proj.Root, proj.Config)
+	releaser.SetRedactedConfig(proj.Redacted)
 
 	ctx := context.Background()
 	if err := releaser.Release(ctx, releaseOpts); err != nil {
@@ -684,6 +688,132 @@ func cmdRelease(args []string, opts *GlobalOptions) int {
 	return 0
 }
 
+// cmdReleaseNotes renders the release notes of the current version to a file.
+func cmdReleaseNotes(args []string) int {
+	if wantsHelp(args) {
+		printReleaseNotesUsage()
+		return 0
+	}
+
+	var targetName, outputPath string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--output" || arg == "-o":
+			if i+1 >= len(args) {
+				out.ErrorPrefix("release notes: %s requires a path", arg)
+				return internalerrors.ExitConfigError
+			}
+			i++
+			outputPath = args[i]
+		case strings.HasPrefix(arg, "--output="):
+			outputPath = strings.TrimPrefix(arg, "--output=")
+		case strings.HasPrefix(arg, "-") && arg != "-":
+			out.ErrorPrefix("release notes: unknown option %q", arg)
+			return internalerrors.ExitConfigError
+		case targetName == "":
+			targetName = arg
+		default:
+			out.ErrorPrefix("release notes: unexpected argument %q", arg)
+			return internalerrors.ExitConfigError
+		}
+	}
+
+	proj, exitCode := loadProject()
+	if proj == nil {
+		return exitCode
+	}
+
+	releaser := release.NewReleaser(proj.Root, proj.Config)
+	notes, err := releaser.Notes(context.Background(), targetName)
+	if err != nil {
+		out.ErrorPrefix("release notes: %v", err)
+		return internalerrors.ExitRuntimeError
+	}
+
+	if outputPath == "-" {
+		fmt.Print(notes)
+		return 0
+	}
+	if outputPath == "" {
+		outputPath = filepath.Join(proj.Root, release.ReleaseNotesFileName)
+	}
+	if err := os.WriteFile(outputPath, []byte(notes), 0644); err != nil {
+		out.ErrorPrefix("release notes: %v", err)
+		return internalerrors.ExitRuntimeError
+	}
+	out.Success("Wrote %s", outputPath)
+	return 0
+}
+
+// cmdPublish publishes targets to their package registries.
+func cmdPublish(args []string, opts *GlobalOptions) int {
+	if wantsHelp(args) {
+		printPublishUsage()
+		return 0
+	}
+
+	publishOpts := release.PublishOptions{}
+	for _, arg := range args {
+		switch arg {
+		case "--dry-run":
+			publishOpts.DryRun = true
+		case "--resume":
+			publishOpts.Resume = true
+		default:
+			if strings.HasPrefix(arg, "-") {
+				out.ErrorPrefix("publish: unknown option %q", arg)
+				return internalerrors.ExitConfigError
+			}
+			publishOpts.Targets = append(publishOpts.Targets, arg)
+		}
+	}
+
+	proj, registry, exitCode := loadProjectWithRegistry()
+	if proj == nil {
+		return exitCode
+	}
+	printProjectWarnings(proj)
+
+	for _, name := range publishOpts.Targets {
+		if _, ok := registry.Get(name); !ok {
+			out.ErrorPrefix("publish: unknown target %q", name)
+			return internalerrors.ExitConfigError
+		}
+	}
+
+	if code := ensureMiseReady(proj); code != 0 {
+		return code
+	}
+	publishOpts.Run = func(_ context.Context, targetName, command string) error {
+		if code := runViaMise(proj, command, targetName, nil, opts, nil); code != 0 {
+			return fmt.Errorf("exit code %d", code)
+		}
+		return nil
+	}
+
+	publisher := release.NewPublisher(proj.Root, proj.Config, registry)
+	if err := publisher.Publish(context.Background(), publishOpts); err != nil {
+		out.ErrorPrefix("publish: %v", err)
+		return internalerrors.ExitRuntimeError
+	}
+	return 0
+}
+
+// releaseCI returns the CI gate of a release: structyl ci if requested with
+// --ci or required by release.require_ci, otherwise nil.
+func releaseCI(cfg *config.Config, requested bool, opts *GlobalOptions) func(context.Context) error {
+	if !requested && (cfg.Release == nil || !cfg.Release.RequireCI) {
+		return nil
+	}
+	return func(context.Context) error {
+		if code := cmdCI("ci", nil, opts); code != 0 {
+			return fmt.Errorf("structyl ci failed with exit code %d", code)
+		}
+		return nil
+	}
+}
+
 // printUnifiedUsage prints the help text for unified commands (build, test, etc.).
 func printUnifiedUsage(cmd string) {
 	defaults := toolchain.GetDefaultToolchains()
@@ -729,25 +859,106 @@ func printReleaseUsage() {
 	out.HelpTitle("structyl release - create a release")
 
 	out.HelpSection("Usage:")
-	out.HelpUsage("structyl release <version> [options]")
+	out.HelpUsage("structyl release [target] <version> [options]")
+	out.HelpUsage("structyl release [target] --auto [options]")
+	out.HelpUsage("structyl release notes [target] [--output <path>]")
 
 	out.HelpSection("Description:")
 	out.Println("  Creates a release by setting the version across all targets,")
 	out.Println("  committing the changes, and optionally pushing to the remote.")
+	out.Println("")
+	out.Println("  With --auto, the version is bumped from the Conventional Commits made")
+	out.Println("  since the last release tag (breaking → major, feat → minor,")
+	out.Println("  fix/perf → patch), and a section is prepended to CHANGELOG.md.")
+	out.Println("")
+	out.Println("  With a target that has its own version configuration, only that target")
+	out.Println("  is released: its version files and the manifests of targets depending")
+	out.Println("  on it are updated, and its own tag format is used.")
+	out.Println("")
+	out.Println("  Before changing anything, the release checks that the working tree is")
+	out.Println("  clean, that HEAD contains the release branch, that the version is")
+	out.Println("  greater than the current one, and that its tags do not exist yet. If a")
+	out.Println("  local step fails, created files, commits, and tags are rolled back.")
+	out.Println("")
+	out.Println("  The commit message and tag messages are rendered from release.commit_message")
+	out.Println("  and release.notes. 'structyl release notes' writes the notes of the current")
+	out.Println("  version to RELEASE_NOTES.md for attaching to a forge release.")
 
 	out.HelpSection("Arguments:")
+	out.HelpFlag("[target]", "Independently versioned target to release (optional)", widthFlagShort)
 	out.HelpFlag("<version>", "Semantic version (X.Y.Z or X.Y.Z-prerelease)", widthFlagShort)
 
 	out.HelpSection("Options:")
+	out.HelpFlag("--auto", "Derive the version from commits and update CHANGELOG.md", widthFlagShort)
 	out.HelpFlag("--push", "Push to remote with tags after commit", widthFlagShort)
 	out.HelpFlag("--dry-run", "Print what would be done without making changes", widthFlagShort)
-	out.HelpFlag("--force", "Force release with uncommitted changes", widthFlagShort)
+	out.HelpFlag("--ci", "Run structyl ci before releasing; abort if it fails", widthFlagShort)
+	out.HelpFlag("--force", "Release with uncommitted changes", widthFlagShort)
 	out.HelpFlag("-h, --help", "Show this help", widthFlagShort)
 
 	out.HelpSection("Examples:")
 	out.HelpExample("structyl release 1.2.3", "Create release 1.2.3")
 	out.HelpExample("structyl release 1.2.3 --push", "Create and push release 1.2.3")
 	out.HelpExample("structyl release 1.2.3 --dry-run", "Preview release without changes")
+	out.HelpExample("structyl release --auto --dry-run", "Preview the next version and changelog")
+	out.HelpExample("structyl release rs 0.4.0", "Release only the rs target")
+	out.HelpExample("structyl release notes", "Write RELEASE_NOTES.md for the current version")
+	out.Println("")
+}
+
+// printReleaseNotesUsage prints the help text for the release notes command.
+func printReleaseNotesUsage() {
+	out.HelpTitle("structyl release notes - render the release notes")
+
+	out.HelpSection("Usage:")
+	out.HelpUsage("structyl release notes [target] [options]")
+
+	out.HelpSection("Description:")
+	out.Println("  Renders the release.notes template for the current version. If the")
+	out.Println("  version is tagged, the notes cover the commits since the previous")
+	out.Println("  release tag up to that tag; otherwise, the commits since the last tag.")
+
+	out.HelpSection("Arguments:")
+	out.HelpFlag("[target]", "Independently versioned target (optional)", widthFlagShort)
+
+	out.HelpSection("Options:")
+	out.HelpFlag("-o, --output", "Output path, or - for stdout (default: RELEASE_NOTES.md)", widthFlagShort)
+	out.HelpFlag("-h, --help", "Show this help", widthFlagShort)
+
+	out.HelpSection("Examples:")
+	out.HelpExample("structyl release notes", "Write RELEASE_NOTES.md in the project root")
+	out.HelpExample("structyl release notes --output -", "Print the notes")
+	out.Println("")
+}
+
+// printPublishUsage prints the help text for the publish command.
+func printPublishUsage() {
+	out.HelpTitle("structyl publish - publish targets to their package registries")
+
+	out.HelpSection("Usage:")
+	out.HelpUsage("structyl publish [target...] [options]")
+
+	out.HelpSection("Description:")
+	out.Println("  Publishes a release. HEAD must carry the release tag of every published")
+	out.Println("  target. publish:dry runs for every target first; if any fails, nothing")
+	out.Println("  is published. Targets are then published in depends_on order.")
+	out.Println("")
+	out.Println("  Published targets are recorded, so after a failure --resume publishes")
+	out.Println("  only the remaining targets.")
+
+	out.HelpSection("Arguments:")
+	out.HelpFlag("[target...]", "Targets to publish (default: all with a publish command)", widthFlagShort)
+
+	out.HelpSection("Options:")
+	out.HelpFlag("--dry-run", "Run publish:dry only", widthFlagShort)
+	out.HelpFlag("--resume", "Publish only the targets not yet published from HEAD", widthFlagShort)
+	out.HelpFlag("-h, --help", "Show this help", widthFlagShort)
+
+	out.HelpSection("Examples:")
+	out.HelpExample("structyl publish", "Publish all targets")
+	out.HelpExample("structyl publish --dry-run", "Check that all targets can be published")
+	out.HelpExample("structyl publish --resume", "Publish the targets left after a failure")
+	out.HelpExample("structyl publish rs", "Publish only the rs target")
 	out.Println("")
 }
 
@@ -797,15 +1008,48 @@ func printConfigUsage() {
 
 	out.HelpSection("Usage:")
 	out.HelpUsage("structyl config <subcommand>")
+	out.HelpUsage("structyl config validate [--json]")
+	out.HelpUsage("structyl config show [target] [--resolved] [--json]")
+	out.HelpUsage("structyl config convert --to <format> [--stdout]")
+	out.HelpUsage("structyl config migrate [--dry-run] [--force]")
+	out.HelpUsage("structyl config schema [--toolchains]")
 
 	out.HelpSection("Subcommands:")
 	out.HelpCommand("validate", "Validate the project configuration", widthFlagShort)
+	out.HelpCommand("show", "Print the resolved configuration as JSON", widthFlagShort)
+	out.HelpCommand("convert", "Rewrite the configuration file in another format", widthFlagShort)
+	out.HelpCommand("migrate", "Rewrite the configuration to the current schema version", widthFlagShort)
+	out.HelpCommand("schema", "Print the JSON Schema of the configuration", widthFlagShort)
+
+	out.HelpSection("Validate Options:")
+	out.HelpFlag("--json", "Output the issues as JSON", widthFlagShort)
+
+	out.HelpSection("Show Options:")
+	out.HelpFlag("--resolved", "Show where each value comes from and the effective commands", widthFlagShort)
+	out.HelpFlag("--json", "With --resolved, output as JSON", widthFlagShort)
+
+	out.HelpSection("Convert Options:")
+	out.HelpFlag("--to <format>", "Target format: json, jsonc, yaml, or toml", widthFlagWithValue)
+	out.HelpFlag("--stdout", "Print the converted file instead of writing it", widthFlagWithValue)
+
+	out.HelpSection("Migrate Options:")
+	out.HelpFlag("--dry-run", "Print the changes without writing them", widthFlagShort)
+	out.HelpFlag("--force", "Rewrite files even if their comments are dropped", widthFlagShort)
+
+	out.HelpSection("Schema Options:")
+	out.HelpFlag("--toolchains", "Print the schema of toolchains.json instead", widthFlagShort)
 
 	out.HelpSection("Options:")
 	out.HelpFlag("-h, --help", "Show this help", widthFlagShort)
 
 	out.HelpSection("Examples:")
 	out.HelpExample("structyl config validate", "Validate project configuration")
+	out.HelpExample("structyl config validate --json", "Report configuration issues as JSON")
+	out.HelpExample("structyl config show --profile ci", "Show the configuration with the ci profile")
+	out.HelpExample("structyl config show rs --resolved", "Show the values and commands of rs with their sources")
+	out.HelpExample("structyl config convert --to yaml", "Replace config.json with config.yaml")
+	out.HelpExample("structyl config migrate --dry-run", "Show what migrating the configuration would change")
+	out.HelpExample("structyl config schema > config.schema.json", "Save the configuration schema for an editor")
 	out.Println("")
 }
 
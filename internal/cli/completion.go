@@ -103,6 +103,7 @@ func builtinCommands() []string {
 		"ci",
 		"ci:release",
 		"release",
+		"publish",
 		"docker-build",
 		"docker-clean",
 		"dockerfile",
@@ -110,6 +111,7 @@ func builtinCommands() []string {
 		"mise",
 		"targets",
 		"config",
+		"tests",
 		"upgrade",
 		"version",
 		"help",
@@ -138,6 +140,8 @@ func globalFlags() []string {
 		"--docker",
 		"--no-docker",
 		"--type",
+		"--tags",
+		"--profile",
 		"--help",
 		"--version",
 	}
@@ -175,7 +179,9 @@ func generateBashCompletion(cmdName string) string {
 
     local commands="%s"
     local flags="%s"
-    local config_subcommands="validate"
+    local config_subcommands="validate show convert migrate schema"
+    local tests_subcommands="lint scaffold matrix fuzz"
+    local version_subcommands="bump set check"
     local completion_shells="bash zsh fish"
 
     case "${prev}" in
@@ -187,6 +193,18 @@ func generateBashCompletion(cmdName string) string {
             COMPREPLY=($(compgen -W "${config_subcommands}" -- "${cur}"))
             return
             ;;
+        tests)
+            COMPREPLY=($(compgen -W "${tests_subcommands}" -- "${cur}"))
+            return
+            ;;
+        version)
+            COMPREPLY=($(compgen -W "${version_subcommands}" -- "${cur}"))
+            return
+            ;;
+        bump)
+            COMPREPLY=($(compgen -W "major minor patch prerelease release" -- "${cur}"))
+            return
+            ;;
         completion)
             COMPREPLY=($(compgen -W "${completion_shells}" -- "${cur}"))
             return
@@ -241,13 +259,14 @@ func generateZshCompletion(cmdName string) string {
 # Add to ~/.zshrc: eval "$(structyl completion zsh)"
 %s
 %s() {
-    local -a commands flags target_commands config_subcommands completion_shells
+    local -a commands flags target_commands config_subcommands tests_subcommands version_subcommands completion_shells
 
     commands=(
         'init:Initialize a new structyl project'
         'ci:Run CI pipeline'
         'ci\:release:Run CI pipeline with release builds'
         'release:Create a release'
+        'publish:Publish targets to package registries'
         'docker-build:Build Docker images'
         'docker-clean:Remove Docker containers and images'
         'dockerfile:Generate Dockerfiles with mise'
@@ -255,6 +274,7 @@ func generateZshCompletion(cmdName string) string {
         'mise:Mise integration commands'
         'targets:List all configured targets'
         'config:Configuration utilities'
+        'tests:Reference test suite utilities'
         'upgrade:Manage pinned CLI version'
         'version:Show version information'
         'help:Show help'
@@ -282,12 +302,31 @@ func generateZshCompletion(cmdName string) string {
         '--docker[Run in Docker container]'
         '--no-docker[Disable Docker mode]'
         '--type=[Filter targets by type]:type:(language auxiliary)'
+        '--tags=[Select reference test cases by tag]:expression:'
+        '--profile=[Apply a configuration profile]:profile:'
         '--help[Show help]'
         '--version[Show version]'
     )
 
     config_subcommands=(
         'validate:Validate configuration'
+        'show:Show the resolved configuration'
+        'convert:Convert the configuration file to another format'
+        'migrate:Migrate the configuration to the current schema version'
+        'schema:Print the JSON Schema of the configuration'
+    )
+
+    tests_subcommands=(
+        'lint:Check reference test suites'
+        'scaffold:Generate a reference test harness'
+        'matrix:Cross-language conformance report'
+        'fuzz:Differential fuzzing across targets'
+    )
+
+    version_subcommands=(
+        'bump:Bump the project version'
+        'set:Set the project version'
+        'check:Check version files for drift'
     )
 
     completion_shells=(
@@ -319,6 +358,16 @@ func generateZshCompletion(cmdName string) string {
         config)
             _describe -t config-subcommands 'config subcommand' config_subcommands
             ;;
+        tests)
+            _describe -t tests-subcommands 'tests subcommand' tests_subcommands
+            ;;
+        version)
+            if (( cur_pos == 2 )); then
+                _describe -t version-subcommands 'version subcommand' version_subcommands
+            elif [[ "${words[3]}" == bump ]] && (( cur_pos == 3 )); then
+                compadd major minor patch prerelease release
+            fi
+            ;;
         completion)
             _describe -t shells 'shell' completion_shells
             ;;
@@ -372,6 +421,7 @@ complete -c %s -f
 		"ci":           "Run CI pipeline",
 		"ci:release":   "Run CI pipeline with release builds",
 		"release":      "Create a release",
+		"publish":      "Publish targets to package registries",
 		"docker-build": "Build Docker images",
 		"docker-clean": "Remove Docker containers and images",
 		"dockerfile":   "Generate Dockerfiles with mise",
@@ -379,6 +429,7 @@ complete -c %s -f
 		"mise":         "Mise integration commands",
 		"targets":      "List all configured targets",
 		"config":       "Configuration utilities",
+		"tests":        "Reference test suite utilities",
 		"upgrade":      "Manage pinned CLI version",
 		"version":      "Show version information",
 		"help":         "Show help",
@@ -403,11 +454,29 @@ complete -c %s -f
 	sb.WriteString(fmt.Sprintf("complete -c %s -l docker -d 'Run in Docker container'\n", cmdName))
 	sb.WriteString(fmt.Sprintf("complete -c %s -l no-docker -d 'Disable Docker mode'\n", cmdName))
 	sb.WriteString(fmt.Sprintf("complete -c %s -l type -d 'Filter targets by type' -xa 'language auxiliary'\n", cmdName))
+	sb.WriteString(fmt.Sprintf("complete -c %s -l tags -d 'Select reference test cases by tag' -x\n", cmdName))
+	sb.WriteString(fmt.Sprintf("complete -c %s -l profile -d 'Apply a configuration profile' -x\n", cmdName))
 	sb.WriteString(fmt.Sprintf("complete -c %s -l help -d 'Show help'\n", cmdName))
 	sb.WriteString(fmt.Sprintf("complete -c %s -l version -d 'Show version'\n", cmdName))
 
 	sb.WriteString("\n# config subcommands\n")
 	sb.WriteString(fmt.Sprintf("complete -c %s -n '__fish_seen_subcommand_from config' -a 'validate' -d 'Validate configuration'\n", cmdName))
+	sb.WriteString(fmt.Sprintf("complete -c %s -n '__fish_seen_subcommand_from config' -a 'show' -d 'Show the resolved configuration'\n", cmdName))
+	sb.WriteString(fmt.Sprintf("complete -c %s -n '__fish_seen_subcommand_from config' -a 'convert' -d 'Convert the configuration file to another format'\n", cmdName))
+	sb.WriteString(fmt.Sprintf("complete -c %s -n '__fish_seen_subcommand_from config' -a 'migrate' -d 'Migrate the configuration to the current schema version'\n", cmdName))
+	sb.WriteString(fmt.Sprintf("complete -c %s -n '__fish_seen_subcommand_from config' -a 'schema' -d 'Print the JSON Schema of the configuration'\n", cmdName))
+
+	sb.WriteString("\n# tests subcommands\n")
+	sb.WriteString(fmt.Sprintf("complete -c %s -n '__fish_seen_subcommand_from tests' -a 'lint' -d 'Check reference test suites'\n", cmdName))
+	sb.WriteString(fmt.Sprintf("complete -c %s -n '__fish_seen_subcommand_from tests' -a 'scaffold' -d 'Generate a reference test harness'\n", cmdName))
+	sb.WriteString(fmt.Sprintf("complete -c %s -n '__fish_seen_subcommand_from tests' -a 'matrix' -d 'Cross-language conformance report'\n", cmdName))
+	sb.WriteString(fmt.Sprintf("complete -c %s -n '__fish_seen_subcommand_from tests' -a 'fuzz' -d 'Differential fuzzing across targets'\n", cmdName))
+
+	sb.WriteString("\n# version subcommands\n")
+	sb.WriteString(fmt.Sprintf("complete -c %s -n '__fish_seen_subcommand_from version; and not __fish_seen_subcommand_from bump set check' -a 'bump' -d 'Bump the project version'\n", cmdName))
+	sb.WriteString(fmt.Sprintf("complete -c %s -n '__fish_seen_subcommand_from version; and not __fish_seen_subcommand_from bump set check' -a 'set' -d 'Set the project version'\n", cmdName))
+	sb.WriteString(fmt.Sprintf("complete -c %s -n '__fish_seen_subcommand_from version; and not __fish_seen_subcommand_from bump set check' -a 'check' -d 'Check version files for drift'\n", cmdName))
+	sb.WriteString(fmt.Sprintf("complete -c %s -n '__fish_seen_subcommand_from bump' -a 'major minor patch prerelease release' -d 'Version part'\n", cmdName))
 
 	sb.WriteString("\n# completion subcommands\n")
 	sb.WriteString(fmt.Sprintf("complete -c %s -n '__fish_seen_subcommand_from completion' -a 'bash' -d 'Generate bash completion'\n", cmdName))
@@ -0,0 +1,592 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/AndreyAkinshin/structyl/internal/config"
+	internalerrors "github.com/AndreyAkinshin/structyl/internal/errors"
+	"github.com/AndreyAkinshin/structyl/internal/mise"
+	"github.com/AndreyAkinshin/structyl/internal/project"
+	"github.com/AndreyAkinshin/structyl/internal/schema"
+	"github.com/AndreyAkinshin/structyl/internal/target"
+	"github.com/AndreyAkinshin/structyl/internal/toolchain"
+)
+
+// cmdConfigShow prints the resolved configuration: defaults applied and the
+// selected profile overlaid. With --resolved, every value is annotated with
+// its source, and each target's effective commands are listed.
+func cmdConfigShow(args []string) int {
+	if wantsHelp(args) {
+		printConfigUsage()
+		return 0
+	}
+
+	var targetName string
+	resolvedOutput, jsonOutput := false, false
+	for _, arg := range args {
+		switch {
+		case arg == "--resolved":
+			resolvedOutput = true
+		case arg == "--json":
+			jsonOutput = true
+		case strings.HasPrefix(arg, "-"):
+			out.ErrorPrefix("config show: unknown option %q", arg)
+			return internalerrors.ExitConfigError
+		case targetName == "":
+			targetName = arg
+		default:
+			out.ErrorPrefix("config show: unexpected argument %q", arg)
+			return internalerrors.ExitConfigError
+		}
+	}
+
+	proj, registry, exitCode := loadProjectWithRegistry()
+	if proj == nil {
+		return exitCode
+	}
+	if targetName != "" {
+		if _, ok := registry.Get(targetName); !ok {
+			out.ErrorPrefix("config show: unknown target %q", targetName)
+			return internalerrors.ExitConfigError
+		}
+	}
+
+	if !resolvedOutput {
+		return printConfigJSON(proj, targetName)
+	}
+
+	report, err := resolveConfig(proj, registry, targetName)
+	if err != nil {
+		out.ErrorPrefix("config show: %v", err)
+		return internalerrors.ExitConfigError
+	}
+	if jsonOutput {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			out.ErrorPrefix("config show: %v", err)
+			return internalerrors.ExitRuntimeError
+		}
+		fmt.Println(string(data))
+		return 0
+	}
+	printResolvedConfig(report)
+	return 0
+}
+
+// cmdConfigConvert rewrites the project configuration file in another
+// format: .structyl/config.json becomes .structyl/config.yaml, for example.
+// Included fragments and target files are left as they are.
+func cmdConfigConvert(args []string) int {
+	if wantsHelp(args) {
+		printConfigUsage()
+		return 0
+	}
+
+	var to string
+	toStdout := false
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--to":
+			if i+1 >= len(args) {
+				out.ErrorPrefix("config convert: --to requires a format")
+				return internalerrors.ExitConfigError
+			}
+			i++
+			to = args[i]
+		case strings.HasPrefix(arg, "--to="):
+			to = strings.TrimPrefix(arg, "--to=")
+		case arg == "--stdout":
+			toStdout = true
+		default:
+			out.ErrorPrefix("config convert: unexpected argument %q", arg)
+			return internalerrors.ExitConfigError
+		}
+	}
+	if !slices.Contains(config.Formats, to) {
+		out.ErrorPrefix("config convert: --to must be one of %s", strings.Join(config.Formats, ", "))
+		return internalerrors.ExitConfigError
+	}
+
+	root, err := project.FindRoot()
+	if err != nil {
+		out.ErrorPrefix("%v", err)
+		return internalerrors.ExitConfigError
+	}
+	path, err := project.FindConfigFile(root)
+	if err != nil {
+		out.ErrorPrefix("%v", err)
+		return internalerrors.ExitConfigError
+	}
+	from := config.FormatOf(path)
+	if from == to {
+		out.ErrorPrefix("config convert: %s is already in %s format", filepath.Base(path), to)
+		return internalerrors.ExitConfigError
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		out.ErrorPrefix("config convert: %v", err)
+		return internalerrors.ExitRuntimeError
+	}
+	converted, err := config.Convert(data, from, to)
+	if err != nil {
+		out.ErrorPrefix("config convert: %v", err)
+		return internalerrors.ExitConfigError
+	}
+	if toStdout {
+		fmt.Print(string(converted))
+		return 0
+	}
+
+	newPath := filepath.Join(filepath.Dir(path), "config."+to)
+	if err := os.WriteFile(newPath, converted, 0644); err != nil {
+		out.ErrorPrefix("config convert: %v", err)
+		return internalerrors.ExitRuntimeError
+	}
+	if err := os.Remove(path); err != nil {
+		out.ErrorPrefix("config convert: %v", err)
+		return internalerrors.ExitRuntimeError
+	}
+	if from != config.FormatJSON {
+		out.WarningSimple("comments in %s are not preserved", filepath.Base(path))
+	}
+	out.Success("Converted %s/%s to %s", project.ConfigDirName, filepath.Base(path), filepath.Base(newPath))
+	return 0
+}
+
+// cmdConfigMigrate rewrites the configuration file, the fragments it
+// includes, and toolchains.json to the current schema version. It prints a
+// diff of each file and the deprecated constructs that the configuration
+// still relies on. With --dry-run, nothing is written. A file whose comments
+// would be dropped is only rewritten with --force.
+func cmdConfigMigrate(args []string) int {
+	if wantsHelp(args) {
+		printConfigUsage()
+		return 0
+	}
+
+	dryRun, force := false, false
+	for _, arg := range args {
+		switch arg {
+		case "--dry-run":
+			dryRun = true
+		case "--force":
+			force = true
+		default:
+			out.ErrorPrefix("config migrate: unexpected argument %q", arg)
+			return internalerrors.ExitConfigError
+		}
+	}
+
+	root, err := project.FindRoot()
+	if err != nil {
+		out.ErrorPrefix("%v", err)
+		return internalerrors.ExitConfigError
+	}
+	path, err := project.FindConfigFile(root)
+	if err != nil {
+		out.ErrorPrefix("%v", err)
+		return internalerrors.ExitConfigError
+	}
+	migrations, err := config.Migrate(path)
+	if err != nil {
+		out.ErrorPrefix("config migrate: %v", err)
+		return internalerrors.ExitConfigError
+	}
+
+	toolchainsPath := filepath.Join(root, project.ConfigDirName, project.ToolchainsFileName)
+	if data, err := os.ReadFile(toolchainsPath); err == nil {
+		m, err := config.MigrateToolchains(data)
+		if err != nil {
+			out.ErrorPrefix("config migrate: %s/%s: %v", project.ConfigDirName, project.ToolchainsFileName, err)
+			return internalerrors.ExitConfigError
+		}
+		m.Path, m.Name = toolchainsPath, project.ConfigDirName+"/"+project.ToolchainsFileName
+		migrations = append(migrations, m)
+	} else if !os.IsNotExist(err) {
+		out.ErrorPrefix("config migrate: %v", err)
+		return internalerrors.ExitRuntimeError
+	}
+
+	if !dryRun && !force {
+		for _, m := range migrations {
+			if m.Changed() && m.DropsComments {
+				out.ErrorPrefix("config migrate: migrating %s rewrites it, which drops its comments", m.Name)
+				out.Hint("Run 'structyl config migrate --dry-run' to see the changes, then migrate by hand or pass --force")
+				return internalerrors.ExitConfigError
+			}
+		}
+	}
+
+	if dryRun {
+		out.DryRunStart()
+	}
+	changed := 0
+	for _, m := range migrations {
+		if !m.Changed() {
+			continue
+		}
+		changed++
+		if m.From != m.To {
+			out.Info("%s: version %s -> %s", m.Name, displayVersion(m.From), m.To)
+		} else {
+			out.Info("%s", m.Name)
+		}
+		for _, c := range m.Changes {
+			out.StepDetail("%s", c)
+		}
+		if data, err := os.ReadFile(m.Path); err == nil {
+			out.Diff(m.Name, string(data), string(m.Data))
+		}
+		if m.DropsComments {
+			out.WarningSimple("comments in %s are not preserved", m.Name)
+		}
+		if !dryRun {
+			if err := os.WriteFile(m.Path, m.Data, 0644); err != nil {
+				out.ErrorPrefix("config migrate: %v", err)
+				return internalerrors.ExitRuntimeError
+			}
+		}
+	}
+	if dryRun {
+		out.DryRunEnd()
+	}
+
+	if raw, err := config.Load(path); err == nil {
+		for _, d := range config.Deprecations(raw) {
+			out.WarningSimple("deprecated: %s", d)
+		}
+	}
+
+	switch {
+	case changed == 0:
+		out.Success("Configuration is up to date (schema version %d)", config.SchemaVersion)
+	case dryRun:
+		out.Info("%d file(s) would be migrated to schema version %d", changed, config.SchemaVersion)
+	default:
+		out.Success("Migrated %d file(s) to schema version %d", changed, config.SchemaVersion)
+	}
+	return 0
+}
+
+// cmdConfigSchema prints the JSON Schema of config.json, or with
+// --toolchains of toolchains.json, as generated from the Go types. It needs
+// no project, so editors can fetch the schema for any workspace.
+func cmdConfigSchema(args []string) int {
+	if wantsHelp(args) {
+		printConfigUsage()
+		return 0
+	}
+
+	name := schema.ConfigFile
+	for _, arg := range args {
+		switch arg {
+		case "--toolchains":
+			name = schema.ToolchainsFile
+		default:
+			out.ErrorPrefix("config schema: unexpected argument %q", arg)
+			return internalerrors.ExitConfigError
+		}
+	}
+
+	data, err := schema.Generated(name)
+	if err != nil {
+		out.ErrorPrefix("config schema: %v", err)
+		return internalerrors.ExitRuntimeError
+	}
+	fmt.Print(string(data))
+	return 0
+}
+
+// displayVersion returns a file version for display; an unversioned file
+// has none.
+func displayVersion(v string) string {
+	if v == "" || v == "0" {
+		return "none"
+	}
+	return v
+}
+
+// printConfigJSON prints the configuration, or one target of it, as JSON.
+func printConfigJSON(proj *project.Project, targetName string) int {
+	// Profiles are already resolved into the configuration shown, and
+	// secrets are redacted.
+	resolved := *proj.Redacted
+	resolved.Profiles = nil
+	var v any = &resolved
+	if targetName != "" {
+		v = resolved.Targets[targetName]
+	}
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		out.ErrorPrefix("config show: %v", err)
+		return internalerrors.ExitRuntimeError
+	}
+	fmt.Println(string(data))
+	return 0
+}
+
+// ResolvedConfigJSON is the output of 'config show --resolved --json'.
+// This structure is stable and part of the public CLI API.
+type ResolvedConfigJSON struct {
+	Profile string               `json:"profile,omitempty"`
+	Values  []ResolvedValueJSON  `json:"values"`
+	Targets []ResolvedTargetJSON `json:"targets"`
+}
+
+// ResolvedValueJSON is a configuration value with its source. Path is the
+// JSON path of the value, as in validation errors.
+type ResolvedValueJSON struct {
+	Path   string      `json:"path"`
+	Value  interface{} `json:"value"`
+	Source SourceJSON  `json:"source"`
+}
+
+// ResolvedTargetJSON lists the effective commands of a target.
+type ResolvedTargetJSON struct {
+	Name     string                `json:"name"`
+	Commands []ResolvedCommandJSON `json:"commands"`
+}
+
+// ResolvedCommandJSON is an effective command of a target. Definition is the
+// command with variables interpolated: a string, a list, or null if the
+// command is disabled.
+type ResolvedCommandJSON struct {
+	Name       string      `json:"name"`
+	Definition interface{} `json:"definition"`
+	Source     SourceJSON  `json:"source"`
+}
+
+// Source kinds of SourceJSON.
+const (
+	sourceBuiltin = "builtin" // Built-in toolchain definition
+	sourceFile    = "file"    // A configuration or toolchains file
+	sourceProfile = "profile" // The selected profile
+	sourceDefault = "default" // Default applied by Structyl
+)
+
+// SourceJSON is where a value comes from.
+type SourceJSON struct {
+	Kind      string `json:"kind"`                // "builtin", "file", "profile", or "default"
+	File      string `json:"file,omitempty"`      // For "file": path relative to the project root
+	Field     string `json:"field,omitempty"`     // For "file": JSON path of the value in the file
+	Profile   string `json:"profile,omitempty"`   // For "profile"
+	Toolchain string `json:"toolchain,omitempty"` // For "builtin"
+}
+
+// String returns the source as shown in the text output.
+func (s SourceJSON) String() string {
+	switch s.Kind {
+	case sourceBuiltin:
+		return fmt.Sprintf("builtin (%s)", s.Toolchain)
+	case sourceFile:
+		if s.Field == "" {
+			return s.File
+		}
+		return s.File + ": " + s.Field
+	case sourceProfile:
+		return "profile " + s.Profile
+	default:
+		return s.Kind
+	}
+}
+
+// configSource returns the source of the configuration value at field.
+func configSource(sources *config.Sources, field string, withField bool) SourceJSON {
+	src := sources.Of(field)
+	switch {
+	case src == "":
+		return SourceJSON{Kind: sourceDefault}
+	case strings.HasPrefix(src, "profile "):
+		return SourceJSON{Kind: sourceProfile, Profile: strings.TrimPrefix(src, "profile ")}
+	case withField:
+		return SourceJSON{Kind: sourceFile, File: src, Field: field}
+	default:
+		return SourceJSON{Kind: sourceFile, File: src}
+	}
+}
+
+// resolveConfig builds the resolved configuration of proj, restricted to a
+// target if targetName is not empty.
+func resolveConfig(proj *project.Project, registry *target.Registry, targetName string) (*ResolvedConfigJSON, error) {
+	sources, err := config.LoadSources(proj.ConfigPath())
+	if err != nil {
+		return nil, err
+	}
+	if sources, err = sources.WithProfile(proj.BaseConfig, proj.Profile); err != nil {
+		return nil, err
+	}
+	userToolchains, err := toolchain.ReadToolchainsFile(proj.Root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", project.ToolchainsFileName, err)
+	}
+
+	report := &ResolvedConfigJSON{
+		Profile: proj.Profile,
+		Values:  []ResolvedValueJSON{},
+		Targets: []ResolvedTargetJSON{},
+	}
+
+	// Values, as the leaves of the configuration's JSON form, with secrets
+	// redacted.
+	shown := *proj.Redacted
+	shown.Profiles = nil
+	data, err := json.Marshal(&shown)
+	if err != nil {
+		return nil, err
+	}
+	var obj map[string]interface{}
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return nil, err
+	}
+	leaves := make(map[string]interface{})
+	collectLeaves(leaves, "", obj)
+	paths := make([]string, 0, len(leaves))
+	prefix := "targets." + targetName + "."
+	for path := range leaves {
+		if targetName == "" || strings.HasPrefix(path, prefix) {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		report.Values = append(report.Values, ResolvedValueJSON{
+			Path:   path,
+			Value:  leaves[path],
+			Source: configSource(sources, path, false),
+		})
+	}
+
+	// Commands of each target, interpolated with secrets redacted.
+	shownRegistry, err := target.NewRegistry(proj.Redacted, proj.Root)
+	if err != nil {
+		return nil, err
+	}
+	names := registry.Names()
+	if targetName != "" {
+		names = []string{targetName}
+	}
+	for _, name := range names {
+		t, _ := shownRegistry.Get(name)
+		resolved := mise.ResolveCommands(proj.Config.Targets[name], proj.Config, proj.Toolchains)
+		cmdNames := make([]string, 0, len(resolved))
+		for cmdName := range resolved {
+			cmdNames = append(cmdNames, cmdName)
+		}
+		sort.Strings(cmdNames)
+
+		rt := ResolvedTargetJSON{Name: name, Commands: []ResolvedCommandJSON{}}
+		for _, cmdName := range cmdNames {
+			cmd := resolved[cmdName]
+			rt.Commands = append(rt.Commands, ResolvedCommandJSON{
+				Name:       cmdName,
+				Definition: interpolateDefinition(t, cmd.Definition),
+				Source:     commandSource(sources, userToolchains, name, cmdName, cmd),
+			})
+		}
+		report.Targets = append(report.Targets, rt)
+	}
+	return report, nil
+}
+
+// commandSource returns the source of a resolved command of a target.
+// userToolchains is the project's toolchains.json as written, or nil.
+func commandSource(sources *config.Sources, userToolchains *toolchain.ToolchainsFile, targetName, cmdName string, cmd mise.ResolvedCommand) SourceJSON {
+	switch cmd.Layer {
+	case mise.LayerTarget:
+		return configSource(sources, fmt.Sprintf("targets.%s.commands.%s", targetName, cmdName), true)
+	case mise.LayerCustomToolchain:
+		return configSource(sources, fmt.Sprintf("toolchains.%s.commands.%s", cmd.Toolchain, cmdName), true)
+	default:
+		if userToolchains != nil {
+			if _, ok := userToolchains.Toolchains[cmd.Toolchain].Commands[cmdName]; ok {
+				return SourceJSON{
+					Kind:  sourceFile,
+					File:  project.ConfigDirName + "/" + project.ToolchainsFileName,
+					Field: fmt.Sprintf("toolchains.%s.commands.%s", cmd.Toolchain, cmdName),
+				}
+			}
+		}
+		return SourceJSON{Kind: sourceBuiltin, Toolchain: cmd.Toolchain}
+	}
+}
+
+// interpolateDefinition interpolates the variables of t in the command
+// strings of a command definition.
+func interpolateDefinition(t target.Target, def interface{}) interface{} {
+	switch v := def.(type) {
+	case string:
+		return target.Interpolate(t, v)
+	case []interface{}:
+		items := make([]interface{}, len(v))
+		for i, item := range v {
+			items[i] = interpolateDefinition(t, item)
+		}
+		return items
+	default:
+		return def
+	}
+}
+
+// collectLeaves adds the non-object values of obj to leaves, by JSON path.
+// Empty objects are omitted.
+func collectLeaves(leaves map[string]interface{}, prefix string, obj map[string]interface{}) {
+	for k, v := range obj {
+		path := k
+		if prefix != "" {
+			path = prefix + "." + k
+		}
+		if child, ok := v.(map[string]interface{}); ok {
+			collectLeaves(leaves, path, child)
+			continue
+		}
+		leaves[path] = v
+	}
+}
+
+// printResolvedConfig prints the resolved configuration as tables.
+func printResolvedConfig(report *ResolvedConfigJSON) {
+	title := "Configuration"
+	if report.Profile != "" {
+		title += fmt.Sprintf(" (profile %s)", report.Profile)
+	}
+	out.Section(title)
+	rows := make([][]string, 0, len(report.Values))
+	for _, v := range report.Values {
+		rows = append(rows, []string{v.Path, formatResolvedValue(v.Value), v.Source.String()})
+	}
+	out.Table([]string{"FIELD", "VALUE", "SOURCE"}, rows)
+
+	for _, t := range report.Targets {
+		out.Section(fmt.Sprintf("Commands of %s", t.Name))
+		rows := make([][]string, 0, len(t.Commands))
+		for _, c := range t.Commands {
+			rows = append(rows, []string{c.Name, formatResolvedValue(c.Definition), c.Source.String()})
+		}
+		out.Table([]string{"COMMAND", "DEFINITION", "SOURCE"}, rows)
+	}
+}
+
+// formatResolvedValue formats a value for the text output: strings as is,
+// null as "(disabled)", and anything else as JSON.
+func formatResolvedValue(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case nil:
+		return "(disabled)"
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(data)
+	}
+}
@@ -0,0 +1,324 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/AndreyAkinshin/structyl/internal/config"
+	"github.com/AndreyAkinshin/structyl/internal/output"
+	"github.com/AndreyAkinshin/structyl/internal/project"
+	"github.com/AndreyAkinshin/structyl/internal/target"
+	"github.com/AndreyAkinshin/structyl/internal/toolchain"
+)
+
+// createResolvedConfigProject creates a project whose values and commands
+// come from every kind of source.
+func createResolvedConfigProject(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	files := map[string]string{
+		".structyl/config.json": `{
+			"project": {"name": "demo"},
+			"include": ["extra.json"],
+			"targets": {
+				"rs": {
+					"type": "language", "title": "Rust", "toolchain": "cargo",
+					"vars": {"flags": "--all", "token": "${file:.structyl/token}"},
+					"commands": {"test": "cargo test ${flags}", "publish": "cargo publish --token ${token}"}
+				}
+			},
+			"profiles": {"ci": {"targets": {"rs": {"commands": {"bench": null}}}}}
+		}`,
+		".structyl/extra.json":      `{"targets": {"rs": {"env": {"RUST_LOG": "warn"}}}}`,
+		".structyl/token":           "s3cr3t-token\n",
+		".structyl/toolchains.json": `{"version": "1.0", "toolchains": {"cargo": {"commands": {"build": "cargo build --locked"}}}}`,
+		"rs/Cargo.toml":             "",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func TestLoadProject_RedactsSecretsInTargetCommands(t *testing.T) {
+	root := createResolvedConfigProject(t)
+	var proj *project.Project
+	withWorkingDir(t, root, func() {
+		var code int
+		if proj, code = loadProject(); code != 0 {
+			t.Fatalf("loadProject() exit code = %d", code)
+		}
+	})
+	registry, err := target.NewRegistry(proj.Config, proj.Root)
+	if err != nil {
+		t.Fatalf("NewRegistry() error = %v", err)
+	}
+	rs, _ := registry.Get("rs")
+	def, _ := rs.GetCommand("publish")
+	cmd := target.Interpolate(rs, def.(string))
+	if cmd != "cargo publish --token s3cr3t-token" {
+		t.Fatalf("interpolated command = %q, want the secret expanded", cmd)
+	}
+
+	var stdout, stderr bytes.Buffer
+	w := output.NewWithWriters(&stdout, &stderr, false)
+	w.Println("Running: %s", cmd)
+	w.ErrorPrefix("%s failed", cmd)
+	if got, want := stdout.String(), "Running: cargo publish --token ***\n"; got != want {
+		t.Errorf("stdout = %q, want %q", got, want)
+	}
+	if got := stderr.String(); strings.Contains(got, "s3cr3t-token") {
+		t.Errorf("stderr = %q, want the secret redacted", got)
+	}
+}
+
+func TestResolveConfig(t *testing.T) {
+	t.Parallel()
+	root := createResolvedConfigProject(t)
+	proj, err := project.LoadProjectWithProfile(root, "ci")
+	if err != nil {
+		t.Fatalf("LoadProjectWithProfile() error = %v", err)
+	}
+	registry, err := target.NewRegistry(proj.Config, proj.Root)
+	if err != nil {
+		t.Fatalf("NewRegistry() error = %v", err)
+	}
+
+	report, err := resolveConfig(proj, registry, "rs")
+	if err != nil {
+		t.Fatalf("resolveConfig() error = %v", err)
+	}
+	if report.Profile != "ci" {
+		t.Errorf("Profile = %q, want %q", report.Profile, "ci")
+	}
+
+	values := make(map[string]string)
+	for _, v := range report.Values {
+		values[v.Path] = v.Source.String()
+	}
+	wantValues := map[string]string{
+		"targets.rs.title":          ".structyl/config.json",
+		"targets.rs.env.RUST_LOG":   ".structyl/extra.json",
+		"targets.rs.directory":      "default",
+		"targets.rs.commands.bench": "profile ci",
+	}
+	for path, want := range wantValues {
+		if got := values[path]; got != want {
+			t.Errorf("source of %s = %q, want %q", path, got, want)
+		}
+	}
+	for _, v := range report.Values {
+		if v.Path == "targets.rs.vars.token" && v.Value != config.Redacted {
+			t.Errorf("targets.rs.vars.token = %v, want it redacted", v.Value)
+		}
+	}
+	if _, ok := values["project.name"]; ok {
+		t.Error("values include project.name, want only the values of target rs")
+	}
+
+	if len(report.Targets) != 1 {
+		t.Fatalf("Targets = %+v, want rs only", report.Targets)
+	}
+	commands := make(map[string]ResolvedCommandJSON)
+	for _, c := range report.Targets[0].Commands {
+		commands[c.Name] = c
+	}
+	wantCommands := []struct {
+		name       string
+		definition interface{}
+		source     string
+	}{
+		{"test", "cargo test --all", ".structyl/config.json: targets.rs.commands.test"},
+		{"build", "cargo build --locked", ".structyl/toolchains.json: toolchains.cargo.commands.build"},
+		{"publish", "cargo publish --token ***", ".structyl/config.json: targets.rs.commands.publish"},
+		{"clean", "cargo clean", "builtin (cargo)"},
+		{"bench", nil, "profile ci"},
+	}
+	for _, tt := range wantCommands {
+		c, ok := commands[tt.name]
+		if !ok {
+			t.Errorf("command %q missing", tt.name)
+			continue
+		}
+		if c.Definition != tt.definition || c.Source.String() != tt.source {
+			t.Errorf("command %q = %v from %q, want %v from %q", tt.name, c.Definition, c.Source, tt.definition, tt.source)
+		}
+	}
+}
+
+func TestCmdConfigShow_Arguments(t *testing.T) {
+	root := createResolvedConfigProject(t)
+	withWorkingDir(t, root, func() {
+		tests := []struct {
+			args []string
+			want int
+		}{
+			{[]string{"rs", "--resolved"}, 0},
+			{[]string{"--resolved", "--json"}, 0},
+			{[]string{"rs"}, 0},
+			{[]string{"go"}, 2},
+			{[]string{"rs", "py"}, 2},
+			{[]string{"--sources"}, 2},
+		}
+		for _, tt := range tests {
+			if got := cmdConfigShow(tt.args); got != tt.want {
+				t.Errorf("cmdConfigShow(%v) = %d, want %d", tt.args, got, tt.want)
+			}
+		}
+	})
+}
+
+func TestCmdConfigConvert(t *testing.T) {
+	root := createTestProject(t)
+	structylDir := filepath.Join(root, project.ConfigDirName)
+	withWorkingDir(t, root, func() {
+		for _, args := range [][]string{{}, {"--to"}, {"--to", "xml"}, {"--to", "json"}, {"--to=yaml", "extra"}} {
+			if got := cmdConfigConvert(args); got != 2 {
+				t.Errorf("cmdConfigConvert(%v) = %d, want 2", args, got)
+			}
+		}
+		if got := cmdConfigConvert([]string{"--to", "yaml", "--stdout"}); got != 0 {
+			t.Fatalf("cmdConfigConvert(--stdout) = %d, want 0", got)
+		}
+		if _, err := os.Stat(filepath.Join(structylDir, "config.json")); err != nil {
+			t.Errorf("config.json missing after --stdout: %v", err)
+		}
+
+		for _, format := range []string{"yaml", "toml", "jsonc", "json"} {
+			before, err := project.LoadProjectFrom(root)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := cmdConfigConvert([]string{"--to=" + format}); got != 0 {
+				t.Fatalf("cmdConfigConvert(--to=%s) = %d, want 0", format, got)
+			}
+			path, err := project.FindConfigFile(root)
+			if err != nil || filepath.Base(path) != "config."+format {
+				t.Fatalf("config file = %q, %v; want config.%s only", path, err, format)
+			}
+			after, err := project.LoadProjectFrom(root)
+			if err != nil {
+				t.Fatalf("loading config.%s: %v", format, err)
+			}
+			if !reflect.DeepEqual(after.Config, before.Config) {
+				t.Errorf("config.%s = %+v, want %+v", format, after.Config, before.Config)
+			}
+		}
+	})
+}
+
+func TestCmdConfigMigrate(t *testing.T) {
+	root := createTestProject(t)
+	structylDir := filepath.Join(root, project.ConfigDirName)
+	configPath := filepath.Join(structylDir, "config.json")
+	toolchainsPath := filepath.Join(structylDir, project.ToolchainsFileName)
+	if err := os.WriteFile(toolchainsPath, []byte(`{"toolchains": {}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	before, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	withWorkingDir(t, root, func() {
+		if got := cmdConfigMigrate([]string{"--yes"}); got != 2 {
+			t.Errorf("cmdConfigMigrate(--yes) = %d, want 2", got)
+		}
+
+		if got := cmdConfigMigrate([]string{"--dry-run"}); got != 0 {
+			t.Fatalf("cmdConfigMigrate(--dry-run) = %d, want 0", got)
+		}
+		if after, _ := os.ReadFile(configPath); string(after) != string(before) {
+			t.Errorf("config.json changed by --dry-run:\n%s", after)
+		}
+
+		if got := cmdConfigMigrate(nil); got != 0 {
+			t.Fatalf("cmdConfigMigrate() = %d, want 0", got)
+		}
+		proj, err := project.LoadProjectFrom(root)
+		if err != nil {
+			t.Fatalf("loading the migrated config: %v", err)
+		}
+		if proj.Config.SchemaVersion != config.SchemaVersion {
+			t.Errorf("SchemaVersion = %d, want %d", proj.Config.SchemaVersion, config.SchemaVersion)
+		}
+		tc, err := toolchain.ReadToolchainsFile(root)
+		if err != nil || tc.Version != config.ToolchainsVersion {
+			t.Errorf("toolchains.json version = %+v, %v; want %q", tc, err, config.ToolchainsVersion)
+		}
+	})
+}
+
+func TestCmdConfigMigrate_Comments(t *testing.T) {
+	root := t.TempDir()
+	structylDir := filepath.Join(root, project.ConfigDirName)
+	if err := os.MkdirAll(structylDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	configPath := filepath.Join(structylDir, "config.jsonc")
+	write := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	withWorkingDir(t, root, func() {
+		// Only schema_version changes: it is inserted and comments are kept.
+		write("{\n  // The project\n  \"project\": {\"name\": \"demo\"}\n}\n")
+		if got := cmdConfigMigrate(nil); got != 0 {
+			t.Fatalf("cmdConfigMigrate() = %d, want 0", got)
+		}
+		want := "{\n  // The project\n  \"schema_version\": 1,\n  \"project\": {\"name\": \"demo\"}\n}\n"
+		if after, _ := os.ReadFile(configPath); string(after) != want {
+			t.Errorf("config.jsonc = %q, want %q", after, want)
+		}
+
+		// Other changes rewrite the file, which needs --force.
+		before := "{\n  // The project\n  \"project\": {\"name\": \"demo\"},\n  \"tests\": {\"comparison\": {\"tolerance_mode\": \"\"}}\n}\n"
+		write(before)
+		if got := cmdConfigMigrate(nil); got != 2 {
+			t.Errorf("cmdConfigMigrate() = %d, want 2", got)
+		}
+		if after, _ := os.ReadFile(configPath); string(after) != before {
+			t.Errorf("config.jsonc changed without --force:\n%s", after)
+		}
+		if got := cmdConfigMigrate([]string{"--force"}); got != 0 {
+			t.Fatalf("cmdConfigMigrate(--force) = %d, want 0", got)
+		}
+		if after, _ := os.ReadFile(configPath); strings.Contains(string(after), "tolerance_mode") {
+			t.Errorf("config.jsonc not migrated with --force:\n%s", after)
+		}
+	})
+}
+
+func TestCmdConfigSchema_Arguments(t *testing.T) {
+	// No project is needed: the schema comes from the Go types.
+	withWorkingDir(t, t.TempDir(), func() {
+		tests := []struct {
+			args []string
+			want int
+		}{
+			{nil, 0},
+			{[]string{"--toolchains"}, 0},
+			{[]string{"--help"}, 0},
+			{[]string{"--json"}, 2},
+			{[]string{"config.json"}, 2},
+		}
+		for _, tt := range tests {
+			if got := cmdConfigSchema(tt.args); got != tt.want {
+				t.Errorf("cmdConfigSchema(%v) = %d, want %d", tt.args, got, tt.want)
+			}
+		}
+	})
+}
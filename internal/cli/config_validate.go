@@ -0,0 +1,425 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/AndreyAkinshin/structyl/internal/config"
+	internalerrors "github.com/AndreyAkinshin/structyl/internal/errors"
+	"github.com/AndreyAkinshin/structyl/internal/mise"
+	"github.com/AndreyAkinshin/structyl/internal/output"
+	"github.com/AndreyAkinshin/structyl/internal/project"
+	"github.com/AndreyAkinshin/structyl/internal/schema"
+	"github.com/AndreyAkinshin/structyl/internal/target"
+	"github.com/AndreyAkinshin/structyl/internal/toolchain"
+)
+
+// Issue severities of 'config validate'.
+const (
+	severityError   = "error"
+	severityWarning = "warning"
+)
+
+// Rule identifiers of 'config validate' issues.
+const (
+	ruleConfig      = "config"
+	ruleSchema      = "schema"
+	ruleDependsOn   = "depends-on"
+	ruleCommandList = "command-list"
+	ruleCIStep      = "ci-step"
+	ruleVersionFile = "version-file"
+	ruleArtifact    = "artifact"
+	ruleToolVersion = "tool-version"
+)
+
+// ConfigValidationJSON is the output of 'config validate --json'.
+// This structure is stable and part of the public CLI API.
+type ConfigValidationJSON struct {
+	Valid   bool              `json:"valid"`
+	Profile string            `json:"profile,omitempty"`
+	Issues  []ConfigIssueJSON `json:"issues"`
+}
+
+// ConfigIssueJSON is a single finding of 'config validate'. Path is the JSON
+// path of the offending value, as in validation errors; File is set when the
+// value is not in the configuration file.
+type ConfigIssueJSON struct {
+	Severity string `json:"severity"`
+	Rule     string `json:"rule"`
+	File     string `json:"file,omitempty"`
+	Path     string `json:"path,omitempty"`
+	Message  string `json:"message"`
+}
+
+// errors returns the number of error-severity issues.
+func (r *ConfigValidationJSON) errors() int {
+	return r.count(severityError)
+}
+
+// warnings returns the number of warning-severity issues.
+func (r *ConfigValidationJSON) warnings() int {
+	return r.count(severityWarning)
+}
+
+func (r *ConfigValidationJSON) count(severity string) int {
+	n := 0
+	for _, issue := range r.Issues {
+		if issue.Severity == severity {
+			n++
+		}
+	}
+	return n
+}
+
+// add appends an issue to the report.
+func (r *ConfigValidationJSON) add(severity, rule, file, path, format string, args ...interface{}) {
+	r.Issues = append(r.Issues, ConfigIssueJSON{
+		Severity: severity,
+		Rule:     rule,
+		File:     file,
+		Path:     path,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// addError appends an error, taking its file and path from a
+// config.ValidationError if err wraps one.
+func (r *ConfigValidationJSON) addError(rule string, err error) {
+	var verr *config.ValidationError
+	if errors.As(err, &verr) {
+		r.add(severityError, rule, verr.File, verr.Field, "%s", verr.Message)
+		return
+	}
+	r.add(severityError, rule, "", "", "%v", err)
+}
+
+// cmdConfigValidate validates the project configuration: the checks made
+// when loading it, the JSON Schema, and the semantic checks of
+// checkConfigSemantics.
+func cmdConfigValidate(args []string) int {
+	if wantsHelp(args) {
+		printConfigUsage()
+		return 0
+	}
+
+	jsonOutput := false
+	for _, arg := range args {
+		switch {
+		case arg == "--json":
+			jsonOutput = true
+		case strings.HasPrefix(arg, "-"):
+			out.ErrorPrefix("config validate: unknown option %q", arg)
+			return internalerrors.ExitConfigError
+		default:
+			out.ErrorPrefix("config validate: unexpected argument %q", arg)
+			return internalerrors.ExitConfigError
+		}
+	}
+
+	report := &ConfigValidationJSON{Issues: []ConfigIssueJSON{}}
+	proj, err := project.LoadProject()
+	if err != nil {
+		if !jsonOutput {
+			out.ErrorPrefix("%v", err)
+			return internalerrors.GetExitCode(err)
+		}
+		report.addError(ruleConfig, err)
+		if code := printConfigValidationJSON(report); code != 0 {
+			return code
+		}
+		return internalerrors.GetExitCode(err)
+	}
+	output.RegisterSecrets(proj.Secrets...)
+	report.Profile = proj.Profile
+
+	registry := validateProjectConfig(proj, report)
+
+	if jsonOutput {
+		if code := printConfigValidationJSON(report); code != 0 {
+			return code
+		}
+	} else {
+		printConfigValidation(proj, registry, report)
+	}
+	if !report.Valid {
+		return internalerrors.ExitConfigError
+	}
+	return 0
+}
+
+// validateProjectConfig fills report with the issues of a loaded project and
+// returns its target registry, or nil if the registry cannot be created.
+func validateProjectConfig(proj *project.Project, report *ConfigValidationJSON) *target.Registry {
+	for _, w := range proj.Warnings {
+		report.add(severityWarning, ruleConfig, "", "", "%s", w)
+	}
+
+	// Run JSON Schema validation on the merged configuration files, as
+	// LoadProject reads them. LoadProject performs Go struct parsing and
+	// semantic validation, but schema validation catches additional issues
+	// like type mismatches and constraint violations defined in the JSON
+	// Schema. Each violation names the fragment or target file it is in.
+	sources, err := config.LoadSources(proj.ConfigPath())
+	if err != nil {
+		report.add(severityError, ruleSchema, "", "", "failed to read config for schema validation: %v", err)
+	} else if violations, err := schema.ConfigErrors(sources.JSON()); err != nil {
+		report.add(severityError, ruleSchema, "", "", "%v", err)
+	} else {
+		for _, v := range violations {
+			report.add(severityError, ruleSchema, sources.File(v.Field), v.Field, "%s", v.Message)
+		}
+	}
+
+	registry, err := target.NewRegistry(proj.Config, proj.Root)
+	if err != nil {
+		report.addError(ruleConfig, err)
+	} else {
+		checkConfigSemantics(proj, registry, report)
+	}
+	report.Valid = report.errors() == 0
+	return registry
+}
+
+// printConfigValidationJSON prints report as JSON.
+func printConfigValidationJSON(report *ConfigValidationJSON) int {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		out.ErrorPrefix("failed to marshal validation report to JSON: %v", err)
+		return internalerrors.ExitRuntimeError
+	}
+	fmt.Println(string(data))
+	return 0
+}
+
+// printConfigValidation prints the issues of report and a summary in
+// human-readable form.
+func printConfigValidation(proj *project.Project, registry *target.Registry, report *ConfigValidationJSON) {
+	for _, issue := range report.Issues {
+		line := issue.Message
+		if issue.Path != "" {
+			line = issue.Path + ": " + line
+		}
+		if issue.File != "" {
+			line = issue.File + ": " + line
+		}
+		if issue.Rule != ruleConfig {
+			line += " [" + issue.Rule + "]"
+		}
+		if issue.Severity == severityError {
+			out.Errorln("error: %s", line)
+		} else {
+			out.WarningSimple("%s", line)
+		}
+	}
+
+	errs, warnings := report.errors(), report.warnings()
+	if errs > 0 {
+		out.FinalFailure("Configuration is invalid: %d error(s), %d warning(s).", errs, warnings)
+		return
+	}
+
+	// Count targets by type
+	targets := registry.All()
+	var langCount, auxCount int
+	for _, t := range targets {
+		if t.Type() == target.TypeLanguage {
+			langCount++
+		} else {
+			auxCount++
+		}
+	}
+
+	out.ValidationSuccess("Configuration is valid.")
+	out.SummaryItem("Project", proj.Config.Project.Name)
+	out.SummaryItem("Targets", fmt.Sprintf("%d (%d language, %d auxiliary)", len(targets), langCount, auxCount))
+	if warnings > 0 {
+		out.SummaryItem("Warnings", fmt.Sprintf("%d", warnings))
+	}
+}
+
+// commandNamePattern matches a command list item that names a command, as
+// opposed to a shell command line.
+var commandNamePattern = regexp.MustCompile(`^[a-z][a-z0-9]*([:-][a-z0-9]+)*$`)
+
+// checkConfigSemantics adds the issues that depend on more than one part of
+// the configuration, on the resolved toolchains, or on the project files:
+//   - depends_on targets that have no build command
+//   - command lists in toolchains that name undefined commands
+//   - ci.steps commands that their target does not define or disables
+//   - version files that do not exist
+//   - artifact targets that do not exist and globs that match nothing
+//   - tool versions that mise cannot parse
+func checkConfigSemantics(proj *project.Project, registry *target.Registry, report *ConfigValidationJSON) {
+	cfg := proj.Config
+	names := registry.Names()
+	resolved := make(map[string]map[string]mise.ResolvedCommand, len(names))
+	for _, name := range names {
+		resolved[name] = mise.ResolveCommands(cfg.Targets[name], cfg, proj.Toolchains)
+	}
+	// runs reports whether a target defines cmd and does not disable it.
+	runs := func(name, cmd string) bool {
+		c, ok := resolved[name][cmd]
+		return ok && c.Definition != nil
+	}
+
+	// Dependencies are built first only if they have a build command.
+	for _, name := range names {
+		if !runs(name, "build") {
+			continue
+		}
+		for i, dep := range cfg.Targets[name].DependsOn {
+			if !runs(dep, "build") {
+				report.add(severityWarning, ruleDependsOn, "", fmt.Sprintf("targets.%s.depends_on[%d]", name, i),
+					"target %q has no build command, so it is not built before %q", dep, name)
+			}
+		}
+	}
+
+	// Command lists of the target's own commands are checked when loading;
+	// lists of the built-in toolchains are shell commands.
+	userToolchains, _ := toolchain.ReadToolchainsFile(proj.Root)
+	seen := make(map[string]bool)
+	for _, name := range names {
+		cmdNames := make([]string, 0, len(resolved[name]))
+		for cmdName := range resolved[name] {
+			cmdNames = append(cmdNames, cmdName)
+		}
+		sort.Strings(cmdNames)
+		for _, cmdName := range cmdNames {
+			cmd := resolved[name][cmdName]
+			list, ok := cmd.Definition.([]interface{})
+			if !ok || cmd.Layer == mise.LayerTarget {
+				continue
+			}
+			var file string
+			if cmd.Layer != mise.LayerCustomToolchain {
+				if userToolchains == nil {
+					continue
+				}
+				if _, ok := userToolchains.Toolchains[cmd.Toolchain].Commands[cmdName]; !ok {
+					continue
+				}
+				file = project.ConfigDirName + "/" + project.ToolchainsFileName
+			}
+			for i, item := range list {
+				s, _ := item.(string)
+				path := fmt.Sprintf("toolchains.%s.commands.%s[%d]", cmd.Toolchain, cmdName, i)
+				if !commandNamePattern.MatchString(s) || s == cmdName || runs(name, s) || seen[file+path] {
+					continue
+				}
+				seen[file+path] = true
+				report.add(severityError, ruleCommandList, file, path,
+					"references command %q, which target %q does not define", s, name)
+			}
+		}
+	}
+
+	if cfg.CI != nil {
+		for i, step := range cfg.CI.Steps {
+			path := fmt.Sprintf("ci.steps[%d].command", i)
+			if step.Target == config.TargetAll {
+				found := false
+				for _, name := range names {
+					found = found || runs(name, step.Command)
+				}
+				if !found {
+					report.add(severityWarning, ruleCIStep, "", path, "no target defines command %q", step.Command)
+				}
+				continue
+			}
+			if c, ok := resolved[step.Target][step.Command]; !ok {
+				report.add(severityError, ruleCIStep, "", path, "target %q does not define command %q", step.Target, step.Command)
+			} else if c.Definition == nil {
+				report.add(severityWarning, ruleCIStep, "", path, "command %q is disabled for target %q", step.Command, step.Target)
+			}
+		}
+	}
+
+	checkFiles := func(field string, files []config.VersionFileConfig) {
+		for i, f := range files {
+			if _, err := os.Stat(filepath.Join(proj.Root, f.Path)); err != nil {
+				report.add(severityError, ruleVersionFile, "", fmt.Sprintf("%s[%d].path", field, i), "file not found: %s", f.Path)
+			}
+		}
+	}
+	if cfg.Version != nil {
+		checkFiles("version.files", cfg.Version.Files)
+	}
+	for _, name := range names {
+		v := cfg.Targets[name].Version
+		if v == nil {
+			continue
+		}
+		checkFiles(fmt.Sprintf("targets.%s.version.files", name), v.Files)
+		deps := make([]string, 0, len(v.DependencyFiles))
+		for dep := range v.DependencyFiles {
+			deps = append(deps, dep)
+		}
+		sort.Strings(deps)
+		for _, dep := range deps {
+			checkFiles(fmt.Sprintf("targets.%s.version.dependency_files.%s", name, dep), v.DependencyFiles[dep])
+		}
+	}
+
+	if cfg.Artifacts != nil {
+		artifactTargets := make([]string, 0, len(cfg.Artifacts.Targets))
+		for name := range cfg.Artifacts.Targets {
+			artifactTargets = append(artifactTargets, name)
+		}
+		sort.Strings(artifactTargets)
+		for _, name := range artifactTargets {
+			t, ok := registry.Get(name)
+			if !ok {
+				report.add(severityError, ruleArtifact, "", "artifacts.targets."+name, "references undefined target %q", name)
+				continue
+			}
+			for i, spec := range cfg.Artifacts.Targets[name] {
+				path := fmt.Sprintf("artifacts.targets.%s[%d].source", name, i)
+				matches, err := filepath.Glob(filepath.Join(proj.Root, t.Directory(), spec.Source))
+				switch {
+				case err != nil:
+					report.add(severityError, ruleArtifact, "", path, "invalid glob pattern %q", spec.Source)
+				case len(matches) == 0:
+					report.add(severityWarning, ruleArtifact, "", path, "%q matches no files; build %q first or fix the pattern", spec.Source, name)
+				}
+			}
+		}
+	}
+
+	checkVersion := func(path, v string) {
+		if err := mise.ValidateToolVersion(v); err != nil {
+			report.add(severityError, ruleToolVersion, "", path, "%v", err)
+		}
+	}
+	for _, name := range names {
+		if v := cfg.Targets[name].ToolchainVersion; v != "" {
+			checkVersion(fmt.Sprintf("targets.%s.toolchain_version", name), v)
+		}
+	}
+	tcNames := make([]string, 0, len(cfg.Toolchains))
+	for name := range cfg.Toolchains {
+		tcNames = append(tcNames, name)
+	}
+	sort.Strings(tcNames)
+	for _, name := range tcNames {
+		if v := cfg.Toolchains[name].Version; v != "" {
+			checkVersion(fmt.Sprintf("toolchains.%s.version", name), v)
+		}
+	}
+	if cfg.Mise != nil {
+		tools := make([]string, 0, len(cfg.Mise.ExtraTools))
+		for tool := range cfg.Mise.ExtraTools {
+			tools = append(tools, tool)
+		}
+		sort.Strings(tools)
+		for _, tool := range tools {
+			checkVersion("mise.extra_tools."+tool, cfg.Mise.ExtraTools[tool])
+		}
+	}
+}
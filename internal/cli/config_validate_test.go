@@ -0,0 +1,147 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/AndreyAkinshin/structyl/internal/project"
+)
+
+// createInvalidSemanticsProject creates a project that loads, but fails
+// every semantic check of 'config validate'.
+func createInvalidSemanticsProject(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	files := map[string]string{
+		".structyl/config.json": `{
+			"project": {"name": "demo"},
+			"version": {"files": [{"path": "pkg/package.json", "key": "/version"}]},
+			"toolchains": {
+				"custom": {"extends": "go", "version": "^1.22", "commands": {"check": ["test", "vet", "go vet ./..."]}}
+			},
+			"targets": {
+				"app": {"type": "language", "title": "App", "toolchain": "custom", "toolchain_version": "1.22", "depends_on": ["lib", "docs"]},
+				"lib": {"type": "language", "title": "Lib", "toolchain": "cargo", "commands": {"build": null}},
+				"docs": {"type": "auxiliary", "title": "Docs", "commands": {"test": "true"}}
+			},
+			"ci": {"steps": [
+				{"name": "deploy", "target": "app", "command": "deploy"},
+				{"name": "lib", "target": "lib", "command": "build"},
+				{"name": "all", "target": "all", "command": "release"}
+			]},
+			"artifacts": {"targets": {"app": [{"source": "dist/*.whl"}], "ghost": [{"source": "out/*"}]}},
+			"mise": {"extra_tools": {"ruff": "latest", "uv": ">=0.4"}}
+		}`,
+		".structyl/toolchains.json": `{"version": "1.0", "toolchains": {"cargo": {"commands": {"check": ["clippy", "cargo fmt --check"]}}}}`,
+		"app/go.mod":                "",
+		"lib/Cargo.toml":            "",
+		"docs/index.md":             "",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func TestValidateProjectConfig(t *testing.T) {
+	t.Parallel()
+	proj, err := project.LoadProjectFrom(createInvalidSemanticsProject(t))
+	if err != nil {
+		t.Fatalf("LoadProjectFrom() error = %v", err)
+	}
+	report := &ConfigValidationJSON{Issues: []ConfigIssueJSON{}}
+	if validateProjectConfig(proj, report) == nil {
+		t.Fatal("validateProjectConfig() registry = nil")
+	}
+
+	type issue struct{ severity, rule, file, path string }
+	var got []issue
+	for _, i := range report.Issues {
+		got = append(got, issue{i.Severity, i.Rule, i.File, i.Path})
+	}
+	want := []issue{
+		{"warning", "depends-on", "", "targets.app.depends_on[0]"},
+		{"warning", "depends-on", "", "targets.app.depends_on[1]"},
+		{"error", "command-list", "", "toolchains.custom.commands.check[1]"},
+		{"error", "command-list", ".structyl/toolchains.json", "toolchains.cargo.commands.check[0]"},
+		{"error", "ci-step", "", "ci.steps[0].command"},
+		{"warning", "ci-step", "", "ci.steps[1].command"},
+		{"warning", "ci-step", "", "ci.steps[2].command"},
+		{"error", "version-file", "", "version.files[0].path"},
+		{"warning", "artifact", "", "artifacts.targets.app[0].source"},
+		{"error", "artifact", "", "artifacts.targets.ghost"},
+		{"error", "tool-version", "", "toolchains.custom.version"},
+		{"error", "tool-version", "", "mise.extra_tools.uv"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("issues =\n%+v\nwant\n%+v", report.Issues, want)
+	}
+	if report.Valid {
+		t.Error("Valid = true, want false")
+	}
+}
+
+func TestValidateProjectConfig_SchemaOfMergedFiles(t *testing.T) {
+	t.Parallel()
+	root := t.TempDir()
+	files := map[string]string{
+		".structyl/config.json":   `{"include": ["project.json"], "targets": {"go": {"type": "language"}}}`,
+		".structyl/project.json":  `{"project": {"name": "demo"}}`,
+		"go/structyl.target.json": `{"title": "Go", "version": {"files": [{"path": "go/go.mod"}]}}`,
+		"go/go.mod":               "",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	proj, err := project.LoadProjectFrom(root)
+	if err != nil {
+		t.Fatalf("LoadProjectFrom() error = %v", err)
+	}
+	report := &ConfigValidationJSON{Issues: []ConfigIssueJSON{}}
+	validateProjectConfig(proj, report)
+
+	var schemaIssues []ConfigIssueJSON
+	for _, i := range report.Issues {
+		if i.Rule == ruleSchema {
+			schemaIssues = append(schemaIssues, i)
+		}
+	}
+	if len(schemaIssues) == 0 {
+		t.Fatal("no schema issues, want the version file of the target file")
+	}
+	for _, got := range schemaIssues {
+		if got.File != "go/structyl.target.json" || got.Path != "targets.go.version.files[0]" {
+			t.Errorf("schema issue = %+v, want it in go/structyl.target.json at targets.go.version.files[0]", got)
+		}
+	}
+}
+
+func TestCmdConfigValidate_JSON(t *testing.T) {
+	withWorkingDir(t, createInvalidSemanticsProject(t), func() {
+		if got := cmdConfigValidate([]string{"--strict"}); got != 2 {
+			t.Errorf("cmdConfigValidate(--strict) = %d, want 2", got)
+		}
+		if got := cmdConfigValidate([]string{"--json"}); got != 2 {
+			t.Errorf("cmdConfigValidate(--json) = %d, want 2", got)
+		}
+	})
+	withWorkingDir(t, createResolvedConfigProject(t), func() {
+		if got := cmdConfigValidate([]string{"--json"}); got != 0 {
+			t.Errorf("cmdConfigValidate(--json) on a valid project = %d, want 0", got)
+		}
+	})
+}
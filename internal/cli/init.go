@@ -7,6 +7,7 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/AndreyAkinshin/structyl/internal/config"
@@ -14,6 +15,7 @@ import (
 	"github.com/AndreyAkinshin/structyl/internal/mise"
 	"github.com/AndreyAkinshin/structyl/internal/output"
 	"github.com/AndreyAkinshin/structyl/internal/project"
+	"github.com/AndreyAkinshin/structyl/internal/version"
 )
 
 // SetupScriptSh contains the shell bootstrap script template.
@@ -68,6 +70,7 @@ func initializeNewProject(cwd, configPath string) (*config.Config, error) {
 	projectName := sanitizeProjectName(filepath.Base(cwd))
 
 	cfg := &config.Config{
+		SchemaVersion: config.SchemaVersion,
 		Project: config.ProjectConfig{
 			Name: projectName,
 		},
@@ -80,6 +83,9 @@ func initializeNewProject(cwd, configPath string) (*config.Config, error) {
 		cfg.Targets[name] = targetCfg
 	}
 
+	// Register the manifests that store each target's package version
+	cfg.Version = detectVersionConfig(cwd, targets)
+
 	// Write .structyl/config.json
 	data, err := json.MarshalIndent(cfg, "", "  ")
 	if err != nil {
@@ -96,7 +102,8 @@ func initializeNewProject(cwd, configPath string) (*config.Config, error) {
 
 // setupStructylFiles creates the standard .structyl directory files.
 // If skipPrompts is true, interactive prompts are skipped (non-interactive mode).
-func setupStructylFiles(w *output.Writer, structylDir string, isNewProject, skipPrompts bool) (created, updated []string) {
+// A missing PROJECT_VERSION is created with initialVersion.
+func setupStructylFiles(w *output.Writer, structylDir, initialVersion string, isNewProject, skipPrompts bool) (created, updated []string) {
 	// Write .structyl/version (pinned CLI version) - only if missing
 	versionFilePath := filepath.Join(structylDir, project.VersionFileName)
 	if _, err := os.Stat(versionFilePath); os.IsNotExist(err) {
@@ -166,7 +173,7 @@ func setupStructylFiles(w *output.Writer, structylDir string, isNewProject, skip
 	// Create PROJECT_VERSION file - only if missing
 	versionPath := filepath.Join(structylDir, "PROJECT_VERSION")
 	if _, err := os.Stat(versionPath); os.IsNotExist(err) {
-		if err := os.WriteFile(versionPath, []byte("0.1.0\n"), 0644); err != nil {
+		if err := os.WriteFile(versionPath, []byte(initialVersion+"\n"), 0644); err != nil {
 			w.WarningSimple("could not create PROJECT_VERSION file: %v", err)
 		} else {
 			created = append(created, ".structyl/PROJECT_VERSION")
@@ -261,7 +268,13 @@ func cmdInit(args []string) int {
 
 	result := &initResult{}
 
-	// Initialize or load config
+	// Initialize or load config, in whichever format it is written
+	if existing, err := config.FindFile(structylDir); err == nil {
+		configPath = existing
+	} else if !os.IsNotExist(err) {
+		w.ErrorPrefix("%v", err)
+		return errors.ExitConfigError
+	}
 	if _, err := os.Stat(configPath); os.IsNotExist(err) {
 		result.isNewProject = true
 		result.cfg, err = initializeNewProject(cwd, configPath)
@@ -279,7 +292,7 @@ func cmdInit(args []string) int {
 	}
 
 	// Setup .structyl files
-	created, updated := setupStructylFiles(w, structylDir, result.isNewProject, opts.Yes)
+	created, updated := setupStructylFiles(w, structylDir, initialProjectVersion(cwd, result.cfg), result.isNewProject, opts.Yes)
 	result.created = append(result.created, created...)
 	result.updated = append(result.updated, updated...)
 
@@ -405,6 +418,45 @@ func detectTargetDirectories(root string) map[string]config.TargetConfig {
 	return targets
 }
 
+// detectVersionConfig returns a version config listing the manifest of each
+// target whose toolchain has a version file preset, or nil if there are none.
+func detectVersionConfig(root string, targets map[string]config.TargetConfig) *config.VersionConfig {
+	names := make([]string, 0, len(targets))
+	for name := range targets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var files []config.VersionFileConfig
+	for _, name := range names {
+		t := targets[name]
+		if f, ok := version.DetectFile(root, t.Directory, t.Toolchain); ok {
+			files = append(files, f)
+		}
+	}
+	if len(files) == 0 {
+		return nil
+	}
+	return &config.VersionConfig{Files: files}
+}
+
+// initialProjectVersion returns the version for a new PROJECT_VERSION file:
+// the first valid version found in a key-based version file, or 0.1.0.
+func initialProjectVersion(root string, cfg *config.Config) string {
+	if cfg.Version != nil {
+		for _, f := range cfg.Version.Files {
+			if f.Key == "" {
+				continue
+			}
+			v, err := version.ReadKey(filepath.Join(root, f.Path), config.VersionFileFormat(f), f.Key)
+			if err == nil && version.Validate(v) == nil {
+				return v
+			}
+		}
+	}
+	return "0.1.0"
+}
+
 // updateGitignore adds Structyl entries to .gitignore.
 func updateGitignore(root string) {
 	gitignorePath := filepath.Join(root, ".gitignore")
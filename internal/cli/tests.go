@@ -0,0 +1,989 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AndreyAkinshin/structyl/internal/config"
+	internalerrors "github.com/AndreyAkinshin/structyl/internal/errors"
+	"github.com/AndreyAkinshin/structyl/internal/project"
+	"github.com/AndreyAkinshin/structyl/internal/scaffold"
+	"github.com/AndreyAkinshin/structyl/internal/target"
+	"github.com/AndreyAkinshin/structyl/internal/tests"
+	"github.com/AndreyAkinshin/structyl/internal/toolchain"
+	"github.com/AndreyAkinshin/structyl/pkg/testhelper"
+)
+
+// cmdTests handles reference test suite utilities.
+func cmdTests(args []string, opts *GlobalOptions) int {
+	if len(args) == 0 {
+		out.ErrorPrefix("tests: subcommand required (lint, scaffold, matrix, fuzz)")
+		return internalerrors.ExitConfigError
+	}
+
+	switch args[0] {
+	case "lint":
+		return cmdTestsLint(args[1:])
+	case "scaffold":
+		return cmdTestsScaffold(args[1:])
+	case "matrix":
+		return cmdTestsMatrix(args[1:], opts)
+	case "fuzz":
+		return cmdTestsFuzz(args[1:], opts)
+	case "-h", "--help":
+		printTestsUsage()
+		return 0
+	default:
+		out.ErrorPrefix("tests: unknown subcommand %q", args[0])
+		return internalerrors.ExitConfigError
+	}
+}
+
+// testsDirectory returns the absolute tests directory and file pattern for a project.
+func testsDirectory(proj *project.Project) (string, string) {
+	dir := proj.Config.Tests.Directory
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(proj.Root, dir)
+	}
+	return dir, proj.Config.Tests.Pattern
+}
+
+// compareOptions returns the comparison options of the tests.comparison
+// section of cfg, as harnesses built on pkg/testhelper read them.
+func compareOptions(cfg *config.Config) (testhelper.CompareOptions, error) {
+	opts := testhelper.DefaultOptions()
+	if cfg.Tests != nil && cfg.Tests.Comparison != nil {
+		c := cfg.Tests.Comparison
+		opts.NaNEqualsNaN = c.NaNEqualsNaN
+		if c.FloatTolerance != nil {
+			opts.FloatTolerance = *c.FloatTolerance
+		}
+		if c.ToleranceMode != "" {
+			opts.ToleranceMode = string(c.ToleranceMode)
+		}
+		if c.ArrayOrder != "" {
+			opts.ArrayOrder = string(c.ArrayOrder)
+		}
+	}
+	if err := testhelper.ValidateOptions(opts); err != nil {
+		return testhelper.CompareOptions{}, fmt.Errorf("tests.comparison: %w", err)
+	}
+	return opts, nil
+}
+
+// cmdTestsLint statically checks the reference tests directory.
+func cmdTestsLint(args []string) int {
+	if wantsHelp(args) {
+		printTestsLintUsage()
+		return 0
+	}
+
+	jsonOutput := false
+	fix := false
+	for _, arg := range args {
+		switch arg {
+		case "--json":
+			jsonOutput = true
+		case "--fix":
+			fix = true
+		default:
+			if strings.HasPrefix(arg, "-") {
+				out.ErrorPrefix("tests lint: unknown option %q", arg)
+				return internalerrors.ExitConfigError
+			}
+			out.ErrorPrefix("tests lint: unexpected argument %q", arg)
+			return internalerrors.ExitConfigError
+		}
+	}
+
+	proj, exitCode := loadProject()
+	if proj == nil {
+		return exitCode
+	}
+
+	testsDir, pattern := testsDirectory(proj)
+	if _, err := os.Stat(testsDir); os.IsNotExist(err) {
+		out.ErrorPrefix("tests lint: tests directory not found: %s", testsDir)
+		return internalerrors.ExitConfigError
+	}
+
+	report, err := tests.Lint(testsDir, tests.LintOptions{Pattern: pattern, Fix: fix})
+	if err != nil {
+		out.ErrorPrefix("tests lint: %v", err)
+		return internalerrors.ExitRuntimeError
+	}
+
+	if jsonOutput {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			out.ErrorPrefix("failed to marshal lint report to JSON: %v", err)
+			return internalerrors.ExitRuntimeError
+		}
+		fmt.Println(string(data))
+	} else {
+		printLintReport(report)
+	}
+
+	if report.Errors() > 0 {
+		return internalerrors.ExitRuntimeError
+	}
+	return 0
+}
+
+// printLintReport prints lint issues and a summary in human-readable form.
+func printLintReport(report *tests.LintReport) {
+	fixed := 0
+	for _, issue := range report.Issues {
+		location := issue.Suite
+		if issue.Path != "" {
+			location = issue.Path
+		}
+		line := fmt.Sprintf("%s: %s [%s]", location, issue.Message, issue.Rule)
+		switch {
+		case issue.Fixed:
+			fixed++
+			out.Success("%s (fixed)", line)
+		case issue.Severity == tests.LintError:
+			out.Errorln("error: %s", line)
+		default:
+			out.Warning("%s", line)
+		}
+	}
+
+	out.Println("")
+	out.SummaryItem("Suites", fmt.Sprintf("%d", report.Suites))
+	out.SummaryItem("Files", fmt.Sprintf("%d", report.Files))
+	if fixed > 0 {
+		out.SummaryItem("Fixed", fmt.Sprintf("%d", fixed))
+	}
+
+	errs, warnings := report.Errors(), report.Warnings()
+	switch {
+	case errs > 0:
+		out.FinalFailure("%d error(s), %d warning(s).", errs, warnings)
+	case warnings > 0:
+		out.FinalSuccess("No errors, %d warning(s).", warnings)
+	default:
+		out.FinalSuccess("Reference tests are clean.")
+	}
+}
+
+// cmdTestsScaffold generates a reference test harness for a language target.
+func cmdTestsScaffold(args []string) int {
+	if wantsHelp(args) {
+		printTestsScaffoldUsage()
+		return 0
+	}
+
+	var targetName, suite, frameworkName string
+	force := false
+	toStdout := false
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--suite" || arg == "--framework":
+			if i+1 >= len(args) {
+				out.ErrorPrefix("tests scaffold: %s requires a value", arg)
+				return internalerrors.ExitConfigError
+			}
+			if arg == "--suite" {
+				suite = args[i+1]
+			} else {
+				frameworkName = args[i+1]
+			}
+			i++
+		case strings.HasPrefix(arg, "--suite="):
+			suite = strings.TrimPrefix(arg, "--suite=")
+		case strings.HasPrefix(arg, "--framework="):
+			frameworkName = strings.TrimPrefix(arg, "--framework=")
+		case arg == "--force":
+			force = true
+		case arg == "--stdout":
+			toStdout = true
+		case strings.HasPrefix(arg, "-"):
+			out.ErrorPrefix("tests scaffold: unknown option %q", arg)
+			return internalerrors.ExitConfigError
+		case targetName == "":
+			targetName = arg
+		default:
+			out.ErrorPrefix("tests scaffold: unexpected argument %q", arg)
+			return internalerrors.ExitConfigError
+		}
+	}
+
+	if targetName == "" {
+		out.ErrorPrefix("tests scaffold: target required")
+		printTestsScaffoldUsage()
+		return internalerrors.ExitConfigError
+	}
+	if suite == "" {
+		out.ErrorPrefix("tests scaffold: --suite is required")
+		return internalerrors.ExitConfigError
+	}
+
+	proj, exitCode := loadProject()
+	if proj == nil {
+		return exitCode
+	}
+
+	targetCfg, ok := proj.Config.Targets[targetName]
+	if !ok {
+		out.ErrorPrefix("tests scaffold: unknown target %q", targetName)
+		return internalerrors.ExitConfigError
+	}
+	if targetCfg.Type != "language" {
+		out.ErrorPrefix("tests scaffold: target %q is not a language target", targetName)
+		return internalerrors.ExitConfigError
+	}
+	targetDir, err := proj.TargetDirectory(targetName)
+	if err != nil {
+		out.ErrorPrefix("tests scaffold: %v", err)
+		return internalerrors.ExitConfigError
+	}
+
+	testsDir, _ := testsDirectory(proj)
+	if info, err := os.Stat(filepath.Join(testsDir, suite)); err != nil || !info.IsDir() {
+		out.ErrorPrefix("tests scaffold: suite %q not found in %s", suite, testsDir)
+		return internalerrors.ExitConfigError
+	}
+	relTestsDir, err := filepath.Rel(proj.Root, testsDir)
+	if err != nil {
+		relTestsDir = testsDir
+	}
+
+	var framework scaffold.Framework
+	if frameworkName != "" {
+		framework, ok = scaffold.ParseFramework(frameworkName)
+		if !ok {
+			out.ErrorPrefix("tests scaffold: unknown framework %q (supported: %s)", frameworkName, joinFrameworks())
+			return internalerrors.ExitConfigError
+		}
+	} else {
+		tc := baseToolchain(proj.Config, targetCfg.Toolchain)
+		if tc == "" {
+			tc, _ = toolchain.Detect(targetDir)
+		}
+		framework, ok = scaffold.FrameworkForToolchain(tc)
+		if !ok {
+			if tc == "" {
+				out.ErrorPrefix("tests scaffold: cannot determine toolchain for target %q", targetName)
+			} else {
+				out.ErrorPrefix("tests scaffold: no default framework for toolchain %q", tc)
+			}
+			out.Hint("Use --framework=<name> (supported: %s)", joinFrameworks())
+			return internalerrors.ExitConfigError
+		}
+	}
+
+	comparison, err := compareOptions(proj.Config)
+	if err != nil {
+		out.ErrorPrefix("tests scaffold: %v", err)
+		return internalerrors.ExitConfigError
+	}
+
+	harness, err := scaffold.Generate(framework, scaffold.Options{
+		Suite:      suite,
+		TestsDir:   filepath.ToSlash(relTestsDir),
+		Comparison: comparison,
+		Package:    scaffold.GoPackageName(targetDir),
+		Command:    fmt.Sprintf("structyl tests scaffold %s --suite %s", targetName, suite),
+	})
+	if err != nil {
+		out.ErrorPrefix("tests scaffold: %v", err)
+		return internalerrors.ExitConfigError
+	}
+
+	if toStdout {
+		for _, file := range harness.Files {
+			fmt.Printf("==> %s <==\n%s\n", file.Path, file.Content)
+		}
+		return 0
+	}
+
+	// Refuse before writing anything so a conflict never leaves a partial harness.
+	for _, file := range harness.Files {
+		path := filepath.Join(targetDir, filepath.FromSlash(file.Path))
+		if _, err := os.Stat(path); err == nil && !file.Shared && !force {
+			out.ErrorPrefix("tests scaffold: %s already exists (use --force to overwrite)", path)
+			return internalerrors.ExitRuntimeError
+		}
+	}
+
+	for _, file := range harness.Files {
+		path := filepath.Join(targetDir, filepath.FromSlash(file.Path))
+		display := filepath.Join(targetCfg.Directory, filepath.FromSlash(file.Path))
+		if _, err := os.Stat(path); err == nil && file.Shared && !force {
+			out.Info("Kept %s", display)
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			out.ErrorPrefix("tests scaffold: %v", err)
+			return internalerrors.ExitRuntimeError
+		}
+		if err := os.WriteFile(path, file.Content, 0644); err != nil {
+			out.ErrorPrefix("tests scaffold: %v", err)
+			return internalerrors.ExitRuntimeError
+		}
+		out.Success("Created %s", display)
+	}
+	for _, note := range harness.Notes {
+		out.Hint("Next: %s", note)
+	}
+	return 0
+}
+
+// baseToolchain follows custom toolchain "extends" chains to the underlying
+// toolchain name. Returns name unchanged if it is not a custom toolchain.
+func baseToolchain(cfg *config.Config, name string) string {
+	for depth := 0; depth < 10; depth++ {
+		custom, ok := cfg.Toolchains[name]
+		if !ok || custom.Extends == "" {
+			return name
+		}
+		name = custom.Extends
+	}
+	return name
+}
+
+func joinFrameworks() string {
+	names := make([]string, 0, len(scaffold.Frameworks()))
+	for _, f := range scaffold.Frameworks() {
+		names = append(names, string(f))
+	}
+	return strings.Join(names, ", ")
+}
+
+// defaultResultsDir is where "tests matrix" collects per-target results,
+// relative to the project root.
+const defaultResultsDir = "artifacts/test-results"
+
+// cmdTestsMatrix runs the reference tests of every language target and
+// reports which cases pass in which target.
+func cmdTestsMatrix(args []string, opts *GlobalOptions) int {
+	if wantsHelp(args) {
+		printTestsMatrixUsage()
+		return 0
+	}
+
+	format := "table"
+	resultsDir := defaultResultsDir
+	outputPath := ""
+	noRun := false
+	var targetNames []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--format" || arg == "--results-dir" || arg == "--output":
+			if i+1 >= len(args) {
+				out.ErrorPrefix("tests matrix: %s requires a value", arg)
+				return internalerrors.ExitConfigError
+			}
+			switch arg {
+			case "--format":
+				format = args[i+1]
+			case "--results-dir":
+				resultsDir = args[i+1]
+			default:
+				outputPath = args[i+1]
+			}
+			i++
+		case strings.HasPrefix(arg, "--format="):
+			format = strings.TrimPrefix(arg, "--format=")
+		case strings.HasPrefix(arg, "--results-dir="):
+			resultsDir = strings.TrimPrefix(arg, "--results-dir=")
+		case strings.HasPrefix(arg, "--output="):
+			outputPath = strings.TrimPrefix(arg, "--output=")
+		case arg == "--no-run":
+			noRun = true
+		case strings.HasPrefix(arg, "-"):
+			out.ErrorPrefix("tests matrix: unknown option %q", arg)
+			return internalerrors.ExitConfigError
+		default:
+			targetNames = append(targetNames, arg)
+		}
+	}
+	if format != "table" && format != "markdown" && format != "json" {
+		out.ErrorPrefix("tests matrix: invalid format %q (expected table, markdown, or json)", format)
+		return internalerrors.ExitConfigError
+	}
+
+	proj, registry, exitCode := loadProjectWithRegistry()
+	if proj == nil {
+		return exitCode
+	}
+	if !filepath.IsAbs(resultsDir) {
+		resultsDir = filepath.Join(proj.Root, resultsDir)
+	}
+
+	targets, code := languageTestTargets(registry, targetNames, noRun, "tests matrix")
+	if targets == nil {
+		return code
+	}
+
+	caseIDs, err := matrixCaseIDs(proj)
+	if err != nil {
+		out.ErrorPrefix("tests matrix: %v", err)
+		return internalerrors.ExitConfigError
+	}
+	comparison, err := compareOptions(proj.Config)
+	if err != nil {
+		out.ErrorPrefix("tests matrix: %v", err)
+		return internalerrors.ExitConfigError
+	}
+
+	if !noRun {
+		if code := ensureMiseReady(proj); code != 0 {
+			return code
+		}
+	}
+
+	results, code := collectTargetResults(proj, registry, targets, resultsDir, !noRun, opts, "tests matrix")
+	if results == nil {
+		return code
+	}
+
+	matrix := tests.BuildMatrix(caseIDs, targets, results, comparison)
+
+	switch {
+	case outputPath != "":
+		var data []byte
+		if format == "json" {
+			data, err = json.MarshalIndent(matrix, "", "  ")
+			data = append(data, '\n')
+		} else {
+			data = []byte(matrix.Markdown())
+		}
+		if err == nil {
+			err = os.WriteFile(outputPath, data, 0644)
+		}
+		if err != nil {
+			out.ErrorPrefix("tests matrix: %v", err)
+			return internalerrors.ExitRuntimeError
+		}
+		out.Success("Wrote conformance matrix to %s", outputPath)
+	case format == "json":
+		data, err := json.MarshalIndent(matrix, "", "  ")
+		if err != nil {
+			out.ErrorPrefix("failed to marshal matrix to JSON: %v", err)
+			return internalerrors.ExitRuntimeError
+		}
+		fmt.Println(string(data))
+	case format == "markdown":
+		fmt.Print(matrix.Markdown())
+	default:
+		printMatrix(matrix)
+	}
+
+	if matrix.Failures() > 0 || matrix.Disagreements > 0 {
+		return internalerrors.ExitRuntimeError
+	}
+	return 0
+}
+
+// defaultFuzzCount is the number of cases "tests fuzz" generates by default.
+const defaultFuzzCount = 100
+
+// fuzzReport is the JSON output of "tests fuzz".
+type fuzzReport struct {
+	Suite       string             `json:"suite"`
+	Seed        int64              `json:"seed"`
+	Cases       int                `json:"cases"`
+	Targets     []string           `json:"targets"`
+	Divergences []fuzzReportResult `json:"divergences"`
+}
+
+type fuzzReportResult struct {
+	Case      string                       `json:"case"`
+	SeedCase  string                       `json:"seed_case"`
+	Mutations []string                     `json:"mutations"`
+	Reason    string                       `json:"reason"`
+	Candidate string                       `json:"candidate"`
+	Outcomes  map[string]tests.FuzzOutcome `json:"outcomes"`
+}
+
+// cmdTestsFuzz mutates the inputs of a suite's cases, runs every target on
+// the mutated inputs, and saves inputs on which targets disagree.
+func cmdTestsFuzz(args []string, opts *GlobalOptions) int {
+	if wantsHelp(args) {
+		printTestsFuzzUsage()
+		return 0
+	}
+
+	count := defaultFuzzCount
+	seed := time.Now().UnixNano()
+	outDir := ""
+	jsonOutput := false
+	var positional []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		name, value, hasValue := strings.Cut(arg, "=")
+		switch {
+		case name == "--count" || name == "--seed" || name == "--out":
+			if !hasValue {
+				if i+1 >= len(args) {
+					out.ErrorPrefix("tests fuzz: %s requires a value", name)
+					return internalerrors.ExitConfigError
+				}
+				value = args[i+1]
+				i++
+			}
+			var err error
+			switch name {
+			case "--count":
+				count, err = strconv.Atoi(value)
+				if err == nil && count <= 0 {
+					err = fmt.Errorf("must be positive")
+				}
+			case "--seed":
+				seed, err = strconv.ParseInt(value, 10, 64)
+			default:
+				outDir = value
+			}
+			if err != nil {
+				out.ErrorPrefix("tests fuzz: invalid %s %q: %v", name, value, err)
+				return internalerrors.ExitConfigError
+			}
+		case arg == "--json":
+			jsonOutput = true
+		case strings.HasPrefix(arg, "-"):
+			out.ErrorPrefix("tests fuzz: unknown option %q", arg)
+			return internalerrors.ExitConfigError
+		default:
+			positional = append(positional, arg)
+		}
+	}
+	if len(positional) == 0 {
+		out.ErrorPrefix("tests fuzz: suite required")
+		printTestsFuzzUsage()
+		return internalerrors.ExitConfigError
+	}
+	suite, targetNames := positional[0], positional[1:]
+	if err := testhelper.ValidateSuiteName(suite); err != nil {
+		out.ErrorPrefix("tests fuzz: %v", err)
+		return internalerrors.ExitConfigError
+	}
+
+	proj, registry, exitCode := loadProjectWithRegistry()
+	if proj == nil {
+		return exitCode
+	}
+	targets, code := languageTestTargets(registry, targetNames, false, "tests fuzz")
+	if targets == nil {
+		return code
+	}
+	if len(targets) < 2 {
+		out.ErrorPrefix("tests fuzz: at least two targets are required to compare outputs")
+		return internalerrors.ExitConfigError
+	}
+
+	testsDir, pattern := testsDirectory(proj)
+	seeds, err := tests.LoadTestSuite(testsDir, suite, pattern)
+	if err != nil {
+		out.ErrorPrefix("tests fuzz: %v", err)
+		return internalerrors.ExitConfigError
+	}
+	comparison, err := compareOptions(proj.Config)
+	if err != nil {
+		out.ErrorPrefix("tests fuzz: %v", err)
+		return internalerrors.ExitConfigError
+	}
+	cases := tests.GenerateFuzzCases(seeds, tests.FuzzOptions{Count: count, Seed: seed})
+	if len(cases) == 0 {
+		out.ErrorPrefix("tests fuzz: suite %q has no inputs to mutate", suite)
+		return internalerrors.ExitConfigError
+	}
+
+	if outDir == "" {
+		outDir = filepath.Join("artifacts", "fuzz", suite)
+	}
+	if !filepath.IsAbs(outDir) {
+		outDir = filepath.Join(proj.Root, outDir)
+	}
+	casesDir := filepath.Join(outDir, "cases")
+	resultsDir := filepath.Join(outDir, "results")
+	candidatesDir := filepath.Join(outDir, "candidates")
+	for _, dir := range []string{casesDir, resultsDir, candidatesDir} {
+		if err := os.RemoveAll(dir); err != nil {
+			out.ErrorPrefix("tests fuzz: %v", err)
+			return internalerrors.ExitRuntimeError
+		}
+	}
+	if err := writeFuzzCases(filepath.Join(casesDir, suite), cases); err != nil {
+		out.ErrorPrefix("tests fuzz: %v", err)
+		return internalerrors.ExitRuntimeError
+	}
+	if !jsonOutput {
+		out.Info("Generated %d case(s) from suite %q (seed %d)", len(cases), suite, seed)
+	}
+
+	if code := ensureMiseReady(proj); code != 0 {
+		return code
+	}
+
+	// Harnesses load the generated cases instead of the project's, and run
+	// all of them regardless of the current tag selection.
+	tags, hadTags := os.LookupEnv(testhelper.TagsEnvVar)
+	_ = os.Unsetenv(testhelper.TagsEnvVar)
+	_ = os.Setenv(testhelper.TestsDirEnvVar, casesDir)
+	results, code := collectTargetResults(proj, registry, targets, resultsDir, true, opts, "tests fuzz")
+	_ = os.Unsetenv(testhelper.TestsDirEnvVar)
+	if hadTags {
+		_ = os.Setenv(testhelper.TagsEnvVar, tags)
+	}
+	if results == nil {
+		return code
+	}
+
+	divergences := tests.FindDivergences(cases, targets, results, comparison)
+	report := fuzzReport{Suite: suite, Seed: seed, Cases: len(cases), Targets: targets, Divergences: []fuzzReportResult{}}
+	for _, d := range divergences {
+		path := filepath.Join(candidatesDir, d.Case.Name+".json")
+		data, err := d.CandidateJSON()
+		if err == nil {
+			err = os.MkdirAll(candidatesDir, 0755)
+		}
+		if err == nil {
+			err = os.WriteFile(path, data, 0644)
+		}
+		if err != nil {
+			out.ErrorPrefix("tests fuzz: %v", err)
+			return internalerrors.ExitRuntimeError
+		}
+		report.Divergences = append(report.Divergences, fuzzReportResult{
+			Case:      d.Case.Name,
+			SeedCase:  d.Case.SeedCase,
+			Mutations: d.Case.Mutations,
+			Reason:    d.Reason,
+			Candidate: path,
+			Outcomes:  d.Outcomes,
+		})
+	}
+
+	if jsonOutput {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			out.ErrorPrefix("failed to marshal fuzz report to JSON: %v", err)
+			return internalerrors.ExitRuntimeError
+		}
+		fmt.Println(string(data))
+	} else {
+		printFuzzReport(report, candidatesDir)
+	}
+
+	if len(divergences) > 0 {
+		return internalerrors.ExitRuntimeError
+	}
+	return 0
+}
+
+// writeFuzzCases writes generated cases as test case files into dir.
+func writeFuzzCases(dir string, cases []tests.FuzzCase) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	for _, fc := range cases {
+		data, err := fc.JSON()
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(dir, fc.Name+".json"), data, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// printFuzzReport prints the divergences found by "tests fuzz".
+func printFuzzReport(r fuzzReport, candidatesDir string) {
+	for _, d := range r.Divergences {
+		out.Warning("%s (from %s; %s): %s", d.Case, d.SeedCase, strings.Join(d.Mutations, "; "), d.Reason)
+	}
+	if len(r.Divergences) == 0 {
+		out.FinalSuccess("All targets agree on %d fuzz case(s).", r.Cases)
+		return
+	}
+	out.Println("")
+	out.Hint("Candidate cases saved to %s; rerun with --seed %d to reproduce.", candidatesDir, r.Seed)
+	out.FinalFailure("%d of %d fuzz case(s) diverge across targets.", len(r.Divergences), r.Cases)
+}
+
+// languageTestTargets returns the named targets, or every language target
+// (with a test command, unless noRun). Returns nil and an exit code on error.
+func languageTestTargets(registry *target.Registry, names []string, noRun bool, cmdName string) ([]string, int) {
+	if len(names) > 0 {
+		for _, name := range names {
+			if _, ok := registry.Get(name); !ok {
+				out.ErrorPrefix("%s: unknown target %q", cmdName, name)
+				return nil, internalerrors.ExitConfigError
+			}
+		}
+		return names, 0
+	}
+
+	var targets []string
+	for _, t := range registry.ByType(target.TypeLanguage) {
+		if noRun || containsString(t.Commands(), "test") {
+			targets = append(targets, t.Name())
+		}
+	}
+	if len(targets) == 0 {
+		out.ErrorPrefix("%s: no language targets with a test command", cmdName)
+		return nil, internalerrors.ExitConfigError
+	}
+	return targets, 0
+}
+
+// collectTargetResults reads the results recorded by each target under
+// resultsDir/<target>. If run is set, each target's results directory is
+// cleared and its test command is run with STRUCTYL_RESULTS_DIR pointing at
+// it first. Returns nil and an exit code on error.
+func collectTargetResults(proj *project.Project, registry *target.Registry, targets []string, resultsDir string, run bool, opts *GlobalOptions, cmdName string) (map[string]map[string]testhelper.CaseResult, int) {
+	if run {
+		defer func() { _ = os.Unsetenv(testhelper.ResultsDirEnvVar) }()
+	}
+	results := make(map[string]map[string]testhelper.CaseResult, len(targets))
+	for _, name := range targets {
+		dir := filepath.Join(resultsDir, name)
+		if run {
+			if err := os.RemoveAll(dir); err != nil {
+				out.ErrorPrefix("%s: %v", cmdName, err)
+				return nil, internalerrors.ExitRuntimeError
+			}
+			if err := os.Setenv(testhelper.ResultsDirEnvVar, dir); err != nil {
+				out.ErrorPrefix("%s: %v", cmdName, err)
+				return nil, internalerrors.ExitRuntimeError
+			}
+			// Failing cases make the test command fail; callers report them.
+			_ = runViaMise(proj, "test", name, nil, opts, registry)
+		}
+		targetResults, err := testhelper.ReadCaseResults(dir)
+		if err != nil {
+			out.ErrorPrefix("%s: target %q: %v", cmdName, name, err)
+			return nil, internalerrors.ExitRuntimeError
+		}
+		if len(targetResults) == 0 {
+			out.WarningSimple("target %q recorded no results (does its harness support %s?)", name, testhelper.ResultsDirEnvVar)
+		}
+		results[name] = targetResults
+	}
+	return results, 0
+}
+
+// matrixCaseIDs returns the IDs of all reference test cases selected by the
+// current tag selection.
+func matrixCaseIDs(proj *project.Project) ([]string, error) {
+	testsDir, pattern := testsDirectory(proj)
+	suites, err := tests.LoadAllSuites(testsDir, pattern)
+	if err != nil {
+		return nil, err
+	}
+	filter, err := testhelper.TagFilterFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for suite, cases := range suites {
+		for _, tc := range cases {
+			if filter.Match(tc.Tags) {
+				ids = append(ids, suite+"/"+tc.Name)
+			}
+		}
+	}
+	return ids, nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// printMatrix prints the conformance matrix as a terminal table.
+func printMatrix(m *tests.Matrix) {
+	headers := append([]string{"Case"}, m.Targets...)
+	headers = append(headers, "")
+	rows := make([][]string, 0, len(m.Cases))
+	for _, c := range m.Cases {
+		row := []string{c.ID}
+		for _, name := range m.Targets {
+			row = append(row, string(c.Results[name]))
+		}
+		if c.Disagreement {
+			row = append(row, "disagree")
+		}
+		rows = append(rows, row)
+	}
+	out.Table(headers, rows)
+
+	if m.Disagreements > 0 {
+		out.Println("")
+		for _, c := range m.Cases {
+			if c.Disagreement {
+				out.Warning("%s: %s", c.ID, c.Reason)
+			}
+		}
+	}
+
+	out.Println("")
+	for _, name := range m.Targets {
+		s := m.Summary[name]
+		out.SummaryItem(name, fmt.Sprintf("%d pass, %d fail, %d skip, %d missing", s.Pass, s.Fail, s.Skip, s.Missing))
+	}
+
+	switch {
+	case m.Disagreements > 0:
+		out.FinalFailure("%d case(s) where targets disagree.", m.Disagreements)
+	case m.Failures() > 0:
+		out.FinalFailure("%d failing result(s); all targets agree.", m.Failures())
+	default:
+		out.FinalSuccess("All targets agree.")
+	}
+}
+
+func printTestsUsage() {
+	out.HelpTitle("structyl tests - reference test suite utilities")
+
+	out.HelpSection("Usage:")
+	out.HelpUsage("structyl tests <subcommand> [options]")
+
+	out.HelpSection("Subcommands:")
+	out.HelpCommand("lint", "Statically check reference test suites", widthFlagShort)
+	out.HelpCommand("scaffold", "Generate a reference test harness for a target", widthFlagShort)
+	out.HelpCommand("matrix", "Report which cases pass in which target", widthFlagShort)
+	out.HelpCommand("fuzz", "Find inputs on which targets disagree", widthFlagShort)
+
+	out.HelpSection("Options:")
+	out.HelpFlag("-h, --help", "Show this help", widthFlagShort)
+
+	out.HelpSection("Examples:")
+	out.HelpExample("structyl tests lint", "Check all suites for problems")
+	out.HelpExample("structyl tests scaffold rs --suite center", "Generate a Rust harness")
+	out.HelpExample("structyl tests matrix --format markdown", "Cross-language conformance report")
+	out.HelpExample("structyl tests fuzz center", "Differential fuzzing of a suite")
+	out.Println("")
+}
+
+func printTestsLintUsage() {
+	out.HelpTitle("structyl tests lint - check reference test suite hygiene")
+
+	out.HelpSection("Usage:")
+	out.HelpUsage("structyl tests lint [--json] [--fix]")
+
+	out.HelpSection("Description:")
+	out.Println("  Validates every case against the test case schema and reports duplicate")
+	out.Println("  inputs, missing or unreferenced $file targets, invalid case names,")
+	out.Println("  suites where every case is skipped, use of reserved fields, and files")
+	out.Println("  that are not canonically formatted.")
+	out.Println("")
+	out.Println("  Exits with code 1 if any errors are found; warnings alone do not fail.")
+	out.Println("")
+
+	out.HelpSection("Options:")
+	out.HelpFlag("--json", "Output the report as JSON", widthFlagShort)
+	out.HelpFlag("--fix", "Apply safe fixes (canonical formatting)", widthFlagShort)
+	out.HelpFlag("-h, --help", "Show this help", widthFlagShort)
+
+	out.HelpSection("Examples:")
+	out.HelpExample("structyl tests lint", "Report problems")
+	out.HelpExample("structyl tests lint --fix", "Reformat case files")
+	out.HelpExample("structyl tests lint --json", "Machine-readable report")
+	out.Println("")
+}
+
+func printTestsScaffoldUsage() {
+	out.HelpTitle("structyl tests scaffold - generate a reference test harness")
+
+	out.HelpSection("Usage:")
+	out.HelpUsage("structyl tests scaffold <target> --suite <suite> [options]")
+
+	out.HelpSection("Description:")
+	out.Println("  Generates test code that loads every case of a suite, applies the")
+	out.Println("  comparison options from tests.comparison, honors skip and tags, and")
+	out.Println("  calls a stub function you implement. The framework is chosen from the")
+	out.Println("  target's toolchain unless --framework is given.")
+	out.Println("")
+	out.Println("  A shared support file is created once per target and kept if it")
+	out.Println("  exists; the per-suite file is never overwritten without --force.")
+	out.Println("")
+
+	out.HelpSection("Options:")
+	out.HelpFlag("--suite=<name>", "Reference test suite (required)", 18)
+	out.HelpFlag("--framework=<name>", fmt.Sprintf("Test framework (%s)", joinFrameworks()), 18)
+	out.HelpFlag("--force", "Overwrite existing files", 18)
+	out.HelpFlag("--stdout", "Print files instead of writing them", 18)
+	out.HelpFlag("-h, --help", "Show this help", 18)
+
+	out.HelpSection("Examples:")
+	out.HelpExample("structyl tests scaffold rs --suite center", "Rust harness for suite center")
+	out.HelpExample("structyl tests scaffold ts --suite center --framework deno", "Deno instead of Vitest")
+	out.Println("")
+}
+
+func printTestsMatrixUsage() {
+	out.HelpTitle("structyl tests matrix - cross-language conformance report")
+
+	out.HelpSection("Usage:")
+	out.HelpUsage("structyl tests matrix [targets...] [options]")
+
+	out.HelpSection("Description:")
+	out.Println("  Runs the test command of each language target with STRUCTYL_RESULTS_DIR")
+	out.Println("  set, collects the per-case results its harness records, and reports")
+	out.Println("  every case as pass, fail, skip, or missing in each target. Cases where")
+	out.Println("  targets disagree with each other are highlighted: some pass while")
+	out.Println("  others fail, or all fail with different outputs.")
+	out.Println("")
+	out.Println("  Exits with code 1 if any result fails or targets disagree.")
+	out.Println("")
+
+	out.HelpSection("Options:")
+	out.HelpFlag("--format=<f>", "Output format: table, markdown, or json", 18)
+	out.HelpFlag("--output=<file>", "Write the report (markdown or json) to a file", 18)
+	out.HelpFlag("--results-dir=<dir>", "Results directory (default: "+defaultResultsDir+")", 18)
+	out.HelpFlag("--no-run", "Report existing results without running tests", 18)
+	out.HelpFlag("-h, --help", "Show this help", 18)
+
+	out.HelpSection("Examples:")
+	out.HelpExample("structyl tests matrix", "Run all targets and print the matrix")
+	out.HelpExample("structyl tests matrix cs py", "Compare two targets")
+	out.HelpExample("structyl tests matrix --no-run --format json", "Re-render collected results")
+	out.Println("")
+}
+
+func printTestsFuzzUsage() {
+	out.HelpTitle("structyl tests fuzz - differential fuzzing across targets")
+
+	out.HelpSection("Usage:")
+	out.HelpUsage("structyl tests fuzz <suite> [targets...] [options]")
+
+	out.HelpSection("Description:")
+	out.Println("  Mutates the inputs of a suite's cases (numeric perturbations, edge values")
+	out.Println("  such as 0, NaN, and ±Infinity, array length changes, empty strings),")
+	out.Println("  runs every language target on the mutated inputs via STRUCTYL_TESTS_DIR,")
+	out.Println("  and compares the targets' outputs with each other using the suite's")
+	out.Println("  comparison settings. Inputs on which targets disagree are saved as")
+	out.Println("  skipped candidate cases for triage.")
+	out.Println("")
+	out.Println("  Exits with code 1 if any divergence is found.")
+	out.Println("")
+
+	out.HelpSection("Options:")
+	out.HelpFlag("--count=<n>", fmt.Sprintf("Number of cases to generate (default: %d)", defaultFuzzCount), 18)
+	out.HelpFlag("--seed=<n>", "Random seed (default: random; printed)", 18)
+	out.HelpFlag("--out=<dir>", "Work directory (default: artifacts/fuzz/<suite>)", 18)
+	out.HelpFlag("--json", "Output the report as JSON", 18)
+	out.HelpFlag("-h, --help", "Show this help", 18)
+
+	out.HelpSection("Examples:")
+	out.HelpExample("structyl tests fuzz center", "Fuzz all language targets")
+	out.HelpExample("structyl tests fuzz center cs py --count 500", "Compare two targets")
+	out.HelpExample("structyl tests fuzz center --seed 42", "Reproduce an earlier run")
+	out.Println("")
+}
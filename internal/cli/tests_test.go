@@ -0,0 +1,225 @@
+package cli
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	internalerrors "github.com/AndreyAkinshin/structyl/internal/errors"
+	"github.com/AndreyAkinshin/structyl/pkg/testhelper"
+)
+
+func writeReferenceCase(t *testing.T, root, suite, name, content string) {
+	t.Helper()
+	dir := filepath.Join(root, "tests", suite)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name+".json"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCmdTests_NoSubcommand_ReturnsError(t *testing.T) {
+	if code := cmdTests(nil, &GlobalOptions{}); code != internalerrors.ExitConfigError {
+		t.Errorf("cmdTests() = %d, want %d", code, internalerrors.ExitConfigError)
+	}
+}
+
+func TestCmdTests_UnknownSubcommand_ReturnsError(t *testing.T) {
+	if code := cmdTests([]string{"bogus"}, &GlobalOptions{}); code != internalerrors.ExitConfigError {
+		t.Errorf("cmdTests() = %d, want %d", code, internalerrors.ExitConfigError)
+	}
+}
+
+func TestCmdTestsLint_CleanSuite_ReturnsZero(t *testing.T) {
+	root := createTestProject(t)
+	writeReferenceCase(t, root, "math", "add", "{\n  \"input\": {},\n  \"output\": 1\n}\n")
+	withWorkingDir(t, root, func() {
+		if code := cmdTestsLint([]string{"--json"}); code != 0 {
+			t.Errorf("cmdTestsLint() = %d, want 0", code)
+		}
+	})
+}
+
+func TestCmdTestsLint_SchemaError_ReturnsOne(t *testing.T) {
+	root := createTestProject(t)
+	writeReferenceCase(t, root, "math", "add", "{\n  \"input\": {}\n}\n")
+	withWorkingDir(t, root, func() {
+		if code := cmdTestsLint(nil); code != internalerrors.ExitRuntimeError {
+			t.Errorf("cmdTestsLint() = %d, want %d", code, internalerrors.ExitRuntimeError)
+		}
+	})
+}
+
+func TestCmdTestsLint_UnknownOption_ReturnsError(t *testing.T) {
+	if code := cmdTestsLint([]string{"--bogus"}); code != internalerrors.ExitConfigError {
+		t.Errorf("cmdTestsLint() = %d, want %d", code, internalerrors.ExitConfigError)
+	}
+}
+
+func TestCmdTestsScaffold_WritesHarness(t *testing.T) {
+	root := createTestProject(t)
+	writeReferenceCase(t, root, "center", "basic", "{\n  \"input\": {},\n  \"output\": 1\n}\n")
+	withWorkingDir(t, root, func() {
+		if code := cmdTestsScaffold([]string{"cs", "--suite", "center"}); code != 0 {
+			t.Fatalf("cmdTestsScaffold() = %d, want 0", code)
+		}
+	})
+	for _, name := range []string{"StructylReference.cs", "ReferenceCenterTests.cs"} {
+		if _, err := os.Stat(filepath.Join(root, "cs", name)); err != nil {
+			t.Errorf("expected %s to be generated: %v", name, err)
+		}
+	}
+}
+
+func TestCmdTestsScaffold_ExistingSuiteFile_ReturnsError(t *testing.T) {
+	root := createTestProject(t)
+	writeReferenceCase(t, root, "center", "basic", "{\n  \"input\": {},\n  \"output\": 1\n}\n")
+	suiteFile := filepath.Join(root, "cs", "ReferenceCenterTests.cs")
+	if err := os.WriteFile(suiteFile, []byte("// mine\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	withWorkingDir(t, root, func() {
+		if code := cmdTestsScaffold([]string{"cs", "--suite=center"}); code != internalerrors.ExitRuntimeError {
+			t.Errorf("cmdTestsScaffold() = %d, want %d", code, internalerrors.ExitRuntimeError)
+		}
+	})
+	data, err := os.ReadFile(suiteFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "// mine\n" {
+		t.Error("existing suite file was overwritten without --force")
+	}
+	if _, err := os.Stat(filepath.Join(root, "cs", "StructylReference.cs")); !os.IsNotExist(err) {
+		t.Error("shared file should not be written when the suite file conflicts")
+	}
+}
+
+func TestCmdTestsScaffold_Errors(t *testing.T) {
+	root := createTestProject(t)
+	writeReferenceCase(t, root, "center", "basic", "{\n  \"input\": {},\n  \"output\": 1\n}\n")
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"missing target", []string{"--suite", "center"}},
+		{"missing suite", []string{"cs"}},
+		{"unknown target", []string{"nope", "--suite", "center"}},
+		{"auxiliary target", []string{"img", "--suite", "center"}},
+		{"unknown suite", []string{"cs", "--suite", "nope"}},
+		{"unknown framework", []string{"cs", "--suite", "center", "--framework", "nunit"}},
+	}
+	withWorkingDir(t, root, func() {
+		for _, tt := range tests {
+			if code := cmdTestsScaffold(tt.args); code != internalerrors.ExitConfigError {
+				t.Errorf("%s: cmdTestsScaffold() = %d, want %d", tt.name, code, internalerrors.ExitConfigError)
+			}
+		}
+	})
+}
+
+func TestCmdTestsScaffold_CaseTemplates(t *testing.T) {
+	root := createTestProject(t)
+	writeReferenceCase(t, root, "center", "grid", `{"$matrix": {"n": [1, 2]}, "input": {"n": {"$param": "n"}}, "output": 1}`)
+	withWorkingDir(t, root, func() {
+		if code := cmdTestsScaffold([]string{"cs", "--suite", "center"}); code != 0 {
+			t.Errorf("cmdTestsScaffold(cs) = %d, want 0", code)
+		}
+	})
+	if _, err := os.Stat(filepath.Join(root, "cs", "StructylReference.cs")); err != nil {
+		t.Errorf("harness for a suite with case templates not written: %v", err)
+	}
+}
+
+func TestCmdTestsMatrix_NoRun_ReportsResults(t *testing.T) {
+	root := createTestProject(t)
+	writeReferenceCase(t, root, "math", "add", "{\n  \"input\": {},\n  \"output\": 1\n}\n")
+	writeReferenceCase(t, root, "math", "sub", "{\n  \"input\": {},\n  \"output\": 0\n}\n")
+	resultsDir := filepath.Join(root, "artifacts", "test-results", "cs")
+	if err := testhelper.WriteCaseResult(resultsDir, testhelper.TestCase{Suite: "math", Name: "add"}, testhelper.CaseResult{Status: testhelper.ResultPass}); err != nil {
+		t.Fatal(err)
+	}
+	output := filepath.Join(root, "matrix.json")
+
+	withWorkingDir(t, root, func() {
+		if code := cmdTestsMatrix([]string{"--no-run", "--format=json", "--output", output}, &GlobalOptions{}); code != 0 {
+			t.Fatalf("cmdTestsMatrix() = %d, want 0", code)
+		}
+	})
+
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var matrix struct {
+		Targets []string `json:"targets"`
+		Cases   []struct {
+			ID      string            `json:"id"`
+			Results map[string]string `json:"results"`
+		} `json:"cases"`
+	}
+	if err := json.Unmarshal(data, &matrix); err != nil {
+		t.Fatalf("invalid JSON output: %v", err)
+	}
+	if len(matrix.Targets) != 1 || matrix.Targets[0] != "cs" {
+		t.Errorf("targets = %v, want [cs]", matrix.Targets)
+	}
+	if len(matrix.Cases) != 2 || matrix.Cases[0].Results["cs"] != "pass" || matrix.Cases[1].Results["cs"] != "missing" {
+		t.Errorf("cases = %+v, want math/add pass and math/sub missing", matrix.Cases)
+	}
+}
+
+func TestCmdTestsMatrix_NoRun_FailingResult_ReturnsOne(t *testing.T) {
+	root := createTestProject(t)
+	writeReferenceCase(t, root, "math", "add", "{\n  \"input\": {},\n  \"output\": 1\n}\n")
+	resultsDir := filepath.Join(root, "artifacts", "test-results", "cs")
+	if err := testhelper.WriteCaseResult(resultsDir, testhelper.TestCase{Suite: "math", Name: "add"}, testhelper.CaseResult{Status: testhelper.ResultFail, Actual: 2.0}); err != nil {
+		t.Fatal(err)
+	}
+	withWorkingDir(t, root, func() {
+		if code := cmdTestsMatrix([]string{"--no-run"}, &GlobalOptions{}); code != internalerrors.ExitRuntimeError {
+			t.Errorf("cmdTestsMatrix() = %d, want %d", code, internalerrors.ExitRuntimeError)
+		}
+	})
+}
+
+func TestCmdTestsMatrix_InvalidArguments_ReturnsError(t *testing.T) {
+	root := createTestProject(t)
+	writeReferenceCase(t, root, "math", "add", "{\n  \"input\": {},\n  \"output\": 1\n}\n")
+	withWorkingDir(t, root, func() {
+		for _, args := range [][]string{
+			{"--format=html"},
+			{"--no-run", "nope"},
+			{"--bogus"},
+		} {
+			if code := cmdTestsMatrix(args, &GlobalOptions{}); code != internalerrors.ExitConfigError {
+				t.Errorf("cmdTestsMatrix(%v) = %d, want %d", args, code, internalerrors.ExitConfigError)
+			}
+		}
+	})
+}
+
+func TestCmdTestsFuzz_InvalidArguments_ReturnsError(t *testing.T) {
+	root := createTestProject(t)
+	writeReferenceCase(t, root, "math", "add", "{\n  \"input\": {\"x\": 1},\n  \"output\": 1\n}\n")
+	withWorkingDir(t, root, func() {
+		for _, args := range [][]string{
+			{},
+			{"math", "--count=0"},
+			{"math", "--seed", "abc"},
+			{"math", "--count"},
+			{"math", "--bogus"},
+			{"../math"},
+			{"math", "nope"},
+			{"math"},       // only one language target
+			{"math", "cs"}, // only one target
+		} {
+			if code := cmdTestsFuzz(args, &GlobalOptions{}); code != internalerrors.ExitConfigError {
+				t.Errorf("cmdTestsFuzz(%v) = %d, want %d", args, code, internalerrors.ExitConfigError)
+			}
+		}
+	})
+}
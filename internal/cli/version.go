@@ -0,0 +1,504 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/AndreyAkinshin/structyl/internal/config"
+	internalerrors "github.com/AndreyAkinshin/structyl/internal/errors"
+	"github.com/AndreyAkinshin/structyl/internal/project"
+	"github.com/AndreyAkinshin/structyl/internal/release"
+	"github.com/AndreyAkinshin/structyl/internal/version"
+)
+
+// versionParts lists the parts accepted by "version bump", in help order.
+var versionParts = []string{"major", "minor", "patch", "prerelease", "release"}
+
+// versionChangeOptions holds the flags shared by "version bump" and "version set".
+type versionChangeOptions struct {
+	pre     string // Prerelease identifier (bump only)
+	dryRun  bool
+	release bool // Hand off to the release workflow
+	push    bool // Push the release (requires release)
+	force   bool // Release with uncommitted changes (requires release)
+}
+
+// cmdVersion prints the CLI version, or changes the project version.
+func cmdVersion(args []string, opts *GlobalOptions) int {
+	if len(args) == 0 {
+		fmt.Printf("structyl %s\n", Version)
+		return 0
+	}
+
+	switch args[0] {
+	case "bump":
+		return cmdVersionBump(args[1:], opts)
+	case "set":
+		return cmdVersionSet(args[1:], opts)
+	case "check":
+		return cmdVersionCheck(args[1:])
+	case "-h", "--help":
+		printVersionUsage()
+		return 0
+	default:
+		out.ErrorPrefix("version: unknown subcommand %q", args[0])
+		return internalerrors.ExitConfigError
+	}
+}
+
+// cmdVersionBump computes the next version from the project version and applies it.
+func cmdVersionBump(args []string, opts *GlobalOptions) int {
+	if wantsHelp(args) {
+		printVersionUsage()
+		return 0
+	}
+
+	positional, vopts, ok := parseVersionChangeArgs("version bump", args, true)
+	if !ok {
+		return internalerrors.ExitConfigError
+	}
+	if len(positional) != 1 {
+		out.ErrorPrefix("version bump: exactly one part required (%s)", strings.Join(versionParts, ", "))
+		return internalerrors.ExitConfigError
+	}
+
+	proj, exitCode := loadProject()
+	if proj == nil {
+		return exitCode
+	}
+
+	current, code := readBaseVersion(proj, "version bump")
+	if code != 0 {
+		return code
+	}
+
+	next, err := version.BumpPre(current, positional[0], vopts.pre)
+	if err != nil {
+		out.ErrorPrefix("version bump: %v", err)
+		return internalerrors.ExitConfigError
+	}
+	return applyProjectVersion(proj, current, next, vopts, opts)
+}
+
+// cmdVersionSet sets the project version to an explicit value.
+func cmdVersionSet(args []string, opts *GlobalOptions) int {
+	if wantsHelp(args) {
+		printVersionUsage()
+		return 0
+	}
+
+	positional, vopts, ok := parseVersionChangeArgs("version set", args, false)
+	if !ok {
+		return internalerrors.ExitConfigError
+	}
+	if len(positional) != 1 {
+		out.ErrorPrefix("version set: exactly one version required")
+		return internalerrors.ExitConfigError
+	}
+	v, err := version.Parse(positional[0])
+	if err != nil {
+		out.ErrorPrefix("version set: %v", err)
+		return internalerrors.ExitConfigError
+	}
+
+	proj, exitCode := loadProject()
+	if proj == nil {
+		return exitCode
+	}
+
+	// A missing or invalid current version is not an error: set replaces it.
+	var current string
+	if config.IsGitTagSource(proj.Config.Version) {
+		current, _ = version.LatestTagVersion(proj.Root, config.ReleaseTagFormat(proj.Config))
+	} else {
+		current, _ = version.Read(version.SourcePath(proj.Root, proj.Config.Version))
+	}
+	return applyProjectVersion(proj, current, v.String(), vopts, opts)
+}
+
+// cmdVersionCheck verifies that every version file matches the project version.
+func cmdVersionCheck(args []string) int {
+	if wantsHelp(args) {
+		printVersionUsage()
+		return 0
+	}
+
+	fix := false
+	for _, arg := range args {
+		switch arg {
+		case "--fix":
+			fix = true
+		default:
+			out.ErrorPrefix("version check: unexpected argument %q", arg)
+			return internalerrors.ExitConfigError
+		}
+	}
+
+	proj, exitCode := loadProject()
+	if proj == nil {
+		return exitCode
+	}
+
+	current, statuses, code := checkProjectVersion(proj, "version check")
+	if code != 0 {
+		return code
+	}
+	printVersionCheck(proj, current, statuses)
+	if len(statuses) == 0 {
+		out.Hint("No version files configured in version.files.")
+		return 0
+	}
+
+	inconsistent := countInconsistent(statuses)
+	if inconsistent == 0 {
+		return 0
+	}
+	if !fix {
+		out.ErrorPrefix("version check: %d file(s) do not match version %s", inconsistent, current)
+		out.Hint("Run 'structyl version check --fix' to update them.")
+		return internalerrors.ExitRuntimeError
+	}
+
+	if err := version.Propagate(current, version.ResolveFiles(proj.Root, proj.Config.Version.Files)); err != nil {
+		out.ErrorPrefix("version check: failed to propagate version: %v", err)
+		return internalerrors.ExitRuntimeError
+	}
+	out.Success("Updated %d file(s) to version %s", inconsistent, current)
+	return 0
+}
+
+// readProjectVersion reads the project version from the version source.
+// A non-zero code means it could not be read; the error has already been printed.
+func readProjectVersion(proj *project.Project, cmdName string) (string, int) {
+	if config.IsGitTagSource(proj.Config.Version) {
+		current, err := version.Resolve(proj.Root, proj.Config)
+		if err != nil {
+			out.ErrorPrefix("%s: cannot derive version from git tags: %v", cmdName, err)
+			return "", internalerrors.ExitRuntimeError
+		}
+		return current, 0
+	}
+
+	sourcePath := version.SourcePath(proj.Root, proj.Config.Version)
+	current, err := version.Read(sourcePath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			out.ErrorPrefix("%s: no project version at %s", cmdName, relativeToRoot(proj, sourcePath))
+			out.Hint("Run 'structyl version set <version>' first.")
+			return "", internalerrors.ExitConfigError
+		}
+		out.ErrorPrefix("%s: %v", cmdName, err)
+		return "", internalerrors.ExitConfigError
+	}
+	return current, 0
+}
+
+// readBaseVersion reads the version that "version bump" starts from: the
+// project version, or the last released version for a git:tag source (whose
+// untagged commits have dev versions). Without a release tag, 0.0.0 is used.
+func readBaseVersion(proj *project.Project, cmdName string) (string, int) {
+	if !config.IsGitTagSource(proj.Config.Version) {
+		return readProjectVersion(proj, cmdName)
+	}
+	current, err := version.LatestTagVersion(proj.Root, config.ReleaseTagFormat(proj.Config))
+	if err != nil {
+		out.ErrorPrefix("%s: cannot read release tags: %v", cmdName, err)
+		return "", internalerrors.ExitRuntimeError
+	}
+	if current == "" {
+		current = "0.0.0"
+	}
+	return current, 0
+}
+
+// versionSourceLabel returns how the version source is shown to users.
+func versionSourceLabel(proj *project.Project) string {
+	if config.IsGitTagSource(proj.Config.Version) {
+		return config.VersionSourceGitTag
+	}
+	return relativeToRoot(proj, version.SourcePath(proj.Root, proj.Config.Version))
+}
+
+// checkProjectVersion reads the project version and checks every configured
+// version file against it. A non-zero code means the project version could
+// not be read; the error has already been printed.
+func checkProjectVersion(proj *project.Project, cmdName string) (string, []version.FileStatus, int) {
+	current, code := readProjectVersion(proj, cmdName)
+	if code != 0 {
+		return "", nil, code
+	}
+
+	var statuses []version.FileStatus
+	if proj.Config.Version != nil {
+		statuses = version.CheckFiles(current, version.ResolveFiles(proj.Root, proj.Config.Version.Files))
+	}
+	return current, statuses, 0
+}
+
+// printVersionCheck prints the project version followed by one line per
+// version file with the version found in it.
+func printVersionCheck(proj *project.Project, current string, statuses []version.FileStatus) {
+	out.Println("%s: %s", versionSourceLabel(proj), current)
+	for _, s := range statuses {
+		path := relativeToRoot(proj, s.Path)
+		switch {
+		case s.OK():
+			out.SummaryPassed(path, s.Found+" ✓")
+		case s.Found != "":
+			out.SummaryFailed(path, fmt.Sprintf("%s ✗ (expected %s)", s.Found, current))
+		default:
+			out.SummaryFailed(path, fmt.Sprintf("✗ (%s)", s.Problem))
+		}
+	}
+}
+
+func countInconsistent(statuses []version.FileStatus) int {
+	n := 0
+	for _, s := range statuses {
+		if !s.OK() {
+			n++
+		}
+	}
+	return n
+}
+
+// parseVersionChangeArgs parses the flags of "version bump" and "version set".
+// Returns false after printing an error if the arguments are invalid.
+func parseVersionChangeArgs(cmdName string, args []string, allowPre bool) ([]string, versionChangeOptions, bool) {
+	var vopts versionChangeOptions
+	var positional []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case allowPre && arg == "--pre":
+			if i+1 >= len(args) {
+				out.ErrorPrefix("%s: --pre requires a value", cmdName)
+				return nil, vopts, false
+			}
+			vopts.pre = args[i+1]
+			i++
+		case allowPre && strings.HasPrefix(arg, "--pre="):
+			vopts.pre = strings.TrimPrefix(arg, "--pre=")
+		case arg == "--dry-run":
+			vopts.dryRun = true
+		case arg == "--release":
+			vopts.release = true
+		case arg == "--push":
+			vopts.push = true
+		case arg == "--force":
+			vopts.force = true
+		case strings.HasPrefix(arg, "-"):
+			out.ErrorPrefix("%s: unknown option %q", cmdName, arg)
+			return nil, vopts, false
+		default:
+			positional = append(positional, arg)
+		}
+	}
+	if (vopts.push || vopts.force) && !vopts.release {
+		out.ErrorPrefix("%s: --push and --force require --release", cmdName)
+		return nil, vopts, false
+	}
+	return positional, vopts, true
+}
+
+// applyProjectVersion writes next to the version source and propagates it to
+// the configured version files, or hands it to the release workflow.
+// current is the previous version ("" if unknown) and is only reported.
+func applyProjectVersion(proj *project.Project, current, next string, vopts versionChangeOptions, opts *GlobalOptions) int {
+	if vopts.release {
+		releaser := release.NewReleaser(proj.Root, proj.Config)
+		releaser.SetRedactedConfig(proj.Redacted)
+		err := releaser.Release(context.Background(), release.Options{
+			Version: next,
+			Push:    vopts.push,
+			DryRun:  vopts.dryRun,
+			Force:   vopts.force,
+			CI:      releaseCI(proj.Config, false, opts),
+		})
+		if err != nil {
+			out.ErrorPrefix("release: %v", err)
+			return internalerrors.ExitRuntimeError
+		}
+		return 0
+	}
+
+	// A git:tag version changes only when a release is tagged.
+	if config.IsGitTagSource(proj.Config.Version) {
+		out.ErrorPrefix("version: version source is %s; the version is set by tagging a release", config.VersionSourceGitTag)
+		out.Hint("Run with --release to tag version %s.", next)
+		return internalerrors.ExitConfigError
+	}
+
+	sourcePath := version.SourcePath(proj.Root, proj.Config.Version)
+	var files []string
+	if proj.Config.Version != nil {
+		for _, f := range proj.Config.Version.Files {
+			files = append(files, f.Path)
+		}
+	}
+
+	if vopts.dryRun {
+		out.DryRunStart()
+		out.Println("Would set version to %s%s", next, previousVersionNote(current))
+		out.SummaryItem("source", relativeToRoot(proj, sourcePath))
+		for _, f := range files {
+			out.SummaryItem("file", f)
+		}
+		out.DryRunEnd()
+		return 0
+	}
+
+	// A failed update restores every file, so the version does not drift.
+	var resolved []config.VersionFileConfig
+	paths := []string{sourcePath}
+	if len(files) > 0 {
+		resolved = version.ResolveFiles(proj.Root, proj.Config.Version.Files)
+		for _, f := range resolved {
+			paths = append(paths, f.Path)
+		}
+	}
+	snapshots, err := snapshotFiles(paths)
+	if err != nil {
+		out.ErrorPrefix("version: %v", err)
+		return internalerrors.ExitRuntimeError
+	}
+	if err := writeProjectVersion(sourcePath, next, resolved); err != nil {
+		out.ErrorPrefix("version: %v", err)
+		if rerr := restoreFiles(snapshots); rerr != nil {
+			out.ErrorPrefix("version: failed to restore files: %v", rerr)
+		}
+		return internalerrors.ExitRuntimeError
+	}
+
+	out.Success("Version set to %s%s", next, previousVersionNote(current))
+	if opts.Verbose {
+		out.SummaryItem("source", relativeToRoot(proj, sourcePath))
+		for _, f := range files {
+			out.SummaryItem("file", f)
+		}
+	}
+	return 0
+}
+
+func previousVersionNote(current string) string {
+	if current == "" {
+		return ""
+	}
+	return fmt.Sprintf(" (was %s)", current)
+}
+
+// relativeToRoot returns path relative to the project root, for display.
+func relativeToRoot(proj *project.Project, path string) string {
+	if rel, err := filepath.Rel(proj.Root, path); err == nil {
+		return filepath.ToSlash(rel)
+	}
+	return path
+}
+
+func printVersionUsage() {
+	out.HelpTitle("structyl version - show or change versions")
+
+	out.HelpSection("Usage:")
+	out.HelpUsage("structyl version")
+	out.HelpUsage("structyl version bump <part> [--pre <id>] [options]")
+	out.HelpUsage("structyl version set <version> [options]")
+	out.HelpUsage("structyl version check [--fix]")
+
+	out.HelpSection("Description:")
+	out.Println("  Without a subcommand, prints the structyl CLI version.")
+	out.Println("")
+	out.Println("  'bump' computes the next project version from the version source and")
+	out.Println("  'set' uses an explicit one. Both write the version source and update")
+	out.Println("  every file in version.files, or hand the version to the release")
+	out.Println("  workflow with --release.")
+	out.Println("")
+	out.Println("  'check' verifies that every file in version.files contains the project")
+	out.Println("  version and exits with code 1 if any does not. 'structyl ci' runs the")
+	out.Println("  same check before the pipeline.")
+	out.Println("")
+
+	out.HelpSection("Parts:")
+	out.HelpFlag("major", "1.2.3 → 2.0.0", widthFlagShort)
+	out.HelpFlag("minor", "1.2.3 → 1.3.0", widthFlagShort)
+	out.HelpFlag("patch", "1.2.3 → 1.2.4", widthFlagShort)
+	out.HelpFlag("prerelease", "1.2.3 → 1.2.4-alpha.1, 1.2.4-alpha.1 → 1.2.4-alpha.2", widthFlagShort)
+	out.HelpFlag("release", "1.2.4-alpha.2 → 1.2.4", widthFlagShort)
+
+	out.HelpSection("Options:")
+	out.HelpFlag("--pre <id>", "Prerelease identifier for bump (e.g., alpha, rc)", widthFlagWithValue)
+	out.HelpFlag("--dry-run", "Print what would be done without making changes", widthFlagWithValue)
+	out.HelpFlag("--release", "Run the release workflow with the new version", widthFlagWithValue)
+	out.HelpFlag("--push", "With --release: push to remote with tags", widthFlagWithValue)
+	out.HelpFlag("--force", "With --release: allow uncommitted changes", widthFlagWithValue)
+	out.HelpFlag("--fix", "With check: update mismatched files", widthFlagWithValue)
+	out.HelpFlag("-h, --help", "Show this help", widthFlagWithValue)
+
+	out.HelpSection("Examples:")
+	out.HelpExample("structyl version bump patch", "1.2.3 → 1.2.4")
+	out.HelpExample("structyl version bump minor --pre rc", "1.2.3 → 1.3.0-rc.1")
+	out.HelpExample("structyl version set 2.0.0", "Set an explicit version")
+	out.HelpExample("structyl version check --fix", "Repair version drift")
+	out.HelpExample("structyl version bump minor --release --push", "Bump, commit, tag, and push")
+	out.Println("")
+}
+
+// writeProjectVersion writes next to the version source at sourcePath and
+// propagates it to files.
+func writeProjectVersion(sourcePath, next string, files []config.VersionFileConfig) error {
+	if err := os.MkdirAll(filepath.Dir(sourcePath), 0755); err != nil {
+		return err
+	}
+	if err := version.Write(sourcePath, next); err != nil {
+		return fmt.Errorf("failed to set version: %w", err)
+	}
+	if err := version.Propagate(next, files); err != nil {
+		return fmt.Errorf("failed to propagate version: %w", err)
+	}
+	return nil
+}
+
+// fileSnapshot is the content of a file, or its absence.
+type fileSnapshot struct {
+	path    string
+	content []byte
+	exists  bool
+}
+
+// snapshotFiles records the contents of paths, for restoreFiles.
+func snapshotFiles(paths []string) ([]fileSnapshot, error) {
+	snapshots := make([]fileSnapshot, 0, len(paths))
+	for _, path := range paths {
+		content, err := os.ReadFile(path)
+		switch {
+		case err == nil:
+			snapshots = append(snapshots, fileSnapshot{path: path, content: content, exists: true})
+		case errors.Is(err, os.ErrNotExist):
+			snapshots = append(snapshots, fileSnapshot{path: path})
+		default:
+			return nil, err
+		}
+	}
+	return snapshots, nil
+}
+
+// restoreFiles restores the files recorded by snapshotFiles, removing those
+// that did not exist.
+func restoreFiles(snapshots []fileSnapshot) error {
+	var errs []error
+	for _, s := range snapshots {
+		var err error
+		if s.exists {
+			err = os.WriteFile(s.path, s.content, 0644)
+		} else if err = os.Remove(s.path); errors.Is(err, os.ErrNotExist) {
+			err = nil
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
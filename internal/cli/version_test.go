@@ -0,0 +1,273 @@
+package cli
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	internalerrors "github.com/AndreyAkinshin/structyl/internal/errors"
+)
+
+// createVersionProject creates a project with version 1.2.3 propagated to
+// go/version.go.
+func createVersionProject(t *testing.T) string {
+	t.Helper()
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := `{
+  "project": {"name": "demo"},
+  "version": {
+    "source": "VERSION",
+    "files": [
+      {"path": "go/version.go", "pattern": "Version = \"[^\"]*\"", "replace": "Version = \"{version}\""}
+    ]
+  }
+}`
+	files := map[string]string{
+		".structyl/config.json": config,
+		"VERSION":               "1.2.3\n",
+		"go/version.go":         "package demo\n\nconst Version = \"1.2.3\"\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func readProjectFile(t *testing.T, root, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(name)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestCmdVersionBump_UpdatesSourceAndFiles(t *testing.T) {
+	root := createVersionProject(t)
+	withWorkingDir(t, root, func() {
+		if code := cmdVersion([]string{"bump", "minor", "--pre", "rc"}, &GlobalOptions{}); code != 0 {
+			t.Fatalf("cmdVersion(bump) = %d, want 0", code)
+		}
+	})
+	if got := readProjectFile(t, root, "VERSION"); got != "1.3.0-rc.1\n" {
+		t.Errorf("VERSION = %q, want 1.3.0-rc.1", got)
+	}
+	if got := readProjectFile(t, root, "go/version.go"); !strings.Contains(got, `Version = "1.3.0-rc.1"`) {
+		t.Errorf("go/version.go not updated:\n%s", got)
+	}
+}
+
+func TestCmdVersionSet_PropagationFails_RestoresFiles(t *testing.T) {
+	root := createVersionProject(t)
+	config := `{
+  "project": {"name": "demo"},
+  "version": {
+    "source": "VERSION",
+    "files": [
+      {"path": "go/version.go", "pattern": "Version = \"[^\"]*\"", "replace": "Version = \"{version}\""},
+      {"path": "py/version.py", "pattern": "__version__ = \"[^\"]*\"", "replace": "__version__ = \"{version}\""}
+    ]
+  }
+}`
+	files := map[string]string{
+		".structyl/config.json": config,
+		"py/version.py":         "VERSION = '1.2.3'\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	withWorkingDir(t, root, func() {
+		if code := cmdVersion([]string{"set", "2.0.0"}, &GlobalOptions{}); code != internalerrors.ExitRuntimeError {
+			t.Errorf("cmdVersion(set) = %d, want %d", code, internalerrors.ExitRuntimeError)
+		}
+	})
+	if got := readProjectFile(t, root, "VERSION"); got != "1.2.3\n" {
+		t.Errorf("VERSION = %q, want it restored to 1.2.3", got)
+	}
+	if got := readProjectFile(t, root, "go/version.go"); !strings.Contains(got, `Version = "1.2.3"`) {
+		t.Errorf("go/version.go not restored:\n%s", got)
+	}
+}
+
+func TestCmdVersionSet_DryRun_ChangesNothing(t *testing.T) {
+	root := createVersionProject(t)
+	withWorkingDir(t, root, func() {
+		if code := cmdVersion([]string{"set", "2.0.0", "--dry-run"}, &GlobalOptions{}); code != 0 {
+			t.Fatalf("cmdVersion(set --dry-run) = %d, want 0", code)
+		}
+	})
+	if got := readProjectFile(t, root, "VERSION"); got != "1.2.3\n" {
+		t.Errorf("VERSION = %q, want unchanged", got)
+	}
+}
+
+func TestCmdVersionSet_MissingSource_CreatesIt(t *testing.T) {
+	root := createVersionProject(t)
+	if err := os.Remove(filepath.Join(root, "VERSION")); err != nil {
+		t.Fatal(err)
+	}
+	withWorkingDir(t, root, func() {
+		if code := cmdVersion([]string{"bump", "patch"}, &GlobalOptions{}); code != internalerrors.ExitConfigError {
+			t.Errorf("cmdVersion(bump) without a version = %d, want %d", code, internalerrors.ExitConfigError)
+		}
+		if code := cmdVersion([]string{"set", "0.1.0"}, &GlobalOptions{}); code != 0 {
+			t.Fatalf("cmdVersion(set) = %d, want 0", code)
+		}
+	})
+	if got := readProjectFile(t, root, "VERSION"); got != "0.1.0\n" {
+		t.Errorf("VERSION = %q, want 0.1.0", got)
+	}
+}
+
+func TestCmdVersion_InvalidArguments_ReturnsError(t *testing.T) {
+	root := createVersionProject(t)
+	withWorkingDir(t, root, func() {
+		for _, args := range [][]string{
+			{"bogus"},
+			{"bump"},
+			{"bump", "huge"},
+			{"bump", "minor", "patch"},
+			{"bump", "release", "--pre", "rc"},
+			{"bump", "minor", "--pre"},
+			{"bump", "minor", "--push"},
+			{"set"},
+			{"set", "v1.0"},
+			{"set", "1.0.0", "--pre", "rc"},
+			{"check", "--bogus"},
+		} {
+			if code := cmdVersion(args, &GlobalOptions{}); code != internalerrors.ExitConfigError {
+				t.Errorf("cmdVersion(%v) = %d, want %d", args, code, internalerrors.ExitConfigError)
+			}
+		}
+	})
+	if got := readProjectFile(t, root, "VERSION"); got != "1.2.3\n" {
+		t.Errorf("VERSION = %q, want unchanged", got)
+	}
+}
+
+func TestCmdVersionCheck_Mismatch_FailsAndFixes(t *testing.T) {
+	root := createVersionProject(t)
+	if err := os.WriteFile(filepath.Join(root, "VERSION"), []byte("1.3.0\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	withWorkingDir(t, root, func() {
+		if code := cmdVersion([]string{"check"}, &GlobalOptions{}); code != internalerrors.ExitRuntimeError {
+			t.Errorf("cmdVersion(check) = %d, want %d", code, internalerrors.ExitRuntimeError)
+		}
+		if code := cmdVersion([]string{"check", "--fix"}, &GlobalOptions{}); code != 0 {
+			t.Errorf("cmdVersion(check --fix) = %d, want 0", code)
+		}
+		if code := cmdVersion([]string{"check"}, &GlobalOptions{}); code != 0 {
+			t.Errorf("cmdVersion(check) after --fix = %d, want 0", code)
+		}
+	})
+	if got := readProjectFile(t, root, "go/version.go"); !strings.Contains(got, `Version = "1.3.0"`) {
+		t.Errorf("go/version.go not fixed:\n%s", got)
+	}
+}
+
+func TestCmdCI_VersionMismatch_FailsBeforePipeline(t *testing.T) {
+	root := createVersionProject(t)
+	if err := os.WriteFile(filepath.Join(root, "VERSION"), []byte("1.3.0\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	withWorkingDir(t, root, func() {
+		if code := cmdCI("ci", nil, &GlobalOptions{}); code != internalerrors.ExitRuntimeError {
+			t.Errorf("cmdCI() = %d, want %d", code, internalerrors.ExitRuntimeError)
+		}
+	})
+	if got := readProjectFile(t, root, "go/version.go"); !strings.Contains(got, `Version = "1.2.3"`) {
+		t.Errorf("ci modified go/version.go:\n%s", got)
+	}
+}
+
+func TestCmdVersion_GitTagSource(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	root := createVersionProject(t)
+	config := readProjectFile(t, root, ".structyl/config.json")
+	config = strings.Replace(config, `"source": "VERSION"`, `"source": "git:tag"`, 1)
+	if err := os.WriteFile(filepath.Join(root, ".structyl", "config.json"), []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{
+		{"init"},
+		{"config", "user.email", "test@test.com"},
+		{"config", "user.name", "Test"},
+		{"add", "-A"},
+		{"commit", "--no-gpg-sign", "-m", "initial"},
+		{"tag", "v1.2.3"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = root
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+
+	withWorkingDir(t, root, func() {
+		// The tagged commit's version matches go/version.go.
+		if code := cmdVersion([]string{"check"}, &GlobalOptions{}); code != 0 {
+			t.Errorf("cmdVersion(check) = %d, want 0", code)
+		}
+		// Without --release there is no file to write the version to.
+		if code := cmdVersion([]string{"bump", "minor"}, &GlobalOptions{}); code != internalerrors.ExitConfigError {
+			t.Errorf("cmdVersion(bump) = %d, want %d", code, internalerrors.ExitConfigError)
+		}
+	})
+	if _, err := os.Stat(filepath.Join(root, "git:tag")); !os.IsNotExist(err) {
+		t.Error("version bump wrote a version file for a git:tag source")
+	}
+}
+
+func TestCmdReleaseNotes_WritesFile(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	root := createVersionProject(t)
+	for _, args := range [][]string{
+		{"init"},
+		{"config", "user.email", "test@test.com"},
+		{"config", "user.name", "Test"},
+		{"add", "-A"},
+		{"commit", "--no-gpg-sign", "-m", "feat: add median"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = root
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+
+	withWorkingDir(t, root, func() {
+		if code := cmdRelease([]string{"notes"}, &GlobalOptions{}); code != 0 {
+			t.Fatalf("cmdRelease(notes) = %d, want 0", code)
+		}
+		if code := cmdRelease([]string{"notes", "--output"}, &GlobalOptions{}); code != internalerrors.ExitConfigError {
+			t.Errorf("cmdRelease(notes --output) = %d, want %d", code, internalerrors.ExitConfigError)
+		}
+	})
+	notes := readProjectFile(t, root, "RELEASE_NOTES.md")
+	if !strings.HasPrefix(notes, "## 1.2.3 (") || !strings.Contains(notes, "add median") {
+		t.Errorf("RELEASE_NOTES.md =\n%s\nwant the notes of 1.2.3", notes)
+	}
+}
@@ -3,18 +3,19 @@ package config
 import (
 	"encoding/json"
 	"fmt"
-	"os"
 )
 
-// Load reads and parses a config.json configuration file.
+// Load reads and parses a configuration file in any of the supported formats,
+// merging the fragments it includes and the per-target structyl.target.json
+// files.
 func Load(path string) (*Config, error) {
-	data, err := os.ReadFile(path)
+	doc, err := loadDocument(path)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read config file: %w", err)
+		return nil, err
 	}
 
 	var cfg Config
-	if err := json.Unmarshal(data, &cfg); err != nil {
+	if err := json.Unmarshal(doc.data, &cfg); err != nil {
 		return nil, fmt.Errorf("failed to parse config file: %w", err)
 	}
 
@@ -33,22 +34,25 @@ func LoadWithDefaults(path string) (*Config, error) {
 }
 
 // LoadAndValidate reads a config file, applies defaults, validates, and returns warnings.
+// Warnings and validation errors caused by an included fragment or a target
+// file name that file.
 func LoadAndValidate(path string) (*Config, []string, error) {
-	data, err := os.ReadFile(path)
+	doc, err := loadDocument(path)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to read config file: %w", err)
+		return nil, nil, err
 	}
 
-	cfg, unknownWarnings, err := LoadWithWarnings(path, data)
-	if err != nil {
-		return nil, nil, err
+	var cfg Config
+	if err := json.Unmarshal(doc.data, &cfg); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse config file: %w", err)
 	}
+	unknownWarnings := doc.warnings
 
 	// applyDefaults must run before Validate: validation rules depend on
 	// default values being present (e.g., tolerance mode checks, array order).
-	applyDefaults(cfg)
+	applyDefaults(&cfg)
 
-	validationWarnings, err := Validate(cfg)
+	validationWarnings, err := Validate(&cfg)
 
 	// Combine warnings from both sources.
 	allWarnings := make([]string, 0, len(unknownWarnings)+len(validationWarnings))
@@ -56,8 +60,8 @@ func LoadAndValidate(path string) (*Config, []string, error) {
 	allWarnings = append(allWarnings, validationWarnings...)
 
 	if err != nil {
-		return nil, allWarnings, err
+		return nil, allWarnings, doc.attribute(err)
 	}
 
-	return cfg, allWarnings, nil
+	return &cfg, allWarnings, nil
 }
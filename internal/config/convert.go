@@ -0,0 +1,373 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Convert converts a configuration file from one format to another. Keys
+// keep their order, except that TOML requires tables after plain values.
+// Comments are not preserved. The result is checked to decode to the same
+// configuration as data.
+func Convert(data []byte, from, to string) ([]byte, error) {
+	obj, err := decodeOrdered(from, data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", from, err)
+	}
+
+	data, err = encode(obj, to)
+	if err != nil {
+		return nil, fmt.Errorf("cannot convert to %s: %w", to, err)
+	}
+
+	converted, err := decodeOrdered(to, data)
+	if err != nil {
+		return nil, fmt.Errorf("converted %s does not parse: %w", to, err)
+	}
+	if !reflect.DeepEqual(plain(obj), plain(converted)) {
+		return nil, fmt.Errorf("converted %s does not match the original", to)
+	}
+	return data, nil
+}
+
+// encode writes obj as a configuration file in format.
+func encode(obj *object, format string) ([]byte, error) {
+	var buf bytes.Buffer
+	var err error
+	switch format {
+	case FormatJSON, FormatJSONC:
+		err = writeJSON(&buf, obj, "  ")
+		buf.WriteByte('\n')
+	case FormatYAML:
+		err = writeYAML(&buf, obj)
+	case FormatTOML:
+		err = writeTOML(&buf, obj)
+	default:
+		return nil, fmt.Errorf("unknown format %q (supported: %s)", format, strings.Join(Formats, ", "))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// plain returns v with objects as maps and numbers as float64, for
+// comparing decoded trees.
+func plain(v any) any {
+	switch v := v.(type) {
+	case *object:
+		m := make(map[string]any, len(v.keys))
+		for _, k := range v.keys {
+			m[k] = plain(v.values[k])
+		}
+		return m
+	case []any:
+		items := make([]any, len(v))
+		for i, item := range v {
+			items[i] = plain(item)
+		}
+		return items
+	case json.Number:
+		f, _ := v.Float64()
+		return f
+	default:
+		return v
+	}
+}
+
+// writeJSON writes v as JSON, indented with indent if it is not empty.
+func writeJSON(buf *bytes.Buffer, v any, indent string) error {
+	return writeJSONValue(buf, v, indent, "")
+}
+
+func writeJSONValue(buf *bytes.Buffer, v any, indent, prefix string) error {
+	newline := func(p string) {
+		if indent != "" {
+			buf.WriteByte('\n')
+			buf.WriteString(p)
+		}
+	}
+	sep := ":"
+	if indent != "" {
+		sep = ": "
+	}
+
+	switch v := v.(type) {
+	case *object:
+		if len(v.keys) == 0 {
+			buf.WriteString("{}")
+			return nil
+		}
+		buf.WriteByte('{')
+		for i, k := range v.keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			newline(prefix + indent)
+			buf.WriteString(jsonString(k))
+			buf.WriteString(sep)
+			if err := writeJSONValue(buf, v.values[k], indent, prefix+indent); err != nil {
+				return err
+			}
+		}
+		newline(prefix)
+		buf.WriteByte('}')
+	case []any:
+		if len(v) == 0 {
+			buf.WriteString("[]")
+			return nil
+		}
+		buf.WriteByte('[')
+		for i, item := range v {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			newline(prefix + indent)
+			if err := writeJSONValue(buf, item, indent, prefix+indent); err != nil {
+				return err
+			}
+		}
+		newline(prefix)
+		buf.WriteByte(']')
+	case string:
+		buf.WriteString(jsonString(v))
+	case json.Number:
+		buf.WriteString(v.String())
+	case bool:
+		buf.WriteString(strconv.FormatBool(v))
+	case nil:
+		buf.WriteString("null")
+	default:
+		return fmt.Errorf("unsupported value %v", v)
+	}
+	return nil
+}
+
+// jsonString returns s as a JSON string, without escaping HTML characters.
+func jsonString(s string) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(s)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// writeYAML writes obj as a YAML document.
+func writeYAML(buf *bytes.Buffer, obj *object) error {
+	node, err := yamlNode(obj)
+	if err != nil {
+		return err
+	}
+	enc := yaml.NewEncoder(buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(node); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+func yamlNode(v any) (*yaml.Node, error) {
+	switch v := v.(type) {
+	case *object:
+		node := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		if len(v.keys) == 0 {
+			node.Style = yaml.FlowStyle
+		}
+		for _, k := range v.keys {
+			value, err := yamlNode(v.values[k])
+			if err != nil {
+				return nil, err
+			}
+			key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: k}
+			node.Content = append(node.Content, key, value)
+		}
+		return node, nil
+	case []any:
+		node := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		if len(v) == 0 {
+			node.Style = yaml.FlowStyle
+		}
+		for _, item := range v {
+			child, err := yamlNode(item)
+			if err != nil {
+				return nil, err
+			}
+			node.Content = append(node.Content, child)
+		}
+		return node, nil
+	case string:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: v}, nil
+	case json.Number:
+		tag := "!!int"
+		if _, err := v.Int64(); err != nil {
+			tag = "!!float"
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: v.String()}, nil
+	case bool:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: strconv.FormatBool(v)}, nil
+	case nil:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}, nil
+	default:
+		return nil, fmt.Errorf("unsupported value %v", v)
+	}
+}
+
+// bareKey matches the keys TOML allows without quotes.
+var bareKey = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// writeTOML writes obj as a TOML document. Objects become tables, and arrays
+// of objects become arrays of tables. TOML has no null, so a configuration
+// with null values (such as disabled commands) cannot be converted.
+func writeTOML(buf *bytes.Buffer, obj *object) error {
+	return writeTOMLTable(buf, obj, nil)
+}
+
+// writeTOMLTable writes the plain values of obj, then its tables under
+// the header path.
+func writeTOMLTable(buf *bytes.Buffer, obj *object, path []string) error {
+	for _, k := range obj.keys {
+		v := obj.values[k]
+		if isTOMLTable(v) || isTOMLArrayOfTables(v) {
+			continue
+		}
+		value, err := tomlInline(v, append(path[:len(path):len(path)], k))
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(buf, "%s = %s\n", tomlKey(k), value)
+	}
+
+	for _, k := range obj.keys {
+		childPath := append(path[:len(path):len(path)], k)
+		switch v := obj.values[k].(type) {
+		case *object:
+			if hasTOMLValues(v) {
+				writeTOMLHeader(buf, "["+tomlPath(childPath)+"]")
+			}
+			if err := writeTOMLTable(buf, v, childPath); err != nil {
+				return err
+			}
+		case []any:
+			if !isTOMLArrayOfTables(v) {
+				continue
+			}
+			for _, item := range v {
+				writeTOMLHeader(buf, "[["+tomlPath(childPath)+"]]")
+				if err := writeTOMLTable(buf, item.(*object), childPath); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// writeTOMLHeader writes a table header, separated by a blank line from
+// what precedes it.
+func writeTOMLHeader(buf *bytes.Buffer, header string) {
+	if buf.Len() > 0 {
+		buf.WriteByte('\n')
+	}
+	buf.WriteString(header)
+	buf.WriteByte('\n')
+}
+
+// hasTOMLValues reports whether a table needs its own header: it has plain
+// values, or nothing at all.
+func hasTOMLValues(obj *object) bool {
+	if len(obj.keys) == 0 {
+		return true
+	}
+	for _, k := range obj.keys {
+		if v := obj.values[k]; !isTOMLTable(v) && !isTOMLArrayOfTables(v) {
+			return true
+		}
+	}
+	return false
+}
+
+func isTOMLTable(v any) bool {
+	_, ok := v.(*object)
+	return ok
+}
+
+// isTOMLArrayOfTables reports whether v is a non-empty array of objects.
+func isTOMLArrayOfTables(v any) bool {
+	items, ok := v.([]any)
+	if !ok || len(items) == 0 {
+		return false
+	}
+	for _, item := range items {
+		if !isTOMLTable(item) {
+			return false
+		}
+	}
+	return true
+}
+
+// tomlInline returns v as an inline TOML value.
+func tomlInline(v any, path []string) (string, error) {
+	switch v := v.(type) {
+	case *object:
+		parts := make([]string, 0, len(v.keys))
+		for _, k := range v.keys {
+			value, err := tomlInline(v.values[k], append(path[:len(path):len(path)], k))
+			if err != nil {
+				return "", err
+			}
+			parts = append(parts, tomlKey(k)+" = "+value)
+		}
+		if len(parts) == 0 {
+			return "{}", nil
+		}
+		return "{ " + strings.Join(parts, ", ") + " }", nil
+	case []any:
+		parts := make([]string, 0, len(v))
+		for _, item := range v {
+			value, err := tomlInline(item, path)
+			if err != nil {
+				return "", err
+			}
+			parts = append(parts, value)
+		}
+		return "[" + strings.Join(parts, ", ") + "]", nil
+	case string:
+		return jsonString(v), nil
+	case json.Number:
+		s := v.String()
+		if _, err := v.Int64(); err != nil && !strings.ContainsAny(s, ".eE") {
+			// An integer out of the TOML range.
+			s += ".0"
+		}
+		return s, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case nil:
+		return "", fmt.Errorf("%s: TOML has no null value; set it in a JSON or YAML fragment listed in include", strings.Join(path, "."))
+	default:
+		return "", errors.New("unsupported value")
+	}
+}
+
+func tomlKey(k string) string {
+	if bareKey.MatchString(k) {
+		return k
+	}
+	return jsonString(k)
+}
+
+func tomlPath(path []string) string {
+	keys := make([]string, len(path))
+	for i, k := range path {
+		keys[i] = tomlKey(k)
+	}
+	return strings.Join(keys, ".")
+}
@@ -2,16 +2,45 @@ package config
 
 const (
 	DefaultVersionSource     = ".structyl/PROJECT_VERSION"
+	DefaultTagFormat         = "v{version}"
 	DefaultTestsDirectory    = "tests"
 	DefaultTestsPattern      = "**/*.json"
 	DefaultFloatTolerance    = 1e-9
-	DefaultToleranceMode     = "relative"
+	DefaultToleranceMode     = ToleranceModeRelative
+	DefaultArrayOrder        = ArrayOrderStrict
 	DefaultNaNEqualsNaN      = true
 	DefaultDockerComposeFile = "docker-compose.yml"
 	DefaultDockerEnvVar      = "STRUCTYL_DOCKER"
 	DefaultMiseAutoGenerate  = true
+	DefaultReleaseRemote     = "origin"
+	DefaultReleaseBranch     = "main"
+	DefaultArtifactsDir      = "artifacts"
 )
 
+// Defaults returns the defaults of optional settings that have a fixed
+// default, by JSON path; "*" stands for any map key. The configuration
+// schema documents them.
+func Defaults() map[string]any {
+	return map[string]any{
+		"version.source":                   DefaultVersionSource,
+		"tests.directory":                  DefaultTestsDirectory,
+		"tests.pattern":                    DefaultTestsPattern,
+		"tests.comparison.float_tolerance": DefaultFloatTolerance,
+		"tests.comparison.tolerance_mode":  DefaultToleranceMode,
+		"tests.comparison.array_order":     DefaultArrayOrder,
+		"tests.comparison.nan_equals_nan":  DefaultNaNEqualsNaN,
+		"docker.compose_file":              DefaultDockerComposeFile,
+		"docker.env_var":                   DefaultDockerEnvVar,
+		"mise.auto_generate":               DefaultMiseAutoGenerate,
+		"release.tag_format":               DefaultTagFormat,
+		"release.remote":                   DefaultReleaseRemote,
+		"release.branch":                   DefaultReleaseBranch,
+		"release.commit_message":           DefaultCommitMessage,
+		"release.notes":                    DefaultReleaseNotes,
+		"artifacts.output_dir":             DefaultArtifactsDir,
+	}
+}
+
 // applyDefaults fills in default values for unset configuration fields.
 func applyDefaults(cfg *Config) {
 	applyVersionDefaults(cfg)
@@ -29,6 +58,75 @@ func applyVersionDefaults(cfg *Config) {
 	}
 }
 
+// VersionSourceGitTag is the version source that derives the project version
+// from the most recent release tag instead of reading a file.
+const VersionSourceGitTag = "git:tag"
+
+// IsGitTagSource reports whether the project version is derived from git tags.
+func IsGitTagSource(cfg *VersionConfig) bool {
+	return cfg != nil && cfg.Source == VersionSourceGitTag
+}
+
+// ReleaseTagFormat returns the configured release tag format, or
+// DefaultTagFormat if none is set.
+func ReleaseTagFormat(cfg *Config) string {
+	if cfg != nil && cfg.Release != nil && cfg.Release.TagFormat != "" {
+		return cfg.Release.TagFormat
+	}
+	return DefaultTagFormat
+}
+
+// Release message templates. Placeholders are replaced when a release is made;
+// see ReleasePlaceholders.
+const (
+	DefaultCommitMessage       = "set version {version}"
+	DefaultTargetCommitMessage = "set {target} version {version}"
+	DefaultReleaseNotes        = "## {version} ({date})\n\n{changelog}"
+)
+
+// ReleasePlaceholders lists the placeholders available in release.commit_message
+// and release.notes.
+var ReleasePlaceholders = []string{"version", "date", "tag", "previous_tag", "target", "changelog", "changes"}
+
+// ReleaseCommitMessage returns the commit message template of a release of
+// target, or of the project if target is empty.
+func ReleaseCommitMessage(cfg *Config, target string) string {
+	if cfg != nil && cfg.Release != nil && cfg.Release.CommitMessage != "" {
+		return cfg.Release.CommitMessage
+	}
+	if target != "" {
+		return DefaultTargetCommitMessage
+	}
+	return DefaultCommitMessage
+}
+
+// ReleaseNotesTemplate returns the configured release notes template, or
+// DefaultReleaseNotes if none is set.
+func ReleaseNotesTemplate(cfg *Config) string {
+	if cfg != nil && cfg.Release != nil && cfg.Release.Notes != "" {
+		return cfg.Release.Notes
+	}
+	return DefaultReleaseNotes
+}
+
+// TargetVersionSource returns the version source of an independently
+// versioned target: the configured source, or .structyl/versions/<name>.
+func TargetVersionSource(name string, t TargetConfig) string {
+	if t.Version != nil && t.Version.Source != "" {
+		return t.Version.Source
+	}
+	return ".structyl/versions/" + name
+}
+
+// TargetTagFormat returns the release tag format of an independently
+// versioned target: the configured format, or <name>-v{version}.
+func TargetTagFormat(name string, t TargetConfig) string {
+	if t.Version != nil && t.Version.TagFormat != "" {
+		return t.Version.TagFormat
+	}
+	return name + "-v{version}"
+}
+
 func applyTestsDefaults(cfg *Config) {
 	if cfg.Tests == nil {
 		cfg.Tests = &TestsConfig{}
@@ -0,0 +1,457 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Configuration file formats. Every format is normalized to JSON when it is
+// read, so validation and unknown-field detection are the same for all.
+const (
+	FormatJSON  = "json"
+	FormatJSONC = "jsonc" // JSON with comments and trailing commas
+	FormatYAML  = "yaml"
+	FormatTOML  = "toml"
+)
+
+// Formats lists the supported configuration file formats.
+var Formats = []string{FormatJSON, FormatJSONC, FormatYAML, FormatTOML}
+
+// FileNames lists the accepted names of the project configuration file in
+// the .structyl directory, one per format.
+var FileNames = []string{"config.json", "config.jsonc", "config.yaml", "config.toml"}
+
+// FindFile returns the path of the project configuration file in dir, the
+// .structyl directory of a project. If there is none, the error satisfies
+// os.IsNotExist; several configuration files in one directory are an error.
+func FindFile(dir string) (string, error) {
+	var found []string
+	for _, name := range FileNames {
+		path := filepath.Join(dir, name)
+		info, err := os.Stat(path)
+		if err == nil && !info.IsDir() {
+			found = append(found, path)
+		} else if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return "", err
+		}
+	}
+	switch len(found) {
+	case 0:
+		return "", &os.PathError{Op: "find", Path: filepath.Join(dir, "config.*"), Err: os.ErrNotExist}
+	case 1:
+		return found[0], nil
+	default:
+		names := make([]string, len(found))
+		for i, path := range found {
+			names[i] = filepath.Base(path)
+		}
+		return "", fmt.Errorf("several configuration files in %s (%s): keep only one", dir, strings.Join(names, ", "))
+	}
+}
+
+// FormatOf returns the format of a configuration file from its extension.
+// Files with an unknown extension are JSON.
+func FormatOf(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".jsonc":
+		return FormatJSONC
+	case ".yaml", ".yml":
+		return FormatYAML
+	case ".toml":
+		return FormatTOML
+	default:
+		return FormatJSON
+	}
+}
+
+// ReadJSON reads the configuration file at path and returns its contents
+// normalized to JSON, as validated by the JSON schema.
+func ReadJSON(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return toJSON(filepath.Base(path), FormatOf(path), data)
+}
+
+// toJSON normalizes data, read from file in format, to JSON. JSONC keeps its
+// byte offsets, so that JSON syntax errors point to the right position.
+func toJSON(file, format string, data []byte) ([]byte, error) {
+	switch format {
+	case FormatJSON:
+		return data, nil
+	case FormatJSONC:
+		return stripJSONC(data), nil
+	}
+	v, err := decodeOrdered(format, data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", file, err)
+	}
+	var buf bytes.Buffer
+	if err := writeJSON(&buf, v, ""); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", file, err)
+	}
+	return buf.Bytes(), nil
+}
+
+// stripJSONC replaces the comments and trailing commas of JSONC data with
+// spaces. Line breaks are kept, so positions in the result are the same.
+func stripJSONC(data []byte) []byte {
+	out := bytes.Clone(data)
+	blank := func(from, to int) {
+		for i := from; i < to && i < len(out); i++ {
+			if out[i] != '\n' && out[i] != '\r' {
+				out[i] = ' '
+			}
+		}
+	}
+
+	lastComma := -1 // Offset of a comma not yet followed by a value
+	for i := 0; i < len(out); i++ {
+		switch c := out[i]; {
+		case c == '"':
+			lastComma = -1
+			for i++; i < len(out) && out[i] != '"'; i++ {
+				if out[i] == '\\' {
+					i++
+				}
+			}
+		case c == '/' && i+1 < len(out) && out[i+1] == '/':
+			end := bytes.IndexByte(out[i:], '\n')
+			if end < 0 {
+				end = len(out) - i
+			}
+			blank(i, i+end)
+			i += end - 1
+		case c == '/' && i+1 < len(out) && out[i+1] == '*':
+			end := bytes.Index(out[i+2:], []byte("*/"))
+			if end < 0 {
+				return out // Unterminated; left for the JSON parser to report
+			}
+			blank(i, i+2+end+2)
+			i += 2 + end + 1
+		case c == ',':
+			lastComma = i
+		case c == '}' || c == ']':
+			if lastComma >= 0 {
+				blank(lastComma, lastComma+1)
+			}
+			lastComma = -1
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+		default:
+			lastComma = -1
+		}
+	}
+	return out
+}
+
+// object is a decoded object that keeps the order of its keys, so that a
+// converted file keeps the layout of the original. Values are *object,
+// []any, string, json.Number, bool, or nil.
+type object struct {
+	keys   []string
+	values map[string]any
+}
+
+func newObject() *object {
+	return &object{values: make(map[string]any)}
+}
+
+// set sets key to v. A new key is added after the existing ones.
+func (o *object) set(key string, v any) {
+	if _, ok := o.values[key]; !ok {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = v
+}
+
+// decodeOrdered decodes a configuration file in format to an ordered tree.
+// The top-level value must be an object.
+func decodeOrdered(format string, data []byte) (*object, error) {
+	var v any
+	var err error
+	switch format {
+	case FormatJSON, FormatJSONC:
+		v, err = decodeJSONOrdered(stripJSONC(data))
+	case FormatYAML:
+		v, err = decodeYAML(data)
+	case FormatTOML:
+		v, err = decodeTOML(data)
+	default:
+		return nil, fmt.Errorf("unknown format %q", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	obj, ok := v.(*object)
+	if !ok {
+		return nil, errors.New("must be an object")
+	}
+	return obj, nil
+}
+
+// decodeJSONOrdered decodes JSON data to an ordered tree.
+func decodeJSONOrdered(data []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	v, err := decodeJSONValue(dec)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err == nil {
+		return nil, errors.New("unexpected data after the top-level value")
+	}
+	return v, nil
+}
+
+func decodeJSONValue(dec *json.Decoder) (any, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch tok {
+	case json.Delim('{'):
+		obj := newObject()
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			v, err := decodeJSONValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			obj.set(key.(string), v)
+		}
+		_, err := dec.Token()
+		return obj, err
+	case json.Delim('['):
+		items := []any{}
+		for dec.More() {
+			v, err := decodeJSONValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, v)
+		}
+		_, err := dec.Token()
+		return items, err
+	default:
+		return tok, nil
+	}
+}
+
+// decodeYAML decodes YAML data to an ordered tree. An empty document is an
+// empty object.
+func decodeYAML(data []byte) (any, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if doc.Kind == 0 {
+		return newObject(), nil
+	}
+	return yamlValue(&doc)
+}
+
+func yamlValue(node *yaml.Node) (any, error) {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		return yamlValue(node.Content[0])
+	case yaml.AliasNode:
+		return yamlValue(node.Alias)
+	case yaml.MappingNode:
+		obj := newObject()
+		explicit := make(map[string]bool)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if key.Kind != yaml.ScalarNode {
+				return nil, fmt.Errorf("line %d: keys must be strings", key.Line)
+			}
+			v, err := yamlValue(value)
+			if err != nil {
+				return nil, err
+			}
+			if key.ShortTag() == "!!merge" {
+				if err := yamlMerge(obj, explicit, v, key.Line); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			if explicit[key.Value] {
+				return nil, fmt.Errorf("line %d: duplicate key %q", key.Line, key.Value)
+			}
+			explicit[key.Value] = true
+			obj.set(key.Value, v)
+		}
+		return obj, nil
+	case yaml.SequenceNode:
+		items := make([]any, 0, len(node.Content))
+		for _, item := range node.Content {
+			v, err := yamlValue(item)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, v)
+		}
+		return items, nil
+	default:
+		return yamlScalar(node)
+	}
+}
+
+// yamlMerge merges the mappings of a merge key ("<<") into obj. Keys set
+// explicitly in obj take precedence.
+func yamlMerge(obj *object, explicit map[string]bool, v any, line int) error {
+	sources := []any{v}
+	if items, ok := v.([]any); ok {
+		sources = items
+	}
+	for _, src := range sources {
+		m, ok := src.(*object)
+		if !ok {
+			return fmt.Errorf("line %d: merge value must be a mapping", line)
+		}
+		for _, k := range m.keys {
+			if !explicit[k] {
+				if _, ok := obj.values[k]; !ok {
+					obj.set(k, m.values[k])
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func yamlScalar(node *yaml.Node) (any, error) {
+	switch node.ShortTag() {
+	case "!!null":
+		return nil, nil
+	case "!!bool":
+		var b bool
+		if err := node.Decode(&b); err != nil {
+			return nil, err
+		}
+		return b, nil
+	case "!!int":
+		var n int64
+		if err := node.Decode(&n); err != nil {
+			return nil, fmt.Errorf("line %d: %w", node.Line, err)
+		}
+		return json.Number(strconv.FormatInt(n, 10)), nil
+	case "!!float":
+		var f float64
+		if err := node.Decode(&f); err != nil {
+			return nil, fmt.Errorf("line %d: %w", node.Line, err)
+		}
+		return floatNumber(f, node.Line)
+	default:
+		// Strings, and timestamps as written.
+		return node.Value, nil
+	}
+}
+
+// floatNumber returns f as a JSON number. JSON has no infinities or NaN.
+func floatNumber(f float64, line int) (json.Number, error) {
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		return "", fmt.Errorf("line %d: %v cannot be represented in JSON", line, f)
+	}
+	return json.Number(strconv.FormatFloat(f, 'g', -1, 64)), nil
+}
+
+// decodeTOML decodes TOML data to an ordered tree. Keys are ordered as they
+// appear in the file.
+func decodeTOML(data []byte) (any, error) {
+	var m map[string]any
+	md, err := toml.Decode(string(data), &m)
+	if err != nil {
+		return nil, err
+	}
+	order := make(map[string]int)
+	for i, key := range md.Keys() {
+		if _, ok := order[key.String()]; !ok {
+			order[key.String()] = i
+		}
+	}
+	return tomlValue(m, nil, order)
+}
+
+func tomlValue(v any, path toml.Key, order map[string]int) (any, error) {
+	switch v := v.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		index := func(k string) int {
+			if i, ok := order[append(path[:len(path):len(path)], k).String()]; ok {
+				return i
+			}
+			return math.MaxInt
+		}
+		sort.SliceStable(keys, func(i, j int) bool {
+			a, b := index(keys[i]), index(keys[j])
+			if a != b {
+				return a < b
+			}
+			return keys[i] < keys[j]
+		})
+		obj := newObject()
+		for _, k := range keys {
+			child, err := tomlValue(v[k], append(path[:len(path):len(path)], k), order)
+			if err != nil {
+				return nil, err
+			}
+			obj.set(k, child)
+		}
+		return obj, nil
+	case []map[string]any:
+		items := make([]any, 0, len(v))
+		for _, item := range v {
+			child, err := tomlValue(item, path, order)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, child)
+		}
+		return items, nil
+	case []any:
+		items := make([]any, 0, len(v))
+		for _, item := range v {
+			child, err := tomlValue(item, path, order)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, child)
+		}
+		return items, nil
+	case int64:
+		return json.Number(strconv.FormatInt(v, 10)), nil
+	case float64:
+		if math.IsInf(v, 0) || math.IsNaN(v) {
+			return nil, fmt.Errorf("%s: %v cannot be represented in JSON", path, v)
+		}
+		return json.Number(strconv.FormatFloat(v, 'g', -1, 64)), nil
+	case string, bool:
+		return v, nil
+	case time.Time:
+		return v.Format(time.RFC3339Nano), nil
+	case fmt.Stringer:
+		// Local dates and times.
+		return v.String(), nil
+	default:
+		return nil, fmt.Errorf("%s: unsupported value %v", path, v)
+	}
+}
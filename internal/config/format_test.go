@@ -0,0 +1,248 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// formatTestConfigs is the same configuration in every format.
+var formatTestConfigs = map[string]string{
+	"config.json": `{
+		"project": {"name": "demo"},
+		"targets": {
+			"rs": {"type": "language", "title": "Rust", "commands": {"test": "cargo test && echo ok", "ci": ["test"]}, "vars": {"x.y": "1"}},
+			"py": {"type": "language", "title": "Python", "depends_on": ["rs"]}
+		},
+		"tests": {"comparison": {"float_tolerance": 1e-9, "tolerance_mode": "relative"}}
+	}`,
+	"config.jsonc": `{
+		// Project metadata
+		"project": {"name": "demo",},
+		"targets": {
+			/* Rust, with "// comment"-like text in strings */
+			"rs": {"type": "language", "title": "Rust", "commands": {"test": "cargo test && echo ok", "ci": ["test",]}, "vars": {"x.y": "1"}},
+			"py": {"type": "language", "title": "Python", "depends_on": ["rs"]},
+		},
+		"tests": {"comparison": {"float_tolerance": 1e-9, "tolerance_mode": "relative"}},
+	}`,
+	"config.yaml": `# Project metadata
+project:
+  name: demo
+targets:
+  rs:
+    type: language
+    title: Rust
+    commands:
+      test: cargo test && echo ok
+      ci: [test]
+    vars:
+      x.y: "1"
+  py:
+    type: language
+    title: Python
+    depends_on: [rs]
+tests:
+  comparison:
+    float_tolerance: 1e-9
+    tolerance_mode: relative
+`,
+	"config.toml": `# Project metadata
+[project]
+name = "demo"
+
+[targets.rs]
+type = "language"
+title = "Rust"
+commands = { test = "cargo test && echo ok", ci = ["test"] }
+vars = { "x.y" = "1" }
+
+[targets.py]
+type = "language"
+title = "Python"
+depends_on = ["rs"]
+
+[tests.comparison]
+float_tolerance = 1e-9
+tolerance_mode = "relative"
+`,
+}
+
+func TestLoadAndValidate_Formats(t *testing.T) {
+	t.Parallel()
+	var want *Config
+	for _, name := range FileNames {
+		path := filepath.Join(filepath.Dir(writeProject(t, map[string]string{
+			".structyl/" + name: formatTestConfigs[name],
+			"rs/Cargo.toml":     "",
+			"py/pyproject.toml": "",
+		})), name)
+
+		cfg, warnings, err := LoadAndValidate(path)
+		if err != nil {
+			t.Fatalf("%s: LoadAndValidate() error = %v", name, err)
+		}
+		if len(warnings) != 0 {
+			t.Errorf("%s: warnings = %v, want none", name, warnings)
+		}
+		if want == nil {
+			want = cfg
+			continue
+		}
+		if !reflect.DeepEqual(cfg, want) {
+			t.Errorf("%s: config = %+v, want the same as config.json %+v", name, cfg, want)
+		}
+	}
+}
+
+func TestLoadAndValidate_FormatErrors(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{"config.jsonc", "{\n  // comment\n  \"project\": {\"name\": \"demo\"} x\n}", "config.jsonc:3:31: invalid character 'x'"},
+		{"config.yaml", "project:\n  name: [demo\n", "failed to parse config file .structyl/config.yaml: yaml: line"},
+		{"config.yaml", "project: {name: demo}\nproject: {name: other}\n", `line 2: duplicate key "project"`},
+		{"config.yaml", "- demo\n", "config.yaml: must be an object"},
+		{"config.yaml", "project:\n  name: 42\n", "project.name"},
+		{"config.toml", "[project]\nname = \n", "failed to parse config file .structyl/config.toml: toml:"},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(filepath.Dir(writeProject(t, map[string]string{".structyl/" + tt.name: tt.content})), tt.name)
+		_, _, err := LoadAndValidate(path)
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s %q: error = %v, want to contain %q", tt.name, tt.content, err, tt.wantErr)
+		}
+	}
+}
+
+func TestLoadAndValidate_UnknownFieldsInYAML(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(filepath.Dir(writeProject(t, map[string]string{
+		".structyl/config.yaml": "project: {name: demo}\ninclude: [ci.toml]\nbogus: 1\n",
+		".structyl/ci.toml":     "unknown = true\n\n[release]\nremote = \"upstream\"\n",
+	})), "config.yaml")
+
+	cfg, warnings, err := LoadAndValidate(path)
+	if err != nil {
+		t.Fatalf("LoadAndValidate() error = %v", err)
+	}
+	if cfg.Release == nil || cfg.Release.Remote != "upstream" {
+		t.Errorf("Release = %+v, want the TOML fragment merged", cfg.Release)
+	}
+	want := []string{
+		`unknown field "bogus" at root level (ignored)`,
+		`.structyl/ci.toml: unknown field "unknown" at root level (ignored)`,
+	}
+	if !reflect.DeepEqual(warnings, want) {
+		t.Errorf("warnings = %q, want %q", warnings, want)
+	}
+}
+
+func TestFindFile(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	if _, err := FindFile(dir); !os.IsNotExist(err) {
+		t.Errorf("FindFile() in empty dir error = %v, want not exist", err)
+	}
+
+	yamlPath := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(yamlPath, []byte("project: {name: demo}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := FindFile(dir); err != nil || got != yamlPath {
+		t.Errorf("FindFile() = %q, %v; want %q", got, err, yamlPath)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "config.json"), []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := FindFile(dir)
+	if err == nil || os.IsNotExist(err) || !strings.Contains(err.Error(), "(config.json, config.yaml): keep only one") {
+		t.Errorf("FindFile() with two files error = %v, want a conflict", err)
+	}
+}
+
+func TestStripJSONC(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{`{"a": 1} // x`, `{"a": 1}     `},
+		{"{\"a\": /* x\ny */ 1}", "{\"a\":     \n     1}"},
+		{`{"a": "// not a comment", "b": "/* nor */"}`, `{"a": "// not a comment", "b": "/* nor */"}`},
+		{`{"a": [1, 2,], "b": "\",",}`, `{"a": [1, 2 ], "b": "\"," }`},
+		{`{"a": 1, /* x */ }`, `{"a": 1          }`},
+	}
+	for _, tt := range tests {
+		if got := string(stripJSONC([]byte(tt.in))); got != tt.want {
+			t.Errorf("stripJSONC(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestConvert(t *testing.T) {
+	t.Parallel()
+	for _, from := range Formats {
+		for _, to := range Formats {
+			src := formatTestConfigs["config."+from]
+			got, err := Convert([]byte(src), from, to)
+			if err != nil {
+				t.Errorf("Convert(%s -> %s) error = %v", from, to, err)
+				continue
+			}
+			if !strings.Contains(string(got), "cargo test && echo ok") {
+				t.Errorf("Convert(%s -> %s) = %s, want HTML characters unescaped", from, to, got)
+			}
+		}
+	}
+
+	// Keys keep their order.
+	got, err := Convert([]byte(`{"targets": {"b": {"title": "B"}, "a": {"title": "A"}}, "project": {"name": "demo"}}`), FormatJSON, FormatYAML)
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+	want := "targets:\n  b:\n    title: B\n  a:\n    title: A\nproject:\n  name: demo\n"
+	if string(got) != want {
+		t.Errorf("Convert() = %q, want %q", got, want)
+	}
+
+	// Arrays of objects become arrays of tables in TOML.
+	got, err = Convert([]byte(`{"ci": {"steps": [{"name": "build"}, {"name": "test"}]}}`), FormatJSON, FormatTOML)
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+	want = "[[ci.steps]]\nname = \"build\"\n\n[[ci.steps]]\nname = \"test\"\n"
+	if string(got) != want {
+		t.Errorf("Convert() = %q, want %q", got, want)
+	}
+
+	// TOML has no null.
+	_, err = Convert([]byte(`{"targets": {"rs": {"commands": {"bench": null}}}}`), FormatJSON, FormatTOML)
+	if err == nil || !strings.Contains(err.Error(), "targets.rs.commands.bench: TOML has no null value") {
+		t.Errorf("Convert() with null to TOML error = %v", err)
+	}
+}
+
+func TestDecodeYAML_MergeKeys(t *testing.T) {
+	t.Parallel()
+	got, err := Convert([]byte(`base: &base {type: language, title: Base}
+rs:
+  <<: *base
+  title: Rust
+`), FormatYAML, FormatJSON)
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+	if !strings.Contains(string(got), `"rs": {
+    "type": "language",
+    "title": "Rust"
+  }`) {
+		t.Errorf("Convert() = %s, want the merged mapping with its own title", got)
+	}
+}
@@ -0,0 +1,343 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// TargetFileName is the name of a per-target configuration file. A target
+// directory containing one contributes its contents to the targets section
+// under the directory's target name.
+const TargetFileName = "structyl.target.json"
+
+// configDirName is the directory holding the project configuration file.
+// Target files are only discovered for configuration files inside it.
+const configDirName = ".structyl"
+
+// document is a configuration file loaded with its includes and target files
+// merged in.
+type document struct {
+	root     string            // Directory file names are relative to
+	main     string            // Name of the configuration file
+	data     []byte            // Merged JSON
+	warnings []string          // Unknown field warnings, prefixed with the fragment name
+	origins  map[string]string // JSON path -> name of the file that set it
+	seen     map[string]bool   // Absolute paths of loaded files
+	version  int               // Schema version of the main file, which its fragments share
+}
+
+// loadDocument reads the configuration file at path and merges the fragments
+// it includes and, if path is a project's .structyl/config.* file, the
+// structyl.target.json files of its configured targets. A value set by two
+// files is an error.
+func loadDocument(path string) (*document, error) {
+	d, abs, err := newDocument(path)
+	if err != nil {
+		return nil, err
+	}
+
+	merged := make(map[string]any)
+	if err := d.addFile(abs, merged); err != nil {
+		return nil, err
+	}
+	if filepath.Base(filepath.Dir(abs)) == configDirName {
+		if err := d.addTargetFiles(merged); err != nil {
+			return nil, err
+		}
+	}
+
+	if d.data, err = json.Marshal(merged); err != nil {
+		return nil, fmt.Errorf("failed to merge config files: %w", err)
+	}
+	return d, nil
+}
+
+// newDocument returns an empty document for the configuration file at path,
+// and the absolute path.
+func newDocument(path string) (*document, string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read config file: %w", err)
+	}
+	root := filepath.Dir(abs)
+	if filepath.Base(root) == configDirName {
+		root = filepath.Dir(root)
+	}
+
+	d := &document{
+		root:    root,
+		origins: make(map[string]string),
+		seen:    make(map[string]bool),
+	}
+	d.main = d.name(abs)
+	return d, abs, nil
+}
+
+// addFile merges the configuration file at path, then the files it includes.
+// Files may be in any of the configuration formats.
+func (d *document) addFile(path string, merged map[string]any) error {
+	d.seen[path] = true
+	name := d.name(path)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if name == d.main {
+			return fmt.Errorf("failed to read config file: %w", err)
+		}
+		return fmt.Errorf("failed to read config fragment %s: %w", name, err)
+	}
+	if data, err = toJSON(name, FormatOf(path), data); err != nil {
+		return err
+	}
+
+	var cfg Config
+	obj, err := decodeObject(name, data, &cfg)
+	if err != nil {
+		return err
+	}
+	if name == d.main {
+		if err := checkSchemaVersion(cfg.SchemaVersion); err != nil {
+			return err
+		}
+		d.version = cfg.SchemaVersion
+	} else if cfg.SchemaVersion != 0 {
+		return &ValidationError{File: name, Field: "schema_version", Message: "is only allowed in " + d.main}
+	}
+	if d.version < SchemaVersion {
+		// Older configurations are migrated when loaded; 'structyl config
+		// migrate' rewrites the file.
+		migrated, changes, err := migrateJSON(data, d.version)
+		if err != nil {
+			return fmt.Errorf("failed to parse config file %s: %w", name, err)
+		}
+		if len(changes) > 0 {
+			data = migrated
+			if obj, err = decodeObject(name, data, &cfg); err != nil {
+				return err
+			}
+			for i, c := range changes {
+				changes[i] = fmt.Sprintf("%s (schema version %d); run 'structyl config migrate'", c, d.version)
+			}
+			d.addWarnings(name, changes)
+		}
+	}
+	d.addWarnings(name, detectUnknownFields(data))
+
+	delete(obj, "include")
+	if err := d.merge(merged, obj, name, ""); err != nil {
+		return err
+	}
+
+	for i, pattern := range cfg.Include {
+		paths, err := resolveInclude(filepath.Dir(path), pattern)
+		if err != nil {
+			return fmt.Errorf("%s: include[%d]: %w", name, i, err)
+		}
+		for _, p := range paths {
+			if d.seen[p] {
+				continue // Included twice, or an include cycle
+			}
+			if err := d.addFile(p, merged); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// addTargetFiles merges the structyl.target.json files found in the
+// directories of configured targets. A target file adds to its target; it
+// does not define a new one.
+func (d *document) addTargetFiles(merged map[string]any) error {
+	// Configured target names by directory.
+	names := make(map[string]string)
+	targets, _ := merged["targets"].(map[string]any)
+	for name, t := range targets {
+		dir := name
+		if obj, ok := t.(map[string]any); ok {
+			if s, ok := obj["directory"].(string); ok && s != "" {
+				dir = filepath.ToSlash(filepath.Clean(s))
+			}
+		}
+		names[dir] = name
+	}
+
+	dirs := make([]string, 0, len(names))
+	for dir := range names {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+
+	for _, dir := range dirs {
+		path := filepath.Join(d.root, filepath.FromSlash(dir), TargetFileName)
+		data, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		name := d.name(path)
+		if err != nil {
+			return fmt.Errorf("failed to read target file %s: %w", name, err)
+		}
+
+		// Decode the target wrapped in a config, so that error and warning
+		// paths are the same as for a target in config.json.
+		var target map[string]any
+		if _, err := decodeObject(name, data, &target); err != nil {
+			return err
+		}
+		wrapped, err := json.Marshal(map[string]any{"targets": map[string]any{names[dir]: target}})
+		if err != nil {
+			return fmt.Errorf("failed to read target file %s: %w", name, err)
+		}
+		obj, err := decodeObject(name, wrapped, &Config{})
+		if err != nil {
+			return err
+		}
+		d.addWarnings(name, detectUnknownFields(wrapped))
+		if err := d.merge(merged, obj, name, ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// merge merges src, read from file, into dst. Objects are merged key by key;
+// any other value may only be set by one file.
+func (d *document) merge(dst, src map[string]any, file, path string) error {
+	keys := make([]string, 0, len(src))
+	for key := range src {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		p := key
+		if path != "" {
+			p = path + "." + key
+		}
+		existing, ok := dst[key]
+		if !ok {
+			dst[key] = src[key]
+			d.origins[p] = file
+			continue
+		}
+		dstObj, dstOK := existing.(map[string]any)
+		srcObj, srcOK := src[key].(map[string]any)
+		if !dstOK || !srcOK {
+			return fmt.Errorf("%s: %s is already set in %s", file, p, d.origin(p))
+		}
+		if err := d.merge(dstObj, srcObj, file, p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// indexPattern matches array indices in validation field paths.
+var indexPattern = regexp.MustCompile(`\[\d+\]`)
+
+// origin returns the name of the file that set the JSON path field, or of the
+// file that set its closest parent.
+func (d *document) origin(field string) string {
+	parts := strings.Split(indexPattern.ReplaceAllString(field, ""), ".")
+	for i := len(parts); i > 0; i-- {
+		if file, ok := d.origins[strings.Join(parts[:i], ".")]; ok {
+			return file
+		}
+	}
+	return d.main
+}
+
+// attribute sets the file of a validation error caused by a fragment.
+// Errors in the main configuration file keep their existing form.
+func (d *document) attribute(err error) error {
+	var ve *ValidationError
+	if errors.As(err, &ve) && ve.File == "" {
+		if file := d.origin(ve.Field); file != d.main {
+			ve.File = file
+		}
+	}
+	return err
+}
+
+// addWarnings records the unknown field warnings of file.
+func (d *document) addWarnings(file string, warnings []string) {
+	sort.Strings(warnings)
+	for _, w := range warnings {
+		if file != d.main {
+			w = file + ": " + w
+		}
+		d.warnings = append(d.warnings, w)
+	}
+}
+
+// name returns the display name of path: relative to the project root if
+// possible.
+func (d *document) name(path string) string {
+	if rel, err := filepath.Rel(d.root, path); err == nil && !strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(rel)
+	}
+	return path
+}
+
+// resolveInclude returns the files matched by an include pattern, relative to
+// dir. A pattern without wildcards must name an existing file.
+func resolveInclude(dir, pattern string) ([]string, error) {
+	if pattern == "" {
+		return nil, errors.New("empty path")
+	}
+	if !filepath.IsAbs(pattern) {
+		pattern = filepath.Join(dir, filepath.FromSlash(pattern))
+	}
+	if !strings.ContainsAny(pattern, "*?[") {
+		if _, err := os.Stat(pattern); err != nil {
+			return nil, err
+		}
+		return []string{pattern}, nil
+	}
+	paths, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern: %w", err)
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// decodeObject decodes data, read from file, into v to check its types, and
+// returns it as a JSON object. Errors name the file and the position or JSON
+// path of the problem.
+func decodeObject(file string, data []byte, v any) (map[string]any, error) {
+	if err := json.Unmarshal(data, v); err != nil {
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			// Offset counts the bytes read, including the offending one.
+			line, col := position(data, syntaxErr.Offset-1)
+			return nil, fmt.Errorf("failed to parse config file %s:%d:%d: %w", file, line, col, err)
+		}
+		// Type errors name the JSON path of the value.
+		return nil, fmt.Errorf("failed to parse config file %s: %w", file, err)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var obj map[string]any
+	if err := dec.Decode(&obj); err != nil || obj == nil {
+		return nil, fmt.Errorf("failed to parse config file %s: must be a JSON object", file)
+	}
+	return obj, nil
+}
+
+// position returns the 1-based line and column of the byte at offset in data.
+func position(data []byte, offset int64) (line, col int) {
+	offset = max(0, min(offset, int64(len(data))))
+	before := data[:offset]
+	line = bytes.Count(before, []byte("\n")) + 1
+	col = int(offset) - bytes.LastIndexByte(before, '\n')
+	return line, col
+}
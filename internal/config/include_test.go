@@ -0,0 +1,185 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeProject writes files (relative paths to contents) under a new project
+// root and returns the path of its .structyl/config.json.
+func writeProject(t *testing.T, files map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return filepath.Join(root, ".structyl", "config.json")
+}
+
+func TestLoadAndValidate_Include(t *testing.T) {
+	t.Parallel()
+	path := writeProject(t, map[string]string{
+		".structyl/config.json": `{
+			"project": {"name": "demo"},
+			"include": ["ci.json", "targets/*.json"],
+			"targets": {"go": {"type": "language", "title": "Go"}}
+		}`,
+		".structyl/ci.json": `{
+			"include": ["release.json"],
+			"ci": {"steps": [{"name": "build", "target": "all", "command": "build"}]}
+		}`,
+		".structyl/release.json":    `{"release": {"tag_format": "release-{version}"}}`,
+		".structyl/targets/py.json": `{"targets": {"py": {"type": "language", "title": "Python"}}}`,
+		".structyl/targets/rs.json": `{"targets": {"rs": {"type": "language", "title": "Rust"}}}`,
+	})
+
+	cfg, warnings, err := LoadAndValidate(path)
+	if err != nil {
+		t.Fatalf("LoadAndValidate() error = %v", err)
+	}
+	if len(warnings) != 0 {
+		t.Errorf("warnings = %v, want none", warnings)
+	}
+	for _, name := range []string{"go", "py", "rs"} {
+		if _, ok := cfg.Targets[name]; !ok {
+			t.Errorf("target %q not loaded", name)
+		}
+	}
+	if cfg.CI == nil || len(cfg.CI.Steps) != 1 {
+		t.Errorf("CI = %+v, want the step from ci.json", cfg.CI)
+	}
+	if cfg.Release == nil || cfg.Release.TagFormat != "release-{version}" {
+		t.Errorf("Release = %+v, want tag_format from the nested include", cfg.Release)
+	}
+}
+
+func TestLoadAndValidate_IncludeErrors(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		files   map[string]string
+		wantErr []string
+	}{
+		{
+			name: "missing fragment",
+			files: map[string]string{
+				".structyl/config.json": `{"project": {"name": "demo"}, "include": ["missing.json"]}`,
+			},
+			wantErr: []string{".structyl/config.json: include[0]", "missing.json"},
+		},
+		{
+			name: "value set twice",
+			files: map[string]string{
+				".structyl/config.json": `{"project": {"name": "demo"}, "include": ["a.json"], "release": {"remote": "origin"}}`,
+				".structyl/a.json":      `{"release": {"remote": "upstream"}}`,
+			},
+			wantErr: []string{".structyl/a.json: release.remote is already set in .structyl/config.json"},
+		},
+		{
+			name: "syntax error",
+			files: map[string]string{
+				".structyl/config.json": `{"project": {"name": "demo"}, "include": ["a.json"]}`,
+				".structyl/a.json":      "{\n  \"release\": {,}\n}",
+			},
+			wantErr: []string{"failed to parse config file .structyl/a.json:2:15"},
+		},
+		{
+			name: "type error",
+			files: map[string]string{
+				".structyl/config.json": `{"project": {"name": "demo"}, "include": ["a.json"]}`,
+				".structyl/a.json":      `{"targets": {"go": {"depends_on": "rs"}}}`,
+			},
+			wantErr: []string{".structyl/a.json", "targets.go.depends_on"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			_, _, err := LoadAndValidate(writeProject(t, tt.files))
+			if err == nil {
+				t.Fatal("LoadAndValidate() error = nil, want error")
+			}
+			for _, want := range tt.wantErr {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error = %q, want to contain %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadAndValidate_IncludeCycle(t *testing.T) {
+	t.Parallel()
+	path := writeProject(t, map[string]string{
+		".structyl/config.json": `{"project": {"name": "demo"}, "include": ["a.json"]}`,
+		".structyl/a.json":      `{"include": ["config.json", "a.json"], "release": {"remote": "upstream"}}`,
+	})
+	cfg, _, err := LoadAndValidate(path)
+	if err != nil {
+		t.Fatalf("LoadAndValidate() error = %v", err)
+	}
+	if cfg.Release == nil || cfg.Release.Remote != "upstream" {
+		t.Errorf("Release = %+v, want remote from a.json", cfg.Release)
+	}
+}
+
+func TestLoadAndValidate_TargetFiles(t *testing.T) {
+	t.Parallel()
+	path := writeProject(t, map[string]string{
+		".structyl/config.json": `{
+			"project": {"name": "demo"},
+			"targets": {
+				"go": {"type": "language", "title": "Go"},
+				"core": {"directory": "packages/core"}
+			}
+		}`,
+		"go/structyl.target.json":            `{"commands": {"build": "go build ./..."}, "colour": "blue"}`,
+		"py/structyl.target.json":            `{"type": "language", "title": "Python"}`,
+		"packages/core/structyl.target.json": `{"type": "auxiliary", "title": "Core"}`,
+		"docs/readme.md":                     "not a target",
+	})
+
+	cfg, warnings, err := LoadAndValidate(path)
+	if err != nil {
+		t.Fatalf("LoadAndValidate() error = %v", err)
+	}
+	if len(cfg.Targets) != 2 {
+		t.Errorf("Targets = %v, want go and core; py is not configured", cfg.Targets)
+	}
+	if got := cfg.Targets["go"].Commands["build"]; got != "go build ./..." {
+		t.Errorf("go build command = %v, want it from go/structyl.target.json", got)
+	}
+	if got := cfg.Targets["core"]; got.Type != "auxiliary" || got.Directory != "packages/core" {
+		t.Errorf("core target = %+v, want it merged from packages/core/structyl.target.json", got)
+	}
+	want := `go/structyl.target.json: unknown field "colour" in target "go" (ignored)`
+	if len(warnings) != 1 || warnings[0] != want {
+		t.Errorf("warnings = %q, want [%q]", warnings, want)
+	}
+}
+
+func TestLoadAndValidate_TargetFileValidationError(t *testing.T) {
+	t.Parallel()
+	path := writeProject(t, map[string]string{
+		".structyl/config.json":   `{"project": {"name": "demo"}, "targets": {"go": {"title": "Go"}}}`,
+		"go/structyl.target.json": `{"type": "compiled"}`,
+	})
+
+	_, _, err := LoadAndValidate(path)
+	var ve *ValidationError
+	if !errors.As(err, &ve) {
+		t.Fatalf("LoadAndValidate() error = %v, want *ValidationError", err)
+	}
+	if ve.File != "go/structyl.target.json" || ve.Field != "targets.go.type" {
+		t.Errorf("error = %q, want it attributed to go/structyl.target.json at targets.go.type", err)
+	}
+}
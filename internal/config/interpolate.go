@@ -0,0 +1,228 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// referencePattern matches ${env:...}, ${secret:...}, and ${file:...}
+// references, with an optional extra $ that escapes them.
+var referencePattern = regexp.MustCompile(`\$?\$\{(env|secret|file):([^}]*)\}`)
+
+// envNamePattern matches environment variable names in references.
+var envNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Redacted replaces secret values in output, as output.Redacted does.
+const Redacted = "***"
+
+// Reference is an ${env:NAME}, ${env:NAME:-default}, ${secret:NAME}, or
+// ${file:path} reference in a configuration value.
+type Reference struct {
+	Scheme     string // "env", "secret", or "file"
+	Name       string // Environment variable name or file path
+	Default    string // For env and secret: the value if the variable is unset or empty
+	HasDefault bool
+}
+
+// Secret reports whether the value of r is a secret: the contents of a file,
+// or an environment variable read with ${secret:NAME}.
+func (r Reference) Secret() bool {
+	return r.Scheme == "secret" || r.Scheme == "file"
+}
+
+// ReplaceReferences replaces the references in s with the results of fn.
+// $${ escapes a reference: $${env:HOME} is the literal text ${env:HOME}.
+func ReplaceReferences(s string, fn func(Reference) (string, error)) (string, error) {
+	var firstErr error
+	result := referencePattern.ReplaceAllStringFunc(s, func(match string) string {
+		if firstErr != nil {
+			return match
+		}
+		if strings.HasPrefix(match, "$$") {
+			return match[1:]
+		}
+		ref, err := parseReference(match)
+		if err == nil {
+			var value string
+			if value, err = fn(ref); err == nil {
+				return value
+			}
+		}
+		firstErr = err
+		return match
+	})
+	if firstErr != nil {
+		return "", firstErr
+	}
+	return result, nil
+}
+
+// parseReference parses a ${scheme:arg} reference.
+func parseReference(match string) (Reference, error) {
+	parts := referencePattern.FindStringSubmatch(match)
+	ref := Reference{Scheme: parts[1], Name: parts[2]}
+	switch ref.Scheme {
+	case "env", "secret":
+		if name, def, ok := strings.Cut(ref.Name, ":-"); ok {
+			ref.Name, ref.Default, ref.HasDefault = name, def, true
+		}
+		if !envNamePattern.MatchString(ref.Name) {
+			return ref, fmt.Errorf("%s: invalid environment variable name %q", match, ref.Name)
+		}
+		// Defaults and paths are written into mise templates as string
+		// literals, which have no escape sequences.
+		if strings.Contains(ref.Default, `"`) {
+			return ref, fmt.Errorf(`%s: default must not contain '"'`, match)
+		}
+	case "file":
+		if ref.Name == "" {
+			return ref, fmt.Errorf("%s: empty file path", match)
+		}
+		if strings.Contains(ref.Name, `"`) {
+			return ref, fmt.Errorf(`%s: file path must not contain '"'`, match)
+		}
+	}
+	return ref, nil
+}
+
+// ExpandValues returns two copies of cfg with the references in target env
+// and vars, docker target environment, and release remote replaced by their
+// values:
+//   - ${env:NAME} is the value of the environment variable NAME, which must
+//     be set.
+//   - ${env:NAME:-default} is the value of NAME, or default if it is unset
+//     or empty.
+//   - ${secret:NAME} and ${secret:NAME:-default} are the same as env, but
+//     the value is a secret.
+//   - ${file:path} is the contents of the file at path, relative to root,
+//     without trailing whitespace. It is a secret.
+//
+// In expanded, every reference is replaced by its value. In redacted, secret
+// references are replaced by Redacted instead, so that it can be shown.
+// secrets lists the non-empty values of the secret references, for redaction
+// from output. A missing environment variable or file is a *ValidationError
+// naming the field.
+func ExpandValues(cfg *Config, root string) (expanded, redacted *Config, secrets []string, err error) {
+	expanded, err = mapValues(cfg, func(ref Reference) (string, error) {
+		v, err := resolveReference(ref, root)
+		if err == nil && ref.Secret() && v != "" {
+			secrets = append(secrets, v)
+		}
+		return v, err
+	})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	redacted, err = mapValues(cfg, func(ref Reference) (string, error) {
+		if ref.Secret() {
+			return Redacted, nil
+		}
+		return resolveReference(ref, root)
+	})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return expanded, redacted, secrets, nil
+}
+
+// mapValues returns a copy of cfg with the references in the fields that
+// ExpandValues expands replaced by the results of fn.
+func mapValues(cfg *Config, fn func(Reference) (string, error)) (*Config, error) {
+	expand := func(field, value string) (string, error) {
+		result, err := ReplaceReferences(value, fn)
+		if err != nil {
+			return "", &ValidationError{Field: field, Message: err.Error()}
+		}
+		return result, nil
+	}
+	expandMap := func(field string, m map[string]string) (map[string]string, error) {
+		if m == nil {
+			return nil, nil
+		}
+		result := make(map[string]string, len(m))
+		for _, k := range sortedKeys(m) {
+			v, err := expand(field+"."+k, m[k])
+			if err != nil {
+				return nil, err
+			}
+			result[k] = v
+		}
+		return result, nil
+	}
+
+	result := *cfg
+	var err error
+	if cfg.Targets != nil {
+		result.Targets = make(map[string]TargetConfig, len(cfg.Targets))
+		for _, name := range sortedKeys(cfg.Targets) {
+			t := cfg.Targets[name]
+			if t.Env, err = expandMap("targets."+name+".env", t.Env); err != nil {
+				return nil, err
+			}
+			if t.Vars, err = expandMap("targets."+name+".vars", t.Vars); err != nil {
+				return nil, err
+			}
+			result.Targets[name] = t
+		}
+	}
+	if cfg.Docker != nil && cfg.Docker.Targets != nil {
+		docker := *cfg.Docker
+		docker.Targets = make(map[string]DockerTargetConfig, len(cfg.Docker.Targets))
+		for _, name := range sortedKeys(cfg.Docker.Targets) {
+			t := cfg.Docker.Targets[name]
+			if t.Environment, err = expandMap("docker.targets."+name+".environment", t.Environment); err != nil {
+				return nil, err
+			}
+			docker.Targets[name] = t
+		}
+		result.Docker = &docker
+	}
+	if cfg.Release != nil {
+		release := *cfg.Release
+		if release.Remote, err = expand("release.remote", release.Remote); err != nil {
+			return nil, err
+		}
+		result.Release = &release
+	}
+	return &result, nil
+}
+
+// resolveReference returns the value of a reference.
+func resolveReference(ref Reference, root string) (string, error) {
+	switch ref.Scheme {
+	case "env", "secret":
+		v, ok := os.LookupEnv(ref.Name)
+		if ref.HasDefault && v == "" {
+			return ref.Default, nil
+		}
+		if !ok {
+			return "", fmt.Errorf("environment variable %s is not set (use ${%s:%s:-default} to make it optional)", ref.Name, ref.Scheme, ref.Name)
+		}
+		return v, nil
+	default:
+		path := filepath.FromSlash(ref.Name)
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(root, path)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("cannot read %s: %w", ref.Name, err)
+		}
+		return strings.TrimRightFunc(string(data), unicode.IsSpace), nil
+	}
+}
+
+// sortedKeys returns the keys of m, sorted.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
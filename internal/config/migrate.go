@@ -0,0 +1,425 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// SchemaVersion is the configuration schema version of this version of
+// Structyl. A configuration without schema_version has version 0.
+const SchemaVersion = 1
+
+// ToolchainsVersion is the toolchains.json version of this version of
+// Structyl.
+const ToolchainsVersion = "1.0"
+
+// migration rewrites a configuration from schema version from to from+1.
+// apply returns a description of each change it made.
+type migration struct {
+	from  int
+	apply func(obj *object) []string
+}
+
+// migrations are the registered migrations, in order. A change to the shape
+// of the configuration increments SchemaVersion and adds a migration, so that
+// older configurations keep loading and 'structyl config migrate' can
+// rewrite them.
+var migrations = []migration{
+	{from: 0, apply: migrateUnversioned},
+}
+
+// migrateUnversioned migrates a configuration written before schema_version
+// existed. An empty tolerance_mode was accepted as relative; it is removed
+// since relative is the default.
+func migrateUnversioned(obj *object) []string {
+	var changes []string
+	removeEmptyToleranceMode := func(prefix string, tests *object) {
+		comparison := tests.child("comparison")
+		if s, ok := comparison.lookup("tolerance_mode").(string); ok && s == "" {
+			comparison.remove("tolerance_mode")
+			changes = append(changes, prefix+"tests.comparison.tolerance_mode: removed the empty value (relative is the default)")
+		}
+	}
+	removeEmptyToleranceMode("", obj.child("tests"))
+	profiles := obj.child("profiles")
+	for _, name := range profiles.fields() {
+		removeEmptyToleranceMode("profiles."+name+".", profiles.child(name).child("tests"))
+	}
+	return changes
+}
+
+// Migration is a configuration file migrated to the current version.
+type Migration struct {
+	Path    string   // File path
+	Name    string   // File name relative to the project root
+	From    string   // Version of the file as written
+	To      string   // Version after migration
+	Changes []string // Changes made by the migrations, besides setting the version
+	Data    []byte   // The migrated file, in its format; the input if nothing changed
+
+	// DropsComments is set if Data is the file re-encoded and the file as
+	// written has comments, which the re-encoding drops.
+	DropsComments bool
+
+	changed bool
+}
+
+// Changed reports whether the migrated file differs from the input.
+func (m *Migration) Changed() bool {
+	return m.changed
+}
+
+// Migrate migrates the configuration file at path and the fragments it
+// includes to SchemaVersion, and sets the schema_version of the file at path.
+// Fragments share the schema version of the file that includes them. Files
+// keep their format. A JSON or JSONC file whose only change is the schema
+// version is edited in place; other changed files are re-encoded, which drops
+// their comments. A file with a newer schema version is an error.
+func Migrate(path string) ([]*Migration, error) {
+	doc, abs, err := newDocument(path)
+	if err != nil {
+		return nil, err
+	}
+	var result []*Migration
+	err = doc.walk(abs, func(path, name string, data []byte) error {
+		m, err := migrateFile(data, FormatOf(path), doc.version, name == doc.main)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		if name == doc.main {
+			doc.version, _ = strconv.Atoi(m.From)
+		}
+		m.Path, m.Name = path, name
+		result = append(result, m)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// walk calls fn with the contents of the configuration file at path, then
+// walks the files it includes, in the order in which they are merged.
+func (d *document) walk(path string, fn func(path, name string, data []byte) error) error {
+	d.seen[path] = true
+	name := d.name(path)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	if err := fn(path, name, data); err != nil {
+		return err
+	}
+
+	jsonData, err := toJSON(name, FormatOf(path), data)
+	if err != nil {
+		return err
+	}
+	var cfg struct {
+		Include []string `json:"include"`
+	}
+	if err := json.Unmarshal(jsonData, &cfg); err != nil {
+		return fmt.Errorf("failed to parse config file %s: %w", name, err)
+	}
+	for i, pattern := range cfg.Include {
+		paths, err := resolveInclude(filepath.Dir(path), pattern)
+		if err != nil {
+			return fmt.Errorf("%s: include[%d]: %w", name, i, err)
+		}
+		for _, p := range paths {
+			if !d.seen[p] {
+				if err := d.walk(p, fn); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// migrateFile migrates a configuration file in format. The main file sets
+// the schema version; a fragment is migrated from schema version from.
+func migrateFile(data []byte, format string, from int, main bool) (*Migration, error) {
+	obj, err := decodeOrdered(format, data)
+	if err != nil {
+		return nil, err
+	}
+	if main {
+		if from, err = schemaVersionOf(obj); err != nil {
+			return nil, err
+		}
+	} else if obj.lookup("schema_version") != nil {
+		return nil, &ValidationError{Field: "schema_version", Message: "is only allowed in the main configuration file"}
+	}
+
+	m := &Migration{
+		From:    strconv.Itoa(from),
+		To:      strconv.Itoa(SchemaVersion),
+		Changes: applyMigrations(obj, from),
+		Data:    data,
+	}
+	m.changed = len(m.Changes) > 0 || (main && from != SchemaVersion)
+	if !m.changed {
+		return m, nil
+	}
+	if main && len(m.Changes) == 0 {
+		if edited, ok := insertSchemaVersion(data, format); ok {
+			m.Data = edited
+			return m, nil
+		}
+	}
+	if main {
+		setFirst(obj, "schema_version", json.Number(strconv.Itoa(SchemaVersion)))
+	}
+	if m.Data, err = encode(obj, format); err != nil {
+		return nil, fmt.Errorf("cannot write %s: %w", format, err)
+	}
+	m.DropsComments = hasComments(data, format)
+	return m, nil
+}
+
+// insertSchemaVersion adds schema_version to a JSON or JSONC file as a text
+// edit, so that its comments and layout are kept. The key is inserted before
+// the first key, or after $schema, on a line of its own if that key is on
+// one. It returns false for other formats and for a file with no such key.
+func insertSchemaVersion(data []byte, format string) ([]byte, bool) {
+	stripped := data
+	switch format {
+	case FormatJSON:
+	case FormatJSONC:
+		stripped = stripJSONC(data)
+	default:
+		return nil, false
+	}
+
+	// nextKey returns the offset of the key that dec reads next.
+	dec := json.NewDecoder(bytes.NewReader(stripped))
+	nextKey := func() (int, bool) {
+		if !dec.More() {
+			return 0, false
+		}
+		offset := int(dec.InputOffset())
+		i := bytes.IndexByte(stripped[offset:], '"')
+		return offset + i, i >= 0
+	}
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil, false
+	}
+	at, ok := nextKey()
+	if !ok {
+		return nil, false
+	}
+	if key, err := dec.Token(); err != nil {
+		return nil, false
+	} else if key == "$schema" {
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return nil, false
+		}
+		if at, ok = nextKey(); !ok {
+			return nil, false
+		}
+	}
+
+	member := `"schema_version": ` + strconv.Itoa(SchemaVersion) + ","
+	lineStart := bytes.LastIndexByte(stripped[:at], '\n') + 1
+	indent := stripped[lineStart:at]
+	var insert string
+	if lineStart > 0 && len(bytes.TrimSpace(indent)) == 0 {
+		insert, at = string(indent)+member+"\n", lineStart
+	} else {
+		insert = member + " "
+	}
+	result := make([]byte, 0, len(data)+len(insert))
+	result = append(result, data[:at]...)
+	result = append(result, insert...)
+	return append(result, data[at:]...), true
+}
+
+// hasComments reports whether data, in format, has comments. Any '#' in a
+// YAML or TOML file is taken for one.
+func hasComments(data []byte, format string) bool {
+	switch format {
+	case FormatJSONC:
+		// stripJSONC blanks comments and trailing commas.
+		stripped := stripJSONC(data)
+		for i := range data {
+			if data[i] != stripped[i] && data[i] != ',' {
+				return true
+			}
+		}
+	case FormatYAML, FormatTOML:
+		return bytes.IndexByte(data, '#') >= 0
+	}
+	return false
+}
+
+// MigrateToolchains migrates the contents of a toolchains.json file to
+// ToolchainsVersion. A file without a version predates versioning and has
+// the current shape.
+func MigrateToolchains(data []byte) (*Migration, error) {
+	obj, err := decodeOrdered(FormatJSON, data)
+	if err != nil {
+		return nil, err
+	}
+	m := &Migration{To: ToolchainsVersion, Data: data}
+	switch v := obj.lookup("version").(type) {
+	case nil:
+	case string:
+		if v != ToolchainsVersion {
+			return nil, &ValidationError{Field: "version", Message: fmt.Sprintf("unsupported toolchains.json version %q (this version of Structyl reads %q)", v, ToolchainsVersion)}
+		}
+		m.From = v
+	default:
+		return nil, &ValidationError{Field: "version", Message: "must be a string"}
+	}
+	m.changed = m.From != m.To
+	if !m.changed {
+		return m, nil
+	}
+	setFirst(obj, "version", ToolchainsVersion)
+	if m.Data, err = encode(obj, FormatJSON); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// migrateJSON migrates a configuration file normalized to JSON from schema
+// version from, for loading. It returns the changes made and the migrated
+// JSON, or data if nothing changed.
+func migrateJSON(data []byte, from int) ([]byte, []string, error) {
+	obj, err := decodeOrdered(FormatJSON, data)
+	if err != nil {
+		return nil, nil, err
+	}
+	changes := applyMigrations(obj, from)
+	if len(changes) == 0 {
+		return data, nil, nil
+	}
+	var buf bytes.Buffer
+	if err := writeJSON(&buf, obj, ""); err != nil {
+		return nil, nil, err
+	}
+	return buf.Bytes(), changes, nil
+}
+
+// applyMigrations applies the migrations from schema version from to
+// SchemaVersion.
+func applyMigrations(obj *object, from int) []string {
+	var changes []string
+	for _, m := range migrations {
+		if m.from >= from {
+			changes = append(changes, m.apply(obj)...)
+		}
+	}
+	return changes
+}
+
+// schemaVersionOf returns the schema_version of a configuration file.
+func schemaVersionOf(obj *object) (int, error) {
+	v, ok := obj.lookup("schema_version").(json.Number)
+	if !ok {
+		if obj.lookup("schema_version") != nil {
+			return 0, &ValidationError{Field: "schema_version", Message: "must be an integer"}
+		}
+		return 0, nil
+	}
+	version, err := strconv.Atoi(v.String())
+	if err != nil {
+		return 0, &ValidationError{Field: "schema_version", Message: "must be an integer"}
+	}
+	return version, checkSchemaVersion(version)
+}
+
+// checkSchemaVersion returns an error for a negative schema version or one
+// newer than SchemaVersion.
+func checkSchemaVersion(version int) error {
+	if version < 0 {
+		return &ValidationError{Field: "schema_version", Message: "must not be negative"}
+	}
+	if version > SchemaVersion {
+		return &ValidationError{
+			Field:   "schema_version",
+			Message: fmt.Sprintf("%d is newer than this version of Structyl supports (%d); upgrade Structyl", version, SchemaVersion),
+		}
+	}
+	return nil
+}
+
+// setFirst sets key in obj. A new key is added after $schema, or first.
+func setFirst(obj *object, key string, v any) {
+	if _, ok := obj.values[key]; !ok {
+		i := 0
+		if len(obj.keys) > 0 && obj.keys[0] == "$schema" {
+			i = 1
+		}
+		obj.keys = append(obj.keys[:i], append([]string{key}, obj.keys[i:]...)...)
+	}
+	obj.values[key] = v
+}
+
+// lookup returns the value of key, or nil if o is nil or has no such key.
+func (o *object) lookup(key string) any {
+	if o == nil {
+		return nil
+	}
+	return o.values[key]
+}
+
+// child returns the object under key, or nil if there is none.
+func (o *object) child(key string) *object {
+	child, _ := o.lookup(key).(*object)
+	return child
+}
+
+// fields returns the keys of o, in order. A nil object has none.
+func (o *object) fields() []string {
+	if o == nil {
+		return nil
+	}
+	return o.keys
+}
+
+// remove deletes key from o.
+func (o *object) remove(key string) {
+	if _, ok := o.values[key]; !ok {
+		return
+	}
+	delete(o.values, key)
+	for i, k := range o.keys {
+		if k == key {
+			o.keys = append(o.keys[:i], o.keys[i+1:]...)
+			break
+		}
+	}
+}
+
+// Deprecations returns the deprecated constructs that cfg relies on, which
+// no migration rewrites. cfg is the configuration as written, without
+// defaults. The legacy toolchain presets behind toolchain.Get are not
+// reported: every preset is also a default toolchain, so no toolchain name
+// in a configuration falls back to them.
+func Deprecations(cfg *Config) []string {
+	var found []string
+	docker := func(prefix string, d *DockerConfig) {
+		if d == nil {
+			return
+		}
+		if d.ComposeFile != "" {
+			found = append(found, prefix+"docker.compose_file: docker-compose is deprecated; generate per-target Dockerfiles with 'structyl dockerfile'")
+		}
+		if len(d.Services) > 0 {
+			found = append(found, prefix+"docker.services: docker-compose is deprecated; generate per-target Dockerfiles with 'structyl dockerfile'")
+		}
+	}
+	docker("", cfg.Docker)
+	for _, name := range ProfileNames(cfg) {
+		docker("profiles."+name+".", cfg.Profiles[name].Docker)
+	}
+	return found
+}
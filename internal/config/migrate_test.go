@@ -0,0 +1,264 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMigrate(t *testing.T) {
+	t.Parallel()
+	path := writeProject(t, map[string]string{
+		".structyl/config.json": `{
+  "$schema": "https://structyl.akinshin.dev/schema/config.json",
+  "project": {"name": "demo"},
+  "include": ["profiles.yaml"],
+  "tests": {"comparison": {"tolerance_mode": "", "float_tolerance": 0.001}}
+}
+`,
+		".structyl/profiles.yaml": "profiles:\n  ci:\n    tests:\n      comparison:\n        tolerance_mode: \"\"\n",
+	})
+
+	migrations, err := Migrate(path)
+	if err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+	if len(migrations) != 2 {
+		t.Fatalf("Migrate() = %d migrations, want config.json and profiles.yaml", len(migrations))
+	}
+
+	main := migrations[0]
+	if main.Name != ".structyl/config.json" || main.From != "0" || main.To != "1" || !main.Changed() {
+		t.Errorf("config.json migration = %s %s -> %s, changed %v", main.Name, main.From, main.To, main.Changed())
+	}
+	want := `{
+  "$schema": "https://structyl.akinshin.dev/schema/config.json",
+  "schema_version": 1,
+  "project": {
+    "name": "demo"
+  },
+  "include": [
+    "profiles.yaml"
+  ],
+  "tests": {
+    "comparison": {
+      "float_tolerance": 0.001
+    }
+  }
+}
+`
+	if string(main.Data) != want {
+		t.Errorf("config.json migrated to\n%s\nwant\n%s", main.Data, want)
+	}
+	wantChanges := []string{"tests.comparison.tolerance_mode: removed the empty value (relative is the default)"}
+	if !reflect.DeepEqual(main.Changes, wantChanges) {
+		t.Errorf("config.json changes = %q, want %q", main.Changes, wantChanges)
+	}
+
+	// Fragments are migrated, but have no schema_version of their own.
+	fragment := migrations[1]
+	if fragment.Name != ".structyl/profiles.yaml" || !fragment.Changed() {
+		t.Errorf("fragment migration = %s, changed %v", fragment.Name, fragment.Changed())
+	}
+	if want := "profiles:\n  ci:\n    tests:\n      comparison: {}\n"; string(fragment.Data) != want {
+		t.Errorf("profiles.yaml migrated to %q, want %q", fragment.Data, want)
+	}
+
+	// A migrated configuration is up to date.
+	for _, m := range migrations {
+		if err := os.WriteFile(m.Path, m.Data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	migrations, err = Migrate(path)
+	if err != nil {
+		t.Fatalf("Migrate() after migration error = %v", err)
+	}
+	for _, m := range migrations {
+		if m.Changed() {
+			t.Errorf("%s changed again: %s", m.Name, m.Data)
+		}
+	}
+}
+
+func TestMigrate_InsertsSchemaVersion(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name, file, content, want string
+	}{
+		{
+			"jsonc keeps comments",
+			"config.jsonc",
+			"{\n  // Project settings\n  \"project\": {\"name\": \"demo\"}, // trailing\n}\n",
+			"{\n  // Project settings\n  \"schema_version\": 1,\n  \"project\": {\"name\": \"demo\"}, // trailing\n}\n",
+		},
+		{
+			"after $schema",
+			"config.json",
+			"{\n\t\"$schema\": \"config.schema.json\",\n\t\"project\": {\"name\": \"demo\"}\n}\n",
+			"{\n\t\"$schema\": \"config.schema.json\",\n\t\"schema_version\": 1,\n\t\"project\": {\"name\": \"demo\"}\n}\n",
+		},
+		{
+			"single line",
+			"config.json",
+			`{"project": {"name": "demo"}}`,
+			`{"schema_version": 1, "project": {"name": "demo"}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			path := filepath.Join(filepath.Dir(writeProject(t, map[string]string{".structyl/" + tt.file: tt.content})), tt.file)
+			migrations, err := Migrate(path)
+			if err != nil {
+				t.Fatalf("Migrate() error = %v", err)
+			}
+			m := migrations[0]
+			if !m.Changed() || m.DropsComments {
+				t.Errorf("Changed() = %v, DropsComments = %v; want true, false", m.Changed(), m.DropsComments)
+			}
+			if string(m.Data) != tt.want {
+				t.Errorf("migrated to\n%s\nwant\n%s", m.Data, tt.want)
+			}
+		})
+	}
+}
+
+func TestMigrate_DropsComments(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		file, content string
+		want          bool
+	}{
+		{"config.jsonc", "{\n  // Settings\n  \"project\": {\"name\": \"demo\"},\n  \"tests\": {\"comparison\": {\"tolerance_mode\": \"\"}},\n}\n", true},
+		{"config.jsonc", "{\n  \"project\": {\"name\": \"demo\"},\n  \"tests\": {\"comparison\": {\"tolerance_mode\": \"\"}},\n}\n", false},
+		{"config.yaml", "# Settings\nproject:\n  name: demo\n", true},
+		{"config.yaml", "project:\n  name: demo\n", false},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(filepath.Dir(writeProject(t, map[string]string{".structyl/" + tt.file: tt.content})), tt.file)
+		migrations, err := Migrate(path)
+		if err != nil {
+			t.Fatalf("Migrate(%s) error = %v", tt.file, err)
+		}
+		if got := migrations[0].DropsComments; got != tt.want {
+			t.Errorf("Migrate(%q).DropsComments = %v, want %v", tt.content, got, tt.want)
+		}
+	}
+}
+
+func TestMigrate_Errors(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		files   map[string]string
+		wantErr string
+	}{
+		{
+			map[string]string{".structyl/config.json": `{"schema_version": 99, "project": {"name": "demo"}}`},
+			"schema_version: 99 is newer than this version of Structyl supports (1); upgrade Structyl",
+		},
+		{
+			map[string]string{".structyl/config.json": `{"schema_version": "1", "project": {"name": "demo"}}`},
+			"schema_version: must be an integer",
+		},
+		{
+			map[string]string{
+				".structyl/config.json": `{"schema_version": 1, "include": ["ci.json"], "project": {"name": "demo"}}`,
+				".structyl/ci.json":     `{"schema_version": 1}`,
+			},
+			".structyl/ci.json: schema_version: is only allowed in the main configuration file",
+		},
+	}
+	for _, tt := range tests {
+		_, err := Migrate(writeProject(t, tt.files))
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("Migrate(%v) error = %v, want %q", tt.files, err, tt.wantErr)
+		}
+	}
+}
+
+func TestLoadAndValidate_SchemaVersion(t *testing.T) {
+	t.Parallel()
+
+	// Older configurations are migrated when loaded, with a warning.
+	path := writeProject(t, map[string]string{
+		".structyl/config.json": `{"project": {"name": "demo"}, "tests": {"comparison": {"tolerance_mode": ""}}}`,
+	})
+	cfg, warnings, err := LoadAndValidate(path)
+	if err != nil {
+		t.Fatalf("LoadAndValidate() error = %v", err)
+	}
+	if cfg.Tests.Comparison.ToleranceMode != DefaultToleranceMode {
+		t.Errorf("ToleranceMode = %q, want the default", cfg.Tests.Comparison.ToleranceMode)
+	}
+	want := []string{"tests.comparison.tolerance_mode: removed the empty value (relative is the default) (schema version 0); run 'structyl config migrate'"}
+	if !reflect.DeepEqual(warnings, want) {
+		t.Errorf("warnings = %q, want %q", warnings, want)
+	}
+
+	// Current configurations load without warnings.
+	path = writeProject(t, map[string]string{
+		".structyl/config.json": `{"schema_version": 1, "project": {"name": "demo"}}`,
+	})
+	if _, warnings, err := LoadAndValidate(path); err != nil || len(warnings) != 0 {
+		t.Errorf("LoadAndValidate() = %q, %v; want no warnings", warnings, err)
+	}
+
+	// Newer configurations are an error.
+	path = writeProject(t, map[string]string{
+		".structyl/config.json": `{"schema_version": 2, "project": {"name": "demo"}}`,
+	})
+	if _, _, err := LoadAndValidate(path); err == nil || !strings.Contains(err.Error(), "upgrade Structyl") {
+		t.Errorf("LoadAndValidate() with a newer schema version error = %v", err)
+	}
+}
+
+func TestMigrateToolchains(t *testing.T) {
+	t.Parallel()
+	m, err := MigrateToolchains([]byte(`{"toolchains": {"mytool": {"commands": {"build": "make"}}}}`))
+	if err != nil {
+		t.Fatalf("MigrateToolchains() error = %v", err)
+	}
+	if !m.Changed() || m.From != "" || m.To != ToolchainsVersion {
+		t.Errorf("MigrateToolchains() = %q -> %q, changed %v", m.From, m.To, m.Changed())
+	}
+	if !strings.HasPrefix(string(m.Data), "{\n  \"version\": \"1.0\",\n  \"toolchains\"") {
+		t.Errorf("MigrateToolchains() data = %s, want the version first", m.Data)
+	}
+
+	data := []byte(`{"version": "1.0", "toolchains": {}}`)
+	if m, err := MigrateToolchains(data); err != nil || m.Changed() || string(m.Data) != string(data) {
+		t.Errorf("MigrateToolchains(current) = %+v, %v; want unchanged", m, err)
+	}
+	if _, err := MigrateToolchains([]byte(`{"version": "2.0"}`)); err == nil || !strings.Contains(err.Error(), `unsupported toolchains.json version "2.0"`) {
+		t.Errorf("MigrateToolchains(2.0) error = %v", err)
+	}
+}
+
+func TestDeprecations(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(filepath.Dir(writeProject(t, map[string]string{
+		".structyl/config.yaml": `project: {name: demo}
+docker:
+  compose_file: compose.yml
+profiles:
+  ci:
+    docker:
+      services:
+        go: {base_image: golang}
+`,
+	})), "config.yaml")
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := Deprecations(cfg)
+	if len(got) != 2 || !strings.HasPrefix(got[0], "docker.compose_file: ") || !strings.HasPrefix(got[1], "profiles.ci.docker.services: ") {
+		t.Errorf("Deprecations() = %q", got)
+	}
+	if got := Deprecations(&Config{}); len(got) != 0 {
+		t.Errorf("Deprecations(empty) = %q, want none", got)
+	}
+}
@@ -0,0 +1,211 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ProfileEnvVar is the environment variable that selects a profile when
+// --profile is not given.
+const ProfileEnvVar = "STRUCTYL_PROFILE"
+
+// profileNamePattern matches profile names. Names are used in file names
+// (mise.<profile>.toml), so they follow the target name rules.
+var profileNamePattern = regexp.MustCompile(`^[a-z][a-z0-9-]*$`)
+
+// ProfileNames returns the names of the profiles defined in cfg, sorted.
+func ProfileNames(cfg *Config) []string {
+	names := make([]string, 0, len(cfg.Profiles))
+	for name := range cfg.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ApplyProfile returns a copy of cfg with the named profile overlaid, or cfg
+// itself if name is empty. cfg must have defaults applied.
+//
+// The overlay is deterministic and independent of map order:
+//  1. The profile's env and vars are merged into every target.
+//  2. Each entry of the profile's targets is merged into that target, so
+//     per-target values win over profile-wide ones.
+//  3. The profile's docker section is merged into docker.
+//  4. The profile's tests.comparison is merged into tests.comparison.
+//
+// Merging is done on the JSON form: objects are merged key by key, and any
+// other value, including an array or null, replaces the existing value. A
+// null command thus disables the command.
+func ApplyProfile(cfg *Config, name string) (*Config, error) {
+	if name == "" {
+		return cfg, nil
+	}
+	if _, ok := cfg.Profiles[name]; !ok {
+		if len(cfg.Profiles) == 0 {
+			return nil, fmt.Errorf("unknown profile %q: no profiles are defined", name)
+		}
+		return nil, fmt.Errorf("unknown profile %q (available: %s)", name, strings.Join(ProfileNames(cfg), ", "))
+	}
+
+	result, err := applyProfile(cfg, name)
+	if err != nil {
+		return nil, fmt.Errorf("profile %q: %w", name, err)
+	}
+	if err := validateConfig(result); err != nil {
+		return nil, fmt.Errorf("profile %q: %w", name, err)
+	}
+	return result, nil
+}
+
+// applyProfile overlays the named profile on a copy of cfg without validating
+// the result.
+func applyProfile(cfg *Config, name string) (*Config, error) {
+	base, err := toJSONObject(cfg)
+	if err != nil {
+		return nil, err
+	}
+	profile, err := profileObject(cfg.Profiles[name])
+	if err != nil {
+		return nil, err
+	}
+
+	targets := childObject(base, "targets")
+	shared := make(map[string]any)
+	for _, key := range []string{"env", "vars"} {
+		if v, ok := profile[key]; ok {
+			shared[key] = v
+		}
+	}
+	for _, t := range targets {
+		if obj, ok := t.(map[string]any); ok {
+			mergePatch(obj, shared)
+		}
+	}
+	if overlays, ok := profile["targets"].(map[string]any); ok {
+		for targetName, overlay := range overlays {
+			target, ok := targets[targetName].(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("targets.%s: target is not defined in targets", targetName)
+			}
+			if obj, ok := overlay.(map[string]any); ok {
+				mergePatch(target, obj)
+			}
+		}
+	}
+	if overlay, ok := profile["docker"].(map[string]any); ok {
+		mergePatch(childObject(base, "docker"), overlay)
+	}
+	if tests, ok := profile["tests"].(map[string]any); ok {
+		if overlay, ok := tests["comparison"].(map[string]any); ok {
+			mergePatch(childObject(childObject(base, "tests"), "comparison"), overlay)
+		}
+	}
+
+	data, err := json.Marshal(base)
+	if err != nil {
+		return nil, err
+	}
+	var result Config
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, err
+	}
+	applyDefaults(&result) // For sections the profile created
+	return &result, nil
+}
+
+// profileObject returns the JSON form of a profile as written.
+func profileObject(p ProfileConfig) (map[string]any, error) {
+	if p.raw != nil {
+		return decodeJSONObject(p.raw)
+	}
+	return toJSONObject(p) // Built in code rather than decoded
+}
+
+// mergePatch merges src into dst: objects key by key, other values replace.
+func mergePatch(dst, src map[string]any) {
+	for key, v := range src {
+		srcObj, srcOK := v.(map[string]any)
+		dstObj, dstOK := dst[key].(map[string]any)
+		if srcOK && dstOK {
+			mergePatch(dstObj, srcObj)
+			continue
+		}
+		if srcOK {
+			copied := make(map[string]any, len(srcObj))
+			mergePatch(copied, srcObj)
+			v = copied
+		}
+		dst[key] = v
+	}
+}
+
+// childObject returns obj[key] as an object, creating it if needed.
+func childObject(obj map[string]any, key string) map[string]any {
+	child, ok := obj[key].(map[string]any)
+	if !ok {
+		child = make(map[string]any)
+		obj[key] = child
+	}
+	return child
+}
+
+// toJSONObject returns the JSON form of v.
+func toJSONObject(v any) (map[string]any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return decodeJSONObject(data)
+}
+
+// decodeJSONObject decodes a JSON object, keeping numbers exact.
+func decodeJSONObject(data []byte) (map[string]any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	obj := make(map[string]any)
+	if err := dec.Decode(&obj); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// validateProfiles checks profile names and that every profile applies to a
+// valid configuration.
+func validateProfiles(cfg *Config) error {
+	for _, name := range ProfileNames(cfg) {
+		field := "profiles." + name
+		if !profileNamePattern.MatchString(name) {
+			return &ValidationError{
+				Field:   field,
+				Message: "profile name must match pattern ^[a-z][a-z0-9-]*$",
+			}
+		}
+		p := cfg.Profiles[name]
+		targetNames := make([]string, 0, len(p.Targets))
+		for targetName := range p.Targets {
+			targetNames = append(targetNames, targetName)
+		}
+		sort.Strings(targetNames)
+		for _, targetName := range targetNames {
+			if _, ok := cfg.Targets[targetName]; !ok {
+				return &ValidationError{
+					Field:   fmt.Sprintf("%s.targets.%s", field, targetName),
+					Message: "target is not defined in targets",
+				}
+			}
+		}
+
+		applied, err := applyProfile(cfg, name)
+		if err == nil {
+			err = validateConfig(applied)
+		}
+		if err != nil {
+			return &ValidationError{Field: field, Message: err.Error()}
+		}
+	}
+	return nil
+}
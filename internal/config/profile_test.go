@@ -0,0 +1,165 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// profileConfig parses a configuration with profiles and applies defaults.
+func profileConfig(t *testing.T, data string) *Config {
+	t.Helper()
+	var cfg Config
+	if err := json.Unmarshal([]byte(data), &cfg); err != nil {
+		t.Fatal(err)
+	}
+	applyDefaults(&cfg)
+	return &cfg
+}
+
+const profileTestConfig = `{
+	"project": {"name": "demo"},
+	"targets": {
+		"rs": {
+			"type": "language", "title": "Rust",
+			"env": {"RUST_LOG": "info", "KEEP": "1"},
+			"commands": {"build": "cargo build", "test": "cargo test", "bench": "cargo bench"}
+		},
+		"py": {"type": "language", "title": "Python", "vars": {"mode": "dev"}}
+	},
+	"docker": {"compose_file": "compose.yml", "targets": {"rs": {"platform": "linux/amd64"}}},
+	"tests": {"comparison": {"tolerance_mode": "relative", "nan_equals_nan": true}},
+	"profiles": {
+		"ci": {
+			"env": {"CI": "1", "RUST_LOG": "warn"},
+			"vars": {"mode": "ci"},
+			"targets": {
+				"rs": {"env": {"RUST_LOG": "debug"}, "commands": {"test": "cargo nextest run", "bench": null}}
+			},
+			"docker": {"targets": {"rs": {"cache_volume": "rs-cache"}}},
+			"tests": {"comparison": {"tolerance_mode": "absolute", "nan_equals_nan": false}}
+		},
+		"local": {}
+	}
+}`
+
+func TestApplyProfile(t *testing.T) {
+	t.Parallel()
+	cfg := profileConfig(t, profileTestConfig)
+
+	got, err := ApplyProfile(cfg, "ci")
+	if err != nil {
+		t.Fatalf("ApplyProfile() error = %v", err)
+	}
+
+	rs := got.Targets["rs"]
+	wantEnv := map[string]string{"RUST_LOG": "debug", "KEEP": "1", "CI": "1"}
+	if !reflect.DeepEqual(rs.Env, wantEnv) {
+		t.Errorf("rs env = %v, want %v (target overlay wins over profile-wide env)", rs.Env, wantEnv)
+	}
+	wantCommands := map[string]interface{}{"build": "cargo build", "test": "cargo nextest run", "bench": nil}
+	if !reflect.DeepEqual(rs.Commands, wantCommands) {
+		t.Errorf("rs commands = %v, want %v", rs.Commands, wantCommands)
+	}
+	if got := got.Targets["py"].Vars["mode"]; got != "ci" {
+		t.Errorf("py vars.mode = %q, want %q", got, "ci")
+	}
+	if got := got.Targets["py"].Env["CI"]; got != "1" {
+		t.Errorf("py env.CI = %q, want the profile-wide value", got)
+	}
+	wantDocker := DockerTargetConfig{Platform: "linux/amd64", CacheVolume: "rs-cache"}
+	if got.Docker.ComposeFile != "compose.yml" || !reflect.DeepEqual(got.Docker.Targets["rs"], wantDocker) {
+		t.Errorf("docker = %+v, want the overlay merged into the base settings", got.Docker)
+	}
+	if c := got.Tests.Comparison; c.ToleranceMode != "absolute" || c.NaNEqualsNaN {
+		t.Errorf("tests.comparison = %+v, want tolerance_mode absolute and nan_equals_nan false", c)
+	}
+
+	// The base configuration is not modified.
+	if cfg.Targets["rs"].Env["RUST_LOG"] != "info" || cfg.Tests.Comparison.ToleranceMode != "relative" {
+		t.Error("ApplyProfile() modified its input")
+	}
+}
+
+func TestApplyProfile_EmptyAndUnknown(t *testing.T) {
+	t.Parallel()
+	cfg := profileConfig(t, profileTestConfig)
+
+	if got, err := ApplyProfile(cfg, ""); err != nil || got != cfg {
+		t.Errorf("ApplyProfile(\"\") = %p, %v; want the input", got, err)
+	}
+	if got, err := ApplyProfile(cfg, "local"); err != nil || !reflect.DeepEqual(got.Targets, cfg.Targets) {
+		t.Errorf("ApplyProfile(local) = %+v, %v; want the targets unchanged", got, err)
+	}
+
+	_, err := ApplyProfile(cfg, "prod")
+	if err == nil || !strings.Contains(err.Error(), `unknown profile "prod" (available: ci, local)`) {
+		t.Errorf("ApplyProfile(prod) error = %v, want the available profiles", err)
+	}
+	_, err = ApplyProfile(profileConfig(t, `{"project": {"name": "demo"}}`), "ci")
+	if err == nil || !strings.Contains(err.Error(), "no profiles are defined") {
+		t.Errorf("ApplyProfile() without profiles error = %v", err)
+	}
+}
+
+func TestValidate_Profiles(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		profiles string
+		wantErr  string
+	}{
+		{"valid", `{"ci": {"targets": {"rs": {"commands": {"test": ["build", "lint"]}}}}}`, ""},
+		{"invalid name", `{"CI": {}}`, "profiles.CI: profile name must match"},
+		{"undefined target", `{"ci": {"targets": {"go": {"env": {"A": "1"}}}}}`, "profiles.ci.targets.go: target is not defined"},
+		{"invalid result", `{"ci": {"tests": {"comparison": {"tolerance_mode": "fuzzy"}}}}`, "profiles.ci: tests.comparison.tolerance_mode"},
+		{"invalid command", `{"ci": {"targets": {"rs": {"commands": {"test": {"run": "x"}}}}}}`, "profiles.ci: targets.rs.commands.test"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			cfg := profileConfig(t, `{
+				"project": {"name": "demo"},
+				"targets": {"rs": {"type": "language", "title": "Rust", "commands": {"build": "cargo build", "lint": "cargo clippy"}}},
+				"profiles": `+tt.profiles+`
+			}`)
+			_, err := Validate(cfg)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() error = %v, want to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDetectUnknownFields_Profiles(t *testing.T) {
+	t.Parallel()
+	warnings := detectUnknownFields([]byte(`{
+		"project": {"name": "demo"},
+		"profiles": {"ci": {
+			"release": {},
+			"tests": {"directory": "x"},
+			"targets": {"rs": {"toolchain": "cargo"}}
+		}}
+	}`))
+	want := []string{
+		`unknown field "release" in profile "ci" (ignored)`,
+		`unknown field "directory" in profile "ci" tests (ignored)`,
+		`unknown field "toolchain" in profile "ci" target "rs" (ignored)`,
+	}
+	for _, w := range want {
+		found := false
+		for _, got := range warnings {
+			found = found || got == w
+		}
+		if !found {
+			t.Errorf("warnings = %q, want to contain %q", warnings, w)
+		}
+	}
+}
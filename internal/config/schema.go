@@ -1,153 +1,224 @@
 // Package config provides configuration loading and validation for config.json.
 package config
 
+import "encoding/json"
+
 // Config represents the complete config.json configuration.
 type Config struct {
-	Project       ProjectConfig              `json:"project"`
-	Version       *VersionConfig             `json:"version,omitempty"`
-	Targets       map[string]TargetConfig    `json:"targets,omitempty"`
-	Toolchains    map[string]ToolchainConfig `json:"toolchains,omitempty"`
-	Mise          *MiseConfig                `json:"mise,omitempty"`
-	Tests         *TestsConfig               `json:"tests,omitempty"`
-	Documentation *DocsConfig                `json:"documentation,omitempty"`
-	Docker        *DockerConfig              `json:"docker,omitempty"`
-	Release       *ReleaseConfig             `json:"release,omitempty"`
-	CI            *CIConfig                  `json:"ci,omitempty"`
-	Artifacts     *ArtifactsConfig           `json:"artifacts,omitempty"`
+	SchemaVersion int                        `json:"schema_version,omitempty" jsonschema:"minimum=0" jsonschema_description:"Configuration schema version; omitted means 0. Run 'structyl config migrate' to update older configurations"`
+	Include       []string                   `json:"include,omitempty" jsonschema_description:"Config fragments merged into this file; paths or glob patterns relative to this file"`
+	Project       ProjectConfig              `json:"project" jsonschema_description:"Project metadata"`
+	Version       *VersionConfig             `json:"version,omitempty" jsonschema_description:"Version management configuration"`
+	Targets       map[string]TargetConfig    `json:"targets,omitempty" jsonschema:"keyPattern=^[a-z][a-z0-9-]*$,keyMinLength=1,keyMaxLength=64" jsonschema_description:"Build targets (languages and auxiliary)"`
+	Toolchains    map[string]ToolchainConfig `json:"toolchains,omitempty" jsonschema_description:"Custom toolchain definitions"`
+	Mise          *MiseConfig                `json:"mise,omitempty" jsonschema_description:"Mise build tool integration configuration"`
+	Tests         *TestsConfig               `json:"tests,omitempty" jsonschema_description:"Reference test system configuration"`
+	Documentation *DocsConfig                `json:"documentation,omitempty" jsonschema_description:"Documentation generation settings"`
+	Docker        *DockerConfig              `json:"docker,omitempty" jsonschema_description:"Docker configuration"`
+	Release       *ReleaseConfig             `json:"release,omitempty" jsonschema_description:"Release workflow configuration"`
+	CI            *CIConfig                  `json:"ci,omitempty" jsonschema_description:"CI pipeline configuration"`
+	Artifacts     *ArtifactsConfig           `json:"artifacts,omitempty" jsonschema_description:"Artifact collection configuration"`
+	Profiles      map[string]ProfileConfig   `json:"profiles,omitempty" jsonschema:"keyPattern=^[a-z][a-z0-9-]*$" jsonschema_description:"Named configuration overlays, selected with --profile or STRUCTYL_PROFILE"`
 }
 
 // ProjectConfig contains project metadata.
 type ProjectConfig struct {
-	Name        string `json:"name"`
-	Description string `json:"description,omitempty"`
-	Homepage    string `json:"homepage,omitempty"`
-	Repository  string `json:"repository,omitempty"`
-	License     string `json:"license,omitempty"`
+	Name        string `json:"name" jsonschema:"pattern=^[a-z][a-z0-9]*(-[a-z0-9]+)*$,minLength=1,maxLength=128" jsonschema_description:"Project name (used in package names, documentation)"`
+	Description string `json:"description,omitempty" jsonschema_description:"Short project description"`
+	Homepage    string `json:"homepage,omitempty" jsonschema:"format=uri" jsonschema_description:"Project homepage URL"`
+	Repository  string `json:"repository,omitempty" jsonschema:"format=uri" jsonschema_description:"Source code repository URL"`
+	License     string `json:"license,omitempty" jsonschema:"examples=MIT|Apache-2.0|GPL-3.0" jsonschema_description:"SPDX license identifier"`
 }
 
 // VersionConfig configures version management.
 type VersionConfig struct {
-	Source string              `json:"source,omitempty"`
-	Files  []VersionFileConfig `json:"files,omitempty"`
+	Source string              `json:"source,omitempty" jsonschema_description:"Path to version file relative to project root, or \"git:tag\" to derive the version from release tags"`
+	Files  []VersionFileConfig `json:"files,omitempty" jsonschema_description:"Files to update with version"`
 }
 
 // VersionFileConfig defines a version file update rule.
+// A rule either rewrites regex matches (Pattern and Replace) or sets the value
+// under a structured key (Key, with Format inferred from Path if empty).
 type VersionFileConfig struct {
-	Path       string `json:"path"`
-	Pattern    string `json:"pattern"`
-	Replace    string `json:"replace"`
-	ReplaceAll bool   `json:"replace_all,omitempty"`
+	Path       string `json:"path" jsonschema_description:"Path to file relative to project root"`
+	Pattern    string `json:"pattern,omitempty" jsonschema_description:"Regex pattern to match version string (RE2 syntax)"`
+	Replace    string `json:"replace,omitempty" jsonschema_description:"Replacement string with {version} placeholder"`
+	Format     string `json:"format,omitempty" jsonschema:"enum=toml|json|xml|yaml" jsonschema_description:"File format for key-based updates. Inferred from the file extension if omitted."`
+	Key        string `json:"key,omitempty" jsonschema:"examples=package.version|/version|Project/PropertyGroup/Version" jsonschema_description:"Location of the version in the file: a dotted key path for TOML and YAML (package.version), a JSON pointer for JSON (/version), or an element path for XML (Project/PropertyGroup/Version)"`
+	ReplaceAll bool   `json:"replace_all,omitempty" jsonschema_description:"Replace all matches instead of requiring exactly one"`
 }
 
 // TargetConfig defines a build target (language or auxiliary).
 type TargetConfig struct {
-	Type             string                 `json:"type"`
-	Title            string                 `json:"title"`
-	Toolchain        string                 `json:"toolchain,omitempty"`
-	ToolchainVersion string                 `json:"toolchain_version,omitempty"` // Override mise tool version
-	Directory        string                 `json:"directory,omitempty"`
-	Cwd              string                 `json:"cwd,omitempty"`
-	Commands         map[string]interface{} `json:"commands,omitempty"`
-	Vars             map[string]string      `json:"vars,omitempty"`
-	Env              map[string]string      `json:"env,omitempty"`
-	DependsOn        []string               `json:"depends_on,omitempty"`
-	DemoPath         string                 `json:"demo_path,omitempty"`
+	Type             string                 `json:"type" jsonschema:"enum=language|auxiliary" jsonschema_description:"Target type: 'language' for programming language implementations, 'auxiliary' for supporting tools"`
+	Title            string                 `json:"title" jsonschema:"minLength=1,maxLength=64" jsonschema_description:"Display name for the target"`
+	Toolchain        string                 `json:"toolchain,omitempty" jsonschema_description:"Toolchain preset name (e.g., cargo, dotnet, npm)"`
+	ToolchainVersion string                 `json:"toolchain_version,omitempty" jsonschema:"examples=1.80.0|latest|nightly" jsonschema_description:"Override mise tool version for this target"`
+	Directory        string                 `json:"directory,omitempty" jsonschema_description:"Directory path (defaults to target key)"`
+	Cwd              string                 `json:"cwd,omitempty" jsonschema_description:"Working directory for commands (defaults to directory)"`
+	Commands         map[string]interface{} `json:"commands,omitempty" jsonschema:"ref=commandDefinition" jsonschema_description:"Command definitions or overrides"`
+	Vars             map[string]string      `json:"vars,omitempty" jsonschema_description:"Custom variables for command interpolation"`
+	Env              map[string]string      `json:"env,omitempty" jsonschema_description:"Environment variables for commands"`
+	DependsOn        []string               `json:"depends_on,omitempty" jsonschema_description:"Target dependencies for build ordering"`
+	DemoPath         string                 `json:"demo_path,omitempty" jsonschema_description:"Path to demo source file for documentation generation"`
+	Version          *TargetVersionConfig   `json:"version,omitempty" jsonschema_description:"Independent target version, released with 'structyl release <target> <version>'. Targets without it share the project version."` // Independent version; nil uses the project version
+}
+
+// TargetVersionConfig gives a target its own version, released independently
+// of the project version.
+type TargetVersionConfig struct {
+	Source    string              `json:"source,omitempty" jsonschema_description:"Path to the target's version file relative to project root, or \"git:tag\" to derive the version from the target's release tags. Defaults to .structyl/versions/<target>."`
+	Files     []VersionFileConfig `json:"files,omitempty" jsonschema_description:"Files to update with the target version"`
+	TagFormat string              `json:"tag_format,omitempty" jsonschema:"examples=rs-v{version}" jsonschema_description:"Release tag format with {version} placeholder. Defaults to <target>-v{version}."`
+	// DependencyFiles lists, per dependency target, the files of this target
+	// that pin the dependency's version. They are updated when the dependency
+	// is released.
+	DependencyFiles map[string][]VersionFileConfig `json:"dependency_files,omitempty" jsonschema_description:"Files of this target that pin a dependency's version, keyed by dependency target name (must be listed in depends_on). Updated when the dependency is released."`
 }
 
 // ToolchainConfig defines a custom toolchain.
 type ToolchainConfig struct {
-	Extends  string                 `json:"extends,omitempty"`
-	Version  string                 `json:"version,omitempty"` // Mise tool version for this toolchain
-	Commands map[string]interface{} `json:"commands,omitempty"`
+	Extends  string                 `json:"extends,omitempty" jsonschema_description:"Base toolchain to extend"`
+	Version  string                 `json:"version,omitempty" jsonschema_description:"Tool version for mise integration (e.g., '1.80.0', 'latest')"`
+	Commands map[string]interface{} `json:"commands,omitempty" jsonschema:"ref=commandDefinition" jsonschema_description:"Command definitions"`
 }
 
 // MiseConfig configures mise integration.
 // Note: mise is always required; there is no way to disable it.
 type MiseConfig struct {
-	AutoGenerate *bool             `json:"auto_generate,omitempty"` // Regenerate mise.toml before each run (default: true)
-	ExtraTools   map[string]string `json:"extra_tools,omitempty"`   // Additional mise tools to install
+	AutoGenerate *bool             `json:"auto_generate,omitempty" jsonschema_description:"Regenerate mise.toml before target command execution. When true, synchronizes tool versions with toolchain config. Set false and use 'structyl mise sync' for manual control."`
+	ExtraTools   map[string]string `json:"extra_tools,omitempty" jsonschema_description:"Additional mise tools to install"`
 }
 
 // TestsConfig configures the reference test system.
 type TestsConfig struct {
-	Directory  string            `json:"directory,omitempty"`
-	Pattern    string            `json:"pattern,omitempty"`
-	Comparison *ComparisonConfig `json:"comparison,omitempty"`
+	Directory  string            `json:"directory,omitempty" jsonschema_description:"Test data directory"`
+	Pattern    string            `json:"pattern,omitempty" jsonschema_description:"Glob pattern for test files. Note: pkg/testhelper.LoadTestSuite supports *.json only (immediate directory); recursive patterns (**/*.json) are only supported by Structyl's internal test runner."`
+	Comparison *ComparisonConfig `json:"comparison,omitempty" jsonschema_description:"Output comparison settings"`
 }
 
 // ComparisonConfig defines test result comparison settings.
 type ComparisonConfig struct {
-	FloatTolerance *float64 `json:"float_tolerance,omitempty"` // nil means use default; explicit 0 is respected
-	ToleranceMode  string   `json:"tolerance_mode,omitempty"`  // "relative", "absolute", or "ulp"
-	ArrayOrder     string   `json:"array_order,omitempty"`     // "strict" or "unordered"
-	NaNEqualsNaN   bool     `json:"nan_equals_nan,omitempty"`
+	FloatTolerance *float64      `json:"float_tolerance,omitempty" jsonschema:"minimum=0" jsonschema_description:"Tolerance for floating point comparisons. Use 0 for exact match requirement."` // nil means use default; explicit 0 is respected
+	ToleranceMode  ToleranceMode `json:"tolerance_mode,omitempty" jsonschema_description:"How tolerance is applied"`
+	ArrayOrder     ArrayOrder    `json:"array_order,omitempty" jsonschema_description:"Whether array element order matters"`
+	NaNEqualsNaN   bool          `json:"nan_equals_nan,omitempty" jsonschema_description:"Whether NaN equals NaN in comparisons"`
 }
 
 // DocsConfig configures documentation generation.
 type DocsConfig struct {
-	ReadmeTemplate string   `json:"readme_template,omitempty"`
-	Placeholders   []string `json:"placeholders,omitempty"`
+	ReadmeTemplate string   `json:"readme_template,omitempty" jsonschema_description:"Path to README template file"`
+	Placeholders   []string `json:"placeholders,omitempty" jsonschema_description:"Supported placeholder names"`
 }
 
 // DockerConfig configures Docker integration.
 type DockerConfig struct {
-	ComposeFile string                        `json:"compose_file,omitempty"`
-	EnvVar      string                        `json:"env_var,omitempty"`
-	Services    map[string]ServiceConfig      `json:"services,omitempty"`
-	Targets     map[string]DockerTargetConfig `json:"targets,omitempty"`
+	ComposeFile string                        `json:"compose_file,omitempty" jsonschema_description:"Docker Compose file path"`
+	EnvVar      string                        `json:"env_var,omitempty" jsonschema_description:"Environment variable to enable Docker mode"`
+	Services    map[string]ServiceConfig      `json:"services,omitempty" jsonschema_description:"Per-target Docker service overrides for image building (base_image, dockerfile, platform, volumes). Use 'targets' for runtime configuration."`
+	Targets     map[string]DockerTargetConfig `json:"targets,omitempty" jsonschema_description:"Per-target Docker runtime configuration (platform, cache_volume, entrypoint, environment). Use 'services' for image building options."`
 }
 
 // ServiceConfig defines Docker service settings for image building.
 type ServiceConfig struct {
-	BaseImage  string   `json:"base_image,omitempty"`
-	Dockerfile string   `json:"dockerfile,omitempty"`
-	Platform   string   `json:"platform,omitempty"`
-	Volumes    []string `json:"volumes,omitempty"`
+	BaseImage  string   `json:"base_image,omitempty" jsonschema_description:"Base Docker image"`
+	Dockerfile string   `json:"dockerfile,omitempty" jsonschema_description:"Custom Dockerfile path"`
+	Platform   string   `json:"platform,omitempty" jsonschema_description:"Target platform (e.g., linux/amd64)"`
+	Volumes    []string `json:"volumes,omitempty" jsonschema_description:"Additional volume mounts"`
 }
 
 // DockerTargetConfig defines Docker settings for a specific target.
 type DockerTargetConfig struct {
-	Platform    string            `json:"platform,omitempty"`
-	CacheVolume string            `json:"cache_volume,omitempty"`
-	Entrypoint  string            `json:"entrypoint,omitempty"`
-	Environment map[string]string `json:"environment,omitempty"`
+	Platform    string            `json:"platform,omitempty" jsonschema_description:"Target platform (e.g., linux/amd64)"`
+	CacheVolume string            `json:"cache_volume,omitempty" jsonschema_description:"Volume path for build cache"`
+	Entrypoint  string            `json:"entrypoint,omitempty" jsonschema_description:"Container entrypoint override"`
+	Environment map[string]string `json:"environment,omitempty" jsonschema_description:"Additional environment variables"`
 }
 
 // ReleaseConfig configures the release workflow.
 type ReleaseConfig struct {
-	TagFormat   string   `json:"tag_format,omitempty"`
-	ExtraTags   []string `json:"extra_tags,omitempty"`
-	PreCommands []string `json:"pre_commands,omitempty"`
-	Remote      string   `json:"remote,omitempty"`
-	Branch      string   `json:"branch,omitempty"`
+	TagFormat     string   `json:"tag_format,omitempty" jsonschema_description:"Git tag format with {version} placeholder"`
+	ExtraTags     []string `json:"extra_tags,omitempty" jsonschema_description:"Additional tags to create"`
+	PreCommands   []string `json:"pre_commands,omitempty" jsonschema_description:"Commands to run before release. Executed via 'sh -c' on all platforms including Windows."`
+	Remote        string   `json:"remote,omitempty" jsonschema_description:"Git remote name"`
+	Branch        string   `json:"branch,omitempty" jsonschema_description:"Release branch"`
+	RequireCI     bool     `json:"require_ci,omitempty" jsonschema_description:"Run 'structyl ci' before every release and abort if it fails"`
+	CommitMessage string   `json:"commit_message,omitempty" jsonschema_description:"Release commit message template; placeholders: {version}, {date}, {tag}, {previous_tag}, {target}, {changelog}, {changes}"`
+	Notes         string   `json:"notes,omitempty" jsonschema_description:"Release notes template, used for annotated tag messages and 'structyl release notes'"`
+	AnnotateTags  bool     `json:"annotate_tags,omitempty" jsonschema_description:"Create annotated tags with the release notes as the message"`
+	Sign          bool     `json:"sign,omitempty" jsonschema_description:"Sign the release commit and tags with GPG (implies annotate_tags)"`
+	SigningKey    string   `json:"signing_key,omitempty" jsonschema_description:"Key used for signing; defaults to git's user.signingkey"`
 }
 
 // CIConfig configures the CI pipeline.
 type CIConfig struct {
-	Steps []CIStep `json:"steps,omitempty"`
+	Steps []CIStep `json:"steps,omitempty" jsonschema_description:"CI pipeline step definitions"`
 }
 
 // CIStep defines a single step in the CI pipeline.
 type CIStep struct {
-	Name            string   `json:"name"`
-	Target          string   `json:"target"`
-	Command         string   `json:"command"`
-	Flags           []string `json:"flags,omitempty"`
-	DependsOn       []string `json:"depends_on,omitempty"`
-	ContinueOnError bool     `json:"continue_on_error,omitempty"`
+	Name            string   `json:"name" jsonschema_description:"Step name for display and references"`
+	Target          string   `json:"target" jsonschema:"minLength=1" jsonschema_description:"Target name or 'all'"`
+	Command         string   `json:"command" jsonschema_description:"Command to execute"`
+	Flags           []string `json:"flags,omitempty" jsonschema_description:"Additional command flags"`
+	DependsOn       []string `json:"depends_on,omitempty" jsonschema_description:"Step names that must complete first"`
+	ContinueOnError bool     `json:"continue_on_error,omitempty" jsonschema_description:"Continue pipeline if step fails"`
 }
 
 // ArtifactsConfig configures artifact collection.
 type ArtifactsConfig struct {
-	OutputDir string                    `json:"output_dir,omitempty"`
-	Targets   map[string][]ArtifactSpec `json:"targets,omitempty"`
+	OutputDir string                    `json:"output_dir,omitempty" jsonschema_description:"Base output directory for artifacts"`
+	Targets   map[string][]ArtifactSpec `json:"targets,omitempty" jsonschema_description:"Per-target artifact specifications"`
 }
 
 // ArtifactSpec defines an artifact to collect.
 type ArtifactSpec struct {
-	Source      string `json:"source"`
-	Destination string `json:"destination,omitempty"`
-	Rename      string `json:"rename,omitempty"`
+	Source      string `json:"source" jsonschema_description:"Glob pattern for source files"`
+	Destination string `json:"destination,omitempty" jsonschema_description:"Subdirectory within output_dir"`
+	Rename      string `json:"rename,omitempty" jsonschema_description:"Rename pattern for collected files"`
+}
+
+// ProfileConfig is a named overlay on the configuration, selected with
+// --profile or STRUCTYL_PROFILE. See ApplyProfile for the merge rules.
+type ProfileConfig struct {
+	Env     map[string]string              `json:"env,omitempty" jsonschema_description:"Environment variables merged into every target"`
+	Vars    map[string]string              `json:"vars,omitempty" jsonschema_description:"Variables merged into every target"`
+	Targets map[string]ProfileTargetConfig `json:"targets,omitempty" jsonschema_description:"Per-target overlays; applied after env and vars, so their values win"`
+	Docker  *DockerConfig                  `json:"docker,omitempty" jsonschema_description:"Merged into the docker section"`
+	Tests   *ProfileTestsConfig            `json:"tests,omitempty" jsonschema_description:"Merged into the tests section"`
+
+	raw json.RawMessage // The profile as written, so that explicit zero values overlay too
+}
+
+// ProfileTargetConfig overlays a single target.
+type ProfileTargetConfig struct {
+	Env      map[string]string      `json:"env,omitempty" jsonschema_description:"Environment variables merged into the target"`
+	Vars     map[string]string      `json:"vars,omitempty" jsonschema_description:"Variables merged into the target"`
+	Commands map[string]interface{} `json:"commands,omitempty" jsonschema:"ref=commandDefinition" jsonschema_description:"Command overrides; null disables a command"`
+}
+
+// ProfileTestsConfig overlays the test settings.
+type ProfileTestsConfig struct {
+	Comparison *ComparisonConfig `json:"comparison,omitempty" jsonschema_description:"Merged into tests.comparison"`
+}
+
+// UnmarshalJSON decodes a profile and keeps its raw JSON.
+func (p *ProfileConfig) UnmarshalJSON(data []byte) error {
+	type plain ProfileConfig
+	if err := json.Unmarshal(data, (*plain)(p)); err != nil {
+		return err
+	}
+	p.raw = append(json.RawMessage(nil), data...)
+	return nil
+}
+
+// MarshalJSON encodes a profile as written, if it was decoded.
+func (p ProfileConfig) MarshalJSON() ([]byte, error) {
+	if p.raw != nil {
+		return p.raw, nil
+	}
+	type plain ProfileConfig
+	return json.Marshal(plain(p))
 }
 
 // ToleranceMode represents how float comparison tolerance is applied.
@@ -162,6 +233,9 @@ const (
 	ToleranceModeULP ToleranceMode = "ulp"
 )
 
+// ToleranceModes lists the valid tolerance modes.
+var ToleranceModes = []ToleranceMode{ToleranceModeAbsolute, ToleranceModeRelative, ToleranceModeULP}
+
 // ArrayOrder represents how array elements are compared.
 type ArrayOrder string
 
@@ -171,3 +245,6 @@ const (
 	// ArrayOrderUnordered allows elements to match in any order (set comparison).
 	ArrayOrderUnordered ArrayOrder = "unordered"
 )
+
+// ArrayOrders lists the valid array orders.
+var ArrayOrders = []ArrayOrder{ArrayOrderStrict, ArrayOrderUnordered}
@@ -0,0 +1,140 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Sources records where the values of a configuration come from: the
+// configuration file, fragment, or target file that sets them, or the
+// selected profile.
+type Sources struct {
+	doc           *document
+	data          map[string]any  // Merged JSON of the configuration files
+	profile       string          // Selected profile, if any
+	profileFields map[string]bool // JSON paths set by the profile
+}
+
+// LoadSources loads the configuration file at path, with its includes and
+// target files, and returns the sources of its values.
+func LoadSources(path string) (*Sources, error) {
+	doc, err := loadDocument(path)
+	if err != nil {
+		return nil, err
+	}
+	data, err := decodeJSONObject(doc.data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+	return &Sources{doc: doc, data: data}, nil
+}
+
+// WithProfile returns a copy of s that also attributes the values set by the
+// named profile of cfg. An empty name selects no profile.
+func (s *Sources) WithProfile(cfg *Config, name string) (*Sources, error) {
+	if name == "" {
+		return s, nil
+	}
+	p, ok := cfg.Profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown profile %q", name)
+	}
+	obj, err := profileObject(p)
+	if err != nil {
+		return nil, err
+	}
+
+	// The same fields applyProfile overlays.
+	fields := make(map[string]bool)
+	for _, key := range []string{"env", "vars"} {
+		values, ok := obj[key].(map[string]any)
+		if !ok {
+			continue
+		}
+		for targetName := range cfg.Targets {
+			for k := range values {
+				fields[fmt.Sprintf("targets.%s.%s.%s", targetName, key, k)] = true
+			}
+		}
+	}
+	if targets, ok := obj["targets"].(map[string]any); ok {
+		addLeafPaths(fields, "targets", targets)
+	}
+	if docker, ok := obj["docker"].(map[string]any); ok {
+		addLeafPaths(fields, "docker", docker)
+	}
+	if tests, ok := obj["tests"].(map[string]any); ok {
+		if comparison, ok := tests["comparison"].(map[string]any); ok {
+			addLeafPaths(fields, "tests.comparison", comparison)
+		}
+	}
+
+	result := *s
+	result.profile = name
+	result.profileFields = fields
+	return &result, nil
+}
+
+// Of returns the source of the value at the JSON path field (for example
+// "targets.rs.commands.test"): "profile <name>" if the selected profile sets
+// it, else the name of the file that sets it, relative to the project root.
+// Returns "" if no file sets the value, so it is a default.
+func (s *Sources) Of(field string) string {
+	parts := strings.Split(field, ".")
+	if s.profile != "" {
+		for i := len(parts); i > 0; i-- {
+			if s.profileFields[strings.Join(parts[:i], ".")] {
+				return "profile " + s.profile
+			}
+		}
+	}
+	if !hasPath(s.data, parts) {
+		return ""
+	}
+	return s.doc.origin(field)
+}
+
+// JSON returns the merged JSON of the configuration files, before a profile
+// or defaults are applied.
+func (s *Sources) JSON() []byte {
+	return s.doc.data
+}
+
+// File returns the name of the fragment or target file that sets the value
+// at the JSON path field, or of the file that sets its closest parent. As in
+// ValidationError.File, it returns "" for the configuration file itself.
+func (s *Sources) File(field string) string {
+	if file := s.doc.origin(field); file != s.doc.main {
+		return file
+	}
+	return ""
+}
+
+// addLeafPaths adds the paths of the non-object values in obj, under prefix.
+func addLeafPaths(fields map[string]bool, prefix string, obj map[string]any) {
+	for k, v := range obj {
+		path := prefix + "." + k
+		if child, ok := v.(map[string]any); ok {
+			addLeafPaths(fields, path, child)
+			continue
+		}
+		fields[path] = true
+	}
+}
+
+// hasPath reports whether obj has a value, possibly null, at the path parts.
+func hasPath(obj map[string]any, parts []string) bool {
+	for i, part := range parts {
+		v, ok := obj[part]
+		if !ok {
+			return false
+		}
+		if i == len(parts)-1 {
+			return true
+		}
+		if obj, ok = v.(map[string]any); !ok {
+			return false
+		}
+	}
+	return false
+}
@@ -0,0 +1,74 @@
+package config
+
+import "testing"
+
+func TestSources(t *testing.T) {
+	t.Parallel()
+	path := writeProject(t, map[string]string{
+		".structyl/config.json": `{
+			"project": {"name": "demo"},
+			"include": ["ci.json"],
+			"targets": {
+				"rs": {"type": "language", "title": "Rust", "commands": {"test": "cargo test"}},
+				"py": {"type": "language"}
+			},
+			"profiles": {"ci": {
+				"env": {"CI": "1"},
+				"targets": {"rs": {"commands": {"bench": null}}},
+				"tests": {"comparison": {"float_tolerance": 0.001}}
+			}}
+		}`,
+		".structyl/ci.json":       `{"release": {"remote": "upstream"}}`,
+		"py/structyl.target.json": `{"title": "Python"}`,
+	})
+
+	sources, err := LoadSources(path)
+	if err != nil {
+		t.Fatalf("LoadSources() error = %v", err)
+	}
+	tests := []struct {
+		field string
+		want  string
+	}{
+		{"project.name", ".structyl/config.json"},
+		{"targets.rs.commands.test", ".structyl/config.json"},
+		{"release.remote", ".structyl/ci.json"},
+		{"targets.py.title", "py/structyl.target.json"},
+		{"targets.rs.directory", ""},
+		{"targets.rs.commands.bench", ""},
+		{"tests.comparison.float_tolerance", ""},
+	}
+	for _, tt := range tests {
+		if got := sources.Of(tt.field); got != tt.want {
+			t.Errorf("Of(%q) = %q, want %q", tt.field, got, tt.want)
+		}
+	}
+
+	cfg, _, err := LoadAndValidate(path)
+	if err != nil {
+		t.Fatalf("LoadAndValidate() error = %v", err)
+	}
+	profiled, err := sources.WithProfile(cfg, "ci")
+	if err != nil {
+		t.Fatalf("WithProfile() error = %v", err)
+	}
+	tests = []struct {
+		field string
+		want  string
+	}{
+		{"targets.rs.commands.test", ".structyl/config.json"},
+		{"targets.rs.commands.bench", "profile ci"},
+		{"targets.py.env.CI", "profile ci"},
+		{"tests.comparison.float_tolerance", "profile ci"},
+		{"tests.comparison.tolerance_mode", ""},
+	}
+	for _, tt := range tests {
+		if got := profiled.Of(tt.field); got != tt.want {
+			t.Errorf("with profile: Of(%q) = %q, want %q", tt.field, got, tt.want)
+		}
+	}
+
+	if _, err := sources.WithProfile(cfg, "prod"); err == nil {
+		t.Error("WithProfile(prod) error = nil, want unknown profile error")
+	}
+}
@@ -49,6 +49,57 @@ func detectUnknownFields(data []byte) []string {
 		warnings = append(warnings, targetWarnings...)
 	}
 
+	if profilesRaw, ok := raw["profiles"]; ok {
+		warnings = append(warnings, checkProfilesUnknownFields(profilesRaw)...)
+	}
+
+	return warnings
+}
+
+func checkProfilesUnknownFields(data json.RawMessage) []string {
+	var warnings []string
+
+	var profiles map[string]json.RawMessage
+	if err := json.Unmarshal(data, &profiles); err != nil {
+		// Should not happen since Config.Profiles parsed successfully.
+		return []string{"internal: failed to re-parse profiles for unknown field detection"}
+	}
+
+	knownProfileFields := getJSONFields(reflect.TypeOf(ProfileConfig{}))
+	knownTargetFields := getJSONFields(reflect.TypeOf(ProfileTargetConfig{}))
+	knownTestsFields := getJSONFields(reflect.TypeOf(ProfileTestsConfig{}))
+	for profileName, profileRaw := range profiles {
+		var profile map[string]json.RawMessage
+		if err := json.Unmarshal(profileRaw, &profile); err != nil {
+			continue
+		}
+		for key := range profile {
+			if !knownProfileFields[key] {
+				warnings = append(warnings, fmt.Sprintf("unknown field %q in profile %q (ignored)", key, profileName))
+			}
+		}
+
+		var tests map[string]json.RawMessage
+		if err := json.Unmarshal(profile["tests"], &tests); err == nil {
+			for key := range tests {
+				if !knownTestsFields[key] {
+					warnings = append(warnings, fmt.Sprintf("unknown field %q in profile %q tests (ignored)", key, profileName))
+				}
+			}
+		}
+
+		var targets map[string]map[string]json.RawMessage
+		if err := json.Unmarshal(profile["targets"], &targets); err == nil {
+			for targetName, target := range targets {
+				for key := range target {
+					if !knownTargetFields[key] {
+						warnings = append(warnings, fmt.Sprintf("unknown field %q in profile %q target %q (ignored)", key, profileName, targetName))
+					}
+				}
+			}
+		}
+	}
+
 	return warnings
 }
 
@@ -2,7 +2,10 @@ package config
 
 import (
 	"fmt"
+	"path/filepath"
 	"regexp"
+	"sort"
+	"strings"
 
 	"github.com/AndreyAkinshin/structyl/internal/topsort"
 )
@@ -28,6 +31,38 @@ const (
 	TypeAuxiliary = "auxiliary"
 )
 
+// Version file formats for key-based version files.
+const (
+	VersionFormatTOML = "toml"
+	VersionFormatJSON = "json"
+	VersionFormatXML  = "xml"
+	VersionFormatYAML = "yaml"
+)
+
+// versionFormatsByExt maps file extensions to version file formats.
+var versionFormatsByExt = map[string]string{
+	".toml":    VersionFormatTOML,
+	".json":    VersionFormatJSON,
+	".xml":     VersionFormatXML,
+	".csproj":  VersionFormatXML,
+	".fsproj":  VersionFormatXML,
+	".vbproj":  VersionFormatXML,
+	".props":   VersionFormatXML,
+	".targets": VersionFormatXML,
+	".yaml":    VersionFormatYAML,
+	".yml":     VersionFormatYAML,
+}
+
+// VersionFileFormat returns the format of a key-based version file: the
+// configured format, or one inferred from the file extension.
+// Returns "" if the format is unknown.
+func VersionFileFormat(f VersionFileConfig) string {
+	if f.Format != "" {
+		return f.Format
+	}
+	return versionFormatsByExt[strings.ToLower(filepath.Ext(f.Path))]
+}
+
 // Validation patterns from the specification.
 var (
 	// Project name: must start with lowercase letter, may contain lowercase, digits, hyphens.
@@ -40,38 +75,57 @@ var (
 
 // ValidationError represents a configuration validation error.
 type ValidationError struct {
+	File    string // Included fragment or target file that set Field; empty for config.json
 	Field   string
 	Message string
 }
 
 func (e *ValidationError) Error() string {
+	if e.File != "" {
+		return fmt.Sprintf("%s: %s: %s", e.File, e.Field, e.Message)
+	}
 	return fmt.Sprintf("%s: %s", e.Field, e.Message)
 }
 
 // Validate checks a configuration for errors and returns warnings for non-fatal issues.
 // Note: warnings are reserved for future use (deprecated fields, migration hints, etc.)
 func Validate(cfg *Config) (warnings []string, err error) {
-	if err := validateProject(cfg); err != nil {
+	if err := validateConfig(cfg); err != nil {
+		return nil, err
+	}
+	if err := validateProfiles(cfg); err != nil {
 		return nil, err
 	}
+	return nil, nil
+}
+
+// validateConfig checks a configuration, without its profiles.
+func validateConfig(cfg *Config) error {
+	if err := validateProject(cfg); err != nil {
+		return err
+	}
 
 	if err := validateTargets(cfg); err != nil {
-		return nil, err
+		return err
 	}
 
 	if err := validateCI(cfg); err != nil {
-		return nil, err
+		return err
 	}
 
 	if err := validateTests(cfg); err != nil {
-		return nil, err
+		return err
 	}
 
 	if err := validateVersion(cfg); err != nil {
-		return nil, err
+		return err
 	}
 
-	return nil, nil
+	if err := validateRelease(cfg); err != nil {
+		return err
+	}
+
+	return nil
 }
 
 // validateCI validates CI configuration steps.
@@ -170,6 +224,9 @@ func validateTargets(cfg *Config) error {
 		if err := validateCommandCycles(name, target.Commands); err != nil {
 			return err
 		}
+		if err := validateTargetVersion(cfg, name, target); err != nil {
+			return err
+		}
 	}
 	return nil
 }
@@ -393,7 +450,7 @@ func validateTests(cfg *Config) error {
 
 	// Validate tolerance_mode
 	switch c.ToleranceMode {
-	case "", string(ToleranceModeRelative), string(ToleranceModeAbsolute), string(ToleranceModeULP):
+	case "", ToleranceModeRelative, ToleranceModeAbsolute, ToleranceModeULP:
 		// Valid values
 	default:
 		return &ValidationError{
@@ -404,7 +461,7 @@ func validateTests(cfg *Config) error {
 
 	// Validate array_order
 	switch c.ArrayOrder {
-	case "", string(ArrayOrderStrict), string(ArrayOrderUnordered):
+	case "", ArrayOrderStrict, ArrayOrderUnordered:
 		// Valid values
 	default:
 		return &ValidationError{
@@ -414,7 +471,7 @@ func validateTests(cfg *Config) error {
 	}
 
 	// ULP tolerance requires integer values (representing bit distance)
-	ulpRequiresInteger := c.ToleranceMode == string(ToleranceModeULP)
+	ulpRequiresInteger := c.ToleranceMode == ToleranceModeULP
 	toleranceIsNonInteger := c.FloatTolerance != nil && *c.FloatTolerance != float64(int(*c.FloatTolerance))
 	if ulpRequiresInteger && toleranceIsNonInteger {
 		return &ValidationError{
@@ -432,14 +489,64 @@ func validateVersion(cfg *Config) error {
 		return nil
 	}
 
-	// Validate regex patterns in version files
-	for i, f := range cfg.Version.Files {
+	if strings.HasPrefix(cfg.Version.Source, "git:") && !IsGitTagSource(cfg.Version) {
+		return &ValidationError{
+			Field:   "version.source",
+			Message: fmt.Sprintf("unknown git source %q (want %q)", cfg.Version.Source, VersionSourceGitTag),
+		}
+	}
+	if IsGitTagSource(cfg.Version) && strings.Count(ReleaseTagFormat(cfg), "{version}") != 1 {
+		return &ValidationError{
+			Field:   "release.tag_format",
+			Message: "must contain {version} exactly once when version.source is \"git:tag\"",
+		}
+	}
+
+	return validateVersionFiles("version.files", cfg.Version.Files)
+}
+
+// releasePlaceholder matches a placeholder in a release message template.
+var releasePlaceholder = regexp.MustCompile(`\{([a-z_]+)\}`)
+
+// validateRelease checks the release message templates for unknown placeholders.
+func validateRelease(cfg *Config) error {
+	if cfg.Release == nil {
+		return nil
+	}
+	templates := []struct{ field, value string }{
+		{"release.commit_message", cfg.Release.CommitMessage},
+		{"release.notes", cfg.Release.Notes},
+	}
+	for _, tmpl := range templates {
+		for _, m := range releasePlaceholder.FindAllStringSubmatch(tmpl.value, -1) {
+			if !containsString(ReleasePlaceholders, m[1]) {
+				return &ValidationError{
+					Field:   tmpl.field,
+					Message: fmt.Sprintf("unknown placeholder %s (available: {%s})", m[0], strings.Join(ReleasePlaceholders, "}, {")),
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// validateVersionFiles checks the version file rules listed under field.
+func validateVersionFiles(field string, files []VersionFileConfig) error {
+	for i, f := range files {
+		if f.Key != "" || f.Format != "" {
+			if err := validateVersionKey(fmt.Sprintf("%s[%d]", field, i), f); err != nil {
+				return err
+			}
+			continue
+		}
+
+		// Validate regex patterns in version files
 		if f.Pattern == "" {
 			continue
 		}
 		if _, err := regexp.Compile(f.Pattern); err != nil {
 			return &ValidationError{
-				Field:   fmt.Sprintf("version.files[%d].pattern", i),
+				Field:   fmt.Sprintf("%s[%d].pattern", field, i),
 				Message: fmt.Sprintf("invalid regex: %v", err),
 			}
 		}
@@ -447,3 +554,83 @@ func validateVersion(cfg *Config) error {
 
 	return nil
 }
+
+// validateTargetVersion checks the independent version configuration of a
+// target. Dependency files must refer to independently versioned targets
+// listed in depends_on.
+func validateTargetVersion(cfg *Config, name string, target TargetConfig) error {
+	v := target.Version
+	if v == nil {
+		return nil
+	}
+	field := fmt.Sprintf("targets.%s.version", name)
+
+	if strings.HasPrefix(v.Source, "git:") && v.Source != VersionSourceGitTag {
+		return &ValidationError{
+			Field:   field + ".source",
+			Message: fmt.Sprintf("unknown git source %q (want %q)", v.Source, VersionSourceGitTag),
+		}
+	}
+	if strings.Count(TargetTagFormat(name, target), "{version}") != 1 {
+		return &ValidationError{Field: field + ".tag_format", Message: "must contain {version} exactly once"}
+	}
+	if err := validateVersionFiles(field+".files", v.Files); err != nil {
+		return err
+	}
+
+	deps := make([]string, 0, len(v.DependencyFiles))
+	for dep := range v.DependencyFiles {
+		deps = append(deps, dep)
+	}
+	sort.Strings(deps)
+	for _, dep := range deps {
+		depField := fmt.Sprintf("%s.dependency_files.%s", field, dep)
+		if !containsString(target.DependsOn, dep) {
+			return &ValidationError{Field: depField, Message: fmt.Sprintf("%q is not listed in depends_on", dep)}
+		}
+		if depTarget, ok := cfg.Targets[dep]; !ok || depTarget.Version == nil {
+			return &ValidationError{Field: depField, Message: fmt.Sprintf("%q is not an independently versioned target", dep)}
+		}
+		if err := validateVersionFiles(depField, v.DependencyFiles[dep]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// validateVersionKey checks a key-based version file rule; field is the
+// rule's position in the configuration (e.g., "version.files[0]").
+func validateVersionKey(field string, f VersionFileConfig) error {
+	if f.Pattern != "" || f.Replace != "" {
+		return &ValidationError{Field: field, Message: "pattern/replace and key/format are mutually exclusive"}
+	}
+	if f.Key == "" {
+		return &ValidationError{Field: field + ".key", Message: "required when format is set"}
+	}
+	switch VersionFileFormat(f) {
+	case VersionFormatTOML, VersionFormatJSON, VersionFormatXML, VersionFormatYAML:
+	case "":
+		return &ValidationError{
+			Field:   field + ".format",
+			Message: fmt.Sprintf("cannot infer format from %q; set format to toml, json, xml, or yaml", f.Path),
+		}
+	default:
+		return &ValidationError{
+			Field:   field + ".format",
+			Message: fmt.Sprintf("unknown format %q (want toml, json, xml, or yaml)", f.Format),
+		}
+	}
+	if VersionFileFormat(f) == VersionFormatJSON && !strings.HasPrefix(f.Key, "/") {
+		return &ValidationError{Field: field + ".key", Message: "must be a JSON pointer starting with \"/\""}
+	}
+	return nil
+}
@@ -677,7 +677,7 @@ func TestValidate_ToleranceMode_Valid(t *testing.T) {
 				Project: ProjectConfig{Name: "myproject"},
 				Tests: &TestsConfig{
 					Comparison: &ComparisonConfig{
-						ToleranceMode: mode,
+						ToleranceMode: ToleranceMode(mode),
 					},
 				},
 			}
@@ -699,7 +699,7 @@ func TestValidate_ToleranceMode_Invalid(t *testing.T) {
 				Project: ProjectConfig{Name: "myproject"},
 				Tests: &TestsConfig{
 					Comparison: &ComparisonConfig{
-						ToleranceMode: mode,
+						ToleranceMode: ToleranceMode(mode),
 					},
 				},
 			}
@@ -730,7 +730,7 @@ func TestValidate_ArrayOrder_Valid(t *testing.T) {
 				Project: ProjectConfig{Name: "myproject"},
 				Tests: &TestsConfig{
 					Comparison: &ComparisonConfig{
-						ArrayOrder: order,
+						ArrayOrder: ArrayOrder(order),
 					},
 				},
 			}
@@ -752,7 +752,7 @@ func TestValidate_ArrayOrder_Invalid(t *testing.T) {
 				Project: ProjectConfig{Name: "myproject"},
 				Tests: &TestsConfig{
 					Comparison: &ComparisonConfig{
-						ArrayOrder: order,
+						ArrayOrder: ArrayOrder(order),
 					},
 				},
 			}
@@ -914,6 +914,173 @@ func TestValidate_VersionPattern_Invalid(t *testing.T) {
 	}
 }
 
+func TestValidate_VersionKey(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name      string
+		file      VersionFileConfig
+		wantField string // empty means valid
+	}{
+		{"toml inferred", VersionFileConfig{Path: "rs/Cargo.toml", Key: "package.version"}, ""},
+		{"json inferred", VersionFileConfig{Path: "ts/package.json", Key: "/version"}, ""},
+		{"xml inferred from csproj", VersionFileConfig{Path: "cs/Demo.csproj", Key: "Project/PropertyGroup/Version"}, ""},
+		{"explicit format", VersionFileConfig{Path: "VERSION.cfg", Format: "yaml", Key: "version"}, ""},
+		{"with pattern", VersionFileConfig{Path: "Cargo.toml", Key: "package.version", Pattern: "x", Replace: "y"}, "version.files[0]"},
+		{"format without key", VersionFileConfig{Path: "Cargo.toml", Format: "toml"}, "version.files[0].key"},
+		{"unknown extension", VersionFileConfig{Path: "setup.cfg", Key: "metadata.version"}, "version.files[0].format"},
+		{"unknown format", VersionFileConfig{Path: "setup.cfg", Format: "ini", Key: "version"}, "version.files[0].format"},
+		{"json relative pointer", VersionFileConfig{Path: "package.json", Key: "version"}, "version.files[0].key"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			cfg := &Config{
+				Project: ProjectConfig{Name: "myproject"},
+				Version: &VersionConfig{Files: []VersionFileConfig{tt.file}},
+			}
+			_, err := Validate(cfg)
+			if tt.wantField == "" {
+				if err != nil {
+					t.Errorf("Validate() error = %v, want nil", err)
+				}
+				return
+			}
+			valErr, ok := err.(*ValidationError)
+			if !ok {
+				t.Fatalf("Validate() error = %v (%T), want ValidationError", err, err)
+			}
+			if valErr.Field != tt.wantField {
+				t.Errorf("ValidationError.Field = %q, want %q", valErr.Field, tt.wantField)
+			}
+		})
+	}
+}
+
+func TestValidate_VersionSource(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name      string
+		source    string
+		tagFormat string
+		wantField string // empty means valid
+	}{
+		{"file", ".structyl/PROJECT_VERSION", "", ""},
+		{"git tag", "git:tag", "", ""},
+		{"git tag with custom format", "git:tag", "release-{version}", ""},
+		{"unknown git source", "git:describe", "", "version.source"},
+		{"git tag without placeholder", "git:tag", "latest", "release.tag_format"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			cfg := &Config{
+				Project: ProjectConfig{Name: "myproject"},
+				Version: &VersionConfig{Source: tt.source},
+				Release: &ReleaseConfig{TagFormat: tt.tagFormat},
+			}
+			_, err := Validate(cfg)
+			if tt.wantField == "" {
+				if err != nil {
+					t.Errorf("Validate() error = %v, want nil", err)
+				}
+				return
+			}
+			valErr, ok := err.(*ValidationError)
+			if !ok {
+				t.Fatalf("Validate() error = %v (%T), want ValidationError", err, err)
+			}
+			if valErr.Field != tt.wantField {
+				t.Errorf("ValidationError.Field = %q, want %q", valErr.Field, tt.wantField)
+			}
+		})
+	}
+}
+
+func TestValidate_TargetVersion(t *testing.T) {
+	t.Parallel()
+	rsFiles := []VersionFileConfig{{Path: "ts/package.json", Key: "/dependencies/demo-rs"}}
+	tests := []struct {
+		name      string
+		version   *TargetVersionConfig
+		dependsOn []string
+		wantField string // empty means valid
+	}{
+		{"defaults", &TargetVersionConfig{}, nil, ""},
+		{"git tag source", &TargetVersionConfig{Source: "git:tag", TagFormat: "ts@{version}"}, nil, ""},
+		{"dependency files", &TargetVersionConfig{DependencyFiles: map[string][]VersionFileConfig{"rs": rsFiles}}, []string{"rs"}, ""},
+		{"unknown git source", &TargetVersionConfig{Source: "git:describe"}, nil, "targets.ts.version.source"},
+		{"tag format without placeholder", &TargetVersionConfig{TagFormat: "ts-latest"}, nil, "targets.ts.version.tag_format"},
+		{"invalid file", &TargetVersionConfig{Files: []VersionFileConfig{{Path: "x", Pattern: "("}}}, nil, "targets.ts.version.files[0].pattern"},
+		{"dependency not in depends_on", &TargetVersionConfig{DependencyFiles: map[string][]VersionFileConfig{"rs": rsFiles}}, nil, "targets.ts.version.dependency_files.rs"},
+		{"dependency without own version", &TargetVersionConfig{DependencyFiles: map[string][]VersionFileConfig{"go": rsFiles}}, []string{"go"}, "targets.ts.version.dependency_files.go"},
+		{"invalid dependency file", &TargetVersionConfig{DependencyFiles: map[string][]VersionFileConfig{"rs": {{Path: "package.json", Key: "version"}}}}, []string{"rs"}, "targets.ts.version.dependency_files.rs[0].key"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			cfg := &Config{
+				Project: ProjectConfig{Name: "myproject"},
+				Targets: map[string]TargetConfig{
+					"rs": {Type: "language", Title: "Rust", Version: &TargetVersionConfig{}},
+					"go": {Type: "language", Title: "Go"},
+					"ts": {Type: "language", Title: "TypeScript", DependsOn: tt.dependsOn, Version: tt.version},
+				},
+			}
+			_, err := Validate(cfg)
+			if tt.wantField == "" {
+				if err != nil {
+					t.Errorf("Validate() error = %v, want nil", err)
+				}
+				return
+			}
+			valErr, ok := err.(*ValidationError)
+			if !ok {
+				t.Fatalf("Validate() error = %v (%T), want ValidationError", err, err)
+			}
+			if valErr.Field != tt.wantField {
+				t.Errorf("ValidationError.Field = %q, want %q", valErr.Field, tt.wantField)
+			}
+		})
+	}
+}
+
+func TestValidate_ReleaseTemplates(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name      string
+		release   *ReleaseConfig
+		wantField string // empty means valid
+	}{
+		{"defaults", &ReleaseConfig{}, ""},
+		{"all placeholders", &ReleaseConfig{
+			CommitMessage: "chore({target}): release {version} on {date}",
+			Notes:         "# {tag}\n\nSince {previous_tag}:\n\n{changelog}\n\n{changes}",
+		}, ""},
+		{"unknown commit placeholder", &ReleaseConfig{CommitMessage: "release {ver}"}, "release.commit_message"},
+		{"unknown notes placeholder", &ReleaseConfig{Notes: "{version}: {summary}"}, "release.notes"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			cfg := &Config{Project: ProjectConfig{Name: "myproject"}, Release: tt.release}
+			_, err := Validate(cfg)
+			if tt.wantField == "" {
+				if err != nil {
+					t.Errorf("Validate() error = %v, want nil", err)
+				}
+				return
+			}
+			valErr, ok := err.(*ValidationError)
+			if !ok {
+				t.Fatalf("Validate() error = %v (%T), want ValidationError", err, err)
+			}
+			if valErr.Field != tt.wantField {
+				t.Errorf("ValidationError.Field = %q, want %q", valErr.Field, tt.wantField)
+			}
+		})
+	}
+}
+
 func TestValidate_VersionPattern_MultipleFiles(t *testing.T) {
 	t.Parallel()
 	// Second file has invalid pattern
@@ -1005,7 +1172,7 @@ func TestValidate_NonULPTolerance_Fractional_Valid(t *testing.T) {
 				Project: ProjectConfig{Name: "myproject"},
 				Tests: &TestsConfig{
 					Comparison: &ComparisonConfig{
-						ToleranceMode:  mode,
+						ToleranceMode:  ToleranceMode(mode),
 						FloatTolerance: ptr(0.001), // Fractional value
 					},
 				},
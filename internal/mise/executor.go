@@ -94,10 +94,11 @@ func (e *Executor) miseOutput(ctx context.Context, args []string) ([]byte, error
 	return e.runner.Output(ctx, "mise", args, e.projectRoot)
 }
 
-// logCommand prints the command being executed if verbose mode is enabled.
+// logCommand prints the command being executed if verbose mode is enabled,
+// with registered secrets redacted.
 func (e *Executor) logCommand(args []string) {
 	if e.verbose {
-		fmt.Printf("Running: mise %s\n", strings.Join(args, " "))
+		fmt.Print(output.Redact(fmt.Sprintf("Running: mise %s\n", strings.Join(args, " "))))
 	}
 }
 
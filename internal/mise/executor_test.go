@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"io"
+	"os"
 	"reflect"
 	"strings"
 	"testing"
@@ -457,6 +458,30 @@ func TestExecutor_RunTask_WithMock(t *testing.T) {
 	}
 }
 
+func TestExecutor_RunTask_VerboseRedactsSecrets(t *testing.T) {
+	output.RegisterSecrets("s3cr3t-token")
+	e := NewExecutorWithRunner("/project", &mockCommandRunner{})
+	e.SetVerbose(true)
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	runErr := e.RunTask(context.Background(), "publish:rs", []string{"--token", "s3cr3t-token"})
+	os.Stdout = stdout
+	_ = w.Close()
+	echoed, _ := io.ReadAll(r)
+
+	if runErr != nil {
+		t.Fatalf("RunTask() error = %v", runErr)
+	}
+	if got, want := string(echoed), "Running: mise run publish:rs --token ***\n"; got != want {
+		t.Errorf("verbose echo = %q, want %q", got, want)
+	}
+}
+
 func TestExecutor_RunTask_Error(t *testing.T) {
 	expectedErr := errors.New("command failed")
 	mock := &mockCommandRunner{
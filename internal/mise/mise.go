@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
 	"sort"
 	"strings"
 
@@ -34,6 +35,7 @@ var defaultCommands = struct {
 	Standard: []string{
 		"clean", "restore", "build", "build:release", "test",
 		"check", "check:fix", "bench", "demo", "doc", "pack",
+		"publish", "publish:dry",
 	},
 	Aggregate: []string{
 		"clean", "restore", "build", "build:release", "test",
@@ -72,8 +74,31 @@ func applyTaskConfig(task *MiseTask, dir string, env map[string]string) {
 		task.Dir = dir
 	}
 	if len(env) > 0 {
-		task.Env = env
+		task.Env = make(map[string]string, len(env))
+		for k, v := range env {
+			task.Env[k] = miseTemplate(v)
+		}
+	}
+}
+
+// miseTemplate rewrites the ${env:...} and ${file:...} references of a
+// configuration value as mise templates. mise resolves them when it runs the
+// task, so their values, which may be secrets, are not written to mise.toml.
+func miseTemplate(value string) string {
+	result, err := config.ReplaceReferences(value, func(ref config.Reference) (string, error) {
+		switch {
+		case ref.Scheme == "file":
+			return `{{ read_file(path="` + ref.Name + `") | trim_end }}`, nil
+		case ref.HasDefault:
+			return `{{ get_env(name="` + ref.Name + `", default="` + ref.Default + `") }}`, nil
+		default:
+			return "{{ env." + ref.Name + " }}", nil
+		}
+	})
+	if err != nil {
+		return value // Invalid references are reported when the configuration is loaded
 	}
+	return result
 }
 
 // GenerateMiseToml generates the content of a mise.toml file.
@@ -296,13 +321,51 @@ func generateTasksWithToolchains(cfg *config.Config, loaded *toolchain.Toolchain
 //  3. Loaded .structyl/toolchains.json overrides
 //  4. Hardcoded Go defaults
 func getResolvedCommandsForTargetWithToolchains(targetCfg config.TargetConfig, cfg *config.Config, loaded *toolchain.ToolchainsFile) map[string]interface{} {
-	commands := make(map[string]interface{})
+	resolved := ResolveCommands(targetCfg, cfg, loaded)
+	commands := make(map[string]interface{}, len(resolved))
+	for name, cmd := range resolved {
+		commands[name] = cmd.Definition
+	}
+	return commands
+}
+
+// CommandLayer identifies the layer of the command resolution that defined a
+// resolved command. Later layers override earlier ones.
+type CommandLayer int
+
+const (
+	// LayerToolchain is the target's toolchain in the loaded toolchains
+	// (toolchains.json merged over the defaults) or the built-in toolchains.
+	LayerToolchain CommandLayer = iota
+	// LayerExtends is the toolchain extended by a custom toolchain.
+	LayerExtends
+	// LayerCustomToolchain is a custom toolchain in config.json toolchains.
+	LayerCustomToolchain
+	// LayerTarget is the target's own commands in config.json.
+	LayerTarget
+)
+
+// ResolvedCommand is a command definition with the layer that defined it.
+type ResolvedCommand struct {
+	Definition interface{}
+	Layer      CommandLayer
+	Toolchain  string // Toolchain that defined the command; empty for LayerTarget
+}
+
+// ResolveCommands resolves the commands of a target like
+// getResolvedCommandsForTargetWithToolchains, recording the layer each
+// command comes from.
+func ResolveCommands(targetCfg config.TargetConfig, cfg *config.Config, loaded *toolchain.ToolchainsFile) map[string]ResolvedCommand {
+	commands := make(map[string]ResolvedCommand)
+	add := func(defs map[string]interface{}, layer CommandLayer, tcName string) {
+		for k, v := range defs {
+			commands[k] = ResolvedCommand{Definition: v, Layer: layer, Toolchain: tcName}
+		}
+	}
 
 	// Get toolchain commands from loaded config (or fall back to builtins)
 	if tc, ok := toolchain.GetFromConfig(targetCfg.Toolchain, loaded); ok {
-		for k, v := range tc.Commands {
-			commands[k] = v
-		}
+		add(tc.Commands, LayerToolchain, targetCfg.Toolchain)
 	}
 
 	// Override with custom toolchain commands if defined in config.json
@@ -310,21 +373,15 @@ func getResolvedCommandsForTargetWithToolchains(targetCfg config.TargetConfig, c
 		// If extending, get base commands first
 		if tcCfg.Extends != "" {
 			if base, ok := toolchain.GetFromConfig(tcCfg.Extends, loaded); ok {
-				for k, v := range base.Commands {
-					commands[k] = v
-				}
+				add(base.Commands, LayerExtends, tcCfg.Extends)
 			}
 		}
 		// Apply custom commands from config.json
-		for k, v := range tcCfg.Commands {
-			commands[k] = v
-		}
+		add(tcCfg.Commands, LayerCustomToolchain, targetCfg.Toolchain)
 	}
 
 	// Override with target-specific commands
-	for k, v := range targetCfg.Commands {
-		commands[k] = v
-	}
+	add(targetCfg.Commands, LayerTarget, "")
 
 	return commands
 }
@@ -441,9 +498,49 @@ func WriteMiseTomlWithToolchains(projectRoot string, cfg *config.Config, loaded
 		return false, fmt.Errorf("failed to write mise.toml: %w", err)
 	}
 
+	for _, profile := range config.ProfileNames(cfg) {
+		content, err := GenerateProfileToml(cfg, loaded, profile)
+		if err != nil {
+			return false, err
+		}
+		path := ProfileTomlPath(projectRoot, profile)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			return false, fmt.Errorf("failed to write %s: %w", filepath.Base(path), err)
+		}
+	}
+
 	return true, nil
 }
 
+// ProfileTomlPath returns the path of the mise.<profile>.toml file of a profile.
+func ProfileTomlPath(projectRoot, profile string) string {
+	return filepath.Join(projectRoot, fmt.Sprintf("mise.%s.toml", profile))
+}
+
+// GenerateProfileToml generates the content of the mise.<profile>.toml file
+// of a profile: the tasks whose definition the profile changes. When MISE_ENV
+// includes the profile, mise loads the file after mise.toml and its tasks
+// replace those of mise.toml.
+func GenerateProfileToml(cfg *config.Config, loaded *toolchain.ToolchainsFile, profile string) (string, error) {
+	profiled, err := config.ApplyProfile(cfg, profile)
+	if err != nil {
+		return "", err
+	}
+
+	base := generateTasksWithToolchains(cfg, loaded)
+	changed := make(map[string]MiseTask)
+	for name, task := range generateTasksWithToolchains(profiled, loaded) {
+		if baseTask, ok := base[name]; !ok || !reflect.DeepEqual(baseTask, task) {
+			changed[name] = task
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Tasks of the %q profile, used when MISE_ENV includes %q.\n\n", profile, profile)
+	writeTasks(&b, changed)
+	return b.String(), nil
+}
+
 // MiseTomlExists checks if a mise.toml file exists in the project root.
 func MiseTomlExists(projectRoot string) bool {
 	path := filepath.Join(projectRoot, "mise.toml")
@@ -246,6 +246,43 @@ func TestGenerateMiseToml_WithEnvVars(t *testing.T) {
 	}
 }
 
+func TestGenerateMiseToml_EnvReferences(t *testing.T) {
+	t.Parallel()
+	cfg := &config.Config{
+		Targets: map[string]config.TargetConfig{
+			"go": {
+				Toolchain: "go",
+				Directory: "go",
+				Env: map[string]string{
+					"TOKEN":   "${file:.secrets/token}",
+					"LEVEL":   "${env:LOG_LEVEL:-info}",
+					"KEY":     "${secret:API_KEY}",
+					"USER":    "user-${env:USER}",
+					"LITERAL": "$${env:HOME}",
+				},
+			},
+		},
+	}
+
+	content, err := GenerateMiseToml(cfg)
+	if err != nil {
+		t.Fatalf("GenerateMiseToml() error = %v", err)
+	}
+
+	// References become mise templates, so their values are never written.
+	for _, want := range []string{
+		`TOKEN = "{{ read_file(path=\".secrets/token\") | trim_end }}"`,
+		`LEVEL = "{{ get_env(name=\"LOG_LEVEL\", default=\"info\") }}"`,
+		`KEY = "{{ env.API_KEY }}"`,
+		`USER = "user-{{ env.USER }}"`,
+		`LITERAL = "${env:HOME}"`,
+	} {
+		if !strings.Contains(content, want) {
+			t.Errorf("mise.toml missing %s\n%s", want, content)
+		}
+	}
+}
+
 func TestCapitalize(t *testing.T) {
 	t.Parallel()
 	tests := []struct {
@@ -593,6 +630,49 @@ func TestGetResolvedCommands_WithExtends(t *testing.T) {
 	}
 }
 
+func TestResolveCommands_Layers(t *testing.T) {
+	t.Parallel()
+
+	cfg := &config.Config{
+		Toolchains: map[string]config.ToolchainConfig{
+			"my-cargo": {
+				Extends:  "cargo",
+				Commands: map[string]interface{}{"build": "cargo build --all-features"},
+			},
+		},
+	}
+	targetCfg := config.TargetConfig{
+		Toolchain: "my-cargo",
+		Commands:  map[string]interface{}{"test": "cargo nextest run"},
+	}
+
+	commands := ResolveCommands(targetCfg, cfg, nil)
+
+	tests := []struct {
+		name      string
+		layer     CommandLayer
+		toolchain string
+	}{
+		{"clean", LayerExtends, "cargo"},
+		{"build", LayerCustomToolchain, "my-cargo"},
+		{"test", LayerTarget, ""},
+	}
+	for _, tt := range tests {
+		cmd, ok := commands[tt.name]
+		if !ok {
+			t.Errorf("command %q not resolved", tt.name)
+			continue
+		}
+		if cmd.Layer != tt.layer || cmd.Toolchain != tt.toolchain {
+			t.Errorf("command %q = layer %d toolchain %q, want layer %d toolchain %q",
+				tt.name, cmd.Layer, cmd.Toolchain, tt.layer, tt.toolchain)
+		}
+	}
+	if got := commands["build"].Definition; got != "cargo build --all-features" {
+		t.Errorf("build = %v, want the custom toolchain command", got)
+	}
+}
+
 func TestWriteMiseTomlWithToolchains_WriteIfMissing_FileExists(t *testing.T) {
 	t.Parallel()
 	tmpDir := t.TempDir()
@@ -700,3 +780,50 @@ func TestGenerateMiseToml_RunSequenceWithParallelTasks(t *testing.T) {
 		t.Errorf("missing parallel tasks array, got:\n%s", content)
 	}
 }
+
+func TestWriteMiseTomlWithToolchains_Profiles(t *testing.T) {
+	t.Parallel()
+	tmpDir := t.TempDir()
+
+	cfg := &config.Config{
+		Project: config.ProjectConfig{Name: "demo"},
+		Targets: map[string]config.TargetConfig{
+			"rs": {Type: "language", Title: "Rust", Toolchain: "cargo", Directory: "rs", Cwd: "rs"},
+			"go": {Type: "language", Title: "Go", Toolchain: "go", Directory: "go", Cwd: "go"},
+		},
+		Profiles: map[string]config.ProfileConfig{
+			"ci": {
+				Targets: map[string]config.ProfileTargetConfig{
+					"rs": {Commands: map[string]interface{}{"test": "cargo nextest run"}},
+				},
+			},
+		},
+	}
+
+	if _, err := WriteMiseTomlWithToolchains(tmpDir, cfg, nil, WriteAlways); err != nil {
+		t.Fatalf("WriteMiseTomlWithToolchains() error = %v", err)
+	}
+
+	base, err := os.ReadFile(filepath.Join(tmpDir, "mise.toml"))
+	if err != nil {
+		t.Fatalf("ReadFile error = %v", err)
+	}
+	if strings.Contains(string(base), "nextest") {
+		t.Error("mise.toml contains the profile's command")
+	}
+
+	content, err := os.ReadFile(ProfileTomlPath(tmpDir, "ci"))
+	if err != nil {
+		t.Fatalf("ReadFile error = %v", err)
+	}
+	profile := string(content)
+	if !strings.Contains(profile, `MISE_ENV includes "ci"`) {
+		t.Error("missing header comment")
+	}
+	if !strings.Contains(profile, `[tasks."test:rs"]`) || !strings.Contains(profile, "cargo nextest run") {
+		t.Errorf("missing overridden test:rs task:\n%s", profile)
+	}
+	if strings.Contains(profile, `[tasks."test:go"]`) || strings.Contains(profile, "[tools]") {
+		t.Errorf("profile file contains unchanged tasks or tools:\n%s", profile)
+	}
+}
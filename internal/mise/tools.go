@@ -2,7 +2,11 @@
 package mise
 
 import (
+	"errors"
+	"fmt"
+	"regexp"
 	"sort"
+	"strings"
 
 	"github.com/AndreyAkinshin/structyl/internal/config"
 	"github.com/AndreyAkinshin/structyl/internal/toolchain"
@@ -281,3 +285,50 @@ func SupportedToolchains(loaded *toolchain.ToolchainsFile) []string {
 	sort.Strings(supported)
 	return supported
 }
+
+// versionToken matches a single mise version: a number, a prefix of one, or a
+// name such as "latest", "lts", "stable", or "temurin-21".
+var versionToken = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._+-]*$`)
+
+// versionPrefixes are the mise version prefixes whose remainder is not a
+// version number.
+var versionPrefixes = []string{"ref:", "tag:", "path:"}
+
+// ValidateToolVersion checks that v is a tool version mise can parse: one or
+// more space-separated versions, each a version token optionally preceded by
+// "prefix:" or "sub-N:", or a "ref:", "tag:", or "path:" reference. Range
+// constraints such as ">=1.2" or "^1.2" are not supported by mise.
+func ValidateToolVersion(v string) error {
+	versions := strings.Fields(v)
+	if len(versions) == 0 {
+		return errors.New("version is empty")
+	}
+	for _, version := range versions {
+		if err := validateVersionToken(version); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateVersionToken(version string) error {
+	for _, prefix := range versionPrefixes {
+		if strings.HasPrefix(version, prefix) {
+			if version == prefix {
+				return fmt.Errorf("%q is missing a value after %q", version, prefix)
+			}
+			return nil
+		}
+	}
+	rest := version
+	if name, value, ok := strings.Cut(version, ":"); ok {
+		if name != "prefix" && !strings.HasPrefix(name, "sub-") {
+			return fmt.Errorf("%q has an unknown prefix %q", version, name+":")
+		}
+		rest = value
+	}
+	if !versionToken.MatchString(rest) {
+		return fmt.Errorf("%q is not a version mise can parse (use a version such as \"1.22\", \"latest\", or \"prefix:1.22\"; ranges are not supported)", version)
+	}
+	return nil
+}
@@ -425,3 +425,19 @@ func TestGetToolsSorted_SingleItem(t *testing.T) {
 		t.Errorf("sorted[0] = %v, want [go 1.22]", sorted[0])
 	}
 }
+
+func TestValidateToolVersion(t *testing.T) {
+	t.Parallel()
+	valid := []string{"1.22", "latest", "lts", "stable", "temurin-21", "3.12.1", "1.0.0-rc.1+build", "prefix:1.22", "sub-1:latest", "ref:main", "path:/opt/go", "3.11 3.12"}
+	for _, v := range valid {
+		if err := ValidateToolVersion(v); err != nil {
+			t.Errorf("ValidateToolVersion(%q) = %v, want nil", v, err)
+		}
+	}
+	invalid := []string{"", " ", ">=1.22", "^1.2", "~1.2", "1.*", "\"1.22\"", "semver:1.2", "ref:"}
+	for _, v := range invalid {
+		if err := ValidateToolVersion(v); err == nil {
+			t.Errorf("ValidateToolVersion(%q) = nil, want error", v)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package output
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around changes.
+const diffContext = 3
+
+// Diff prints a unified diff of a file's contents before and after a change.
+func (w *Writer) Diff(name, before, after string) {
+	for _, line := range strings.SplitAfter(UnifiedDiff(name, before, after), "\n") {
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" {
+			continue
+		}
+		switch {
+		case !w.color:
+			w.Println("%s", line)
+		case strings.HasPrefix(line, "---"), strings.HasPrefix(line, "+++"):
+			w.Println("%s%s%s", bold, line, reset)
+		case strings.HasPrefix(line, "@@"):
+			w.Println("%s%s%s", cyan, line, reset)
+		case strings.HasPrefix(line, "-"):
+			w.Println("%s%s%s", red, line, reset)
+		case strings.HasPrefix(line, "+"):
+			w.Println("%s%s%s", green, line, reset)
+		default:
+			w.Println("%s", line)
+		}
+	}
+}
+
+// UnifiedDiff returns a unified diff of before and after, line by line, or
+// "" if they are equal.
+func UnifiedDiff(name, before, after string) string {
+	a, b := splitLines(before), splitLines(after)
+	ops := diffLines(a, b)
+
+	var sb strings.Builder
+	for start := 0; start < len(ops); {
+		// Find the next change and the end of its hunk.
+		for start < len(ops) && ops[start].kind == ' ' {
+			start++
+		}
+		if start == len(ops) {
+			break
+		}
+		first := max(start-diffContext, 0)
+		end := start
+		for i := start; i < len(ops); i++ {
+			if ops[i].kind != ' ' {
+				end = i + 1
+			} else if i-end >= 2*diffContext {
+				break
+			}
+		}
+		last := min(end+diffContext, len(ops))
+
+		if sb.Len() == 0 {
+			fmt.Fprintf(&sb, "--- a/%s\n+++ b/%s\n", name, name)
+		}
+		aStart, bStart := ops[first].a, ops[first].b
+		var aLen, bLen int
+		for _, op := range ops[first:last] {
+			if op.kind != '+' {
+				aLen++
+			}
+			if op.kind != '-' {
+				bLen++
+			}
+		}
+		fmt.Fprintf(&sb, "@@ -%s +%s @@\n", hunkRange(aStart, aLen), hunkRange(bStart, bLen))
+		for _, op := range ops[first:last] {
+			sb.WriteByte(op.kind)
+			sb.WriteString(op.text)
+			sb.WriteByte('\n')
+		}
+		start = last
+	}
+	return sb.String()
+}
+
+// diffOp is a line of a diff: ' ' unchanged, '-' removed, or '+' added.
+// a and b are the 0-based line numbers in the old and new text where the
+// line is, or would be.
+type diffOp struct {
+	kind byte
+	text string
+	a, b int
+}
+
+// diffLines returns the edit script turning a into b, from their longest
+// common subsequence.
+func diffLines(a, b []string) []diffOp {
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var ops []diffOp
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			ops = append(ops, diffOp{' ', a[i], i, j})
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			ops = append(ops, diffOp{'-', a[i], i, j})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', b[j], i, j})
+			j++
+		}
+	}
+	return ops
+}
+
+// hunkRange formats the start line and length of a hunk. An empty range
+// starts at the line before it.
+func hunkRange(start, length int) string {
+	if length == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if length == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, length)
+}
+
+// splitLines splits s into lines, without a trailing empty line.
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
@@ -0,0 +1,58 @@
+package output
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name          string
+		before, after string
+		want          string
+	}{
+		{"equal", "a\nb\n", "a\nb\n", ""},
+		{
+			"insert at start",
+			"a\nb\n",
+			"x\na\nb\n",
+			"--- a/f\n+++ b/f\n@@ -1,2 +1,3 @@\n+x\n a\n b\n",
+		},
+		{
+			"replace in the middle",
+			"1\n2\n3\n4\n5\n6\n7\n8\n9\n",
+			"1\n2\n3\n4\nfive\n6\n7\n8\n9\n",
+			"--- a/f\n+++ b/f\n@@ -2,7 +2,7 @@\n 2\n 3\n 4\n-5\n+five\n 6\n 7\n 8\n",
+		},
+		{
+			"separate hunks",
+			"a\n1\n2\n3\n4\n5\n6\n7\nb\n",
+			"A\n1\n2\n3\n4\n5\n6\n7\nB\n",
+			"--- a/f\n+++ b/f\n@@ -1,4 +1,4 @@\n-a\n+A\n 1\n 2\n 3\n@@ -6,4 +6,4 @@\n 5\n 6\n 7\n-b\n+B\n",
+		},
+		{
+			"from empty",
+			"",
+			"a\n",
+			"--- a/f\n+++ b/f\n@@ -0,0 +1 @@\n+a\n",
+		},
+	}
+	for _, tt := range tests {
+		if got := UnifiedDiff("f", tt.before, tt.after); got != tt.want {
+			t.Errorf("%s: UnifiedDiff() =\n%s\nwant\n%s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestWriterDiff(t *testing.T) {
+	t.Parallel()
+	w, stdout, _ := newTestWriter()
+	w.Diff("config.json", "{\n  \"a\": 1\n}\n", "{\n  \"a\": 2\n}\n")
+	got := stdout.String()
+	for _, want := range []string{"--- a/config.json\n", "-  \"a\": 1\n", "+  \"a\": 2\n"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Diff() output = %q, want it to contain %q", got, want)
+		}
+	}
+}
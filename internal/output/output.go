@@ -11,6 +11,9 @@
 // For testing, use NewWithWriters to inject custom io.Writers and capture output.
 // Tests should create isolated Writer instances rather than modifying the global.
 //
+// Values registered with RegisterSecrets, such as the secret values of the
+// project configuration, are redacted from the output of every Writer.
+//
 // Write errors are intentionally ignored throughout this package. CLI output
 // failures (broken pipe, closed terminal) are non-recoverable and should not
 // affect exit codes or program flow.
@@ -94,23 +97,24 @@ func (w *Writer) Debug(format string, args ...interface{}) {
 // Print formats and writes to the output stream.
 // Write errors are intentionally ignored: CLI output failures (broken pipe,
 // closed terminal) are non-recoverable and should not affect the exit code.
+// Registered secrets are redacted, as in every method of Writer.
 func (w *Writer) Print(format string, args ...interface{}) {
-	_, _ = fmt.Fprintf(w.out, format, args...)
+	_, _ = io.WriteString(w.out, Redact(fmt.Sprintf(format, args...)))
 }
 
 // Println writes a line to stdout.
 func (w *Writer) Println(format string, args ...interface{}) {
-	_, _ = fmt.Fprintf(w.out, format+"\n", args...)
+	_, _ = io.WriteString(w.out, Redact(fmt.Sprintf(format+"\n", args...)))
 }
 
 // Error writes to stderr.
 func (w *Writer) Error(format string, args ...interface{}) {
-	_, _ = fmt.Fprintf(w.err, format, args...)
+	_, _ = io.WriteString(w.err, Redact(fmt.Sprintf(format, args...)))
 }
 
 // Errorln writes a line to stderr.
 func (w *Writer) Errorln(format string, args ...interface{}) {
-	_, _ = fmt.Fprintf(w.err, format+"\n", args...)
+	_, _ = io.WriteString(w.err, Redact(fmt.Sprintf(format+"\n", args...)))
 }
 
 // Info prints an info message (skipped in quiet mode).
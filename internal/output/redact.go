@@ -0,0 +1,66 @@
+package output
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Redacted replaces secret values in output.
+const Redacted = "***"
+
+// secrets holds the values redacted from the output of every Writer. Like
+// the Writer settings, they are registered once at startup, when the
+// configuration is loaded.
+var secrets struct {
+	sync.RWMutex
+	values   []string // Longest first, so that overlapping values are fully masked
+	replacer *strings.Replacer
+}
+
+// RegisterSecrets adds values to redact from all output. Only the values of
+// secret references should be registered: every occurrence of a value is
+// replaced, wherever it appears. Empty values are ignored.
+func RegisterSecrets(values ...string) {
+	secrets.Lock()
+	defer secrets.Unlock()
+	for _, v := range values {
+		if v == "" {
+			continue
+		}
+		found := false
+		for _, existing := range secrets.values {
+			found = found || existing == v
+		}
+		if !found {
+			secrets.values = append(secrets.values, v)
+		}
+	}
+	sort.SliceStable(secrets.values, func(i, j int) bool {
+		return len(secrets.values[i]) > len(secrets.values[j])
+	})
+	pairs := make([]string, 0, 2*len(secrets.values))
+	for _, v := range secrets.values {
+		pairs = append(pairs, v, Redacted)
+	}
+	secrets.replacer = strings.NewReplacer(pairs...)
+}
+
+// Redact returns s with the registered secrets replaced by Redacted. Use it
+// for output not written through a Writer.
+func Redact(s string) string {
+	secrets.RLock()
+	defer secrets.RUnlock()
+	if secrets.replacer == nil {
+		return s
+	}
+	return secrets.replacer.Replace(s)
+}
+
+// resetSecrets clears the registered secrets (for testing).
+func resetSecrets() {
+	secrets.Lock()
+	defer secrets.Unlock()
+	secrets.values = nil
+	secrets.replacer = nil
+}
@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 
 	"github.com/AndreyAkinshin/structyl/internal/config"
+	internalerrors "github.com/AndreyAkinshin/structyl/internal/errors"
 	"github.com/AndreyAkinshin/structyl/internal/toolchain"
 	"github.com/AndreyAkinshin/structyl/internal/version"
 )
@@ -13,7 +14,11 @@ import (
 // Project represents a loaded structyl project.
 type Project struct {
 	Root       string
-	Config     *config.Config
+	Config     *config.Config // Configuration with the selected profile applied and references expanded
+	BaseConfig *config.Config // Configuration without a profile, with references as written
+	Profile    string         // Selected profile; empty if none
+	Redacted   *config.Config // Config with secret values replaced by config.Redacted, for output
+	Secrets    []string       // Secret values in Config, to redact from output
 	Toolchains *toolchain.ToolchainsFile
 	Warnings   []string
 }
@@ -27,15 +32,39 @@ func LoadProject() (*Project, error) {
 	return LoadProjectFrom(root)
 }
 
-// LoadProjectFrom loads a project from a specified root directory.
+// LoadProjectFrom loads a project from a specified root directory, applying
+// the profile selected by STRUCTYL_PROFILE, if any.
 func LoadProjectFrom(root string) (*Project, error) {
-	configPath := filepath.Join(root, ConfigDirName, ConfigFileName)
+	return LoadProjectWithProfile(root, os.Getenv(config.ProfileEnvVar))
+}
 
-	cfg, warnings, err := config.LoadAndValidate(configPath)
+// LoadProjectWithProfile loads a project from a specified root directory and
+// applies the named profile. An empty profile selects none.
+func LoadProjectWithProfile(root, profile string) (*Project, error) {
+	configPath, err := FindConfigFile(root)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load configuration: %w", err)
 	}
 
+	baseCfg, warnings, err := config.LoadAndValidate(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load configuration: %w", err)
+	}
+	cfg, err := config.ApplyProfile(baseCfg, profile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load configuration: %w", err)
+	}
+	// References are expanded in Config only: BaseConfig keeps them, so that
+	// generated files such as mise.toml do not contain their values.
+	cfg, redacted, secrets, err := config.ExpandValues(cfg, root)
+	if err != nil {
+		return nil, &internalerrors.StructylError{
+			Kind:    internalerrors.KindConfig,
+			Message: fmt.Sprintf("failed to load configuration: %v", err),
+			Cause:   err,
+		}
+	}
+
 	// Load toolchains configuration (merges with defaults)
 	toolchains, err := toolchain.LoadToolchains(root)
 	if err != nil {
@@ -52,7 +81,8 @@ func LoadProjectFrom(root string) (*Project, error) {
 
 	// Validate VERSION file if it exists and is configured
 	// (version config is auto-populated with defaults, so only validate if file exists)
-	if cfg.Version != nil && cfg.Version.Source != "" {
+	// (a git:tag source has no file; its version is derived on demand)
+	if cfg.Version != nil && cfg.Version.Source != "" && !config.IsGitTagSource(cfg.Version) {
 		versionFile := filepath.Join(root, cfg.Version.Source)
 		if _, statErr := os.Stat(versionFile); statErr == nil {
 			// File exists, validate its contents
@@ -66,14 +96,29 @@ func LoadProjectFrom(root string) (*Project, error) {
 	return &Project{
 		Root:       root,
 		Config:     cfg,
+		BaseConfig: baseCfg,
+		Profile:    profile,
+		Redacted:   redacted,
+		Secrets:    secrets,
 		Toolchains: toolchains,
 		Warnings:   warnings,
 	}, nil
 }
 
-// ConfigPath returns the full path to the project configuration file.
+// ConfigPath returns the full path to the project configuration file, in
+// whichever format it is written.
 func (p *Project) ConfigPath() string {
-	return filepath.Join(p.Root, ConfigDirName, ConfigFileName)
+	path, err := FindConfigFile(p.Root)
+	if err != nil {
+		return filepath.Join(p.Root, ConfigDirName, ConfigFileName)
+	}
+	return path
+}
+
+// FindConfigFile returns the path of the configuration file of the project
+// at root.
+func FindConfigFile(root string) (string, error) {
+	return config.FindFile(filepath.Join(root, ConfigDirName))
 }
 
 // TargetDirectory returns the absolute path to a target's directory.
@@ -5,6 +5,9 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/AndreyAkinshin/structyl/internal/config"
+	internalerrors "github.com/AndreyAkinshin/structyl/internal/errors"
 )
 
 func TestFindRootFrom_Found(t *testing.T) {
@@ -56,6 +59,45 @@ func TestFindRootFrom_FoundFromSubdir(t *testing.T) {
 	}
 }
 
+func TestFindRootFrom_OtherFormats(t *testing.T) {
+	root := t.TempDir()
+	structylDir := filepath.Join(root, ".structyl")
+	subdir := filepath.Join(root, "rs", "src")
+	if err := os.MkdirAll(subdir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(structylDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	yamlPath := filepath.Join(structylDir, "config.yaml")
+	if err := os.WriteFile(yamlPath, []byte("project:\n  name: test\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	found, err := FindRootFrom(subdir)
+	if err != nil {
+		t.Fatalf("FindRootFrom() error = %v", err)
+	}
+	if found != root {
+		t.Errorf("FindRootFrom() = %q, want %q", found, root)
+	}
+	proj, err := LoadProjectFrom(root)
+	if err != nil {
+		t.Fatalf("LoadProjectFrom() error = %v", err)
+	}
+	if proj.Config.Project.Name != "test" || proj.ConfigPath() != yamlPath {
+		t.Errorf("project %q from %q, want test from %q", proj.Config.Project.Name, proj.ConfigPath(), yamlPath)
+	}
+
+	// A second configuration file is ambiguous.
+	if err := os.WriteFile(filepath.Join(structylDir, "config.toml"), []byte("[project]\nname = \"test\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := FindRootFrom(subdir); err == nil || !strings.Contains(err.Error(), "keep only one") {
+		t.Errorf("FindRootFrom() with two configuration files error = %v", err)
+	}
+}
+
 func TestFindRootFrom_NotFound(t *testing.T) {
 	// Create temp dir without config.json
 	dir := t.TempDir()
@@ -125,6 +167,114 @@ func TestLoadProjectFrom_WithTargets(t *testing.T) {
 	}
 }
 
+func TestLoadProjectFrom_TargetFileAndInclude(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		".structyl/config.json":       `{"project": {"name": "myproject"}, "include": ["targets.json"]}`,
+		".structyl/targets.json":      `{"targets": {"cs": {"type": "language", "title": "C#"}, "py": {"type": "language"}}}`,
+		"py/" + config.TargetFileName: `{"title": "Python"}`,
+		"cs/.keep":                    "",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	proj, err := LoadProjectFrom(root)
+	if err != nil {
+		t.Fatalf("LoadProjectFrom() error = %v", err)
+	}
+	if len(proj.Config.Targets) != 2 {
+		t.Errorf("Config.Targets = %v, want cs and py from the fragment", proj.Config.Targets)
+	}
+	if got := proj.Config.Targets["py"].Title; got != "Python" {
+		t.Errorf("py title = %q, want it from py/%s", got, config.TargetFileName)
+	}
+}
+
+func TestLoadProjectWithProfile(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, ".structyl"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(root, "cs"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	cfg := `{
+		"project": {"name": "myproject"},
+		"targets": {"cs": {"type": "language", "title": "C#", "env": {"MODE": "dev"}}},
+		"profiles": {"ci": {"env": {"MODE": "ci"}}}
+	}`
+	if err := os.WriteFile(filepath.Join(root, ".structyl", "config.json"), []byte(cfg), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	proj, err := LoadProjectWithProfile(root, "ci")
+	if err != nil {
+		t.Fatalf("LoadProjectWithProfile() error = %v", err)
+	}
+	if proj.Profile != "ci" {
+		t.Errorf("Profile = %q, want %q", proj.Profile, "ci")
+	}
+	if got := proj.Config.Targets["cs"].Env["MODE"]; got != "ci" {
+		t.Errorf("Config env MODE = %q, want the profile value", got)
+	}
+	if got := proj.BaseConfig.Targets["cs"].Env["MODE"]; got != "dev" {
+		t.Errorf("BaseConfig env MODE = %q, want the base value", got)
+	}
+
+	if _, err := LoadProjectWithProfile(root, "prod"); err == nil {
+		t.Error("LoadProjectWithProfile() expected error for unknown profile")
+	}
+}
+
+func TestLoadProjectWithProfile_References(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, ".structyl"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(root, "cs"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	cfg := `{
+		"project": {"name": "myproject"},
+		"targets": {"cs": {"type": "language", "title": "C#", "env": {"TOKEN": "${env:STRUCTYL_TEST_TOKEN}"}}},
+		"profiles": {"ci": {"env": {"TOKEN": "${env:STRUCTYL_TEST_CI_TOKEN}"}}}
+	}`
+	if err := os.WriteFile(filepath.Join(root, ".structyl", "config.json"), []byte(cfg), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("STRUCTYL_TEST_TOKEN", "local-token")
+
+	proj, err := LoadProjectWithProfile(root, "")
+	if err != nil {
+		t.Fatalf("LoadProjectWithProfile() error = %v", err)
+	}
+	if got := proj.Config.Targets["cs"].Env["TOKEN"]; got != "local-token" {
+		t.Errorf("Config env TOKEN = %q, want the expanded value", got)
+	}
+	if got := proj.BaseConfig.Targets["cs"].Env["TOKEN"]; got != "${env:STRUCTYL_TEST_TOKEN}" {
+		t.Errorf("BaseConfig env TOKEN = %q, want the reference as written", got)
+	}
+	if got := proj.Redacted.Targets["cs"].Env["TOKEN"]; got != "local-token" {
+		t.Errorf("Redacted env TOKEN = %q, want the ${env:...} value, which is not a secret", got)
+	}
+
+	// The ci profile needs a variable that is not set.
+	_, err = LoadProjectWithProfile(root, "ci")
+	if err == nil || !strings.Contains(err.Error(), "targets.cs.env.TOKEN: environment variable STRUCTYL_TEST_CI_TOKEN is not set") {
+		t.Fatalf("LoadProjectWithProfile(ci) error = %v, want the missing variable", err)
+	}
+	if code := internalerrors.GetExitCode(err); code != internalerrors.ExitConfigError {
+		t.Errorf("exit code = %d, want %d", code, internalerrors.ExitConfigError)
+	}
+}
+
 func TestLoadProjectFrom_MissingTargetDir(t *testing.T) {
 	root := t.TempDir()
 
@@ -5,12 +5,16 @@ import (
 	"errors"
 	"os"
 	"path/filepath"
+
+	"github.com/AndreyAkinshin/structyl/internal/config"
 )
 
 // ConfigDirName is the name of the structyl configuration directory.
 const ConfigDirName = ".structyl"
 
-// ConfigFileName is the name of the configuration file.
+// ConfigFileName is the name of the configuration file written by init.
+// config.jsonc, config.yaml, and config.toml are accepted too; see
+// config.FileNames.
 const ConfigFileName = "config.json"
 
 // ToolchainsFileName is the name of the toolchains configuration file.
@@ -19,10 +23,11 @@ const ToolchainsFileName = "toolchains.json"
 // VersionFileName is the name of the version file inside .structyl directory.
 const VersionFileName = "version"
 
-// ErrNoProjectRoot is returned when .structyl/config.json is not found.
-var ErrNoProjectRoot = errors.New(".structyl/config.json not found: not a structyl project (or any parent up to the root)")
+// ErrNoProjectRoot is returned when no .structyl configuration file is found.
+var ErrNoProjectRoot = errors.New(".structyl/config.json (or .jsonc, .yaml, .toml) not found: not a structyl project (or any parent up to the root)")
 
-// FindRoot walks up from the current working directory until it finds .structyl/config.json.
+// FindRoot walks up from the current working directory until it finds a
+// .structyl directory with a configuration file.
 func FindRoot() (string, error) {
 	cwd, err := os.Getwd()
 	if err != nil {
@@ -31,7 +36,9 @@ func FindRoot() (string, error) {
 	return FindRootFrom(cwd)
 }
 
-// FindRootFrom walks up from the given directory until it finds .structyl/config.json.
+// FindRootFrom walks up from the given directory until it finds a .structyl
+// directory with a configuration file. A directory with several
+// configuration files is an error.
 func FindRootFrom(startDir string) (string, error) {
 	dir, err := filepath.Abs(startDir)
 	if err != nil {
@@ -39,10 +46,13 @@ func FindRootFrom(startDir string) (string, error) {
 	}
 
 	for {
-		configPath := filepath.Join(dir, ConfigDirName, ConfigFileName)
-		if _, err := os.Stat(configPath); err == nil {
+		_, err := config.FindFile(filepath.Join(dir, ConfigDirName))
+		if err == nil {
 			return dir, nil
 		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return "", err
+		}
 
 		parent := filepath.Dir(dir)
 		if parent == dir {
@@ -0,0 +1,181 @@
+package release
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ChangelogFileName is the changelog written by automatic releases, relative
+// to the project root.
+const ChangelogFileName = "CHANGELOG.md"
+
+// changelogTitle is the heading of a newly created changelog.
+const changelogTitle = "# Changelog"
+
+// conventionalHeader matches a Conventional Commits header: type(scope)!: description.
+var conventionalHeader = regexp.MustCompile(`^([a-zA-Z]+)(?:\(([^()]*)\))?(!)?: (.+)$`)
+
+// Commit is a git commit considered for an automatic release.
+type Commit struct {
+	Hash    string
+	Subject string
+	Body    string
+	Files   []string // Changed files, relative to the repository root
+}
+
+// ConventionalCommit is a commit whose message follows Conventional Commits.
+type ConventionalCommit struct {
+	Commit
+	Type        string // Lowercased type (feat, fix, ...)
+	Scope       string
+	Description string
+	Breaking    bool
+	Targets     []string // Targets whose directories the commit changed
+}
+
+// ParseConventional parses c's message as a Conventional Commit.
+// Returns false if the subject has no type prefix.
+func ParseConventional(c Commit) (ConventionalCommit, bool) {
+	m := conventionalHeader.FindStringSubmatch(strings.TrimSpace(c.Subject))
+	if m == nil {
+		return ConventionalCommit{}, false
+	}
+	breaking := m[3] == "!"
+	for _, line := range strings.Split(c.Body, "\n") {
+		if strings.HasPrefix(line, "BREAKING CHANGE:") || strings.HasPrefix(line, "BREAKING-CHANGE:") {
+			breaking = true
+		}
+	}
+	return ConventionalCommit{
+		Commit:      c,
+		Type:        strings.ToLower(m[1]),
+		Scope:       strings.TrimSpace(m[2]),
+		Description: strings.TrimSpace(m[4]),
+		Breaking:    breaking,
+	}, true
+}
+
+// BumpLevel returns the version part to bump for commits: "major" if any
+// commit is breaking, "minor" for features, "patch" for fixes, or "" if no
+// commit warrants a release.
+func BumpLevel(commits []ConventionalCommit) string {
+	level := ""
+	for _, c := range commits {
+		switch {
+		case c.Breaking:
+			return "major"
+		case c.Type == "feat":
+			level = "minor"
+		case (c.Type == "fix" || c.Type == "perf") && level == "":
+			level = "patch"
+		}
+	}
+	return level
+}
+
+// changelogSections lists the changelog sections in order. Commits of other
+// types (chore, docs, test, ...) are left out of the changelog.
+var changelogSections = []struct {
+	title   string
+	include func(ConventionalCommit) bool
+}{
+	{"Breaking Changes", func(c ConventionalCommit) bool { return c.Breaking }},
+	{"Features", func(c ConventionalCommit) bool { return !c.Breaking && c.Type == "feat" }},
+	{"Bug Fixes", func(c ConventionalCommit) bool { return !c.Breaking && c.Type == "fix" }},
+	{"Performance", func(c ConventionalCommit) bool { return !c.Breaking && c.Type == "perf" }},
+	{"Reverts", func(c ConventionalCommit) bool { return !c.Breaking && c.Type == "revert" }},
+}
+
+// RenderChangelogSection renders the changelog section for a release.
+// Each entry is prefixed with the targets it changed, or with its scope if
+// it changed no target directory.
+func RenderChangelogSection(ver, date string, commits []ConventionalCommit) string {
+	return fmt.Sprintf("## %s (%s)\n", ver, date) + renderChangelogEntries(commits)
+}
+
+// renderChangelogEntries renders the subsections of a changelog section,
+// each preceded by a blank line.
+func renderChangelogEntries(commits []ConventionalCommit) string {
+	var sb strings.Builder
+	for _, section := range changelogSections {
+		var entries []string
+		for _, c := range commits {
+			if section.include(c) {
+				entries = append(entries, changelogEntry(c))
+			}
+		}
+		if len(entries) == 0 {
+			continue
+		}
+		fmt.Fprintf(&sb, "\n### %s\n\n", section.title)
+		for _, e := range entries {
+			sb.WriteString(e)
+		}
+	}
+	return sb.String()
+}
+
+func changelogEntry(c ConventionalCommit) string {
+	scope := strings.Join(c.Targets, ", ")
+	if scope == "" {
+		scope = c.Scope
+	}
+	if scope != "" {
+		return fmt.Sprintf("- **%s:** %s (%s)\n", scope, c.Description, shortHash(c.Hash))
+	}
+	return fmt.Sprintf("- %s (%s)\n", c.Description, shortHash(c.Hash))
+}
+
+// shortHash abbreviates a commit hash for changelog entries.
+func shortHash(hash string) string {
+	if len(hash) > 7 {
+		return hash[:7]
+	}
+	return hash
+}
+
+// PrependChangelog inserts section before the newest release in the
+// changelog at path, creating the file with a title if it does not exist.
+func PrependChangelog(path, section string) error {
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	content := strings.TrimRight(string(data), "\n")
+	switch i := strings.Index("\n"+content, "\n## "); {
+	case content == "":
+		content = changelogTitle + "\n\n" + section
+	case i >= 0:
+		content = content[:i] + section + "\n" + string(data)[i:]
+	default:
+		content += "\n\n" + section
+	}
+	return os.WriteFile(path, []byte(content), 0644)
+}
+
+// assignTargets sets the Targets of each commit to the targets whose
+// directories contain a changed file. dirs maps target names to directories
+// relative to the repository root.
+func assignTargets(commits []ConventionalCommit, dirs map[string]string) {
+	names := make([]string, 0, len(dirs))
+	for name := range dirs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for i := range commits {
+		for _, name := range names {
+			prefix := strings.TrimSuffix(dirs[name], "/") + "/"
+			for _, f := range commits[i].Files {
+				if strings.HasPrefix(f, prefix) {
+					commits[i].Targets = append(commits[i].Targets, name)
+					break
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,245 @@
+package release
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AndreyAkinshin/structyl/internal/config"
+)
+
+func TestParseConventional(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		subject, body string
+		want          ConventionalCommit
+		ok            bool
+	}{
+		{"feat: add median", "", ConventionalCommit{Type: "feat", Description: "add median"}, true},
+		{"Fix(parser): handle NaN", "", ConventionalCommit{Type: "fix", Scope: "parser", Description: "handle NaN"}, true},
+		{"feat(api)!: drop v1", "", ConventionalCommit{Type: "feat", Scope: "api", Description: "drop v1", Breaking: true}, true},
+		{"refactor: rename", "details\n\nBREAKING CHANGE: renamed Foo", ConventionalCommit{Type: "refactor", Description: "rename", Breaking: true}, true},
+		{"Update README", "", ConventionalCommit{}, false},
+		{"feat:missing space", "", ConventionalCommit{}, false},
+	}
+	for _, tt := range tests {
+		c := Commit{Subject: tt.subject, Body: tt.body}
+		got, ok := ParseConventional(c)
+		if ok != tt.ok {
+			t.Errorf("ParseConventional(%q) ok = %v, want %v", tt.subject, ok, tt.ok)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		tt.want.Commit = c
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseConventional(%q) = %+v, want %+v", tt.subject, got, tt.want)
+		}
+	}
+}
+
+func TestBumpLevel(t *testing.T) {
+	t.Parallel()
+	cc := func(typ string, breaking bool) ConventionalCommit {
+		return ConventionalCommit{Type: typ, Breaking: breaking}
+	}
+	tests := []struct {
+		commits []ConventionalCommit
+		want    string
+	}{
+		{nil, ""},
+		{[]ConventionalCommit{cc("chore", false), cc("docs", false)}, ""},
+		{[]ConventionalCommit{cc("fix", false), cc("chore", false)}, "patch"},
+		{[]ConventionalCommit{cc("fix", false), cc("feat", false), cc("fix", false)}, "minor"},
+		{[]ConventionalCommit{cc("feat", false), cc("chore", true)}, "major"},
+	}
+	for _, tt := range tests {
+		if got := BumpLevel(tt.commits); got != tt.want {
+			t.Errorf("BumpLevel(%v) = %q, want %q", tt.commits, got, tt.want)
+		}
+	}
+}
+
+func TestRenderChangelogSection(t *testing.T) {
+	t.Parallel()
+	commits := []ConventionalCommit{
+		{Commit: Commit{Hash: "aaaaaaaaaa"}, Type: "feat", Description: "add median", Targets: []string{"go", "py"}},
+		{Commit: Commit{Hash: "bbbbbbbbbb"}, Type: "fix", Scope: "docs", Description: "fix typo"},
+		{Commit: Commit{Hash: "cccccccccc"}, Type: "chore", Description: "bump deps"},
+		{Commit: Commit{Hash: "dddddddddd"}, Type: "feat", Description: "drop v1", Breaking: true, Targets: []string{"rs"}},
+	}
+
+	got := RenderChangelogSection("2.0.0", "2026-01-02", commits)
+	want := `## 2.0.0 (2026-01-02)
+
+### Breaking Changes
+
+- **rs:** drop v1 (ddddddd)
+
+### Features
+
+- **go, py:** add median (aaaaaaa)
+
+### Bug Fixes
+
+- **docs:** fix typo (bbbbbbb)
+`
+	if got != want {
+		t.Errorf("RenderChangelogSection() =\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestPrependChangelog(t *testing.T) {
+	t.Parallel()
+	section := "## 1.1.0 (2026-01-02)\n\n### Features\n\n- new (aaaaaaa)\n"
+	tests := []struct {
+		name     string
+		existing *string
+		want     string
+	}{
+		{"missing file", nil, "# Changelog\n\n" + section},
+		{"title only", ptr("# Changelog\n\nAll notable changes.\n"), "# Changelog\n\nAll notable changes.\n\n" + section},
+		{"previous release", ptr("# Changelog\n\n## 1.0.0 (2025-12-01)\n\n- old\n"),
+			"# Changelog\n\n" + section + "\n## 1.0.0 (2025-12-01)\n\n- old\n"},
+		{"release at start", ptr("## 1.0.0\n"), section + "\n## 1.0.0\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			path := filepath.Join(t.TempDir(), ChangelogFileName)
+			if tt.existing != nil {
+				if err := os.WriteFile(path, []byte(*tt.existing), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := PrependChangelog(path, section); err != nil {
+				t.Fatalf("PrependChangelog() error = %v", err)
+			}
+			got, _ := os.ReadFile(path)
+			if string(got) != tt.want {
+				t.Errorf("PrependChangelog() =\n%q\nwant:\n%q", got, tt.want)
+			}
+		})
+	}
+}
+
+func ptr(s string) *string { return &s }
+
+func TestAssignTargets(t *testing.T) {
+	t.Parallel()
+	commits := []ConventionalCommit{
+		{Commit: Commit{Files: []string{"py/stats.py", "go/stats.go", "README.md"}}},
+		{Commit: Commit{Files: []string{"gopher/x.go"}}},
+	}
+	assignTargets(commits, map[string]string{"go": "go", "py": "py/"})
+	if !reflect.DeepEqual(commits[0].Targets, []string{"go", "py"}) {
+		t.Errorf("commits[0].Targets = %v, want [go py]", commits[0].Targets)
+	}
+	if commits[1].Targets != nil {
+		t.Errorf("commits[1].Targets = %v, want none (prefix must match a whole directory)", commits[1].Targets)
+	}
+}
+
+// commitFile writes a file and commits it with message.
+func commitFile(t *testing.T, dir, name, message string) {
+	t.Helper()
+	path := filepath.Join(dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(message+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{{"add", "-A"}, {"commit", "--no-gpg-sign", "-m", message}} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+}
+
+func TestRelease_Auto_BumpsFromCommitsAndWritesChangelog(t *testing.T) {
+	dir := createTestGitRepo(t)
+	if err := os.MkdirAll(filepath.Join(dir, ".structyl"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".structyl", "PROJECT_VERSION"), []byte("1.4.2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	commitFile(t, dir, "go/go.mod", "fix: fix before the last release")
+	cmd := exec.Command("git", "tag", "v1.4.2")
+	cmd.Dir = dir
+	if err := cmd.Run(); err != nil {
+		t.Fatal(err)
+	}
+	commitFile(t, dir, "go/stats.go", "feat: add median")
+	commitFile(t, dir, "py/stats.py", "fix(py): handle empty input")
+	commitFile(t, dir, "README.md", "docs: explain median")
+
+	cfg := &config.Config{Targets: map[string]config.TargetConfig{"go": {}, "py": {}}}
+	r := NewReleaser(dir, cfg)
+	r.now = func() time.Time { return time.Date(2026, 3, 4, 0, 0, 0, 0, time.UTC) }
+
+	captureStdout(t, func() {
+		if err := r.Release(context.Background(), Options{Auto: true}); err != nil {
+			t.Fatalf("Release() error = %v", err)
+		}
+	})
+
+	content, _ := os.ReadFile(filepath.Join(dir, ".structyl", "PROJECT_VERSION"))
+	if string(content) != "1.5.0\n" {
+		t.Errorf("PROJECT_VERSION = %q, want 1.5.0", content)
+	}
+	changelog, err := os.ReadFile(filepath.Join(dir, ChangelogFileName))
+	if err != nil {
+		t.Fatalf("changelog not written: %v", err)
+	}
+	for _, want := range []string{"## 1.5.0 (2026-03-04)", "- **go:** add median", "- **py:** handle empty input"} {
+		if !strings.Contains(string(changelog), want) {
+			t.Errorf("changelog missing %q:\n%s", want, changelog)
+		}
+	}
+	for _, unwanted := range []string{"explain median", "before the last release"} {
+		if strings.Contains(string(changelog), unwanted) {
+			t.Errorf("changelog includes %q:\n%s", unwanted, changelog)
+		}
+	}
+
+	// The changelog is part of the release commit.
+	cmd = exec.Command("git", "show", "--name-only", "--format=%s", "HEAD")
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(out), "set version 1.5.0") || !strings.Contains(string(out), ChangelogFileName) {
+		t.Errorf("release commit = %q, want version commit including %s", out, ChangelogFileName)
+	}
+}
+
+func TestRelease_Auto_NothingToRelease(t *testing.T) {
+	dir := createTestGitRepo(t)
+	if err := os.MkdirAll(filepath.Join(dir, ".structyl"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".structyl", "PROJECT_VERSION"), []byte("1.0.0\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	commitFile(t, dir, "README.md", "docs: readme")
+
+	r := NewReleaser(dir, &config.Config{})
+	var err error
+	captureStdout(t, func() {
+		err = r.Release(context.Background(), Options{Auto: true})
+	})
+	if err == nil || !strings.Contains(err.Error(), "nothing to release") {
+		t.Errorf("Release() error = %v, want 'nothing to release'", err)
+	}
+}
@@ -0,0 +1,207 @@
+package release
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"github.com/AndreyAkinshin/structyl/internal/config"
+	"github.com/AndreyAkinshin/structyl/internal/version"
+)
+
+// ReleaseNotesFileName is the file 'structyl release notes' writes by default,
+// relative to the project root.
+const ReleaseNotesFileName = "RELEASE_NOTES.md"
+
+// releaseInfo describes a release for the message templates.
+type releaseInfo struct {
+	version     string
+	date        string
+	tag         string
+	previousTag string // Empty for the first release
+	target      string // Empty for project releases
+	commits     []ConventionalCommit
+}
+
+// render replaces the placeholders in tmpl (see config.ReleasePlaceholders).
+func (i *releaseInfo) render(tmpl string) string {
+	return strings.NewReplacer(
+		"{version}", i.version,
+		"{date}", i.date,
+		"{tag}", i.tag,
+		"{previous_tag}", i.previousTag,
+		"{target}", i.target,
+		"{changelog}", strings.TrimSpace(renderChangelogEntries(i.commits)),
+		"{changes}", strings.TrimSpace(renderChanges(i.commits)),
+	).Replace(tmpl)
+}
+
+// notes renders the release notes.
+func (r *Releaser) notes(info *releaseInfo) string {
+	return strings.TrimSpace(info.render(config.ReleaseNotesTemplate(r.config))) + "\n"
+}
+
+// commitMessage renders the release commit message.
+func (r *Releaser) commitMessage(scope *versionScope, info *releaseInfo) string {
+	return info.render(config.ReleaseCommitMessage(r.config, scope.target))
+}
+
+// annotateTags reports whether release tags are annotated with the release notes.
+// Signed tags are always annotated.
+func (r *Releaser) annotateTags() bool {
+	return r.config.Release != nil && (r.config.Release.AnnotateTags || r.config.Release.Sign)
+}
+
+// historyPlaceholders are the placeholders that require reading git history.
+var historyPlaceholders = []string{"{previous_tag}", "{changelog}", "{changes}"}
+
+// releaseInfo describes the release of verStr from HEAD. The commits since the
+// last release are only read if a template needs them; plan provides them
+// for automatic releases.
+func (r *Releaser) releaseInfo(ctx context.Context, scope *versionScope, verStr string, plan *autoPlan) (*releaseInfo, error) {
+	info := &releaseInfo{
+		version: verStr,
+		date:    r.now().Format("2006-01-02"),
+		tag:     scope.tags(verStr)[0],
+		target:  scope.target,
+	}
+	if plan != nil {
+		info.previousTag, info.commits = plan.lastTag, plan.commits
+		return info, nil
+	}
+
+	templates := config.ReleaseCommitMessage(r.config, scope.target)
+	if r.annotateTags() {
+		templates += config.ReleaseNotesTemplate(r.config)
+	}
+	needsHistory := false
+	for _, p := range historyPlaceholders {
+		needsHistory = needsHistory || strings.Contains(templates, p)
+	}
+	if !needsHistory {
+		return info, nil
+	}
+
+	var err error
+	if info.previousTag, err = r.lastReleaseTag(ctx, scope); err != nil {
+		return nil, err
+	}
+	if info.commits, err = r.conventionalCommits(ctx, scope, info.previousTag, "HEAD"); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// Notes renders the release notes of the current version of target, or of
+// the project if target is empty. If the release is tagged, the notes cover
+// the commits between the previous release tag and the release tag;
+// otherwise, the commits since the last release tag.
+func (r *Releaser) Notes(ctx context.Context, target string) (string, error) {
+	scope, err := r.scope(target)
+	if err != nil {
+		return "", err
+	}
+	ver, err := version.ResolveSource(r.projectRoot, scope.source, scope.tagFormat)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", scope.describe(), err)
+	}
+
+	info := &releaseInfo{
+		version: ver,
+		date:    r.now().Format("2006-01-02"),
+		tag:     scope.tags(ver)[0],
+		target:  scope.target,
+	}
+	rev, exclude := "HEAD", ""
+	tagged, err := r.tagExists(ctx, info.tag)
+	if err != nil {
+		return "", err
+	}
+	if tagged {
+		rev, exclude = info.tag, info.tag
+		cmd := exec.CommandContext(ctx, "git", "log", "-1", "--format=%cs", info.tag)
+		cmd.Dir = r.projectRoot
+		out, err := cmd.Output()
+		if err != nil {
+			return "", fmt.Errorf("failed to read release date: %w", err)
+		}
+		info.date = strings.TrimSpace(string(out))
+	}
+
+	if info.previousTag, err = r.previousTag(ctx, scope, rev, exclude); err != nil {
+		return "", err
+	}
+	if info.commits, err = r.conventionalCommits(ctx, scope, info.previousTag, rev); err != nil {
+		return "", err
+	}
+	return r.notes(info), nil
+}
+
+// conventionalCommits returns the Conventional Commits after from (all
+// commits if from is empty) up to rev, newest first. For project releases,
+// each commit is assigned the targets it changed.
+func (r *Releaser) conventionalCommits(ctx context.Context, scope *versionScope, from, rev string) ([]ConventionalCommit, error) {
+	commits, err := r.commitsSince(ctx, from, rev, scope.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read git history: %w", err)
+	}
+
+	var conventional []ConventionalCommit
+	for _, c := range commits {
+		if cc, ok := ParseConventional(c); ok {
+			conventional = append(conventional, cc)
+		}
+	}
+	if scope.target == "" {
+		assignTargets(conventional, r.targetDirs())
+	}
+	return conventional, nil
+}
+
+// renderChanges renders the changelog entries grouped by target, one
+// subsection per target. Entries that changed no target are listed under
+// "Other"; a commit that changed several targets is listed under each.
+func renderChanges(commits []ConventionalCommit) string {
+	byTarget := make(map[string][]string)
+	for _, section := range changelogSections {
+		for _, c := range commits {
+			if !section.include(c) {
+				continue
+			}
+			entry := fmt.Sprintf("- %s (%s)\n", c.Description, shortHash(c.Hash))
+			targets := c.Targets
+			if len(targets) == 0 {
+				targets = []string{""}
+			}
+			for _, t := range targets {
+				byTarget[t] = append(byTarget[t], entry)
+			}
+		}
+	}
+
+	names := make([]string, 0, len(byTarget))
+	for name := range byTarget {
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	if _, ok := byTarget[""]; ok {
+		names = append(names, "")
+	}
+
+	var sb strings.Builder
+	for _, name := range names {
+		title := name
+		if title == "" {
+			title = "Other"
+		}
+		fmt.Fprintf(&sb, "\n### %s\n\n", title)
+		for _, e := range byTarget[name] {
+			sb.WriteString(e)
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,164 @@
+package release
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AndreyAkinshin/structyl/internal/config"
+)
+
+func TestReleaseInfo_Render(t *testing.T) {
+	t.Parallel()
+	info := &releaseInfo{
+		version:     "1.5.0",
+		date:        "2026-03-04",
+		tag:         "v1.5.0",
+		previousTag: "v1.4.2",
+		commits: []ConventionalCommit{
+			{Commit: Commit{Hash: "1111111aaaa"}, Type: "feat", Description: "add median", Targets: []string{"go", "py"}},
+			{Commit: Commit{Hash: "2222222bbbb"}, Type: "fix", Description: "handle empty input", Targets: []string{"py"}},
+			{Commit: Commit{Hash: "3333333cccc"}, Type: "perf", Scope: "ci", Description: "cache builds"},
+			{Commit: Commit{Hash: "4444444dddd"}, Type: "docs", Description: "explain median", Targets: []string{"go"}},
+		},
+	}
+
+	got := info.render("{tag} ({date}), since {previous_tag}\n\n{changelog}\n\n---\n\n{changes}")
+	want := `v1.5.0 (2026-03-04), since v1.4.2
+
+### Features
+
+- **go, py:** add median (1111111)
+
+### Bug Fixes
+
+- **py:** handle empty input (2222222)
+
+### Performance
+
+- **ci:** cache builds (3333333)
+
+---
+
+### go
+
+- add median (1111111)
+
+### py
+
+- add median (1111111)
+- handle empty input (2222222)
+
+### Other
+
+- cache builds (3333333)`
+	if got != want {
+		t.Errorf("render() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestCommitAndTagArgs(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name       string
+		release    *config.ReleaseConfig
+		wantCommit []string
+		wantTag    []string
+	}{
+		{
+			name:       "defaults",
+			release:    nil,
+			wantCommit: []string{"commit", "-m", "msg"},
+			wantTag:    []string{"tag", "--annotate", "--message", "notes", "v1.0.0"},
+		},
+		{
+			name:       "signed",
+			release:    &config.ReleaseConfig{Sign: true},
+			wantCommit: []string{"commit", "-m", "msg", "--gpg-sign"},
+			wantTag:    []string{"tag", "--sign", "--message", "notes", "v1.0.0"},
+		},
+		{
+			name:       "signing key",
+			release:    &config.ReleaseConfig{Sign: true, SigningKey: "ABCD1234"},
+			wantCommit: []string{"commit", "-m", "msg", "--gpg-sign=ABCD1234"},
+			wantTag:    []string{"tag", "--local-user=ABCD1234", "--message", "notes", "v1.0.0"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			r := NewReleaser(t.TempDir(), &config.Config{Release: tt.release})
+			if got := r.commitArgs("msg"); !reflect.DeepEqual(got, tt.wantCommit) {
+				t.Errorf("commitArgs() = %q, want %q", got, tt.wantCommit)
+			}
+			if got := r.tagArgs("v1.0.0", "notes"); !reflect.DeepEqual(got, tt.wantTag) {
+				t.Errorf("tagArgs() = %q, want %q", got, tt.wantTag)
+			}
+			if got := r.tagArgs("v1.0.0", ""); !reflect.DeepEqual(got, []string{"tag", "v1.0.0"}) {
+				t.Errorf("tagArgs() without message = %q, want a lightweight tag", got)
+			}
+		})
+	}
+}
+
+func TestRelease_CommitMessageTemplateAndAnnotatedTags(t *testing.T) {
+	dir, _ := createTestGitRepoWithRemote(t)
+	commitFile(t, dir, ".structyl/PROJECT_VERSION", "1.0.0")
+	git(t, dir, "tag", "v1.0.0")
+	commitFile(t, dir, "go/stats.go", "feat: add median")
+
+	cfg := &config.Config{
+		Targets: map[string]config.TargetConfig{"go": {}},
+		Release: &config.ReleaseConfig{
+			Branch:        git(t, dir, "branch", "--show-current"),
+			CommitMessage: "chore(release): {tag}",
+			Notes:         "Release {version}\n{changes}",
+			AnnotateTags:  true,
+		},
+	}
+	r := NewReleaser(dir, cfg)
+	captureStdout(t, func() {
+		if err := r.Release(context.Background(), Options{Version: "1.1.0", Push: true}); err != nil {
+			t.Fatalf("Release() error = %v", err)
+		}
+	})
+
+	if got := git(t, dir, "log", "-1", "--format=%s"); got != "chore(release): v1.1.0" {
+		t.Errorf("commit message = %q, want the rendered template", got)
+	}
+	if got := git(t, dir, "cat-file", "-t", "v1.1.0"); got != "tag" {
+		t.Errorf("v1.1.0 is a %s, want an annotated tag", got)
+	}
+	message := git(t, dir, "tag", "--list", "--format=%(contents)", "v1.1.0")
+	if !strings.HasPrefix(message, "Release 1.1.0") || !strings.Contains(message, "- add median") {
+		t.Errorf("tag message = %q, want the rendered notes", message)
+	}
+}
+
+func TestNotes_TaggedRelease(t *testing.T) {
+	dir := createTestGitRepo(t)
+	commitFile(t, dir, ".structyl/PROJECT_VERSION", "1.0.0")
+	git(t, dir, "tag", "v1.0.0")
+	commitFile(t, dir, "src/a.go", "feat: add median")
+	commitFile(t, dir, ".structyl/PROJECT_VERSION", "1.1.0")
+	git(t, dir, "tag", "v1.1.0")
+	commitFile(t, dir, "src/b.go", "fix: after the release")
+
+	r := NewReleaser(dir, &config.Config{})
+	r.now = func() time.Time { return time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC) }
+
+	// HEAD is past the tag: the notes still describe the tagged release.
+	git(t, dir, "reset", "-q", "--hard", "HEAD")
+	notes, err := r.Notes(context.Background(), "")
+	if err != nil {
+		t.Fatalf("Notes() error = %v", err)
+	}
+	if !strings.HasPrefix(notes, "## 1.1.0 (") || strings.Contains(notes, "2000-01-01") {
+		t.Errorf("Notes() = %q, want the version and the date of the tagged commit", notes)
+	}
+	if !strings.Contains(notes, "add median") || strings.Contains(notes, "after the release") {
+		t.Errorf("Notes() =\n%s\nwant only the commits between v1.0.0 and v1.1.0", notes)
+	}
+}
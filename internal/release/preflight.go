@@ -0,0 +1,135 @@
+package release
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/AndreyAkinshin/structyl/internal/version"
+)
+
+// preflight verifies that a release of verStr can succeed before anything is
+// changed: the working tree is clean (unless forced), HEAD is the release
+// branch, the version is not older than the current one, and none of the release tags exist yet (on the remote too when
+// pushing).
+func (r *Releaser) preflight(ctx context.Context, scope *versionScope, verStr string, opts Options) error {
+	if !opts.Force {
+		if err := r.checkGitClean(ctx); err != nil {
+			return err
+		}
+	}
+	if err := r.checkBranch(ctx); err != nil {
+		return err
+	}
+	if err := r.checkVersionOrder(scope, verStr); err != nil {
+		return err
+	}
+	for _, tag := range scope.tags(verStr) {
+		exists, err := r.tagExists(ctx, tag)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return fmt.Errorf("tag %s already exists", tag)
+		}
+	}
+	if opts.Push {
+		remote := r.getRemote()
+		for _, tag := range scope.tags(verStr) {
+			exists, err := r.remoteTagExists(ctx, remote, tag)
+			if err != nil {
+				return err
+			}
+			if exists {
+				return fmt.Errorf("tag %s already exists on %s", tag, remote)
+			}
+		}
+	}
+	return nil
+}
+
+// checkBranch verifies that HEAD is the release branch (release.branch),
+// which the release commit is added to.
+func (r *Releaser) checkBranch(ctx context.Context) error {
+	branch := r.getBranch()
+	current, err := r.getCurrentBranch(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read the current branch: %w", err)
+	}
+	if current == "HEAD" {
+		return fmt.Errorf("HEAD is detached; check out the %s branch first", branch)
+	}
+	if current != branch {
+		return fmt.Errorf("current branch is %s, not the release branch %s; check out %s first", current, branch, branch)
+	}
+	return nil
+}
+
+// checkVersionOrder verifies that verStr is greater than the current version
+// of the scope. A scope without a current version accepts any version.
+//
+// A version file may already hold verStr, as in the first release of a new
+// project; that version is released if it has no tag yet, which the tag
+// checks verify.
+func (r *Releaser) checkVersionOrder(scope *versionScope, verStr string) error {
+	var current string
+	var err error
+	if scope.gitTagSource() {
+		current, err = version.LatestTagVersion(r.projectRoot, scope.tagFormat)
+	} else if current, err = version.Read(scope.source); errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read current version: %w", err)
+	}
+	if current == "" {
+		return nil
+	}
+
+	c, err := version.Compare(verStr, current)
+	if err != nil {
+		return fmt.Errorf("failed to compare with current version: %w", err)
+	}
+	if c < 0 || (c == 0 && scope.gitTagSource()) {
+		return fmt.Errorf("version %s is not greater than the current %s %s", verStr, scope.describe(), current)
+	}
+	return nil
+}
+
+// tagExists reports whether tag exists in the local repository.
+func (r *Releaser) tagExists(ctx context.Context, tag string) (bool, error) {
+	if _, err := r.revParse(ctx, "refs/tags/"+tag); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to look up tag %s: %w", tag, err)
+	}
+	return true, nil
+}
+
+// remoteTagExists reports whether tag exists on remote.
+func (r *Releaser) remoteTagExists(ctx context.Context, remote, tag string) (bool, error) {
+	cmd := exec.CommandContext(ctx, "git", "ls-remote", "--tags", remote, "refs/tags/"+tag)
+	cmd.Dir = r.projectRoot
+	out, err := cmd.Output()
+	if err != nil {
+		return false, fmt.Errorf("failed to list tags on %s: %w", remote, err)
+	}
+	return strings.TrimSpace(string(out)) != "", nil
+}
+
+// revParse resolves ref to a commit hash. The error is an *exec.ExitError
+// with exit code 1 if ref does not exist.
+func (r *Releaser) revParse(ctx context.Context, ref string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", "rev-parse", "-q", "--verify", ref+"^{commit}")
+	cmd.Dir = r.projectRoot
+	out, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
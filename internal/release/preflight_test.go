@@ -0,0 +1,202 @@
+package release
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/AndreyAkinshin/structyl/internal/config"
+)
+
+// git runs a git command in dir, failing the test on error.
+func git(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v failed: %v\n%s", args, err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func TestRelease_Preflight_RejectsUnsafeReleases(t *testing.T) {
+	tests := []struct {
+		name    string
+		setup   func(t *testing.T, dir string)
+		cfg     *config.Config
+		version string
+		want    string
+	}{
+		{
+			name:    "existing tag",
+			setup:   func(t *testing.T, dir string) { git(t, dir, "tag", "v1.2.0") },
+			cfg:     &config.Config{},
+			version: "1.2.0",
+			want:    "tag v1.2.0 already exists",
+		},
+		{
+			name:    "existing extra tag",
+			setup:   func(t *testing.T, dir string) { git(t, dir, "tag", "stable") },
+			cfg:     &config.Config{Release: &config.ReleaseConfig{ExtraTags: []string{"stable"}}},
+			version: "1.2.0",
+			want:    "tag stable already exists",
+		},
+		{
+			name:    "version not greater",
+			setup:   func(t *testing.T, dir string) { commitFile(t, dir, ".structyl/PROJECT_VERSION", "1.2.0") },
+			cfg:     &config.Config{},
+			version: "1.1.9",
+			want:    "not greater than the current version 1.2.0",
+		},
+		{
+			name:    "version equal to last tag",
+			setup:   func(t *testing.T, dir string) { git(t, dir, "tag", "v1.2.0") },
+			cfg:     &config.Config{Version: &config.VersionConfig{Source: config.VersionSourceGitTag}},
+			version: "1.2.0",
+			want:    "not greater than the current version 1.2.0",
+		},
+		{
+			name:    "not on the release branch",
+			setup:   func(t *testing.T, dir string) { git(t, dir, "branch", "release") },
+			cfg:     &config.Config{Release: &config.ReleaseConfig{Branch: "release"}},
+			version: "1.2.0",
+			want:    "current branch is main, not the release branch release",
+		},
+		{
+			name:    "detached HEAD",
+			setup:   func(t *testing.T, dir string) { git(t, dir, "checkout", "-q", "--detach") },
+			cfg:     &config.Config{},
+			version: "1.2.0",
+			want:    "HEAD is detached",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := createTestGitRepo(t)
+			tt.setup(t, dir)
+			head := git(t, dir, "rev-parse", "HEAD")
+
+			r := NewReleaser(dir, tt.cfg)
+			var err error
+			captureStdout(t, func() {
+				err = r.Release(context.Background(), Options{Version: tt.version})
+			})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("Release() error = %v, want containing %q", err, tt.want)
+			}
+			if got := git(t, dir, "rev-parse", "HEAD"); got != head {
+				t.Error("Release() committed although a preflight check failed")
+			}
+			if status := git(t, dir, "status", "--porcelain"); status != "" {
+				t.Errorf("Release() changed the working tree although a preflight check failed:\n%s", status)
+			}
+		})
+	}
+}
+
+func TestRelease_Preflight_ForceSkipsOnlyCleanTreeCheck(t *testing.T) {
+	dir := createTestGitRepo(t)
+	commitFile(t, dir, ".structyl/PROJECT_VERSION", "2.0.0")
+	if err := os.WriteFile(filepath.Join(dir, "wip.txt"), []byte("uncommitted\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	r := NewReleaser(dir, &config.Config{})
+	var err error
+	captureStdout(t, func() {
+		err = r.Release(context.Background(), Options{Version: "1.0.0", Force: true})
+	})
+	if err == nil || !strings.Contains(err.Error(), "not greater than the current version 2.0.0") {
+		t.Fatalf("Release() with Force error = %v, want the version check to fail", err)
+	}
+
+	captureStdout(t, func() {
+		err = r.Release(context.Background(), Options{Version: "2.1.0", Force: true})
+	})
+	if err != nil {
+		t.Fatalf("Release() with Force and uncommitted changes error = %v", err)
+	}
+}
+
+func TestRelease_FirstRelease_CurrentVersion(t *testing.T) {
+	dir := createTestGitRepo(t)
+	commitFile(t, dir, ".structyl/PROJECT_VERSION", "0.1.0")
+	head := git(t, dir, "rev-parse", "HEAD")
+
+	r := NewReleaser(dir, &config.Config{})
+	var err error
+	captureStdout(t, func() {
+		err = r.Release(context.Background(), Options{Version: "0.1.0"})
+	})
+	if err != nil {
+		t.Fatalf("Release() of the current, untagged version error = %v", err)
+	}
+	if got := git(t, dir, "rev-parse", "HEAD"); got != head {
+		t.Error("Release() committed although no file changed")
+	}
+
+	// Once tagged, the version cannot be released again.
+	git(t, dir, "tag", "v0.1.0")
+	captureStdout(t, func() {
+		err = r.Release(context.Background(), Options{Version: "0.1.0"})
+	})
+	if err == nil || !strings.Contains(err.Error(), "tag v0.1.0 already exists") {
+		t.Errorf("Release() of a tagged version error = %v, want the tag check to fail", err)
+	}
+}
+
+func TestRelease_CIGate(t *testing.T) {
+	dir := createTestGitRepo(t)
+	head := git(t, dir, "rev-parse", "HEAD")
+
+	ran := false
+	r := NewReleaser(dir, &config.Config{})
+	var err error
+	captureStdout(t, func() {
+		err = r.Release(context.Background(), Options{
+			Version: "1.0.0",
+			CI: func(context.Context) error {
+				ran = true
+				return errors.New("exit code 1")
+			},
+		})
+	})
+	if !ran {
+		t.Fatal("Release() did not run the CI gate")
+	}
+	if err == nil || !strings.Contains(err.Error(), "CI: exit code 1") {
+		t.Errorf("Release() error = %v, want the CI failure", err)
+	}
+	if got := git(t, dir, "rev-parse", "HEAD"); got != head {
+		t.Error("Release() committed although CI failed")
+	}
+}
+
+func TestRelease_CIGate_ChangedWorkingTree(t *testing.T) {
+	dir := createTestGitRepo(t)
+	head := git(t, dir, "rev-parse", "HEAD")
+
+	r := NewReleaser(dir, &config.Config{})
+	var err error
+	captureStdout(t, func() {
+		err = r.Release(context.Background(), Options{
+			Version: "1.0.0",
+			CI: func(context.Context) error {
+				// A build output that is not ignored.
+				return os.WriteFile(filepath.Join(dir, "out.bin"), []byte("binary"), 0644)
+			},
+		})
+	})
+	if err == nil || !strings.Contains(err.Error(), "the working tree changed (?? out.bin)") {
+		t.Errorf("Release() error = %v, want the working tree check to fail", err)
+	}
+	if got := git(t, dir, "rev-parse", "HEAD"); got != head {
+		t.Error("Release() committed the output of CI")
+	}
+}
@@ -0,0 +1,282 @@
+package release
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/AndreyAkinshin/structyl/internal/config"
+	"github.com/AndreyAkinshin/structyl/internal/output"
+	"github.com/AndreyAkinshin/structyl/internal/target"
+	"github.com/AndreyAkinshin/structyl/internal/version"
+)
+
+// Commands run by the publish workflow.
+const (
+	publishCommand    = "publish"
+	publishDryCommand = "publish:dry"
+)
+
+// publishStateFile is the file in the git directory that records the targets
+// published from a commit, so that a failed publish can be resumed.
+const publishStateFile = "structyl-publish.json"
+
+// PublishOptions configures publish behavior.
+type PublishOptions struct {
+	Targets []string // Targets to publish; empty publishes every target with a publish command
+	Resume  bool     // Skip the targets already published from HEAD by a previous run
+	DryRun  bool     // Run publish:dry only
+	// Run runs a command of a target. The CLI runs it through mise, like
+	// every other command, so that it gets the pinned tools, the task
+	// environment, and the profile overlays. Nil runs it directly with
+	// target.Execute.
+	Run func(ctx context.Context, target, command string) error
+}
+
+// publishState records the targets published from a commit.
+type publishState struct {
+	Commit    string   `json:"commit"`
+	Published []string `json:"published"`
+}
+
+// Publisher handles the publish workflow: publishing every target to its
+// package registry from a release commit.
+type Publisher struct {
+	releaser *Releaser
+	registry *target.Registry
+	out      *output.Writer
+}
+
+// NewPublisher creates a new Publisher.
+func NewPublisher(projectRoot string, cfg *config.Config, registry *target.Registry) *Publisher {
+	return &Publisher{
+		releaser: NewReleaser(projectRoot, cfg),
+		registry: registry,
+		out:      output.New(),
+	}
+}
+
+// SetOutput sets a custom output writer (for testing).
+func (p *Publisher) SetOutput(out *output.Writer) {
+	p.out = out
+}
+
+// Publish runs publish:dry for every target and, if all succeed, publish in
+// dependency order. Published targets are recorded so that a failed publish
+// can be resumed with opts.Resume.
+func (p *Publisher) Publish(ctx context.Context, opts PublishOptions) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	targets, err := p.targets(opts.Targets)
+	if err != nil {
+		return err
+	}
+	if len(targets) == 0 {
+		return fmt.Errorf("no targets have a %s command", publishCommand)
+	}
+
+	head, err := p.releaser.revParse(ctx, "HEAD")
+	if err != nil {
+		return fmt.Errorf("failed to resolve HEAD: %w", err)
+	}
+	statePath, err := p.statePath(ctx)
+	if err != nil {
+		return err
+	}
+	state, err := loadPublishState(statePath)
+	if err != nil {
+		return err
+	}
+	if state == nil || state.Commit != head {
+		state = &publishState{Commit: head}
+	}
+	published := make(map[string]bool, len(state.Published))
+	for _, name := range state.Published {
+		published[name] = true
+	}
+
+	var pending []target.Target
+	for _, t := range targets {
+		if !published[t.Name()] {
+			pending = append(pending, t)
+		}
+	}
+	if len(pending) < len(targets) && !opts.Resume {
+		return fmt.Errorf("%s already published from this commit; run with --resume to publish the remaining targets", strings.Join(state.Published, ", "))
+	}
+	if len(pending) == 0 {
+		p.out.FinalSuccess("All targets are already published.")
+		return nil
+	}
+
+	steps := &stepCounter{}
+
+	p.out.Step(steps.next(), "Checking release tags...")
+	if err := p.checkReleaseTags(ctx, pending); err != nil {
+		return err
+	}
+
+	p.out.Step(steps.next(), "Running %s...", publishDryCommand)
+	for _, t := range pending {
+		if cmd, ok := t.GetCommand(publishDryCommand); !ok || cmd == nil {
+			p.out.StepDetail("%s: no %s command, skipped", t.Name(), publishDryCommand)
+			continue
+		}
+		p.out.StepDetail("%s", t.Name())
+		if err := p.run(ctx, opts, t, publishDryCommand); err != nil {
+			return fmt.Errorf("[%s] %s failed; nothing was published: %w", t.Name(), publishDryCommand, err)
+		}
+	}
+
+	if opts.DryRun {
+		p.out.FinalSuccess("Dry run passed for %d target(s).", len(pending))
+		return nil
+	}
+
+	p.out.Step(steps.next(), "Publishing...")
+	for _, t := range pending {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		p.out.StepDetail("%s", t.Name())
+		if err := p.run(ctx, opts, t, publishCommand); err != nil {
+			if len(state.Published) > 0 {
+				p.out.Hint("Published: %s. Fix the failure and run with --resume to publish the rest.", strings.Join(state.Published, ", "))
+			}
+			return fmt.Errorf("[%s] %s failed: %w", t.Name(), publishCommand, err)
+		}
+		state.Published = append(state.Published, t.Name())
+		if err := savePublishState(statePath, state); err != nil {
+			return err
+		}
+	}
+
+	p.out.FinalSuccess("Published %d target(s).", len(pending))
+	return nil
+}
+
+// run runs a command of t with opts.Run, or directly if it is nil.
+func (p *Publisher) run(ctx context.Context, opts PublishOptions, t target.Target, command string) error {
+	if opts.Run != nil {
+		return opts.Run(ctx, t.Name(), command)
+	}
+	return t.Execute(ctx, command, target.ExecOptions{})
+}
+
+// targets returns the targets to publish in dependency order: the named
+// targets, or every target with a publish command if names is empty.
+func (p *Publisher) targets(names []string) ([]target.Target, error) {
+	requested := make(map[string]bool, len(names))
+	for _, name := range names {
+		t, ok := p.registry.Get(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown target %q", name)
+		}
+		if cmd, ok := t.GetCommand(publishCommand); !ok || cmd == nil {
+			return nil, fmt.Errorf("target %q has no %s command", name, publishCommand)
+		}
+		requested[name] = true
+	}
+
+	ordered, err := p.registry.TopologicalOrder()
+	if err != nil {
+		return nil, err
+	}
+	var targets []target.Target
+	for _, t := range ordered {
+		if len(names) > 0 && !requested[t.Name()] {
+			continue
+		}
+		if cmd, ok := t.GetCommand(publishCommand); ok && cmd != nil {
+			targets = append(targets, t)
+		}
+	}
+	return targets, nil
+}
+
+// checkReleaseTags verifies that HEAD carries the release tag of the current
+// version of every target: the project tag, or the target's own tag for an
+// independently versioned target.
+func (p *Publisher) checkReleaseTags(ctx context.Context, targets []target.Target) error {
+	out, err := gitOutput(ctx, p.releaser.projectRoot, "tag", "--points-at", "HEAD")
+	if err != nil {
+		return fmt.Errorf("failed to list tags at HEAD: %w", err)
+	}
+	atHead := make(map[string]bool)
+	for _, tag := range strings.Fields(out) {
+		atHead[tag] = true
+	}
+
+	checked := make(map[string]bool)
+	for _, t := range targets {
+		scopeName := ""
+		if tc := p.releaser.config.Targets[t.Name()]; tc.Version != nil {
+			scopeName = t.Name()
+		}
+		if checked[scopeName] {
+			continue
+		}
+		checked[scopeName] = true
+
+		scope, err := p.releaser.scope(scopeName)
+		if err != nil {
+			return err
+		}
+		ver, err := version.ResolveSource(p.releaser.projectRoot, scope.source, scope.tagFormat)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", scope.describe(), err)
+		}
+		tag := scope.tags(ver)[0]
+		if !atHead[tag] {
+			return fmt.Errorf("HEAD is not tagged %s; publish from the commit created by structyl release", tag)
+		}
+		p.out.StepDetail("%s", tag)
+	}
+	return nil
+}
+
+// statePath returns the path of the publish state file.
+func (p *Publisher) statePath(ctx context.Context) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", "rev-parse", "--absolute-git-dir")
+	cmd.Dir = p.releaser.projectRoot
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to find git directory: %w", err)
+	}
+	return filepath.Join(strings.TrimSpace(string(out)), publishStateFile), nil
+}
+
+// loadPublishState reads the publish state, or returns nil if there is none.
+func loadPublishState(path string) (*publishState, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read publish state: %w", err)
+	}
+	var state publishState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("invalid publish state %s: %w", path, err)
+	}
+	return &state, nil
+}
+
+// savePublishState writes the publish state.
+func savePublishState(path string, state *publishState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to record published targets: %w", err)
+	}
+	return nil
+}
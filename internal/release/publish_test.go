@@ -0,0 +1,168 @@
+package release
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/AndreyAkinshin/structyl/internal/config"
+	"github.com/AndreyAkinshin/structyl/internal/output"
+	"github.com/AndreyAkinshin/structyl/internal/target"
+)
+
+// createPublishProject creates a tagged release of a project where b depends
+// on a. Published targets append their names to published.log; b fails to
+// publish while the file fail-b exists.
+func createPublishProject(t *testing.T, bDry string) (string, *Publisher) {
+	t.Helper()
+	dir := createTestGitRepo(t)
+	commitFile(t, dir, ".structyl/PROJECT_VERSION", "1.0.0")
+	for _, name := range []string{"a", "b", "docs"} {
+		commitFile(t, dir, name+"/README.md", "add "+name)
+	}
+	git(t, dir, "tag", "v1.0.0")
+
+	cfg := &config.Config{Targets: map[string]config.TargetConfig{
+		"a": {Type: "language", Title: "A", Commands: map[string]interface{}{
+			"publish:dry": "true",
+			"publish":     "echo a >> ../published.log",
+		}},
+		"b": {Type: "language", Title: "B", DependsOn: []string{"a"}, Commands: map[string]interface{}{
+			"publish:dry": bDry,
+			"publish":     "test ! -f ../fail-b && echo b >> ../published.log",
+		}},
+		"docs": {Type: "auxiliary", Title: "Docs", Commands: map[string]interface{}{
+			"publish": nil,
+		}},
+	}}
+	registry, err := target.NewRegistry(cfg, dir)
+	if err != nil {
+		t.Fatalf("NewRegistry() error = %v", err)
+	}
+	p := NewPublisher(dir, cfg, registry)
+	p.SetOutput(output.NewWithWriters(&bytes.Buffer{}, &bytes.Buffer{}, false))
+	return dir, p
+}
+
+// publishLog returns the targets published so far, in order.
+func publishLog(t *testing.T, dir string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(dir, "published.log"))
+	if os.IsNotExist(err) {
+		return ""
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.Join(strings.Fields(string(data)), ",")
+}
+
+func publish(t *testing.T, p *Publisher, opts PublishOptions) error {
+	t.Helper()
+	var err error
+	captureStdout(t, func() {
+		err = p.Publish(context.Background(), opts)
+	})
+	return err
+}
+
+func TestPublish_PublishesInDependencyOrder(t *testing.T) {
+	dir, p := createPublishProject(t, "true")
+	if err := publish(t, p, PublishOptions{}); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+	if got := publishLog(t, dir); got != "a,b" {
+		t.Errorf("published = %q, want a,b", got)
+	}
+
+	// Publishing the same commit again must not republish.
+	if err := publish(t, p, PublishOptions{}); err == nil || !strings.Contains(err.Error(), "already published") {
+		t.Errorf("second Publish() error = %v, want 'already published'", err)
+	}
+}
+
+func TestPublish_DryRunFailure_PublishesNothing(t *testing.T) {
+	dir, p := createPublishProject(t, "exit 1")
+	err := publish(t, p, PublishOptions{})
+	if err == nil || !strings.Contains(err.Error(), "[b] publish:dry failed") {
+		t.Fatalf("Publish() error = %v, want the publish:dry failure of b", err)
+	}
+	if got := publishLog(t, dir); got != "" {
+		t.Errorf("published = %q, want nothing", got)
+	}
+}
+
+func TestPublish_Resume(t *testing.T) {
+	dir, p := createPublishProject(t, "true")
+	failB := filepath.Join(dir, "fail-b")
+	if err := os.WriteFile(failB, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := publish(t, p, PublishOptions{}); err == nil || !strings.Contains(err.Error(), "[b] publish failed") {
+		t.Fatalf("Publish() error = %v, want the publish failure of b", err)
+	}
+	if got := publishLog(t, dir); got != "a" {
+		t.Fatalf("published = %q, want a", got)
+	}
+
+	if err := os.Remove(failB); err != nil {
+		t.Fatal(err)
+	}
+	if err := publish(t, p, PublishOptions{}); err == nil || !strings.Contains(err.Error(), "--resume") {
+		t.Errorf("Publish() without Resume error = %v, want a hint to resume", err)
+	}
+	if err := publish(t, p, PublishOptions{Resume: true}); err != nil {
+		t.Fatalf("Publish(Resume) error = %v", err)
+	}
+	if got := publishLog(t, dir); got != "a,b" {
+		t.Errorf("published = %q, want a,b (a only once)", got)
+	}
+}
+
+func TestPublish_RequiresReleaseTag(t *testing.T) {
+	dir, p := createPublishProject(t, "true")
+	commitFile(t, dir, "a/README.md", "fix: after the release")
+
+	err := publish(t, p, PublishOptions{})
+	if err == nil || !strings.Contains(err.Error(), "HEAD is not tagged v1.0.0") {
+		t.Errorf("Publish() error = %v, want the missing release tag", err)
+	}
+	if got := publishLog(t, dir); got != "" {
+		t.Errorf("published = %q, want nothing", got)
+	}
+}
+
+func TestPublish_Targets(t *testing.T) {
+	dir, p := createPublishProject(t, "true")
+	if err := publish(t, p, PublishOptions{Targets: []string{"b"}}); err != nil {
+		t.Fatalf("Publish(b) error = %v", err)
+	}
+	if got := publishLog(t, dir); got != "b" {
+		t.Errorf("published = %q, want b", got)
+	}
+
+	if err := publish(t, p, PublishOptions{Targets: []string{"docs"}}); err == nil || !strings.Contains(err.Error(), "no publish command") {
+		t.Errorf("Publish(docs) error = %v, want 'no publish command'", err)
+	}
+}
+
+func TestPublish_RunsCommandsWithRun(t *testing.T) {
+	dir, p := createPublishProject(t, "exit 1")
+	var ran []string
+	err := publish(t, p, PublishOptions{Run: func(_ context.Context, target, command string) error {
+		ran = append(ran, command+":"+target)
+		return nil
+	}})
+	if err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+	if got, want := strings.Join(ran, ","), "publish:dry:a,publish:dry:b,publish:a,publish:b"; got != want {
+		t.Errorf("Run calls = %q, want %q", got, want)
+	}
+	if got := publishLog(t, dir); got != "" {
+		t.Errorf("published = %q, want nothing run directly", got)
+	}
+}
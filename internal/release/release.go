@@ -3,24 +3,19 @@ package release
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/AndreyAkinshin/structyl/internal/config"
 	"github.com/AndreyAkinshin/structyl/internal/output"
 	"github.com/AndreyAkinshin/structyl/internal/version"
 )
 
-// Default values for release configuration.
-const (
-	defaultRemote    = "origin"
-	defaultBranch    = "main"
-	defaultTagFormat = "v{version}"
-)
-
 // stepCounter provides auto-incrementing step numbers for release output.
 // Using a counter avoids manual stepNum++ which is error-prone when steps are reordered.
 type stepCounter struct {
@@ -35,16 +30,23 @@ func (s *stepCounter) next() int {
 // Options configures release behavior.
 type Options struct {
 	Version string // Version to release
+	Target  string // Independently versioned target to release; empty releases the project
+	Auto    bool   // Derive Version from commits since the last tag and update the changelog
 	Push    bool   // Push to remote after commit
 	DryRun  bool   // Print what would be done without doing it
-	Force   bool   // Force release even with uncommitted changes
+	Force   bool   // Release with uncommitted changes, skipping the clean tree check
+	// CI runs the CI pipeline after the preflight checks; a failure aborts
+	// the release before anything is changed. Nil skips it.
+	CI func(context.Context) error
 }
 
 // Releaser handles the release workflow.
 type Releaser struct {
 	projectRoot string
 	config      *config.Config
+	redacted    *config.Config // config with secrets redacted, for output
 	out         *output.Writer
+	now         func() time.Time
 }
 
 // NewReleaser creates a new Releaser.
@@ -52,7 +54,9 @@ func NewReleaser(projectRoot string, cfg *config.Config) *Releaser {
 	return &Releaser{
 		projectRoot: projectRoot,
 		config:      cfg,
+		redacted:    cfg,
 		out:         output.New(),
+		now:         time.Now,
 	}
 }
 
@@ -61,6 +65,12 @@ func (r *Releaser) SetOutput(out *output.Writer) {
 	r.out = out
 }
 
+// SetRedactedConfig sets the configuration shown in output, with secret
+// values redacted, such as a token in release.remote.
+func (r *Releaser) SetRedactedConfig(cfg *config.Config) {
+	r.redacted = cfg
+}
+
 // Release performs the release workflow.
 func (r *Releaser) Release(ctx context.Context, opts Options) error {
 	// Check for cancellation at start
@@ -68,6 +78,19 @@ func (r *Releaser) Release(ctx context.Context, opts Options) error {
 		return err
 	}
 
+	scope, err := r.scope(opts.Target)
+	if err != nil {
+		return err
+	}
+
+	var plan *autoPlan
+	if opts.Auto {
+		if plan, err = r.planAuto(ctx, scope); err != nil {
+			return err
+		}
+		opts.Version = plan.version
+	}
+
 	// Validate version format
 	ver, err := version.Parse(opts.Version)
 	if err != nil {
@@ -75,41 +98,114 @@ func (r *Releaser) Release(ctx context.Context, opts Options) error {
 	}
 	verStr := ver.String()
 
-	// Check git state
-	if !opts.Force {
-		if err := r.checkGitClean(ctx); err != nil {
-			return err
-		}
+	if err := r.preflight(ctx, scope, verStr, opts); err != nil {
+		return fmt.Errorf("preflight check failed: %w", err)
 	}
 
 	if opts.DryRun {
-		return r.dryRun(ctx, verStr, opts)
+		return r.dryRun(ctx, scope, verStr, opts, plan)
 	}
 
 	steps := &stepCounter{}
 
-	r.out.Step(steps.next(), "Setting version to %s", verStr)
-	if err := r.setVersion(verStr); err != nil {
-		return fmt.Errorf("failed to set version: %w", err)
+	if opts.CI != nil {
+		r.out.Step(steps.next(), "Running CI...")
+		if err := r.runCI(ctx, opts.CI); err != nil {
+			return fmt.Errorf("preflight check failed: CI: %w", err)
+		}
 	}
 
-	if r.config.Version != nil && len(r.config.Version.Files) > 0 {
-		r.out.Step(steps.next(), "Propagating version to configured files...")
-		// Resolve paths relative to project root
-		resolvedFiles := make([]config.VersionFileConfig, len(r.config.Version.Files))
-		for i, f := range r.config.Version.Files {
-			resolvedFiles[i] = config.VersionFileConfig{
-				Path:       filepath.Join(r.projectRoot, f.Path),
-				Pattern:    f.Pattern,
-				Replace:    f.Replace,
-				ReplaceAll: f.ReplaceAll,
+	info, err := r.releaseInfo(ctx, scope, verStr, plan)
+	if err != nil {
+		return err
+	}
+
+	tx, err := r.begin(ctx)
+	if err != nil {
+		return err
+	}
+	if err := r.apply(ctx, tx, steps, scope, info, opts, plan); err != nil {
+		r.out.Step(steps.next(), "Rolling back...")
+		if rollbackErr := tx.rollback(ctx); rollbackErr != nil {
+			r.out.WarningSimple("rollback incomplete: %v", rollbackErr)
+		} else {
+			r.out.StepDetail("Restored the repository to its state before the release")
+		}
+		return err
+	}
+
+	// A failed push is not rolled back: the release is complete locally and
+	// the push can be retried.
+	branch := r.getBranch()
+	tags := scope.tags(verStr)
+	if opts.Push {
+		remote, shown := r.getRemote(), r.shownRemote()
+		r.out.Step(steps.next(), "Pushing to %s...", shown)
+
+		if err := r.gitPush(ctx, remote, branch); err != nil {
+			r.out.Hint("The release was created locally; retry with: git push %s %s %s", shown, branch, strings.Join(tags, " "))
+			return fmt.Errorf("failed to push branch: %w", err)
+		}
+		for _, tag := range tags {
+			if err := r.gitPushTag(ctx, remote, tag); err != nil {
+				r.out.Hint("The release was created locally; retry with: git push %s %s", shown, strings.Join(tags, " "))
+				return fmt.Errorf("failed to push tag %s: %w", tag, err)
 			}
 		}
+	}
+
+	r.out.FinalSuccess("Release %s completed successfully!", scope.releaseName(verStr))
+	if !opts.Push {
+		r.out.Hint("Run with --push to push to remote.")
+	}
+
+	return nil
+}
+
+// apply performs the local steps of a release: updating files, running the
+// pre-commit commands, committing, and tagging. tx records the tags it
+// creates.
+func (r *Releaser) apply(ctx context.Context, tx *transaction, steps *stepCounter, scope *versionScope, info *releaseInfo, opts Options, plan *autoPlan) error {
+	verStr := info.version
+	if plan != nil {
+		r.out.Step(steps.next(), "Bumping %s %s: %s → %s (%s)", plan.level, scope.describe(), plan.current, verStr, plan.describeRange())
+	}
+
+	// A git:tag version lives in the release tag, not in a file.
+	gitTagSource := scope.gitTagSource()
+	if !gitTagSource {
+		r.out.Step(steps.next(), "Setting %s to %s", scope.describe(), verStr)
+		if err := r.setVersion(scope, verStr); err != nil {
+			return fmt.Errorf("failed to set version: %w", err)
+		}
+	}
+
+	if len(scope.files) > 0 {
+		r.out.Step(steps.next(), "Propagating version to configured files...")
+		resolvedFiles := version.ResolveFiles(r.projectRoot, scope.files)
 		if err := version.Propagate(verStr, resolvedFiles); err != nil {
 			return fmt.Errorf("failed to propagate version: %w", err)
 		}
 	}
 
+	if dependents := r.dependentFiles(scope); len(dependents) > 0 {
+		r.out.Step(steps.next(), "Updating %s in dependent targets...", scope.describe())
+		for _, name := range sortedKeys(dependents) {
+			r.out.StepDetail("%s", name)
+			if err := version.Propagate(verStr, version.ResolveFiles(r.projectRoot, dependents[name])); err != nil {
+				return fmt.Errorf("failed to update dependent target %s: %w", name, err)
+			}
+		}
+	}
+
+	if plan != nil {
+		r.out.Step(steps.next(), "Updating %s...", filepath.ToSlash(scope.changelogPath()))
+		section := RenderChangelogSection(verStr, info.date, plan.commits)
+		if err := PrependChangelog(filepath.Join(r.projectRoot, scope.changelogPath()), section); err != nil {
+			return fmt.Errorf("failed to update changelog: %w", err)
+		}
+	}
+
 	if r.config.Release != nil && len(r.config.Release.PreCommands) > 0 {
 		r.out.Step(steps.next(), "Running pre-commit commands...")
 		for _, cmdStr := range r.config.Release.PreCommands {
@@ -127,68 +223,82 @@ func (r *Releaser) Release(ctx context.Context, opts Options) error {
 	if err := r.gitAddAll(ctx); err != nil {
 		return fmt.Errorf("git add failed: %w", err)
 	}
-	commitMsg := fmt.Sprintf("set version %s", verStr)
-	if err := r.gitCommit(ctx, commitMsg); err != nil {
-		return fmt.Errorf("git commit failed: %w", err)
+	// A release may have nothing to commit: with a git:tag source the tag
+	// alone sets the version, and the first release of a project may release
+	// the version its files already hold.
+	commit, err := r.hasStagedChanges(ctx)
+	if err != nil {
+		return fmt.Errorf("git diff failed: %w", err)
 	}
-
-	branch := r.getBranch()
-	currentBranch, err := r.getCurrentBranch(ctx)
-	if err == nil && currentBranch != branch {
-		r.out.Step(steps.next(), "Moving %s branch to HEAD...", branch)
-		if err := r.gitBranchForce(ctx, branch); err != nil {
-			return fmt.Errorf("failed to move branch: %w", err)
+	if commit {
+		if err := r.gitCommit(ctx, r.commitMessage(scope, info)); err != nil {
+			return fmt.Errorf("git commit failed: %w", err)
 		}
+	} else {
+		r.out.StepDetail("No changes to commit; releasing HEAD")
 	}
 
-	if opts.Push {
-		remote := r.getRemote()
-		r.out.Step(steps.next(), "Pushing to %s...", remote)
-
-		// Create tags
-		tags := r.getTags(verStr)
-		for _, tag := range tags {
-			r.out.StepDetail("Creating tag: %s", tag)
-			if err := r.gitTag(ctx, tag); err != nil {
-				return fmt.Errorf("failed to create tag %s: %w", tag, err)
-			}
-		}
-
-		// Push branch
-		if err := r.gitPush(ctx, remote, branch); err != nil {
-			return fmt.Errorf("failed to push branch: %w", err)
+	// Tags are created for pushing, and always for a git:tag source, where
+	// the tag is what sets the version.
+	if gitTagSource || opts.Push {
+		r.out.Step(steps.next(), "Creating tags...")
+		message := ""
+		if r.annotateTags() {
+			message = r.notes(info)
 		}
-
-		// Push tags
-		for _, tag := range tags {
-			if err := r.gitPushTag(ctx, remote, tag); err != nil {
-				return fmt.Errorf("failed to push tag %s: %w", tag, err)
-			}
+		if err := r.createTags(ctx, tx, scope.tags(verStr), message); err != nil {
+			return err
 		}
 	}
-
-	r.out.FinalSuccess("Release %s completed successfully!", verStr)
-	if !opts.Push {
-		r.out.Hint("Run with --push to push to remote.")
-	}
-
 	return nil
 }
 
 // dryRun prints what would be done without doing it.
-func (r *Releaser) dryRun(_ context.Context, verStr string, opts Options) error {
+// plan is nil unless the version was derived from commits.
+func (r *Releaser) dryRun(ctx context.Context, scope *versionScope, verStr string, opts Options, plan *autoPlan) error {
+	info, err := r.releaseInfo(ctx, scope, verStr, plan)
+	if err != nil {
+		return err
+	}
+
 	r.out.DryRunStart()
 
 	steps := &stepCounter{}
-	r.out.Step(steps.next(), "Set version to: %s", verStr)
+	if opts.CI != nil {
+		r.out.Step(steps.next(), "Run CI")
+	}
+	if plan != nil {
+		r.out.Step(steps.next(), "Bump %s %s: %s → %s (%s)", plan.level, scope.describe(), plan.current, verStr, plan.describeRange())
+	}
+	gitTagSource := scope.gitTagSource()
+	if !gitTagSource {
+		r.out.Step(steps.next(), "Set %s to: %s", scope.describe(), verStr)
+	}
 
-	if r.config.Version != nil && len(r.config.Version.Files) > 0 {
+	if len(scope.files) > 0 {
 		r.out.Step(steps.next(), "Propagate version to:")
-		for _, f := range r.config.Version.Files {
+		for _, f := range scope.files {
 			r.out.StepDetail("%s", f.Path)
 		}
 	}
 
+	if dependents := r.dependentFiles(scope); len(dependents) > 0 {
+		r.out.Step(steps.next(), "Update %s in dependent targets:", scope.describe())
+		for _, name := range sortedKeys(dependents) {
+			for _, f := range dependents[name] {
+				r.out.StepDetail("%s: %s", name, f.Path)
+			}
+		}
+	}
+
+	if plan != nil {
+		r.out.Step(steps.next(), "Prepend to %s:", filepath.ToSlash(scope.changelogPath()))
+		section := RenderChangelogSection(verStr, info.date, plan.commits)
+		for _, line := range strings.Split(strings.TrimRight(section, "\n"), "\n") {
+			r.out.StepDetail("%s", line)
+		}
+	}
+
 	if r.config.Release != nil && len(r.config.Release.PreCommands) > 0 {
 		r.out.Step(steps.next(), "Run pre-commit commands:")
 		for _, cmd := range r.config.Release.PreCommands {
@@ -196,15 +306,30 @@ func (r *Releaser) dryRun(_ context.Context, verStr string, opts Options) error
 		}
 	}
 
-	r.out.Step(steps.next(), "Create commit: \"set version %s\"", verStr)
+	if gitTagSource {
+		r.out.Step(steps.next(), "Create commit if anything changed: %q", r.commitMessage(scope, info))
+	} else {
+		r.out.Step(steps.next(), "Create commit: %q", r.commitMessage(scope, info))
+	}
 
 	branch := r.getBranch()
-	r.out.Step(steps.next(), "Move %s branch to HEAD", branch)
+
+	tags := scope.tags(verStr)
+	if gitTagSource || opts.Push {
+		r.out.Step(steps.next(), "Create %s:", r.describeTags())
+		for _, tag := range tags {
+			r.out.StepDetail("%s", tag)
+		}
+		if r.annotateTags() {
+			r.out.Step(steps.next(), "Tag message:")
+			for _, line := range strings.Split(strings.TrimRight(r.notes(info), "\n"), "\n") {
+				r.out.StepDetail("%s", line)
+			}
+		}
+	}
 
 	if opts.Push {
-		remote := r.getRemote()
-		tags := r.getTags(verStr)
-		r.out.Step(steps.next(), "Push to %s:", remote)
+		r.out.Step(steps.next(), "Push to %s:", r.shownRemote())
 		r.out.StepDetail("Branch: %s", branch)
 		for _, tag := range tags {
 			r.out.StepDetail("Tag: %s", tag)
@@ -215,6 +340,149 @@ func (r *Releaser) dryRun(_ context.Context, verStr string, opts Options) error
 	return nil
 }
 
+// autoPlan is the release derived from commits since the last release tag.
+type autoPlan struct {
+	current string               // Current version of the released scope
+	version string               // Version to release
+	level   string               // Bumped part: major, minor, or patch
+	lastTag string               // Last release tag; empty if there is none
+	commits []ConventionalCommit // Conventional commits since lastTag, newest first
+}
+
+func (p *autoPlan) describeRange() string {
+	if p.lastTag == "" {
+		return fmt.Sprintf("%d commit(s), no previous release tag", len(p.commits))
+	}
+	return fmt.Sprintf("%d commit(s) since %s", len(p.commits), p.lastTag)
+}
+
+// planAuto derives the next version from the Conventional Commits made since
+// the last tag matching the tag format. A target release only considers
+// commits that changed the target's directory.
+func (r *Releaser) planAuto(ctx context.Context, scope *versionScope) (*autoPlan, error) {
+	lastTag, err := r.lastReleaseTag(ctx, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	// A git:tag source has no version file: the last release is the last tag.
+	var current string
+	if scope.gitTagSource() {
+		current = "0.0.0"
+		if lastTag != "" {
+			v, ok := version.TagVersion(lastTag, scope.tagFormat)
+			if !ok {
+				return nil, fmt.Errorf("tag %q does not contain a valid version for tag format %q", lastTag, scope.tagFormat)
+			}
+			current = v
+		}
+	} else if current, err = version.Read(scope.source); err != nil {
+		return nil, fmt.Errorf("failed to read current version: %w", err)
+	}
+	conventional, err := r.conventionalCommits(ctx, scope, lastTag, "HEAD")
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &autoPlan{current: current, lastTag: lastTag, commits: conventional}
+	plan.level = BumpLevel(conventional)
+	if plan.level == "" {
+		return nil, fmt.Errorf("no feat, fix, perf, or breaking commits (%s); nothing to release", plan.describeRange())
+	}
+	if plan.version, err = version.Bump(current, plan.level); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// lastReleaseTag returns the most recent tag reachable from HEAD that matches
+// the scope's tag format, or "" if there is none.
+func (r *Releaser) lastReleaseTag(ctx context.Context, scope *versionScope) (string, error) {
+	return r.previousTag(ctx, scope, "HEAD", "")
+}
+
+// previousTag returns the most recent tag reachable from rev that matches the
+// scope's tag format, other than exclude, or "" if there is none.
+func (r *Releaser) previousTag(ctx context.Context, scope *versionScope, rev, exclude string) (string, error) {
+	pattern := strings.ReplaceAll(scope.tagFormat, "{version}", "*")
+
+	// git describe fails without a matching tag, so check for one first.
+	cmd := exec.CommandContext(ctx, "git", "tag", "--list", "--merged", rev, pattern)
+	cmd.Dir = r.projectRoot
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to list release tags: %w", err)
+	}
+	found := false
+	for _, tag := range strings.Fields(string(out)) {
+		found = found || tag != exclude
+	}
+	if !found {
+		return "", nil
+	}
+
+	args := []string{"describe", "--tags", "--abbrev=0", "--match", pattern}
+	if exclude != "" {
+		args = append(args, "--exclude", exclude)
+	}
+	cmd = exec.CommandContext(ctx, "git", append(args, rev)...)
+	cmd.Dir = r.projectRoot
+	if out, err = cmd.Output(); err != nil {
+		return "", fmt.Errorf("failed to find last release tag: %w", err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// commitsSince returns the commits after tag (all commits if tag is empty) up
+// to rev, newest first, with changed files relative to the project root. If
+// dir is not empty, only commits that changed files under dir are returned.
+func (r *Releaser) commitsSince(ctx context.Context, tag, rev, dir string) ([]Commit, error) {
+	args := []string{"log", "--no-merges", "--relative", "--name-only", "--format=%x1e%H%x1f%s%x1f%b%x1f"}
+	if tag != "" {
+		args = append(args, tag+".."+rev)
+	} else {
+		args = append(args, rev)
+	}
+	if dir != "" {
+		args = append(args, "--", dir)
+	}
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = r.projectRoot
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+
+	var commits []Commit
+	for _, record := range strings.Split(string(out), "\x1e") {
+		fields := strings.Split(record, "\x1f")
+		if len(fields) != 4 {
+			continue
+		}
+		c := Commit{Hash: fields[0], Subject: fields[1], Body: strings.TrimSpace(fields[2])}
+		for _, line := range strings.Split(fields[3], "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				c.Files = append(c.Files, line)
+			}
+		}
+		commits = append(commits, c)
+	}
+	return commits, nil
+}
+
+// targetDirs maps target names to their directories.
+func (r *Releaser) targetDirs() map[string]string {
+	dirs := make(map[string]string, len(r.config.Targets))
+	for name, t := range r.config.Targets {
+		dir := t.Directory
+		if dir == "" {
+			dir = name
+		}
+		dirs[name] = filepath.ToSlash(dir)
+	}
+	return dirs
+}
+
 // checkGitClean verifies the git working directory is clean.
 func (r *Releaser) checkGitClean(ctx context.Context) error {
 	cmd := exec.CommandContext(ctx, "git", "diff-index", "--quiet", "HEAD", "--")
@@ -225,14 +493,39 @@ func (r *Releaser) checkGitClean(ctx context.Context) error {
 	return nil
 }
 
-// setVersion writes the version to the VERSION file.
-func (r *Releaser) setVersion(verStr string) error {
-	versionFile := config.DefaultVersionSource
-	if r.config.Version != nil && r.config.Version.Source != "" {
-		versionFile = r.config.Version.Source
+// runCI runs the CI gate and verifies that it left the working tree as it
+// found it, so that no build output is committed with the release.
+func (r *Releaser) runCI(ctx context.Context, ci func(context.Context) error) error {
+	before, err := gitOutput(ctx, r.projectRoot, "status", "--porcelain", "--untracked-files=all")
+	if err != nil {
+		return fmt.Errorf("git status failed: %w", err)
+	}
+	if err := ci(ctx); err != nil {
+		return err
+	}
+	after, err := gitOutput(ctx, r.projectRoot, "status", "--porcelain", "--untracked-files=all")
+	if err != nil {
+		return fmt.Errorf("git status failed: %w", err)
+	}
+	if after == before {
+		return nil
+	}
+	existing := make(map[string]bool)
+	for _, line := range strings.Split(before, "\n") {
+		existing[line] = true
 	}
+	var changed []string
+	for _, line := range strings.Split(strings.TrimRight(after, "\n"), "\n") {
+		if !existing[line] {
+			changed = append(changed, strings.TrimSpace(line))
+		}
+	}
+	return fmt.Errorf("the working tree changed (%s); add build outputs to .gitignore", strings.Join(changed, ", "))
+}
 
-	path := filepath.Join(r.projectRoot, versionFile)
+// setVersion writes the version to the scope's version file.
+func (r *Releaser) setVersion(scope *versionScope, verStr string) error {
+	path := scope.source
 
 	// Ensure parent directory exists
 	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
@@ -260,27 +553,87 @@ func (r *Releaser) gitAddAll(ctx context.Context) error {
 
 // gitCommit creates a commit with the given message.
 func (r *Releaser) gitCommit(ctx context.Context, message string) error {
-	cmd := exec.CommandContext(ctx, "git", "commit", "-m", message)
+	cmd := exec.CommandContext(ctx, "git", r.commitArgs(message)...)
 	cmd.Dir = r.projectRoot
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	return cmd.Run()
 }
 
-// gitTag creates a tag.
-func (r *Releaser) gitTag(ctx context.Context, tag string) error {
-	cmd := exec.CommandContext(ctx, "git", "tag", tag)
+// hasStagedChanges reports whether the index differs from HEAD.
+func (r *Releaser) hasStagedChanges(ctx context.Context) (bool, error) {
+	cmd := exec.CommandContext(ctx, "git", "diff", "--cached", "--quiet")
 	cmd.Dir = r.projectRoot
-	return cmd.Run()
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+		return true, nil
+	}
+	return false, err
+}
+
+// createTags creates the release tags at HEAD, recording them in tx.
+// The tags are annotated with message unless it is empty.
+func (r *Releaser) createTags(ctx context.Context, tx *transaction, tags []string, message string) error {
+	for _, tag := range tags {
+		r.out.StepDetail("Creating tag: %s", tag)
+		if err := r.gitTag(ctx, tag, message); err != nil {
+			return fmt.Errorf("failed to create tag %s: %w", tag, err)
+		}
+		tx.tags = append(tx.tags, tag)
+	}
+	return nil
 }
 
-// gitBranchForce moves a branch to HEAD.
-func (r *Releaser) gitBranchForce(ctx context.Context, branch string) error {
-	cmd := exec.CommandContext(ctx, "git", "branch", "-f", branch, "HEAD")
+// gitTag creates a tag, annotated with message unless it is empty.
+func (r *Releaser) gitTag(ctx context.Context, tag, message string) error {
+	cmd := exec.CommandContext(ctx, "git", r.tagArgs(tag, message)...)
 	cmd.Dir = r.projectRoot
 	return cmd.Run()
 }
 
+// commitArgs returns the git arguments that create the release commit,
+// signed if release.sign is set.
+func (r *Releaser) commitArgs(message string) []string {
+	args := []string{"commit", "-m", message}
+	switch rc := r.config.Release; {
+	case rc == nil || !rc.Sign:
+	case rc.SigningKey != "":
+		args = append(args, "--gpg-sign="+rc.SigningKey)
+	default:
+		args = append(args, "--gpg-sign")
+	}
+	return args
+}
+
+// tagArgs returns the git arguments that create a release tag: a lightweight
+// tag if message is empty, otherwise an annotated tag, signed if
+// release.sign is set.
+func (r *Releaser) tagArgs(tag, message string) []string {
+	if message == "" {
+		return []string{"tag", tag}
+	}
+	args := []string{"tag", "--annotate"}
+	if rc := r.config.Release; rc != nil && rc.Sign {
+		args = []string{"tag", "--sign"}
+		if rc.SigningKey != "" {
+			args = []string{"tag", "--local-user=" + rc.SigningKey}
+		}
+	}
+	return append(args, "--message", message, tag)
+}
+
+// describeTags names the kind of release tags in step output.
+func (r *Releaser) describeTags() string {
+	switch {
+	case r.config.Release != nil && r.config.Release.Sign:
+		return "signed tags"
+	case r.annotateTags():
+		return "annotated tags"
+	}
+	return "tags"
+}
+
 // gitPush pushes a branch to remote.
 func (r *Releaser) gitPush(ctx context.Context, remote, branch string) error {
 	cmd := exec.CommandContext(ctx, "git", "push", remote, branch)
@@ -315,7 +668,15 @@ func (r *Releaser) getRemote() string {
 	if r.config.Release != nil && r.config.Release.Remote != "" {
 		return r.config.Release.Remote
 	}
-	return defaultRemote
+	return config.DefaultReleaseRemote
+}
+
+// shownRemote returns the remote as shown in output, with secrets redacted.
+func (r *Releaser) shownRemote() string {
+	if r.redacted.Release != nil && r.redacted.Release.Remote != "" {
+		return r.redacted.Release.Remote
+	}
+	return config.DefaultReleaseRemote
 }
 
 // getBranch returns the branch name from config or default.
@@ -323,26 +684,5 @@ func (r *Releaser) getBranch() string {
 	if r.config.Release != nil && r.config.Release.Branch != "" {
 		return r.config.Release.Branch
 	}
-	return defaultBranch
-}
-
-// getTags returns the list of tags to create for the version.
-func (r *Releaser) getTags(verStr string) []string {
-	tagFormat := defaultTagFormat
-	if r.config.Release != nil && r.config.Release.TagFormat != "" {
-		tagFormat = r.config.Release.TagFormat
-	}
-
-	tags := []string{
-		strings.ReplaceAll(tagFormat, "{version}", verStr),
-	}
-
-	// Add extra tags
-	if r.config.Release != nil {
-		for _, extraTag := range r.config.Release.ExtraTags {
-			tags = append(tags, strings.ReplaceAll(extraTag, "{version}", verStr))
-		}
-	}
-
-	return tags
+	return config.DefaultReleaseBranch
 }
@@ -0,0 +1,129 @@
+package release
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/AndreyAkinshin/structyl/internal/config"
+	"github.com/AndreyAkinshin/structyl/internal/version"
+)
+
+// versionScope is what a release versions: the whole project, or a single
+// independently versioned target.
+type versionScope struct {
+	target    string                     // Target name; empty for the project
+	source    string                     // Absolute version file path, or config.VersionSourceGitTag
+	files     []config.VersionFileConfig // Files to propagate the version to (relative paths)
+	tagFormat string
+	extraTags []string
+	dir       string // Target directory relative to the project root; empty for the project
+}
+
+// scope returns the version scope of a release of target, or of the project
+// if target is empty.
+func (r *Releaser) scope(target string) (*versionScope, error) {
+	if target == "" {
+		s := &versionScope{
+			source:    version.SourcePath(r.projectRoot, r.config.Version),
+			tagFormat: config.ReleaseTagFormat(r.config),
+		}
+		if config.IsGitTagSource(r.config.Version) {
+			s.source = config.VersionSourceGitTag
+		}
+		if r.config.Version != nil {
+			s.files = r.config.Version.Files
+		}
+		if r.config.Release != nil {
+			s.extraTags = r.config.Release.ExtraTags
+		}
+		return s, nil
+	}
+
+	t, ok := r.config.Targets[target]
+	if !ok {
+		return nil, fmt.Errorf("unknown target %q", target)
+	}
+	if t.Version == nil {
+		return nil, fmt.Errorf("target %q has no version configuration and is released with the project", target)
+	}
+	s := &versionScope{
+		target:    target,
+		source:    config.TargetVersionSource(target, t),
+		files:     t.Version.Files,
+		tagFormat: config.TargetTagFormat(target, t),
+		dir:       r.targetDirs()[target],
+	}
+	if s.source != config.VersionSourceGitTag {
+		s.source = filepath.Join(r.projectRoot, s.source)
+	}
+	return s, nil
+}
+
+// gitTagSource reports whether the version lives in the release tag rather
+// than in a file.
+func (s *versionScope) gitTagSource() bool {
+	return s.source == config.VersionSourceGitTag
+}
+
+// tags returns the tags to create for a release of ver.
+func (s *versionScope) tags(ver string) []string {
+	tags := []string{strings.ReplaceAll(s.tagFormat, "{version}", ver)}
+	for _, extraTag := range s.extraTags {
+		tags = append(tags, strings.ReplaceAll(extraTag, "{version}", ver))
+	}
+	return tags
+}
+
+// describe names the released scope in step output.
+func (s *versionScope) describe() string {
+	if s.target == "" {
+		return "version"
+	}
+	return s.target + " version"
+}
+
+// releaseName names the release of ver in messages.
+func (s *versionScope) releaseName(ver string) string {
+	if s.target == "" {
+		return ver
+	}
+	return s.target + " " + ver
+}
+
+// changelogPath returns the changelog of the scope, relative to the project root.
+func (s *versionScope) changelogPath() string {
+	return filepath.Join(filepath.FromSlash(s.dir), ChangelogFileName)
+}
+
+// dependentFiles maps each target that depends on the scope's target to the
+// files in which it pins the target's version. Empty for project releases.
+func (r *Releaser) dependentFiles(s *versionScope) map[string][]config.VersionFileConfig {
+	if s.target == "" {
+		return nil
+	}
+	deps := make(map[string][]config.VersionFileConfig)
+	for name, t := range r.config.Targets {
+		if t.Version == nil || len(t.Version.DependencyFiles[s.target]) == 0 {
+			continue
+		}
+		for _, dep := range t.DependsOn {
+			if dep == s.target {
+				deps[name] = t.Version.DependencyFiles[s.target]
+				break
+			}
+		}
+	}
+	return deps
+}
+
+// sortedKeys returns the keys of m in sorted order.
+func sortedKeys(m map[string][]config.VersionFileConfig) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,202 @@
+package release
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// transaction records the repository state before a release so that a
+// release whose local steps fail can be rolled back.
+type transaction struct {
+	root      string                  // Repository root; git paths are relative to it
+	head      string                  // HEAD commit before the release
+	dirty     map[string]fileSnapshot // Tracked files that differed from HEAD before the release
+	untracked map[string]bool         // Untracked files before the release
+	tags      []string                // Tags created by the release
+}
+
+// fileSnapshot is the content of a file, or its absence.
+type fileSnapshot struct {
+	content []byte
+	exists  bool
+}
+
+// begin records the repository state before a release.
+func (r *Releaser) begin(ctx context.Context) (*transaction, error) {
+	root, err := gitOutput(ctx, r.projectRoot, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, fmt.Errorf("failed to find repository root: %w", err)
+	}
+	tx := &transaction{
+		root:      strings.TrimSpace(root),
+		dirty:     make(map[string]fileSnapshot),
+		untracked: make(map[string]bool),
+	}
+	if tx.head, err = r.revParse(ctx, "HEAD"); err != nil {
+		return nil, fmt.Errorf("repository has no commits to release from")
+	}
+
+	// Without --force these are usually empty, but untracked files are
+	// allowed in a clean tree and must survive a rollback.
+	changed, err := tx.changedFiles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range changed {
+		content, err := os.ReadFile(filepath.Join(tx.root, path))
+		switch {
+		case err == nil:
+			tx.dirty[path] = fileSnapshot{content: content, exists: true}
+		case errors.Is(err, os.ErrNotExist):
+			tx.dirty[path] = fileSnapshot{}
+		default:
+			return nil, err
+		}
+	}
+	untracked, err := tx.untrackedFiles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range untracked {
+		tx.untracked[path] = true
+	}
+	return tx, nil
+}
+
+// rollback restores the state recorded by begin: it deletes the created tags,
+// resets HEAD and the index, restores changed
+// files, and removes files the release created.
+//
+// Rollback runs even if ctx is canceled, since cancellation is a common reason
+// for a release to fail.
+func (tx *transaction) rollback(ctx context.Context) error {
+	ctx = context.WithoutCancel(ctx)
+	var errs []error
+
+	for _, tag := range tx.tags {
+		if _, err := gitOutput(ctx, tx.root, "tag", "-d", tag); err != nil {
+			errs = append(errs, fmt.Errorf("failed to delete tag %s: %w", tag, err))
+		}
+	}
+
+	// A mixed reset undoes the release commit and staging but keeps the
+	// working tree, which is restored file by file below.
+	if _, err := gitOutput(ctx, tx.root, "reset", "-q", tx.head); err != nil {
+		return errors.Join(append(errs, fmt.Errorf("failed to reset to %s: %w", tx.head, err))...)
+	}
+
+	changed, err := tx.changedFiles(ctx)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	var checkout []string
+	for _, path := range changed {
+		if _, ok := tx.dirty[path]; !ok {
+			checkout = append(checkout, path)
+		}
+	}
+	if len(checkout) > 0 {
+		args := append([]string{"checkout", "-q", tx.head, "--"}, checkout...)
+		if _, err := gitOutput(ctx, tx.root, args...); err != nil {
+			errs = append(errs, fmt.Errorf("failed to restore files: %w", err))
+		}
+	}
+	for path, snapshot := range tx.dirty {
+		if err := snapshot.restore(filepath.Join(tx.root, path)); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	untracked, err := tx.untrackedFiles(ctx)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	for _, path := range untracked {
+		if tx.untracked[path] {
+			continue
+		}
+		if err := removeCreated(tx.root, path); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// changedFiles returns the tracked files that differ from HEAD in the index
+// or working tree.
+func (tx *transaction) changedFiles(ctx context.Context) ([]string, error) {
+	out, err := gitOutput(ctx, tx.root, "diff", "--name-only", "-z", "HEAD")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list changed files: %w", err)
+	}
+	return splitNul(out), nil
+}
+
+// untrackedFiles returns the untracked files that are not ignored.
+func (tx *transaction) untrackedFiles(ctx context.Context) ([]string, error) {
+	out, err := gitOutput(ctx, tx.root, "ls-files", "--others", "--exclude-standard", "-z")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list untracked files: %w", err)
+	}
+	return splitNul(out), nil
+}
+
+// restore writes the snapshot back to path.
+func (s fileSnapshot) restore(path string) error {
+	if !s.exists {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, s.content, 0644)
+}
+
+// removeCreated removes the file at path (relative to root) and the parent
+// directories it leaves empty.
+func removeCreated(root, path string) error {
+	full := filepath.Join(root, filepath.FromSlash(path))
+	if err := os.Remove(full); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	for dir := filepath.Dir(full); dir != root && strings.HasPrefix(dir, root); dir = filepath.Dir(dir) {
+		if os.Remove(dir) != nil {
+			break // Not empty
+		}
+	}
+	return nil
+}
+
+// gitOutput runs a git command in dir and returns its output. The error
+// includes git's message.
+func gitOutput(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if msg := strings.TrimSpace(stderr.String()); err != nil && msg != "" {
+		return "", fmt.Errorf("%w: %s", err, msg)
+	}
+	return string(out), err
+}
+
+// splitNul splits NUL-terminated git output.
+func splitNul(s string) []string {
+	var parts []string
+	for _, p := range strings.Split(s, "\x00") {
+		if p != "" {
+			parts = append(parts, p)
+		}
+	}
+	return parts
+}
@@ -0,0 +1,94 @@
+package release
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/AndreyAkinshin/structyl/internal/config"
+)
+
+func TestRelease_PreCommandFails_RestoresFiles(t *testing.T) {
+	dir := createTestGitRepo(t)
+	commitFile(t, dir, ".structyl/PROJECT_VERSION", "1.0.0")
+	commitFile(t, dir, "pkg/VERSION.txt", "1.0.0")
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("untracked\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	head := git(t, dir, "rev-parse", "HEAD")
+
+	cfg := &config.Config{
+		Version: &config.VersionConfig{Files: []config.VersionFileConfig{
+			{Path: "pkg/VERSION.txt", Pattern: `\d+\.\d+\.\d+`, Replace: "{version}"},
+		}},
+		Release: &config.ReleaseConfig{PreCommands: []string{
+			"mkdir -p build && echo artifact > build/out.txt",
+			"exit 3",
+		}},
+	}
+	r := NewReleaser(dir, cfg)
+	var err error
+	captureStdout(t, func() {
+		err = r.Release(context.Background(), Options{Version: "1.1.0"})
+	})
+	if err == nil || !strings.Contains(err.Error(), `pre-commit command "exit 3" failed`) {
+		t.Fatalf("Release() error = %v, want the pre-commit failure", err)
+	}
+
+	if got := git(t, dir, "rev-parse", "HEAD"); got != head {
+		t.Error("HEAD moved after a failed release")
+	}
+	if status := git(t, dir, "status", "--porcelain"); status != "?? notes.txt" {
+		t.Errorf("git status after rollback = %q, want only the untracked notes.txt", status)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "build")); !os.IsNotExist(err) {
+		t.Error("rollback did not remove the directory created by the pre-commit command")
+	}
+}
+
+func TestRelease_TagFails_RemovesCommitAndTags(t *testing.T) {
+	dir, _ := createTestGitRepoWithRemote(t)
+	commitFile(t, dir, ".structyl/PROJECT_VERSION", "1.0.0")
+	commitFile(t, dir, "tracked.txt", "original")
+
+	// An invalid extra tag name passes the preflight tag checks but makes
+	// creating the second release tag fail, before pushing. Force allows a
+	// dirty tree whose changes must survive the rollback.
+	if err := os.WriteFile(filepath.Join(dir, "tracked.txt"), []byte("work in progress\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	head := git(t, dir, "rev-parse", "HEAD")
+
+	cfg := &config.Config{Release: &config.ReleaseConfig{
+		ExtraTags: []string{"stable..1"},
+	}}
+	r := NewReleaser(dir, cfg)
+	var err error
+	captureStdout(t, func() {
+		err = r.Release(context.Background(), Options{Version: "1.1.0", Push: true, Force: true})
+	})
+	if err == nil || !strings.Contains(err.Error(), "failed to create tag stable..1") {
+		t.Fatalf("Release() error = %v, want the tag failure", err)
+	}
+
+	if got := git(t, dir, "rev-parse", "HEAD"); got != head {
+		t.Error("rollback did not remove the release commit")
+	}
+	if tags := git(t, dir, "tag", "--list"); tags != "" {
+		t.Errorf("tags after rollback = %q, want none", tags)
+	}
+	for name, want := range map[string]string{
+		".structyl/PROJECT_VERSION": "1.0.0\n",
+		"tracked.txt":               "work in progress\n",
+	} {
+		got, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != want {
+			t.Errorf("%s after rollback = %q, want %q", name, got, want)
+		}
+	}
+}
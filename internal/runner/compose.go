@@ -85,8 +85,8 @@ func WriteComposeFile(projectRoot string, cfg *config.Config) error {
 // generateServiceForTarget creates a Docker service configuration for a target.
 func generateServiceForTarget(name string, targetCfg config.TargetConfig, dockerCfg *config.DockerConfig) ComposeService {
 	service := ComposeService{
-		WorkingDir: "/workspace",
-		Volumes:    []string{".:/workspace"},
+		WorkingDir: containerWorkspace,
+		Volumes:    []string{".:" + containerWorkspace},
 	}
 
 	// Use custom base image if specified
@@ -104,7 +104,7 @@ func generateServiceForTarget(name string, targetCfg config.TargetConfig, docker
 	// Add target directory as working directory if specified
 	// Always use forward slashes for Docker container paths (Linux containers)
 	if targetCfg.Directory != "" {
-		service.WorkingDir = "/workspace/" + strings.ReplaceAll(targetCfg.Directory, "\\", "/")
+		service.WorkingDir = containerWorkspace + "/" + strings.ReplaceAll(targetCfg.Directory, "\\", "/")
 	}
 
 	// Add environment variables from target
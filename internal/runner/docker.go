@@ -12,6 +12,7 @@ import (
 	"strings"
 
 	"github.com/AndreyAkinshin/structyl/internal/config"
+	"github.com/AndreyAkinshin/structyl/pkg/testhelper"
 )
 
 // DockerCommandRunner abstracts Docker command execution for testing.
@@ -44,6 +45,9 @@ func (r *execDockerRunner) CheckAvailable() error {
 	return nil
 }
 
+// containerWorkspace is where generated compose services mount the project.
+const containerWorkspace = "/workspace"
+
 // defaultDockerRunner is the production command runner.
 var defaultDockerRunner DockerCommandRunner = &execDockerRunner{}
 
@@ -147,12 +151,68 @@ func (r *DockerRunner) buildRunArgs(service, cmd string) []string {
 		args = append(args, "--user", fmt.Sprintf("%d:%d", os.Getuid(), os.Getgid()))
 	}
 
+	args = append(args, r.testEnvArgs()...)
 	args = append(args, service)
 	args = append(args, shellCommandArgs(cmd)...)
 
 	return args
 }
 
+// testEnvArgs forwards the test harness environment variables into the
+// container. Value variables are passed through unchanged. Directory
+// variables are rewritten to their container paths: directories inside the
+// project resolve under the /workspace mount, others are bind-mounted
+// under /structyl.
+func (r *DockerRunner) testEnvArgs() []string {
+	var args []string
+	for _, name := range []string{testhelper.TagsEnvVar, testhelper.ComparisonEnvVar} {
+		if _, ok := os.LookupEnv(name); ok {
+			args = append(args, "-e", name)
+		}
+	}
+
+	dirs := []struct{ name, mount string }{
+		{testhelper.ResultsDirEnvVar, "/structyl/results"},
+		{testhelper.TestsDirEnvVar, "/structyl/tests"},
+	}
+	for _, d := range dirs {
+		dir := os.Getenv(d.name)
+		if dir == "" {
+			continue
+		}
+		if path, ok := r.workspacePath(dir); ok {
+			args = append(args, "-e", d.name+"="+path)
+			continue
+		}
+		if abs, err := filepath.Abs(dir); err == nil {
+			dir = abs
+		}
+		args = append(args, "-v", dir+":"+d.mount, "-e", d.name+"="+d.mount)
+	}
+	return args
+}
+
+// workspacePath maps a host path inside the project root to its path in the
+// container. Returns false if the path lies outside the project.
+func (r *DockerRunner) workspacePath(path string) (string, bool) {
+	root, err := filepath.Abs(r.projectRoot)
+	if err != nil {
+		return "", false
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", false
+	}
+	rel, err := filepath.Rel(root, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	if rel == "." {
+		return containerWorkspace, true
+	}
+	return containerWorkspace + "/" + filepath.ToSlash(rel), true
+}
+
 // Build builds Docker images for services.
 // If per-target Dockerfiles exist (from mise dockerfile), they will be used.
 // Otherwise falls back to docker-compose.
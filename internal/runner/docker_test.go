@@ -27,6 +27,7 @@ import (
 	"testing"
 
 	"github.com/AndreyAkinshin/structyl/internal/config"
+	"github.com/AndreyAkinshin/structyl/pkg/testhelper"
 )
 
 func TestNewDockerRunner(t *testing.T) {
@@ -232,6 +233,47 @@ func TestBuildRunArgs_ShellCommand(t *testing.T) {
 	}
 }
 
+func TestBuildRunArgs_TestEnvironment(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+	t.Setenv(testhelper.TagsEnvVar, "fast")
+	t.Setenv(testhelper.ComparisonEnvVar, `{"float_tolerance":0.1}`)
+	t.Setenv(testhelper.ResultsDirEnvVar, filepath.Join(root, ".structyl", "results", "go"))
+	t.Setenv(testhelper.TestsDirEnvVar, outside)
+	runner := NewDockerRunner(root, nil)
+
+	args := strings.Join(runner.buildRunArgs("service", "cmd"), " ")
+
+	want := []string{
+		"-e " + testhelper.TagsEnvVar + " ",
+		"-e " + testhelper.ComparisonEnvVar + " ",
+		"-e " + testhelper.ResultsDirEnvVar + "=/workspace/.structyl/results/go ",
+		"-v " + outside + ":/structyl/tests -e " + testhelper.TestsDirEnvVar + "=/structyl/tests ",
+	}
+	for _, w := range want {
+		if !strings.Contains(args, w) {
+			t.Errorf("args %q should contain %q", args, w)
+		}
+	}
+	if strings.Index(args, "-e ") > strings.Index(args, " service ") {
+		t.Errorf("environment flags should precede the service name: %q", args)
+	}
+}
+
+func TestBuildRunArgs_NoTestEnvironment(t *testing.T) {
+	for _, name := range []string{testhelper.TagsEnvVar, testhelper.ComparisonEnvVar, testhelper.ResultsDirEnvVar, testhelper.TestsDirEnvVar} {
+		t.Setenv(name, "")
+		_ = os.Unsetenv(name)
+	}
+	runner := NewDockerRunner("/project", nil)
+
+	for _, arg := range runner.buildRunArgs("service", "cmd") {
+		if arg == "-e" || arg == "-v" {
+			t.Errorf("unexpected %s flag without test environment", arg)
+		}
+	}
+}
+
 func TestBuildRunArgs_ServiceName(t *testing.T) {
 	t.Parallel()
 	runner := NewDockerRunner("/project", nil)
@@ -0,0 +1,373 @@
+// Package scaffold generates reference test harnesses for language targets.
+//
+// A harness consists of a shared support file, which loads test cases and
+// implements the comparison semantics of [testhelper.CompareOptions], and one
+// file per suite that wires a user-implemented function into the target's
+// native test framework. Go harnesses use package testhelper directly.
+package scaffold
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/AndreyAkinshin/structyl/pkg/testhelper"
+)
+
+//go:embed templates/*.tmpl
+var templateFS embed.FS
+
+// Framework identifies a test framework a harness can be generated for.
+type Framework string
+
+const (
+	FrameworkGo     Framework = "go"     // Go testing package
+	FrameworkCargo  Framework = "cargo"  // cargo test (Rust integration tests)
+	FrameworkXUnit  Framework = "xunit"  // xUnit (C#)
+	FrameworkPytest Framework = "pytest" // pytest (Python)
+	FrameworkVitest Framework = "vitest" // Vitest (TypeScript)
+	FrameworkDeno   Framework = "deno"   // Deno.test (TypeScript)
+	FrameworkJUnit  Framework = "junit"  // JUnit 5 (Java)
+)
+
+// toolchainFrameworks maps built-in toolchains to their default framework.
+var toolchainFrameworks = map[string]Framework{
+	"go":     FrameworkGo,
+	"cargo":  FrameworkCargo,
+	"dotnet": FrameworkXUnit,
+	"python": FrameworkPytest,
+	"uv":     FrameworkPytest,
+	"poetry": FrameworkPytest,
+	"npm":    FrameworkVitest,
+	"pnpm":   FrameworkVitest,
+	"yarn":   FrameworkVitest,
+	"bun":    FrameworkVitest,
+	"deno":   FrameworkDeno,
+	"gradle": FrameworkJUnit,
+	"maven":  FrameworkJUnit,
+}
+
+// Frameworks returns all supported frameworks in sorted order.
+func Frameworks() []Framework {
+	return []Framework{FrameworkCargo, FrameworkDeno, FrameworkGo, FrameworkJUnit, FrameworkPytest, FrameworkVitest, FrameworkXUnit}
+}
+
+// ParseFramework parses a framework name.
+// Returns the framework and true if valid, or empty string and false otherwise.
+func ParseFramework(s string) (Framework, bool) {
+	for _, f := range Frameworks() {
+		if string(f) == s {
+			return f, true
+		}
+	}
+	return "", false
+}
+
+// FrameworkForToolchain returns the default framework for a built-in toolchain.
+// Returns empty string and false if the toolchain has no scaffold support.
+func FrameworkForToolchain(toolchain string) (Framework, bool) {
+	f, ok := toolchainFrameworks[toolchain]
+	return f, ok
+}
+
+// Options configures harness generation.
+type Options struct {
+	// Suite is the reference test suite name (directory under the tests directory).
+	Suite string
+	// TestsDir is the tests directory relative to the project root, using forward slashes.
+	TestsDir string
+	// Comparison holds the comparison options embedded into the harness.
+	Comparison testhelper.CompareOptions
+	// Package is the Go package name (FrameworkGo only; defaults to "main").
+	Package string
+	// Command is the command line that generated the harness, recorded in file headers.
+	Command string
+}
+
+// File is a generated harness file.
+type File struct {
+	// Path is relative to the target directory, using forward slashes.
+	Path string
+	// Content is the file content.
+	Content []byte
+	// Shared is true for support files used by every suite of the target.
+	// Existing shared files are kept unless regeneration is forced.
+	Shared bool
+}
+
+// Harness is the set of files generated for one suite.
+type Harness struct {
+	Framework Framework
+	Files     []File
+	// Notes lists manual steps the user must take (e.g., adding dependencies).
+	Notes []string
+}
+
+// frameworkSpec describes the files generated for a framework.
+type frameworkSpec struct {
+	sharedPath string // relative to the target directory
+	suitePath  string // template for the suite file path
+	notes      []string
+}
+
+var frameworkSpecs = map[Framework]frameworkSpec{
+	FrameworkGo: {
+		sharedPath: "structyl_reference_test.go",
+		suitePath:  "reference_{{.Snake}}_test.go",
+		notes:      []string{"go get github.com/AndreyAkinshin/structyl/pkg/testhelper in the target module"},
+	},
+	FrameworkCargo: {
+		sharedPath: "tests/structyl_reference/mod.rs",
+		suitePath:  "tests/reference_{{.Snake}}.rs",
+		notes:      []string{"add serde_json to [dev-dependencies] in Cargo.toml"},
+	},
+	FrameworkXUnit: {
+		sharedPath: "StructylReference.cs",
+		suitePath:  "Reference{{.Pascal}}Tests.cs",
+		notes:      []string{"move the files into your xUnit test project if the target directory is not one"},
+	},
+	FrameworkPytest: {
+		sharedPath: "tests/structyl_reference.py",
+		suitePath:  "tests/test_reference_{{.Snake}}.py",
+	},
+	FrameworkVitest: {
+		sharedPath: "tests/structyl-reference.ts",
+		suitePath:  "tests/reference-{{.Kebab}}.test.ts",
+		notes:      []string{"add vitest to devDependencies if it is not installed"},
+	},
+	FrameworkDeno: {
+		sharedPath: "tests/structyl_reference.ts",
+		suitePath:  "tests/reference_{{.Snake}}_test.ts",
+		notes:      []string{"run with deno test --allow-read --allow-env --allow-write"},
+	},
+	FrameworkJUnit: {
+		sharedPath: "src/test/java/StructylReference.java",
+		suitePath:  "src/test/java/Reference{{.Pascal}}Test.java",
+		notes:      []string{"add org.junit.jupiter:junit-jupiter and com.fasterxml.jackson.core:jackson-databind test dependencies"},
+	},
+}
+
+// templateData is passed to the framework templates.
+type templateData struct {
+	Suite          string
+	TestsDir       string
+	Package        string
+	Command        string
+	Pascal         string // "QuantileEstimators"
+	Snake          string // "quantile_estimators"
+	Kebab          string // "quantile-estimators"
+	FloatTolerance string // language-neutral float literal, e.g. "1e-09"
+	ToleranceMode  string
+	NaNEqualsNaN   bool
+	ArrayOrder     string
+}
+
+// Generate generates a harness for the given framework.
+func Generate(framework Framework, opts Options) (*Harness, error) {
+	spec, ok := frameworkSpecs[framework]
+	if !ok {
+		return nil, fmt.Errorf("unsupported framework %q", framework)
+	}
+	if err := testhelper.ValidateSuiteName(opts.Suite); err != nil {
+		return nil, err
+	}
+	if err := testhelper.ValidateOptions(opts.Comparison); err != nil {
+		return nil, fmt.Errorf("invalid comparison options: %w", err)
+	}
+
+	data, err := newTemplateData(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	shared, err := render(string(framework)+"_shared.tmpl", data)
+	if err != nil {
+		return nil, err
+	}
+	suite, err := render(string(framework)+"_suite.tmpl", data)
+	if err != nil {
+		return nil, err
+	}
+	suitePath, err := renderString(spec.suitePath, data)
+	if err != nil {
+		return nil, err
+	}
+
+	if framework == FrameworkGo {
+		if shared, err = format.Source(shared); err != nil {
+			return nil, fmt.Errorf("generated Go code is invalid: %w", err)
+		}
+		if suite, err = format.Source(suite); err != nil {
+			return nil, fmt.Errorf("generated Go code is invalid: %w", err)
+		}
+	}
+
+	return &Harness{
+		Framework: framework,
+		Files: []File{
+			{Path: spec.sharedPath, Content: shared, Shared: true},
+			{Path: suitePath, Content: suite},
+		},
+		Notes: spec.notes,
+	}, nil
+}
+
+func newTemplateData(opts Options) (*templateData, error) {
+	words := splitWords(opts.Suite)
+	if len(words) == 0 {
+		return nil, fmt.Errorf("suite name %q has no letters or digits to build identifiers from", opts.Suite)
+	}
+
+	testsDir := opts.TestsDir
+	if testsDir == "" {
+		testsDir = "tests"
+	}
+	pkg := opts.Package
+	if pkg == "" {
+		pkg = "main"
+	}
+
+	c := opts.Comparison
+	mode := c.ToleranceMode
+	if mode == "" {
+		mode = testhelper.ToleranceModeRelative
+	}
+	order := c.ArrayOrder
+	if order == "" {
+		order = testhelper.ArrayOrderStrict
+	}
+
+	return &templateData{
+		Suite:          opts.Suite,
+		TestsDir:       strings.TrimSuffix(filepath.ToSlash(testsDir), "/"),
+		Package:        pkg,
+		Command:        opts.Command,
+		Pascal:         pascalCase(words),
+		Snake:          strings.Join(words, "_"),
+		Kebab:          strings.Join(words, "-"),
+		FloatTolerance: floatLiteral(c.FloatTolerance),
+		ToleranceMode:  mode,
+		NaNEqualsNaN:   c.NaNEqualsNaN,
+		ArrayOrder:     order,
+	}, nil
+}
+
+func render(name string, data *templateData) ([]byte, error) {
+	tmpl, err := template.ParseFS(templateFS, "templates/"+name, "templates/partial_*.tmpl")
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template %s: %w", name, err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("failed to render template %s: %w", name, err)
+	}
+	return buf.Bytes(), nil
+}
+
+func renderString(text string, data *templateData) (string, error) {
+	tmpl, err := template.New("path").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// splitWords splits a suite name into lowercase words at non-alphanumeric
+// characters and lower-to-upper case transitions ("quantileEstimators",
+// "quantile-estimators" → ["quantile", "estimators"]).
+func splitWords(s string) []string {
+	var words []string
+	var current []rune
+	flush := func() {
+		if len(current) > 0 {
+			words = append(words, strings.ToLower(string(current)))
+			current = nil
+		}
+	}
+	var prev rune
+	for _, r := range s {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			flush()
+		case unicode.IsUpper(r) && len(current) > 0 && unicode.IsLower(prev):
+			flush()
+			current = append(current, r)
+		default:
+			current = append(current, r)
+		}
+		prev = r
+	}
+	flush()
+	return words
+}
+
+// pascalCase joins words into a PascalCase identifier. A leading digit is
+// prefixed with "Suite" so the result is a valid identifier.
+func pascalCase(words []string) string {
+	var b strings.Builder
+	for _, w := range words {
+		runes := []rune(w)
+		runes[0] = unicode.ToUpper(runes[0])
+		b.WriteString(string(runes))
+	}
+	id := b.String()
+	if unicode.IsDigit([]rune(id)[0]) {
+		return "Suite" + id
+	}
+	return id
+}
+
+// floatLiteral formats f as a literal valid in every target language
+// (always containing a '.' or exponent).
+func floatLiteral(f float64) string {
+	s := strconv.FormatFloat(f, 'g', -1, 64)
+	if !strings.ContainsAny(s, ".e") {
+		s += ".0"
+	}
+	return s
+}
+
+var goPackageRe = regexp.MustCompile(`(?m)^package\s+([A-Za-z_][A-Za-z0-9_]*)`)
+
+// GoPackageName returns the package name of the non-test Go files in dir.
+// Falls back to a name derived from the directory, or "main" if none can be
+// derived.
+func GoPackageName(dir string) string {
+	files, _ := filepath.Glob(filepath.Join(dir, "*.go"))
+	sort.Strings(files)
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		if m := goPackageRe.FindSubmatch(data); m != nil {
+			return string(m[1])
+		}
+	}
+
+	words := splitWords(filepath.Base(dir))
+	if len(words) == 0 {
+		return "main"
+	}
+	name := strings.Join(words, "")
+	if unicode.IsDigit([]rune(name)[0]) {
+		return "main"
+	}
+	return name
+}
@@ -0,0 +1,326 @@
+package scaffold
+
+import (
+	"encoding/json"
+	"go/parser"
+	"go/token"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/AndreyAkinshin/structyl/internal/config"
+	"github.com/AndreyAkinshin/structyl/pkg/testhelper"
+)
+
+func testOptions() Options {
+	return Options{
+		Suite:      "quantile-estimators",
+		TestsDir:   "tests",
+		Comparison: testhelper.DefaultOptions(),
+		Package:    "stats",
+		Command:    "structyl tests scaffold x --suite quantile-estimators",
+	}
+}
+
+func TestGenerate_AllFrameworks(t *testing.T) {
+	wantSuitePaths := map[Framework]string{
+		FrameworkGo:     "reference_quantile_estimators_test.go",
+		FrameworkCargo:  "tests/reference_quantile_estimators.rs",
+		FrameworkXUnit:  "ReferenceQuantileEstimatorsTests.cs",
+		FrameworkPytest: "tests/test_reference_quantile_estimators.py",
+		FrameworkVitest: "tests/reference-quantile-estimators.test.ts",
+		FrameworkDeno:   "tests/reference_quantile_estimators_test.ts",
+		FrameworkJUnit:  "src/test/java/ReferenceQuantileEstimatorsTest.java",
+	}
+	for _, f := range Frameworks() {
+		t.Run(string(f), func(t *testing.T) {
+			h, err := Generate(f, testOptions())
+			if err != nil {
+				t.Fatalf("Generate() error = %v", err)
+			}
+			if len(h.Files) != 2 {
+				t.Fatalf("len(Files) = %d, want 2", len(h.Files))
+			}
+			if !h.Files[0].Shared || h.Files[1].Shared {
+				t.Error("expected shared file first, suite file second")
+			}
+			if got := h.Files[1].Path; got != wantSuitePaths[f] {
+				t.Errorf("suite path = %q, want %q", got, wantSuitePaths[f])
+			}
+			if !strings.Contains(string(h.Files[1].Content), "quantile-estimators") {
+				t.Error("suite file does not reference the suite name")
+			}
+			if f == FrameworkGo {
+				// Loading and comparison are left to package testhelper.
+				return
+			}
+			if !strings.Contains(string(h.Files[0].Content), "1e-09") {
+				t.Error("shared file does not embed the float tolerance")
+			}
+			for _, env := range []string{"STRUCTYL_TESTS_DIR", "STRUCTYL_RESULTS_DIR"} {
+				if !strings.Contains(string(h.Files[0].Content), env) {
+					t.Errorf("shared file does not honor %s", env)
+				}
+			}
+		})
+	}
+}
+
+func TestGenerate_GoOutputParses(t *testing.T) {
+	opts := testOptions()
+	opts.Comparison.ToleranceMode = testhelper.ToleranceModeULP
+	opts.Comparison.FloatTolerance = 4
+	opts.Comparison.ArrayOrder = testhelper.ArrayOrderUnordered
+	h, err := Generate(FrameworkGo, opts)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	for _, file := range h.Files {
+		f, err := parser.ParseFile(token.NewFileSet(), file.Path, file.Content, 0)
+		if err != nil {
+			t.Fatalf("%s does not parse: %v", file.Path, err)
+		}
+		if f.Name.Name != "stats" {
+			t.Errorf("%s: package = %q, want %q", file.Path, f.Name.Name, "stats")
+		}
+	}
+	shared := string(h.Files[0].Content)
+	for _, want := range []string{`"github.com/AndreyAkinshin/structyl/pkg/testhelper"`, "testhelper.RunSuiteFromDir(", `referenceTestsDir = "tests"`} {
+		if !strings.Contains(shared, want) {
+			t.Errorf("shared file missing %q", want)
+		}
+	}
+	if suite := string(h.Files[1].Content); !strings.Contains(suite, `runReferenceSuite(t, "quantile-estimators", runQuantileEstimators)`) {
+		t.Errorf("suite file does not run the suite through runReferenceSuite:\n%s", suite)
+	}
+}
+
+func TestGenerate_ProbesEveryConfigFileName(t *testing.T) {
+	for _, f := range Frameworks() {
+		h, err := Generate(f, testOptions())
+		if err != nil {
+			t.Fatalf("Generate(%s) error = %v", f, err)
+		}
+		if f == FrameworkGo {
+			continue // testhelper.FindProjectRoot
+		}
+		for _, name := range config.FileNames {
+			if !strings.Contains(string(h.Files[0].Content), `"`+name+`"`) {
+				t.Errorf("%s shared file does not probe .structyl/%s", f, name)
+			}
+		}
+	}
+}
+
+// templateSuiteFiles is a suite with case templates whose expanded names
+// exercise the formatting of every kind of parameter value.
+var templateSuiteFiles = map[string]string{
+	"a.json": `{"input": {"x": 1}, "output": 1}`,
+	"q.json": `{
+		"$matrix": {"n": [10, 1e3, 1.50], "v": [[1.0, {"b": 2, "a": "é"}], "<&>"]},
+		"input": {"n": {"$param": "n"}, "v": [{"$param": "v"}]},
+		"output": {"$param": "n"},
+		"tags": ["grid"]
+	}`,
+	"q-b.json": `{
+		"$matrix": [{"p": 0.5, "$output": 2}, {"p": true, "q": null}],
+		"input": {"p": {"$param": "p"}},
+		"output": 1
+	}`,
+}
+
+// writeTemplateSuite writes templateSuiteFiles as suite "grid" of a new
+// project and returns its root.
+func writeTemplateSuite(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, ".structyl"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, ".structyl", "config.json"), []byte(`{"project": {"name": "demo"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	suiteDir := filepath.Join(root, "tests", "grid")
+	if err := os.MkdirAll(suiteDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range templateSuiteFiles {
+		if err := os.WriteFile(filepath.Join(suiteDir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+// jsonCase is a loaded case in a form comparable across languages.
+type jsonCase struct {
+	Name   string
+	Input  interface{}
+	Output interface{}
+}
+
+func TestGenerate_PytestExpandsCaseTemplates(t *testing.T) {
+	python, err := exec.LookPath("python3")
+	if err != nil {
+		t.Skip("python3 not found")
+	}
+	root := writeTemplateSuite(t)
+	opts := testOptions()
+	opts.Suite = "grid"
+	h, err := Generate(FrameworkPytest, opts)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	harnessDir := filepath.Join(root, "py", "tests")
+	if err := os.MkdirAll(harnessDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(harnessDir, "structyl_reference.py"), h.Files[0].Content, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command(python, "-c", `import json, structyl_reference as r
+print(json.dumps([{"Name": n, "Input": c["input"], "Output": c["output"]} for n, c in r.load_suite("grid")]))`)
+	cmd.Dir = filepath.Join(root, "py")
+	cmd.Env = append(os.Environ(), "PYTHONPATH="+harnessDir, "STRUCTYL_TESTS_DIR=", "STRUCTYL_TEST_TAGS=")
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("load_suite failed: %v\n%s", err, output)
+	}
+	var got []jsonCase
+	if err := json.Unmarshal(output, &got); err != nil {
+		t.Fatalf("cannot parse load_suite output: %v\n%s", err, output)
+	}
+
+	cases, err := testhelper.LoadTestSuite(root, "grid")
+	if err != nil {
+		t.Fatalf("LoadTestSuite() error = %v", err)
+	}
+	want := make([]jsonCase, len(cases))
+	for i, tc := range cases {
+		data, err := json.Marshal(jsonCase{Name: tc.Name, Input: tc.Input, Output: tc.Output})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := json.Unmarshal(data, &want[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("pytest harness cases =\n%+v\nwant (testhelper)\n%+v", got, want)
+	}
+}
+
+func TestGenerate_Errors(t *testing.T) {
+	if _, err := Generate("nunit", testOptions()); err == nil {
+		t.Error("expected error for unknown framework")
+	}
+
+	opts := testOptions()
+	opts.Suite = "../escape"
+	if _, err := Generate(FrameworkGo, opts); err == nil {
+		t.Error("expected error for invalid suite name")
+	}
+
+	opts = testOptions()
+	opts.Comparison.FloatTolerance = -1
+	if _, err := Generate(FrameworkGo, opts); err == nil {
+		t.Error("expected error for invalid comparison options")
+	}
+}
+
+func TestFrameworkForToolchain(t *testing.T) {
+	tests := map[string]Framework{
+		"go":     FrameworkGo,
+		"cargo":  FrameworkCargo,
+		"dotnet": FrameworkXUnit,
+		"uv":     FrameworkPytest,
+		"pnpm":   FrameworkVitest,
+		"deno":   FrameworkDeno,
+		"maven":  FrameworkJUnit,
+	}
+	for tc, want := range tests {
+		if got, ok := FrameworkForToolchain(tc); !ok || got != want {
+			t.Errorf("FrameworkForToolchain(%q) = %q, %v; want %q", tc, got, ok, want)
+		}
+	}
+	if _, ok := FrameworkForToolchain("make"); ok {
+		t.Error("FrameworkForToolchain(\"make\") should not be supported")
+	}
+}
+
+func TestParseFramework(t *testing.T) {
+	for _, f := range Frameworks() {
+		if got, ok := ParseFramework(string(f)); !ok || got != f {
+			t.Errorf("ParseFramework(%q) = %q, %v", f, got, ok)
+		}
+	}
+	if _, ok := ParseFramework("nunit"); ok {
+		t.Error("ParseFramework(\"nunit\") should fail")
+	}
+}
+
+func TestSplitWords(t *testing.T) {
+	tests := map[string][]string{
+		"quantile-estimators": {"quantile", "estimators"},
+		"quantileEstimators":  {"quantile", "estimators"},
+		"hodges_lehmann":      {"hodges", "lehmann"},
+		"center":              {"center"},
+		"2d-points":           {"2d", "points"},
+	}
+	for in, want := range tests {
+		if got := splitWords(in); !reflect.DeepEqual(got, want) {
+			t.Errorf("splitWords(%q) = %v, want %v", in, got, want)
+		}
+	}
+}
+
+func TestPascalCase(t *testing.T) {
+	tests := map[string]string{
+		"quantile-estimators": "QuantileEstimators",
+		"center":              "Center",
+		"2d-points":           "Suite2dPoints",
+	}
+	for in, want := range tests {
+		if got := pascalCase(splitWords(in)); got != want {
+			t.Errorf("pascalCase(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestFloatLiteral(t *testing.T) {
+	tests := map[float64]string{
+		1e-9: "1e-09",
+		4:    "4.0",
+		0.5:  "0.5",
+	}
+	for in, want := range tests {
+		if got := floatLiteral(in); got != want {
+			t.Errorf("floatLiteral(%v) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestGoPackageName(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a_test.go"), []byte("package other_test\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "stats.go"), []byte("// Package stats.\npackage stats\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got := GoPackageName(dir); got != "stats" {
+		t.Errorf("GoPackageName() = %q, want %q", got, "stats")
+	}
+
+	named := filepath.Join(t.TempDir(), "my-lib")
+	if err := os.Mkdir(named, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if got := GoPackageName(named); got != "mylib" {
+		t.Errorf("GoPackageName(my-lib) = %q, want %q", got, "mylib")
+	}
+}
@@ -0,0 +1,304 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Schema is a JSON Schema, or a subschema of one. Fields are encoded in
+// declaration order, so that generated schema files are stable and read
+// top-down.
+type Schema struct {
+	Schema               string     `json:"$schema,omitempty"`
+	ID                   string     `json:"$id,omitempty"`
+	Ref                  string     `json:"$ref,omitempty"`
+	Title                string     `json:"title,omitempty"`
+	Description          string     `json:"description,omitempty"`
+	Type                 string     `json:"type,omitempty"`
+	Const                any        `json:"const,omitempty"`
+	Enum                 []string   `json:"enum,omitempty"`
+	Format               string     `json:"format,omitempty"`
+	Pattern              string     `json:"pattern,omitempty"`
+	MinLength            *int       `json:"minLength,omitempty"`
+	MaxLength            *int       `json:"maxLength,omitempty"`
+	Minimum              *float64   `json:"minimum,omitempty"`
+	Default              any        `json:"default,omitempty"`
+	Examples             []string   `json:"examples,omitempty"`
+	Required             []string   `json:"required,omitempty"`
+	AnyOf                []*Schema  `json:"anyOf,omitempty"`
+	OneOf                []*Schema  `json:"oneOf,omitempty"`
+	Properties           Properties `json:"properties,omitempty"`
+	PropertyNames        *Schema    `json:"propertyNames,omitempty"`
+	AdditionalProperties *Schema    `json:"additionalProperties,omitempty"`
+	Items                *Schema    `json:"items,omitempty"`
+	Defs                 Properties `json:"$defs,omitempty"`
+}
+
+// Property is a named subschema: an object property or a definition.
+type Property struct {
+	Name   string
+	Schema *Schema
+}
+
+// Properties are named subschemas, encoded as an object in order.
+type Properties []Property
+
+// MarshalJSON encodes the properties as an object, in order.
+func (p Properties) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, prop := range p {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		name, err := json.Marshal(prop.Name)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(prop.Schema)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// Generator derives a JSON Schema from Go types. Object properties come from
+// exported struct fields and their json tags; a field without omitempty is
+// required. Two more struct tags describe a field:
+//
+//	jsonschema_description:"Text shown by editors"
+//	jsonschema:"minLength=1,maxLength=64,pattern=^[a-z]+$,format=uri,minimum=0,enum=a|b,examples=a|b,const=1.0,required,ref=name,keyPattern=^[a-z]+$,keyMinLength=1,keyMaxLength=64"
+//
+// ref=name replaces the schema of the field's values (the elements of a map
+// or slice) with a reference to the definition name in Named; keyPattern,
+// keyMinLength, and keyMaxLength constrain the keys of a map. Tag values must
+// not contain commas.
+type Generator struct {
+	// Defs names the struct types generated once, under $defs, and
+	// referenced wherever they are used.
+	Defs map[reflect.Type]string
+	// Named are definitions for values whose Go type does not describe
+	// them, such as interface{}; fields refer to them with ref=name.
+	Named map[string]*Schema
+	// Enums lists the values of string types.
+	Enums map[reflect.Type][]string
+	// Defaults are the default values of optional settings, by JSON path.
+	// A definition takes the defaults of the path where it is first used.
+	Defaults map[string]any
+	// Extend adjusts the schema generated for a struct type.
+	Extend map[reflect.Type]func(*Schema)
+
+	defs Properties
+	done map[string]bool
+}
+
+// Generate returns the schema of t, with root's metadata ($schema, $id,
+// title, and description) and the definitions it refers to.
+func (g *Generator) Generate(t reflect.Type, root Schema) *Schema {
+	g.defs, g.done = nil, make(map[string]bool)
+	s := g.structSchema(t, "")
+	s.Schema, s.ID, s.Title, s.Description = root.Schema, root.ID, root.Title, root.Description
+	for _, name := range sortedKeys(g.Named) {
+		if g.done[name] {
+			g.defs = append(g.defs, Property{name, g.Named[name]})
+		}
+	}
+	s.Defs = g.defs
+	return s
+}
+
+// Marshal encodes a generated schema as indented JSON with a trailing newline.
+func Marshal(s *Schema) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(s); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// typeSchema returns the schema of a value of type t at the JSON path path.
+func (g *Generator) typeSchema(t reflect.Type, path string) *Schema {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if values, ok := g.Enums[t]; ok {
+		return &Schema{Type: "string", Enum: values}
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: g.typeSchema(t.Elem(), path+"[]")}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.typeSchema(t.Elem(), path+".*")}
+	case reflect.Struct:
+		name, ok := g.Defs[t]
+		if !ok {
+			return g.structSchema(t, path)
+		}
+		if !g.done[name] {
+			g.done[name] = true
+			def := g.structSchema(t, path)
+			g.defs = append(g.defs, Property{name, def})
+		}
+		return &Schema{Ref: "#/$defs/" + name}
+	default:
+		// interface{} accepts any value unless a field refers to a definition.
+		return &Schema{}
+	}
+}
+
+// structSchema returns the object schema of a struct type.
+func (g *Generator) structSchema(t reflect.Type, path string) *Schema {
+	s := &Schema{Type: "object"}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, omitempty, ok := jsonName(f)
+		if !ok {
+			continue
+		}
+		fieldPath := name
+		if path != "" {
+			fieldPath = path + "." + name
+		}
+		prop := g.typeSchema(f.Type, fieldPath)
+		tag := parseTag(f.Tag.Get("jsonschema"))
+		if ref, ok := tag["ref"]; ok {
+			g.done[ref] = true
+			setValues(prop, &Schema{Ref: "#/$defs/" + ref})
+		}
+		g.applyTag(prop, tag, f.Tag.Get("jsonschema_description"))
+		if def, ok := g.Defaults[fieldPath]; ok {
+			prop.Default = def
+		}
+		if _, required := tag["required"]; required || !omitempty {
+			s.Required = append(s.Required, name)
+		}
+		s.Properties = append(s.Properties, Property{name, prop})
+	}
+	if extend, ok := g.Extend[t]; ok {
+		extend(s)
+	}
+	return s
+}
+
+// setValues replaces the schema of the values of s: its elements, the values
+// of a map, or s itself.
+func setValues(s, values *Schema) {
+	switch {
+	case s.Items != nil:
+		s.Items = values
+	case s.AdditionalProperties != nil:
+		s.AdditionalProperties = values
+	default:
+		*s = *values
+	}
+}
+
+// applyTag sets the constraints of a jsonschema tag and a description.
+func (g *Generator) applyTag(s *Schema, tag map[string]string, description string) {
+	s.Description = description
+	for key, value := range tag {
+		switch key {
+		case "minLength":
+			s.MinLength = intPtr(value)
+		case "maxLength":
+			s.MaxLength = intPtr(value)
+		case "minimum":
+			v, _ := strconv.ParseFloat(value, 64)
+			s.Minimum = &v
+		case "pattern":
+			s.Pattern = value
+		case "format":
+			s.Format = value
+		case "const":
+			s.Const = value
+		case "enum":
+			s.Enum = strings.Split(value, "|")
+		case "examples":
+			s.Examples = strings.Split(value, "|")
+		case "keyPattern", "keyMinLength", "keyMaxLength":
+			if s.PropertyNames == nil {
+				s.PropertyNames = &Schema{}
+			}
+			switch key {
+			case "keyPattern":
+				s.PropertyNames.Pattern = value
+			case "keyMinLength":
+				s.PropertyNames.MinLength = intPtr(value)
+			default:
+				s.PropertyNames.MaxLength = intPtr(value)
+			}
+		case "required", "ref":
+		default:
+			panic(fmt.Sprintf("schema: unknown jsonschema tag key %q", key))
+		}
+	}
+}
+
+// jsonName returns the JSON name of a struct field and whether it is
+// omitted when empty. ok is false for fields that are not encoded.
+func jsonName(f reflect.StructField) (name string, omitempty, ok bool) {
+	if !f.IsExported() {
+		return "", false, false
+	}
+	tag := f.Tag.Get("json")
+	if tag == "-" {
+		return "", false, false
+	}
+	name, opts, _ := strings.Cut(tag, ",")
+	if name == "" {
+		name = f.Name
+	}
+	return name, strings.Contains(","+opts+",", ",omitempty,"), true
+}
+
+// parseTag parses a jsonschema tag into its keys and values.
+func parseTag(tag string) map[string]string {
+	result := make(map[string]string)
+	if tag == "" {
+		return result
+	}
+	for _, part := range strings.Split(tag, ",") {
+		key, value, _ := strings.Cut(part, "=")
+		result[key] = value
+	}
+	return result
+}
+
+func intPtr(s string) *int {
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		panic(fmt.Sprintf("schema: invalid integer %q in jsonschema tag", s))
+	}
+	return &v
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
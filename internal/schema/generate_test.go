@@ -0,0 +1,98 @@
+package schema
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/AndreyAkinshin/structyl/internal/output"
+	schemafs "github.com/AndreyAkinshin/structyl/schema"
+)
+
+// TestSchemaGenerated_MatchesCommitted fails when the Go types change without
+// the committed schema files being regenerated.
+func TestSchemaGenerated_MatchesCommitted(t *testing.T) {
+	t.Parallel()
+	for _, name := range []string{ConfigFile, ToolchainsFile} {
+		t.Run(name, func(t *testing.T) {
+			generated, err := Generated(name)
+			if err != nil {
+				t.Fatalf("Generated(%q) error = %v", name, err)
+			}
+			committed, err := schemafs.FS.ReadFile(name)
+			if err != nil {
+				t.Fatalf("read %s: %v", name, err)
+			}
+			if diff := output.UnifiedDiff("schema/"+name, string(committed), string(generated)); diff != "" {
+				t.Errorf("schema/%s is out of date; regenerate it with 'mise run generate:schema'\n%s", name, diff)
+			}
+		})
+	}
+}
+
+func TestGenerated_UnknownName_ReturnsError(t *testing.T) {
+	t.Parallel()
+	if _, err := Generated("testcase.schema.json"); err == nil {
+		t.Error("Generated() error = nil, want error for a schema not generated from Go types")
+	}
+}
+
+type generatorMode string
+
+type generatorItem struct {
+	Name string `json:"name" jsonschema_description:"Item name"`
+}
+
+type generatorConfig struct {
+	Name     string                   `json:"name" jsonschema:"minLength=1,pattern=^[a-z]+$" jsonschema_description:"The name"`
+	Mode     generatorMode            `json:"mode,omitempty"`
+	Limit    int                      `json:"limit,omitempty" jsonschema:"minimum=0"`
+	Items    map[string]generatorItem `json:"items,omitempty" jsonschema:"keyPattern=^[a-z]+$"`
+	List     []generatorItem          `json:"list,omitempty"`
+	Commands map[string]interface{}   `json:"commands,omitempty" jsonschema:"ref=command"`
+	Extra    string                   `json:"extra,omitempty" jsonschema:"required"`
+	Skipped  string                   `json:"-"`
+	hidden   string
+}
+
+func TestGenerator_Generate(t *testing.T) {
+	t.Parallel()
+	g := &Generator{
+		Defs:     map[reflect.Type]string{reflect.TypeFor[generatorItem](): "item"},
+		Named:    map[string]*Schema{"command": {Type: "string"}, "unused": {Type: "null"}},
+		Enums:    map[reflect.Type][]string{reflect.TypeFor[generatorMode](): {"fast", "slow"}},
+		Defaults: map[string]any{"mode": "fast", "limit": 3},
+	}
+	s := g.Generate(reflect.TypeFor[generatorConfig](), Schema{Title: "Test"})
+
+	data, err := json.Marshal(s)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	want := `{"title":"Test","type":"object","required":["name","extra"],"properties":{` +
+		`"name":{"description":"The name","type":"string","pattern":"^[a-z]+$","minLength":1},` +
+		`"mode":{"type":"string","enum":["fast","slow"],"default":"fast"},` +
+		`"limit":{"type":"integer","minimum":0,"default":3},` +
+		`"items":{"type":"object","propertyNames":{"pattern":"^[a-z]+$"},"additionalProperties":{"$ref":"#/$defs/item"}},` +
+		`"list":{"type":"array","items":{"$ref":"#/$defs/item"}},` +
+		`"commands":{"type":"object","additionalProperties":{"$ref":"#/$defs/command"}},` +
+		`"extra":{"type":"string"}},` +
+		`"$defs":{"item":{"type":"object","required":["name"],"properties":{"name":{"description":"Item name","type":"string"}}},` +
+		`"command":{"type":"string"}}}`
+	if string(data) != want {
+		t.Errorf("Generate() =\n%s\nwant\n%s", data, want)
+	}
+}
+
+func TestGenerator_UnknownTagKey_Panics(t *testing.T) {
+	t.Parallel()
+	type bad struct {
+		Name string `json:"name" jsonschema:"maxItems=1"`
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("Generate() did not panic on an unknown jsonschema tag key")
+		}
+	}()
+	(&Generator{}).Generate(reflect.TypeFor[bad](), Schema{})
+}
@@ -0,0 +1,99 @@
+package schema
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/AndreyAkinshin/structyl/internal/config"
+	"github.com/AndreyAkinshin/structyl/internal/toolchain"
+)
+
+// Schema files, as embedded and published.
+const (
+	ConfigFile     = "config.schema.json"
+	ToolchainsFile = "toolchains.schema.json"
+)
+
+// draft is the JSON Schema dialect of the generated schemas.
+const draft = "https://json-schema.org/draft/2020-12/schema"
+
+// Generated returns the schema file name generated from the Go types, in
+// the form in which it is committed: ConfigFile or ToolchainsFile.
+func Generated(name string) ([]byte, error) {
+	switch name {
+	case ConfigFile:
+		return Marshal(configGenerator().Generate(reflect.TypeFor[config.Config](), Schema{
+			Schema:      draft,
+			ID:          "https://structyl.akinshin.dev/schema/config.json",
+			Title:       "Structyl Configuration",
+			Description: "Configuration schema for Structyl multi-language project orchestration",
+		}))
+	case ToolchainsFile:
+		return Marshal(toolchainsGenerator().Generate(reflect.TypeFor[toolchain.ToolchainsFile](), Schema{
+			Schema:      draft,
+			ID:          "https://structyl.akinshin.dev/schema/toolchains.json",
+			Title:       "Structyl Toolchains Configuration",
+			Description: "Toolchain definitions for Structyl multi-language project orchestration",
+		}))
+	}
+	return nil, fmt.Errorf("unknown schema %q", name)
+}
+
+// configGenerator generates the schema of config.json.
+func configGenerator() *Generator {
+	return &Generator{
+		Defs: map[reflect.Type]string{
+			reflect.TypeFor[config.VersionFileConfig](): "versionFile",
+			reflect.TypeFor[config.ToolchainConfig]():   "toolchainDefinition",
+			reflect.TypeFor[config.DockerConfig]():      "docker",
+			reflect.TypeFor[config.ComparisonConfig]():  "comparison",
+		},
+		Named: map[string]*Schema{
+			"commandDefinition": commandSchema("A command definition. Commands may have verbosity variants: defining 'build' allows 'build:verbose' and 'build:quiet' variants to be auto-generated based on the toolchain's verbosity flags.",
+				"Command is not available for this target", "Sequence of commands or references"),
+		},
+		Enums: map[reflect.Type][]string{
+			reflect.TypeFor[config.ToleranceMode](): stringsOf(config.ToleranceModes),
+			reflect.TypeFor[config.ArrayOrder]():    stringsOf(config.ArrayOrders),
+		},
+		Defaults: config.Defaults(),
+		Extend: map[reflect.Type]func(*Schema){
+			// A rule is a regex replacement or a structured key.
+			reflect.TypeFor[config.VersionFileConfig](): func(s *Schema) {
+				s.Description = "A version file update rule: a regex replacement (pattern and replace) or a structured key (key, with format)"
+				s.AnyOf = []*Schema{{Required: []string{"pattern", "replace"}}, {Required: []string{"key"}}}
+			},
+		},
+	}
+}
+
+// toolchainsGenerator generates the schema of toolchains.json.
+func toolchainsGenerator() *Generator {
+	return &Generator{
+		Named: map[string]*Schema{
+			"commandValue": commandSchema("",
+				"Command is not available for this toolchain", "Sequence of commands to execute"),
+		},
+	}
+}
+
+// commandSchema returns the schema of a command definition: null, a shell
+// command, or a list.
+func commandSchema(description, null, list string) *Schema {
+	return &Schema{
+		Description: description,
+		OneOf: []*Schema{
+			{Type: "null", Description: null},
+			{Type: "string", Description: "Shell command to execute"},
+			{Type: "array", Description: list, Items: &Schema{Type: "string"}},
+		},
+	}
+}
+
+func stringsOf[S ~string](values []S) []string {
+	result := make([]string, len(values))
+	for i, v := range values {
+		result[i] = string(v)
+	}
+	return result
+}
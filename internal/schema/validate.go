@@ -4,10 +4,15 @@ package schema
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 	"sync"
 
 	"github.com/santhosh-tekuri/jsonschema/v6"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
 
 	schemafs "github.com/AndreyAkinshin/structyl/schema"
 )
@@ -15,6 +20,7 @@ import (
 var (
 	configSchema     *jsonschema.Schema
 	toolchainsSchema *jsonschema.Schema
+	testcaseSchema   *jsonschema.Schema
 	compileOnce      sync.Once
 	compileErr       error
 )
@@ -23,7 +29,7 @@ var (
 //
 // Coverage note: This function has ~51% coverage because error paths cannot be
 // triggered without modifying embedded files at runtime. The embedded schema
-// files (config.schema.json, toolchains.schema.json) are compiled into the
+// files (config.schema.json, toolchains.schema.json, testcase.schema.json) are compiled into the
 // binary and always exist with valid JSON content. Error paths for ReadFile,
 // UnmarshalJSON, AddResource, and Compile failures are defensive programming
 // for conditions that are impossible with correctly embedded resources.
@@ -43,6 +49,12 @@ func compileSchemas() error {
 			return
 		}
 
+		testcaseData, err := schemafs.FS.ReadFile("testcase.schema.json")
+		if err != nil {
+			compileErr = fmt.Errorf("read testcase schema: %w", err)
+			return
+		}
+
 		configDoc, err := jsonschema.UnmarshalJSON(bytes.NewReader(configData))
 		if err != nil {
 			compileErr = fmt.Errorf("unmarshal config schema: %w", err)
@@ -55,6 +67,12 @@ func compileSchemas() error {
 			return
 		}
 
+		testcaseDoc, err := jsonschema.UnmarshalJSON(bytes.NewReader(testcaseData))
+		if err != nil {
+			compileErr = fmt.Errorf("unmarshal testcase schema: %w", err)
+			return
+		}
+
 		if err := compiler.AddResource("config.schema.json", configDoc); err != nil {
 			compileErr = fmt.Errorf("add config schema resource: %w", err)
 			return
@@ -65,6 +83,11 @@ func compileSchemas() error {
 			return
 		}
 
+		if err := compiler.AddResource("testcase.schema.json", testcaseDoc); err != nil {
+			compileErr = fmt.Errorf("add testcase schema resource: %w", err)
+			return
+		}
+
 		configSchema, err = compiler.Compile("config.schema.json")
 		if err != nil {
 			compileErr = fmt.Errorf("compile config schema: %w", err)
@@ -76,6 +99,12 @@ func compileSchemas() error {
 			compileErr = fmt.Errorf("compile toolchains schema: %w", err)
 			return
 		}
+
+		testcaseSchema, err = compiler.Compile("testcase.schema.json")
+		if err != nil {
+			compileErr = fmt.Errorf("compile testcase schema: %w", err)
+			return
+		}
 	})
 
 	return compileErr
@@ -99,6 +128,66 @@ func ValidateConfig(data []byte) error {
 	return nil
 }
 
+// ConfigError is a violation of the config schema.
+type ConfigError struct {
+	Field   string // JSON path of the value, as in config.ValidationError; empty for the document
+	Message string
+}
+
+// ConfigErrors validates JSON data against the config schema and returns
+// each violation with the JSON path of the offending value. The error is
+// non-nil only if data cannot be validated.
+func ConfigErrors(data []byte) ([]ConfigError, error) {
+	if err := compileSchemas(); err != nil {
+		return nil, err
+	}
+
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+
+	err := configSchema.Validate(v)
+	var verr *jsonschema.ValidationError
+	if !errors.As(err, &verr) {
+		return nil, err
+	}
+	p := message.NewPrinter(language.English)
+	var result []ConfigError
+	var walk func(e *jsonschema.ValidationError)
+	walk = func(e *jsonschema.ValidationError) {
+		if len(e.Causes) == 0 {
+			result = append(result, ConfigError{
+				Field:   fieldPath(e.InstanceLocation),
+				Message: e.ErrorKind.LocalizedString(p),
+			})
+			return
+		}
+		for _, c := range e.Causes {
+			walk(c)
+		}
+	}
+	walk(verr)
+	return result, nil
+}
+
+// fieldPath returns the JSON path of an instance location in the form of
+// validation errors: "ci.steps[0].name".
+func fieldPath(location []string) string {
+	var sb strings.Builder
+	for _, token := range location {
+		if _, err := strconv.Atoi(token); err == nil {
+			sb.WriteString("[" + token + "]")
+			continue
+		}
+		if sb.Len() > 0 {
+			sb.WriteByte('.')
+		}
+		sb.WriteString(token)
+	}
+	return sb.String()
+}
+
 // ValidateToolchains validates JSON data against the toolchains schema.
 func ValidateToolchains(data []byte) error {
 	if err := compileSchemas(); err != nil {
@@ -116,3 +205,21 @@ func ValidateToolchains(data []byte) error {
 
 	return nil
 }
+
+// ValidateTestCase validates JSON data against the test case schema.
+func ValidateTestCase(data []byte) error {
+	if err := compileSchemas(); err != nil {
+		return err
+	}
+
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+
+	if err := testcaseSchema.Validate(v); err != nil {
+		return fmt.Errorf("test case validation failed: %w", err)
+	}
+
+	return nil
+}
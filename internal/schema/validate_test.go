@@ -659,3 +659,55 @@ func TestValidateToolchains_ConcurrentAccess(t *testing.T) {
 		t.Errorf("concurrent validation failed: %v", err)
 	}
 }
+
+func TestValidateTestCase(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		data    string
+		wantErr bool
+	}{
+		{"valid", `{"input": {"x": 1}, "output": 2}`, false},
+		{"valid with metadata", `{"input": {}, "output": [], "description": "d", "skip": true, "tags": ["slow"]}`, false},
+		{"missing output", `{"input": {}}`, true},
+		{"null output", `{"input": {}, "output": null}`, true},
+		{"array input", `{"input": [1], "output": 1}`, true},
+		{"non-string tags", `{"input": {}, "output": 1, "tags": [1]}`, true},
+		{"invalid JSON", `{`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := ValidateTestCase([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateTestCase() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfigErrors(t *testing.T) {
+	t.Parallel()
+	data := []byte(`{
+		"targets": {"go": {"type": "compiled", "title": "Go"}},
+		"ci": {"steps": [{"name": 1, "target": "go", "command": "build"}]}
+	}`)
+	got, err := ConfigErrors(data)
+	if err != nil {
+		t.Fatalf("ConfigErrors() error = %v", err)
+	}
+	fields := make(map[string]bool)
+	for _, e := range got {
+		fields[e.Field] = true
+	}
+	for _, want := range []string{"", "targets.go.type", "ci.steps[0].name"} {
+		if !fields[want] {
+			t.Errorf("ConfigErrors() = %+v, want an error at %q", got, want)
+		}
+	}
+
+	got, err = ConfigErrors([]byte(`{"project": {"name": "demo"}}`))
+	if err != nil || len(got) != 0 {
+		t.Errorf("ConfigErrors(valid) = %+v, %v, want none", got, err)
+	}
+}
@@ -308,7 +308,8 @@ func (t *targetImpl) resolveCommandVariant(cmd string, v Verbosity) string {
 //   - ${target}: target name (e.g., "rs", "go")
 //   - ${target_dir}: target directory path
 //   - ${root}: project root directory (absolute path)
-//   - ${version}: project version from PROJECT_VERSION file (empty if not available)
+//   - ${version}: project version from PROJECT_VERSION file, or the target's own
+//     version if it is versioned independently (empty if not available)
 func (t *targetImpl) interpolateVars(cmd string) string {
 	// First, handle escaped variables: $${var} -> placeholder
 	result := strings.ReplaceAll(cmd, "$${", escapePlaceholder)
@@ -340,6 +341,15 @@ func (t *targetImpl) interpolateVars(cmd string) string {
 	return result
 }
 
+// Interpolate replaces the variables in cmd as Execute does for t. Targets
+// not created by NewTarget have no variables; cmd is returned unchanged.
+func Interpolate(t Target, cmd string) string {
+	if impl, ok := t.(*targetImpl); ok {
+		return impl.interpolateVars(cmd)
+	}
+	return cmd
+}
+
 func (t *targetImpl) executeShell(ctx context.Context, cmdStr string, opts ExecOptions) error {
 	workDir := filepath.Join(t.rootDir, t.cwd)
 	shellCmd := buildShellCommand(ctx, cmdStr)
@@ -584,6 +584,22 @@ func TestInterpolateVars_BuiltinVariables(t *testing.T) {
 	}
 }
 
+func TestInterpolate(t *testing.T) {
+	cfg := config.TargetConfig{
+		Type:  "language",
+		Title: "Rust",
+		Vars:  map[string]string{"flags": "--all"},
+	}
+
+	resolver, _ := toolchain.NewResolver(&config.Config{})
+	target, _ := NewTarget("rs", cfg, "/project", "1.2.3", resolver)
+
+	result := Interpolate(target, "cargo test ${flags} # ${target} ${version}")
+	if result != "cargo test --all # rs 1.2.3" {
+		t.Errorf("Interpolate() = %q, want %q", result, "cargo test --all # rs 1.2.3")
+	}
+}
+
 func TestInterpolateVars_EmptyVersion(t *testing.T) {
 	cfg := config.TargetConfig{
 		Type:  "language",
@@ -4,7 +4,6 @@ import (
 	"errors"
 	"fmt"
 	"os"
-	"path/filepath"
 	"sort"
 
 	"github.com/AndreyAkinshin/structyl/internal/config"
@@ -18,25 +17,40 @@ type Registry struct {
 	targets map[string]Target
 }
 
-// loadProjectVersion reads the project version from the configured source file.
+// loadProjectVersion reads the project version from the configured source file,
+// or derives it from git tags if the source is "git:tag".
 // Returns empty string if no version source is configured or if the file doesn't exist.
-// Returns error if the file exists but cannot be read (permission error) or is malformed.
+// Returns error if the file exists but cannot be read (permission error) or is malformed,
+// or if the version cannot be derived from git.
 func loadProjectVersion(cfg *config.Config, rootDir string) (string, error) {
 	if cfg.Version == nil || cfg.Version.Source == "" {
 		return "", nil
 	}
+	v, err := version.Resolve(rootDir, cfg)
+	return checkLoadedVersion(v, err, cfg.Version.Source)
+}
+
+// loadTargetVersion reads the version of an independently versioned target,
+// with the same error handling as loadProjectVersion.
+func loadTargetVersion(name string, targetCfg config.TargetConfig, rootDir string) (string, error) {
+	v, err := version.ResolveTarget(rootDir, name, targetCfg)
+	return checkLoadedVersion(v, err, config.TargetVersionSource(name, targetCfg))
+}
 
-	versionPath := filepath.Join(rootDir, cfg.Version.Source)
-	v, err := version.Read(versionPath)
+// checkLoadedVersion maps a version read from source to the loader result.
+func checkLoadedVersion(v string, err error, source string) (string, error) {
 	if err == nil {
 		return v, nil
 	}
+	if source == config.VersionSourceGitTag {
+		return "", fmt.Errorf("version source %s: %w", config.VersionSourceGitTag, err)
+	}
 	if errors.Is(err, os.ErrNotExist) {
 		// Missing version file is acceptable: ${version} interpolates to empty string
 		return "", nil
 	}
 	// Version file exists but is unreadable or malformed - this is a configuration error
-	return "", fmt.Errorf("version file %q: %w", cfg.Version.Source, err)
+	return "", fmt.Errorf("version file %q: %w", source, err)
 }
 
 // NewRegistry creates a registry from configuration.
@@ -60,7 +74,13 @@ func NewRegistry(cfg *config.Config, rootDir string) (*Registry, error) {
 	}
 
 	for name, targetCfg := range cfg.Targets {
-		t, err := NewTarget(name, targetCfg, rootDir, projectVersion, resolver)
+		targetVersion := projectVersion
+		if targetCfg.Version != nil {
+			if targetVersion, err = loadTargetVersion(name, targetCfg, rootDir); err != nil {
+				return nil, fmt.Errorf("target %q: %w", name, err)
+			}
+		}
+		t, err := NewTarget(name, targetCfg, rootDir, targetVersion, resolver)
 		if err != nil {
 			return nil, fmt.Errorf("target %q: %w", name, err)
 		}
@@ -614,3 +614,41 @@ func TestRegistry_ConcurrentGet(t *testing.T) {
 		<-done
 	}
 }
+
+func TestNewRegistry_TargetVersion_InterpolatesOwnVersion(t *testing.T) {
+	t.Parallel()
+	tmpDir := t.TempDir()
+	for name, content := range map[string]string{
+		".structyl/PROJECT_VERSION": "2.1.0\n",
+		".structyl/versions/rs":     "0.4.0\n",
+	} {
+		path := filepath.Join(tmpDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg := &config.Config{
+		Project: config.ProjectConfig{Name: "test"},
+		Version: &config.VersionConfig{Source: ".structyl/PROJECT_VERSION"},
+		Targets: map[string]config.TargetConfig{
+			"rs": {Type: "language", Title: "Rust", Version: &config.TargetVersionConfig{}},
+			"go": {Type: "language", Title: "Go"},
+			"py": {Type: "language", Title: "Python", Version: &config.TargetVersionConfig{Source: "py/VERSION"}},
+		},
+	}
+
+	r, err := NewRegistry(cfg, tmpDir)
+	if err != nil {
+		t.Fatalf("NewRegistry() error = %v", err)
+	}
+	for name, want := range map[string]string{"rs": "0.4.0", "go": "2.1.0", "py": ""} {
+		tgt, _ := r.Get(name)
+		if got := tgt.(*targetImpl).interpolateVars("${version}"); got != want {
+			t.Errorf("%s: ${version} = %q, want %q", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,297 @@
+package tests
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// File reference encodings (the "encoding" key of a $file reference).
+const (
+	EncodingBase64 = "base64"
+	EncodingHex    = "hex"
+)
+
+// File reference formats (the "format" key of a $file reference).
+const (
+	FormatJSON   = "json"
+	FormatText   = "text"
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson"
+)
+
+// CSV layouts (the "layout" key of a csv $file reference).
+const (
+	LayoutRows    = "rows"    // Array of records, each an array of cells
+	LayoutRecords = "records" // Array of objects keyed by the header row
+	LayoutColumns = "columns" // Object mapping each header to its column of cells
+)
+
+// fileRef is a parsed $file reference object:
+//
+//	{"$file": "x.bin", "encoding": "base64", "sha256": "..."}
+//	{"$file": "data.csv", "format": "csv", "layout": "records"}
+type fileRef struct {
+	Path     string
+	Encoding string
+	Format   string
+	Layout   string
+	SHA256   string
+}
+
+// fileRefKeys lists the keys permitted in a $file reference object.
+var fileRefKeys = map[string]bool{
+	"$file":    true,
+	"encoding": true,
+	"format":   true,
+	"layout":   true,
+	"sha256":   true,
+}
+
+// parseFileRef parses and validates a $file reference object.
+func parseFileRef(v map[string]interface{}) (fileRef, error) {
+	var ref fileRef
+
+	var unknown []string
+	for key := range v {
+		if !fileRefKeys[key] {
+			unknown = append(unknown, key)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return ref, fmt.Errorf("$file reference has unknown key(s): %s", strings.Join(unknown, ", "))
+	}
+
+	path, ok := v["$file"].(string)
+	if !ok || path == "" {
+		return ref, fmt.Errorf("$file must be a non-empty string")
+	}
+	ref.Path = path
+
+	var err error
+	if ref.Encoding, err = fileRefString(v, "encoding"); err != nil {
+		return ref, err
+	}
+	if ref.Format, err = fileRefString(v, "format"); err != nil {
+		return ref, err
+	}
+	if ref.Layout, err = fileRefString(v, "layout"); err != nil {
+		return ref, err
+	}
+	if ref.SHA256, err = fileRefString(v, "sha256"); err != nil {
+		return ref, err
+	}
+
+	switch ref.Encoding {
+	case "", EncodingBase64, EncodingHex:
+	default:
+		return ref, fmt.Errorf("$file %q: unknown encoding %q (must be %q or %q)", path, ref.Encoding, EncodingBase64, EncodingHex)
+	}
+	switch ref.Format {
+	case "", FormatJSON, FormatText, FormatCSV, FormatNDJSON:
+	default:
+		return ref, fmt.Errorf("$file %q: unknown format %q (must be one of: %s, %s, %s, %s)", path, ref.Format, FormatJSON, FormatText, FormatCSV, FormatNDJSON)
+	}
+	if ref.Encoding != "" && ref.Format != "" {
+		return ref, fmt.Errorf("$file %q: \"encoding\" and \"format\" are mutually exclusive", path)
+	}
+	if ref.Layout != "" {
+		if ref.Format != FormatCSV {
+			return ref, fmt.Errorf("$file %q: \"layout\" requires format %q", path, FormatCSV)
+		}
+		switch ref.Layout {
+		case LayoutRows, LayoutRecords, LayoutColumns:
+		default:
+			return ref, fmt.Errorf("$file %q: unknown layout %q (must be one of: %s, %s, %s)", path, ref.Layout, LayoutRows, LayoutRecords, LayoutColumns)
+		}
+	}
+	if ref.SHA256 != "" {
+		if _, err := hex.DecodeString(ref.SHA256); err != nil || len(ref.SHA256) != sha256.Size*2 {
+			return ref, fmt.Errorf("$file %q: sha256 must be a 64-character hex digest", path)
+		}
+	}
+
+	return ref, nil
+}
+
+// fileRefString returns an optional string field of a $file reference.
+func fileRefString(v map[string]interface{}, key string) (string, error) {
+	raw, ok := v[key]
+	if !ok {
+		return "", nil
+	}
+	s, ok := raw.(string)
+	if !ok {
+		return "", fmt.Errorf("$file reference field %q must be a string", key)
+	}
+	return s, nil
+}
+
+// verifyChecksum checks data against the reference's sha256 pin, if any.
+func (r fileRef) verifyChecksum(data []byte) error {
+	if r.SHA256 == "" {
+		return nil
+	}
+	sum := sha256.Sum256(data)
+	actual := hex.EncodeToString(sum[:])
+	if !strings.EqualFold(actual, r.SHA256) {
+		return fmt.Errorf("$file %q: sha256 mismatch (expected %s, got %s)", r.Path, strings.ToLower(r.SHA256), actual)
+	}
+	return nil
+}
+
+// decode converts file contents to a test value according to the reference's
+// encoding and format.
+//
+// Without an explicit encoding or format, contents that parse as JSON are
+// returned as the parsed structure and anything else as a raw string.
+func (r fileRef) decode(data []byte) (interface{}, error) {
+	switch r.Encoding {
+	case EncodingBase64:
+		return base64.StdEncoding.EncodeToString(data), nil
+	case EncodingHex:
+		return hex.EncodeToString(data), nil
+	}
+
+	switch r.Format {
+	case FormatJSON:
+		var value interface{}
+		if err := json.Unmarshal(data, &value); err != nil {
+			return nil, fmt.Errorf("$file %q: invalid JSON: %w", r.Path, err)
+		}
+		return value, nil
+	case FormatText:
+		return string(data), nil
+	case FormatCSV:
+		value, err := decodeCSV(data, r.Layout)
+		if err != nil {
+			return nil, fmt.Errorf("$file %q: %w", r.Path, err)
+		}
+		return value, nil
+	case FormatNDJSON:
+		value, err := decodeNDJSON(data)
+		if err != nil {
+			return nil, fmt.Errorf("$file %q: %w", r.Path, err)
+		}
+		return value, nil
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err == nil {
+		return value, nil
+	}
+	return string(data), nil
+}
+
+// decodeCSV parses CSV data. Numeric cells become float64; all other cells
+// remain strings.
+//
+// The "rows" layout (default) returns every record as an array of cells,
+// the header included. The other layouts treat the first record as a header:
+// "records" returns one object per data record, keyed by column name, and
+// "columns" returns one object mapping each column name to its array of
+// values.
+func decodeCSV(data []byte, layout string) (interface{}, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV: %w", err)
+	}
+
+	if layout == "" || layout == LayoutRows {
+		rows := make([]interface{}, len(records))
+		for i, record := range records {
+			row := make([]interface{}, len(record))
+			for j, cell := range record {
+				row[j] = csvCell(cell)
+			}
+			rows[i] = row
+		}
+		return rows, nil
+	}
+
+	if len(records) == 0 {
+		return nil, fmt.Errorf("CSV with %q layout requires a header row", layout)
+	}
+	header := records[0]
+	seen := make(map[string]bool, len(header))
+	for _, name := range header {
+		if seen[name] {
+			return nil, fmt.Errorf("duplicate CSV column %q", name)
+		}
+		seen[name] = true
+	}
+
+	if layout == LayoutRecords {
+		objects := make([]interface{}, len(records)-1)
+		for i, record := range records[1:] {
+			object := make(map[string]interface{}, len(header))
+			for j, cell := range record {
+				object[header[j]] = csvCell(cell)
+			}
+			objects[i] = object
+		}
+		return objects, nil
+	}
+
+	columns := make(map[string]interface{}, len(header))
+	values := make([][]interface{}, len(header))
+	for i := range values {
+		values[i] = make([]interface{}, 0, len(records)-1)
+	}
+	for _, record := range records[1:] {
+		for j, cell := range record {
+			values[j] = append(values[j], csvCell(cell))
+		}
+	}
+	for i, name := range header {
+		columns[name] = values[i]
+	}
+	return columns, nil
+}
+
+// csvCell converts a CSV cell to float64 when it is a finite number.
+// Non-finite spellings ("NaN", "Inf") stay strings so they match the special
+// string values used by output comparison.
+func csvCell(cell string) interface{} {
+	f, err := strconv.ParseFloat(strings.TrimSpace(cell), 64)
+	if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+		return cell
+	}
+	return f
+}
+
+// decodeNDJSON parses newline-delimited JSON into an array.
+// Blank lines are ignored.
+func decodeNDJSON(data []byte) ([]interface{}, error) {
+	result := []interface{}{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(data)+1)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+		var value interface{}
+		if err := json.Unmarshal(text, &value); err != nil {
+			return nil, fmt.Errorf("invalid NDJSON at line %d: %w", line, err)
+		}
+		result = append(result, value)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,163 @@
+package tests
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeFixture(t *testing.T, dir, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestResolveFileRefs_Encodings(t *testing.T) {
+	t.Parallel()
+	tmpDir := t.TempDir()
+	writeFixture(t, tmpDir, "data.bin", "\x00\x01\xff")
+
+	tests := []struct {
+		encoding string
+		want     string
+	}{
+		{EncodingBase64, "AAH/"},
+		{EncodingHex, "0001ff"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.encoding, func(t *testing.T) {
+			t.Parallel()
+			ref := map[string]interface{}{"$file": "data.bin", "encoding": tt.encoding}
+			got, err := resolveFileRefs(ref, tmpDir)
+			if err != nil {
+				t.Fatalf("resolveFileRefs() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("resolveFileRefs() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveFileRefs_CSV(t *testing.T) {
+	t.Parallel()
+	tmpDir := t.TempDir()
+	writeFixture(t, tmpDir, "data.csv", "x,label\n1.5,a\n2,NaN\n")
+
+	rows, err := resolveFileRefs(map[string]interface{}{"$file": "data.csv", "format": "csv"}, tmpDir)
+	if err != nil {
+		t.Fatalf("resolveFileRefs(rows) error = %v", err)
+	}
+	wantRows := []interface{}{
+		[]interface{}{"x", "label"},
+		[]interface{}{1.5, "a"},
+		[]interface{}{2.0, "NaN"},
+	}
+	if !reflect.DeepEqual(rows, wantRows) {
+		t.Errorf("rows = %v, want %v", rows, wantRows)
+	}
+
+	columns, err := resolveFileRefs(map[string]interface{}{"$file": "data.csv", "format": "csv", "layout": "columns"}, tmpDir)
+	if err != nil {
+		t.Fatalf("resolveFileRefs(columns) error = %v", err)
+	}
+	wantColumns := map[string]interface{}{
+		"x":     []interface{}{1.5, 2.0},
+		"label": []interface{}{"a", "NaN"},
+	}
+	if !reflect.DeepEqual(columns, wantColumns) {
+		t.Errorf("columns = %v, want %v", columns, wantColumns)
+	}
+
+	records, err := resolveFileRefs(map[string]interface{}{"$file": "data.csv", "format": "csv", "layout": "records"}, tmpDir)
+	if err != nil {
+		t.Fatalf("resolveFileRefs(records) error = %v", err)
+	}
+	wantRecords := []interface{}{
+		map[string]interface{}{"x": 1.5, "label": "a"},
+		map[string]interface{}{"x": 2.0, "label": "NaN"},
+	}
+	if !reflect.DeepEqual(records, wantRecords) {
+		t.Errorf("records = %v, want %v", records, wantRecords)
+	}
+}
+
+func TestResolveFileRefs_NDJSON(t *testing.T) {
+	t.Parallel()
+	tmpDir := t.TempDir()
+	writeFixture(t, tmpDir, "data.ndjson", "{\"a\": 1}\n\n[2, 3]\n")
+
+	got, err := resolveFileRefs(map[string]interface{}{"$file": "data.ndjson", "format": "ndjson"}, tmpDir)
+	if err != nil {
+		t.Fatalf("resolveFileRefs() error = %v", err)
+	}
+	want := []interface{}{map[string]interface{}{"a": 1.0}, []interface{}{2.0, 3.0}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("resolveFileRefs() = %v, want %v", got, want)
+	}
+}
+
+func TestResolveFileRefs_TextFormat_KeepsJSONAsString(t *testing.T) {
+	t.Parallel()
+	tmpDir := t.TempDir()
+	writeFixture(t, tmpDir, "data.txt", "[1, 2]")
+
+	got, err := resolveFileRefs(map[string]interface{}{"$file": "data.txt", "format": "text"}, tmpDir)
+	if err != nil {
+		t.Fatalf("resolveFileRefs() error = %v", err)
+	}
+	if got != "[1, 2]" {
+		t.Errorf("resolveFileRefs() = %v, want raw string", got)
+	}
+}
+
+func TestResolveFileRefs_SHA256Pin(t *testing.T) {
+	t.Parallel()
+	tmpDir := t.TempDir()
+	writeFixture(t, tmpDir, "data.txt", "hello")
+	sum := sha256.Sum256([]byte("hello"))
+	digest := hex.EncodeToString(sum[:])
+
+	if _, err := resolveFileRefs(map[string]interface{}{"$file": "data.txt", "sha256": strings.ToUpper(digest)}, tmpDir); err != nil {
+		t.Errorf("resolveFileRefs() with matching sha256 error = %v", err)
+	}
+
+	other := sha256.Sum256([]byte("other"))
+	_, err := resolveFileRefs(map[string]interface{}{"$file": "data.txt", "sha256": hex.EncodeToString(other[:])}, tmpDir)
+	if err == nil || !strings.Contains(err.Error(), "sha256 mismatch") {
+		t.Errorf("resolveFileRefs() error = %v, want sha256 mismatch", err)
+	}
+}
+
+func TestParseFileRef_InvalidReferences_ReturnError(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		ref     map[string]interface{}
+		wantErr string
+	}{
+		{"unknown key", map[string]interface{}{"$file": "x", "extra": 1}, "unknown key(s): extra"},
+		{"empty path", map[string]interface{}{"$file": ""}, "non-empty string"},
+		{"non-string path", map[string]interface{}{"$file": 1}, "non-empty string"},
+		{"unknown encoding", map[string]interface{}{"$file": "x", "encoding": "base32"}, "unknown encoding"},
+		{"unknown format", map[string]interface{}{"$file": "x", "format": "xml"}, "unknown format"},
+		{"encoding and format", map[string]interface{}{"$file": "x", "encoding": "hex", "format": "text"}, "mutually exclusive"},
+		{"layout without csv", map[string]interface{}{"$file": "x", "layout": "rows"}, "requires format"},
+		{"unknown layout", map[string]interface{}{"$file": "x", "format": "csv", "layout": "table"}, "unknown layout"},
+		{"bad sha256", map[string]interface{}{"$file": "x", "sha256": "abc"}, "64-character"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := parseFileRef(tt.ref)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("parseFileRef() error = %v, want substring %q", err, tt.wantErr)
+			}
+		})
+	}
+}
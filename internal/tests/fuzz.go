@@ -0,0 +1,388 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+
+	"github.com/AndreyAkinshin/structyl/pkg/testhelper"
+)
+
+// FuzzCandidateTag is the tag assigned to saved fuzz candidates.
+const FuzzCandidateTag = "fuzz-candidate"
+
+// fuzzAttemptsPerCase bounds the number of mutation attempts per requested
+// case, so that seeds with few mutable values cannot loop forever.
+const fuzzAttemptsPerCase = 20
+
+// FuzzOptions configures fuzz case generation.
+type FuzzOptions struct {
+	Count int   // Number of cases to generate
+	Seed  int64 // Random seed; the same seeds and options yield the same cases
+}
+
+// FuzzCase is a test case whose input was derived by mutating the input of
+// an existing case.
+type FuzzCase struct {
+	TestCase
+	SeedCase  string   // Name of the case the input was derived from
+	Mutations []string // Applied mutations, e.g. "x[2]: 1.5 → NaN"
+}
+
+// GenerateFuzzCases derives up to opts.Count new cases from the inputs of
+// seeds. Each case applies one or two mutations to a copy of a seed input:
+// numeric perturbations and edge values (0, ±1 steps, extremes, NaN,
+// ±Infinity), array length changes (removed, duplicated, or all elements
+// removed), empty strings, and flipped booleans.
+//
+// Cases are named fuzz-0001, fuzz-0002, and so on. Their expected output is
+// a placeholder copied from the seed case: fuzzing compares targets with
+// each other, not with an expected value. Skipped seeds are ignored, and
+// inputs equal to a seed or an earlier case are not repeated, so fewer than
+// opts.Count cases may be returned.
+func GenerateFuzzCases(seeds []TestCase, opts FuzzOptions) []FuzzCase {
+	var active []TestCase
+	seen := make(map[string]bool)
+	for _, seed := range seeds {
+		if seed.Skip {
+			continue
+		}
+		active = append(active, seed)
+		if key, err := json.Marshal(seed.Input); err == nil {
+			seen[string(key)] = true
+		}
+	}
+	if len(active) == 0 || opts.Count <= 0 {
+		return nil
+	}
+
+	rng := rand.New(rand.NewSource(opts.Seed))
+	var cases []FuzzCase
+	for attempt := 0; len(cases) < opts.Count && attempt < opts.Count*fuzzAttemptsPerCase; attempt++ {
+		seed := active[rng.Intn(len(active))]
+		input, mutations := mutateInput(rng, seed.Input)
+		if len(mutations) == 0 {
+			continue
+		}
+		key, err := json.Marshal(input)
+		if err != nil || seen[string(key)] {
+			continue
+		}
+		seen[string(key)] = true
+
+		cases = append(cases, FuzzCase{
+			TestCase: TestCase{
+				Name:   fmt.Sprintf("fuzz-%04d", len(cases)+1),
+				Suite:  seed.Suite,
+				Input:  input,
+				Output: seed.Output,
+			},
+			SeedCase:  seed.Name,
+			Mutations: mutations,
+		})
+	}
+	return cases
+}
+
+// JSON returns the test case file for fc, as read by target harnesses.
+func (fc FuzzCase) JSON() ([]byte, error) {
+	data, err := json.MarshalIndent(map[string]interface{}{
+		"input":  fc.Input,
+		"output": fc.Output,
+	}, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fc.Name, err)
+	}
+	return append(data, '\n'), nil
+}
+
+// fuzzSlot is a mutable position in a JSON value.
+type fuzzSlot struct {
+	path string
+	get  func() interface{}
+	set  func(interface{})
+}
+
+// mutateInput returns a mutated copy of input and the applied mutations.
+func mutateInput(rng *rand.Rand, input map[string]interface{}) (map[string]interface{}, []string) {
+	root := copyJSONValue(input).(map[string]interface{})
+	var mutations []string
+	for n := 1 + rng.Intn(2); n > 0; n-- {
+		slots := collectSlots(root, "")
+		if len(slots) == 0 {
+			break
+		}
+		slot := slots[rng.Intn(len(slots))]
+		value, desc, ok := mutateValue(rng, slot.get())
+		if !ok {
+			continue
+		}
+		slot.set(value)
+		mutations = append(mutations, slot.path+": "+desc)
+	}
+	return root, mutations
+}
+
+// collectSlots lists every mutable value in v in a deterministic order.
+// Objects are traversed but not mutated themselves.
+func collectSlots(v interface{}, path string) []fuzzSlot {
+	var slots []fuzzSlot
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for _, k := range SortedKeys(val) {
+			k := k
+			childPath := k
+			if path != "" {
+				childPath = path + "." + k
+			}
+			if _, isMap := val[k].(map[string]interface{}); !isMap {
+				slots = append(slots, fuzzSlot{
+					path: childPath,
+					get:  func() interface{} { return val[k] },
+					set:  func(x interface{}) { val[k] = x },
+				})
+			}
+			slots = append(slots, collectSlots(val[k], childPath)...)
+		}
+	case []interface{}:
+		for i := range val {
+			i := i
+			childPath := fmt.Sprintf("%s[%d]", path, i)
+			if _, isMap := val[i].(map[string]interface{}); !isMap {
+				slots = append(slots, fuzzSlot{
+					path: childPath,
+					get:  func() interface{} { return val[i] },
+					set:  func(x interface{}) { val[i] = x },
+				})
+			}
+			slots = append(slots, collectSlots(val[i], childPath)...)
+		}
+	}
+	return slots
+}
+
+// mutateValue returns a mutated replacement for v and a description of the
+// change. Returns false if v cannot be mutated or the mutation is a no-op.
+func mutateValue(rng *rand.Rand, v interface{}) (interface{}, string, bool) {
+	switch val := v.(type) {
+	case float64:
+		return mutateNumber(rng, val)
+	case string:
+		if isSpecialFloat(val) {
+			return 0.0, val + " → 0", true
+		}
+		if val == "" {
+			return nil, "", false
+		}
+		return "", strconv.Quote(val) + ` → ""`, true
+	case bool:
+		return !val, fmt.Sprintf("%v → %v", val, !val), true
+	case []interface{}:
+		if len(val) == 0 {
+			return nil, "", false
+		}
+		i := rng.Intn(len(val))
+		switch rng.Intn(3) {
+		case 0:
+			return []interface{}{}, "emptied", true
+		case 1:
+			out := make([]interface{}, 0, len(val)-1)
+			out = append(out, val[:i]...)
+			out = append(out, val[i+1:]...)
+			return out, fmt.Sprintf("removed element %d", i), true
+		default:
+			out := make([]interface{}, 0, len(val)+1)
+			out = append(out, val[:i+1]...)
+			out = append(out, copyJSONValue(val[i]))
+			out = append(out, val[i+1:]...)
+			return out, fmt.Sprintf("duplicated element %d", i), true
+		}
+	}
+	return nil, "", false
+}
+
+// mutateNumber applies a random numeric perturbation or edge value to x.
+func mutateNumber(rng *rand.Rand, x float64) (interface{}, string, bool) {
+	var result interface{}
+	switch rng.Intn(11) {
+	case 0:
+		result = x + 1
+	case 1:
+		result = x - 1
+	case 2:
+		result = -x
+	case 3:
+		result = 0.0
+	case 4:
+		// Small relative perturbation, between 1e-12 and 1e-3.
+		delta := math.Pow(10, -float64(3+rng.Intn(10)))
+		if rng.Intn(2) == 0 {
+			delta = -delta
+		}
+		if x == 0 {
+			result = delta
+		} else {
+			result = x * (1 + delta)
+		}
+	case 5:
+		result = "NaN"
+	case 6:
+		result = "Infinity"
+	case 7:
+		result = "-Infinity"
+	case 8:
+		result = math.MaxFloat64
+	case 9:
+		result = -math.MaxFloat64
+	default:
+		result = math.SmallestNonzeroFloat64
+	}
+	if f, ok := result.(float64); ok && f == x {
+		return nil, "", false
+	}
+	return result, formatFuzzNumber(x) + " → " + formatFuzzNumber(result), true
+}
+
+func formatFuzzNumber(v interface{}) string {
+	if f, ok := v.(float64); ok {
+		return strconv.FormatFloat(f, 'g', -1, 64)
+	}
+	return fmt.Sprint(v)
+}
+
+// copyJSONValue deep-copies a generic JSON value.
+func copyJSONValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(val))
+		for k, item := range val {
+			out[k] = copyJSONValue(item)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		for i, item := range val {
+			out[i] = copyJSONValue(item)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// FuzzOutcome is what one target produced for a fuzz case: either an output
+// or an error.
+type FuzzOutcome struct {
+	Output interface{} `json:"output,omitempty"`
+	Error  string      `json:"error,omitempty"`
+}
+
+// FuzzDivergence is a fuzz case on which targets disagree.
+type FuzzDivergence struct {
+	Case FuzzCase
+	// Outcomes is keyed by target. Targets that recorded no result for the
+	// case are omitted.
+	Outcomes map[string]FuzzOutcome
+	Reason   string
+}
+
+// FindDivergences compares the outcomes of targets on each fuzz case.
+//
+// results maps each target to the results its harness recorded. A case
+// diverges when some targets produce an output while others fail without
+// one, or when two outputs differ under opts (NaN always matches NaN).
+// Cases with fewer than two recorded outcomes are ignored.
+func FindDivergences(cases []FuzzCase, targets []string, results map[string]map[string]testhelper.CaseResult, opts testhelper.CompareOptions) []FuzzDivergence {
+	var divergences []FuzzDivergence
+	for _, fc := range cases {
+		id := fc.Suite + "/" + fc.Name
+		outcomes := make(map[string]FuzzOutcome)
+		var produced, errored []string
+		for _, target := range targets {
+			r, ok := results[target][id]
+			if !ok || r.Status == testhelper.ResultSkip {
+				continue
+			}
+			if r.Status == testhelper.ResultFail && r.Actual == nil {
+				msg := r.Message
+				if msg == "" {
+					msg = "failed without output"
+				}
+				outcomes[target] = FuzzOutcome{Error: msg}
+				errored = append(errored, target)
+				continue
+			}
+			outcomes[target] = FuzzOutcome{Output: r.Actual}
+			produced = append(produced, target)
+		}
+		if len(outcomes) < 2 {
+			continue
+		}
+
+		reason := ""
+		if len(produced) > 0 && len(errored) > 0 {
+			reason = fmt.Sprintf("output in %s, error in %s", strings.Join(produced, ", "), strings.Join(errored, ", "))
+		} else if len(produced) > 1 {
+			for _, target := range produced[1:] {
+				equal, diff, err := compareActuals(outcomes[produced[0]].Output, outcomes[target].Output, opts)
+				if err != nil {
+					reason = fmt.Sprintf("%s and %s: %v", produced[0], target, err)
+					break
+				}
+				if !equal {
+					reason = fmt.Sprintf("%s and %s produce different outputs: %s", produced[0], target, diff)
+					break
+				}
+			}
+		}
+		if reason != "" {
+			divergences = append(divergences, FuzzDivergence{Case: fc, Outcomes: outcomes, Reason: reason})
+		}
+	}
+	return divergences
+}
+
+// fuzzCandidate is the file format of a saved fuzz candidate.
+type fuzzCandidate struct {
+	Description string                 `json:"description"`
+	Input       map[string]interface{} `json:"input"`
+	Output      interface{}            `json:"output"`
+	Skip        bool                   `json:"skip"`
+	Tags        []string               `json:"tags"`
+	Fuzz        fuzzProvenance         `json:"fuzz"`
+}
+
+type fuzzProvenance struct {
+	Seed      string                 `json:"seed"`
+	Mutations []string               `json:"mutations"`
+	Outcomes  map[string]FuzzOutcome `json:"outcomes"`
+}
+
+// CandidateJSON returns a test case file for triaging the divergence.
+//
+// The candidate is skipped and tagged [FuzzCandidateTag]; its output is the
+// seed's output as a placeholder. The "fuzz" field records the seed case,
+// the mutations, and every target's outcome. Once the correct output is
+// known, replace the output, remove "skip", "tags", and "fuzz", and move the
+// file into the suite.
+func (d FuzzDivergence) CandidateJSON() ([]byte, error) {
+	data, err := json.MarshalIndent(fuzzCandidate{
+		Description: fmt.Sprintf("Fuzz candidate derived from %s: %s", d.Case.SeedCase, d.Reason),
+		Input:       d.Case.Input,
+		Output:      d.Case.Output,
+		Skip:        true,
+		Tags:        []string{FuzzCandidateTag},
+		Fuzz: fuzzProvenance{
+			Seed:      d.Case.SeedCase,
+			Mutations: d.Case.Mutations,
+			Outcomes:  d.Outcomes,
+		},
+	}, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", d.Case.Name, err)
+	}
+	return append(data, '\n'), nil
+}
@@ -0,0 +1,183 @@
+package tests
+
+import (
+	"encoding/json"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/AndreyAkinshin/structyl/pkg/testhelper"
+)
+
+func fuzzSeeds() []TestCase {
+	return []TestCase{
+		{
+			Name:   "basic",
+			Suite:  "stats",
+			Input:  map[string]interface{}{"x": []interface{}{1.0, 2.0, 3.0}, "method": "linear", "opts": map[string]interface{}{"trim": true}},
+			Output: 2.0,
+		},
+		{
+			Name:   "skipped",
+			Suite:  "stats",
+			Input:  map[string]interface{}{"x": []interface{}{"skip-marker"}},
+			Output: 0.0,
+			Skip:   true,
+		},
+	}
+}
+
+func TestGenerateFuzzCases(t *testing.T) {
+	seeds := fuzzSeeds()
+	original, _ := json.Marshal(seeds[0].Input)
+
+	cases := GenerateFuzzCases(seeds, FuzzOptions{Count: 50, Seed: 42})
+	if len(cases) == 0 {
+		t.Fatal("GenerateFuzzCases() returned no cases")
+	}
+	if cases[0].Name != "fuzz-0001" || cases[0].Suite != "stats" {
+		t.Errorf("first case = %s/%s, want stats/fuzz-0001", cases[0].Suite, cases[0].Name)
+	}
+
+	seen := make(map[string]bool)
+	for _, fc := range cases {
+		if fc.SeedCase != "basic" {
+			t.Errorf("%s derived from %q, want basic (skipped seeds are ignored)", fc.Name, fc.SeedCase)
+		}
+		if len(fc.Mutations) == 0 || len(fc.Mutations) > 2 {
+			t.Errorf("%s has %d mutations, want 1 or 2", fc.Name, len(fc.Mutations))
+		}
+		if fc.Output != 2.0 {
+			t.Errorf("%s output = %v, want the seed output", fc.Name, fc.Output)
+		}
+		key, err := json.Marshal(fc.Input)
+		if err != nil {
+			t.Fatalf("%s input is not JSON-encodable: %v", fc.Name, err)
+		}
+		if string(key) == string(original) || seen[string(key)] {
+			t.Errorf("%s repeats an earlier input: %s", fc.Name, key)
+		}
+		seen[string(key)] = true
+	}
+
+	// Seeds must not be modified.
+	if after, _ := json.Marshal(seeds[0].Input); string(after) != string(original) {
+		t.Errorf("seed input modified: %s", after)
+	}
+
+	// The same seed yields the same cases.
+	again := GenerateFuzzCases(seeds, FuzzOptions{Count: 50, Seed: 42})
+	if !reflect.DeepEqual(cases, again) {
+		t.Error("GenerateFuzzCases() is not deterministic for a fixed seed")
+	}
+}
+
+func TestGenerateFuzzCases_NothingToMutate(t *testing.T) {
+	seeds := []TestCase{{Name: "empty", Suite: "s", Input: map[string]interface{}{"x": []interface{}{}}, Output: 0.0}}
+	if cases := GenerateFuzzCases(seeds, FuzzOptions{Count: 5, Seed: 1}); len(cases) != 0 {
+		t.Errorf("GenerateFuzzCases() = %d cases, want 0", len(cases))
+	}
+}
+
+func TestMutateValue(t *testing.T) {
+	rng := rand.New(rand.NewSource(7))
+	for _, v := range []interface{}{1.5, 0.0, "NaN", "abc", true, []interface{}{1.0, 2.0}} {
+		for i := 0; i < 20; i++ {
+			got, desc, ok := mutateValue(rng, v)
+			if !ok {
+				continue
+			}
+			if reflect.DeepEqual(got, v) {
+				t.Errorf("mutateValue(%v) = %v (%s), a no-op", v, got, desc)
+			}
+			if desc == "" {
+				t.Errorf("mutateValue(%v) has no description", v)
+			}
+		}
+	}
+	for _, v := range []interface{}{"", []interface{}{}, nil} {
+		if _, _, ok := mutateValue(rng, v); ok {
+			t.Errorf("mutateValue(%#v) ok = true, want false", v)
+		}
+	}
+}
+
+func TestFindDivergences(t *testing.T) {
+	fc := func(name string) FuzzCase {
+		return FuzzCase{TestCase: TestCase{Name: name, Suite: "s", Input: map[string]interface{}{}}, SeedCase: "basic"}
+	}
+	cases := []FuzzCase{fc("fuzz-0001"), fc("fuzz-0002"), fc("fuzz-0003"), fc("fuzz-0004"), fc("fuzz-0005")}
+	results := map[string]map[string]testhelper.CaseResult{
+		"cs": {
+			"s/fuzz-0001": {Status: testhelper.ResultFail, Actual: 1.0},
+			"s/fuzz-0002": {Status: testhelper.ResultFail, Actual: "NaN"},
+			"s/fuzz-0003": {Status: testhelper.ResultFail, Actual: 1.0},
+			"s/fuzz-0004": {Status: testhelper.ResultFail, Message: "empty input"},
+			"s/fuzz-0005": {Status: testhelper.ResultPass, Actual: 1.0},
+		},
+		"py": {
+			"s/fuzz-0001": {Status: testhelper.ResultFail, Actual: 1.0},
+			"s/fuzz-0002": {Status: testhelper.ResultFail, Actual: "NaN"},
+			"s/fuzz-0003": {Status: testhelper.ResultFail, Actual: 1.5},
+			"s/fuzz-0004": {Status: testhelper.ResultFail, Message: "ValueError"},
+		},
+		"rs": {
+			"s/fuzz-0002": {Status: testhelper.ResultFail, Message: "panicked"},
+		},
+	}
+
+	divergences := FindDivergences(cases, []string{"cs", "py", "rs"}, results, testhelper.DefaultOptions())
+
+	var names []string
+	for _, d := range divergences {
+		names = append(names, d.Case.Name)
+	}
+	if got := strings.Join(names, ","); got != "fuzz-0002,fuzz-0003" {
+		t.Fatalf("divergent cases = %s, want fuzz-0002,fuzz-0003", got)
+	}
+	if want := "output in cs, py, error in rs"; divergences[0].Reason != want {
+		t.Errorf("fuzz-0002 reason = %q, want %q", divergences[0].Reason, want)
+	}
+	if !strings.Contains(divergences[1].Reason, "cs and py produce different outputs") {
+		t.Errorf("fuzz-0003 reason = %q", divergences[1].Reason)
+	}
+	if got := divergences[0].Outcomes["rs"]; got.Error != "panicked" {
+		t.Errorf("rs outcome = %+v, want error", got)
+	}
+}
+
+func TestFuzzDivergence_CandidateJSON(t *testing.T) {
+	d := FuzzDivergence{
+		Case: FuzzCase{
+			TestCase:  TestCase{Name: "fuzz-0001", Suite: "s", Input: map[string]interface{}{"x": "NaN"}, Output: 2.0},
+			SeedCase:  "basic",
+			Mutations: []string{"x: 1 → NaN"},
+		},
+		Outcomes: map[string]FuzzOutcome{"cs": {Output: "NaN"}, "py": {Error: "bad input"}},
+		Reason:   "output in cs, error in py",
+	}
+	data, err := d.CandidateJSON()
+	if err != nil {
+		t.Fatalf("CandidateJSON() error = %v", err)
+	}
+
+	var got map[string]interface{}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("candidate is not valid JSON: %v\n%s", err, data)
+	}
+	if got["skip"] != true || !reflect.DeepEqual(got["tags"], []interface{}{FuzzCandidateTag}) {
+		t.Errorf("candidate skip/tags = %v/%v", got["skip"], got["tags"])
+	}
+	if got["output"] != 2.0 {
+		t.Errorf("candidate output = %v, want the seed output", got["output"])
+	}
+	fuzz := got["fuzz"].(map[string]interface{})
+	if fuzz["seed"] != "basic" {
+		t.Errorf("fuzz.seed = %v, want basic", fuzz["seed"])
+	}
+	outcomes := fuzz["outcomes"].(map[string]interface{})
+	if !reflect.DeepEqual(outcomes["py"], map[string]interface{}{"error": "bad input"}) {
+		t.Errorf("fuzz.outcomes.py = %v", outcomes["py"])
+	}
+}
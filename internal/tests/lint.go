@@ -0,0 +1,348 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/AndreyAkinshin/structyl/internal/schema"
+	"github.com/AndreyAkinshin/structyl/pkg/testhelper"
+)
+
+// LintSeverity classifies a lint issue.
+type LintSeverity string
+
+const (
+	// LintError marks an issue that makes the suite fail to load or run correctly.
+	LintError LintSeverity = "error"
+	// LintWarning marks a hygiene issue that does not break loading.
+	LintWarning LintSeverity = "warning"
+)
+
+// Lint rule identifiers.
+const (
+	RuleInvalidJSON      = "invalid-json"
+	RuleSchema           = "schema"
+	RuleMatrix           = "matrix"
+	RuleInvalidName      = "invalid-name"
+	RuleDuplicateInput   = "duplicate-input"
+	RuleMissingFile      = "missing-file"
+	RuleUnreferencedFile = "unreferenced-file"
+	RuleAllSkipped       = "all-skipped"
+	RuleReservedField    = "reserved-field"
+	RuleFormatting       = "formatting"
+)
+
+// reservedFields are test case fields reserved for future specification versions.
+var reservedFields = []string{"timeout", "setup", "teardown"}
+
+// LintIssue is a single finding reported by Lint.
+type LintIssue struct {
+	Severity LintSeverity `json:"severity"`
+	Rule     string       `json:"rule"`
+	Suite    string       `json:"suite"`
+	// Path is relative to the tests directory, using forward slashes.
+	Path    string `json:"path,omitempty"`
+	Message string `json:"message"`
+	// Fixed is true when the issue was corrected by LintOptions.Fix.
+	Fixed bool `json:"fixed,omitempty"`
+}
+
+// LintOptions configures Lint.
+type LintOptions struct {
+	// Pattern selects test case files (same semantics as tests.pattern).
+	Pattern string
+	// Fix applies safe mechanical fixes (canonical JSON formatting).
+	Fix bool
+}
+
+// LintReport is the result of linting a tests directory.
+type LintReport struct {
+	Suites int         `json:"suites"`
+	Files  int         `json:"files"`
+	Issues []LintIssue `json:"issues"`
+}
+
+// Errors returns the number of error-severity issues that were not fixed.
+func (r *LintReport) Errors() int {
+	return r.count(LintError)
+}
+
+// Warnings returns the number of warning-severity issues that were not fixed.
+func (r *LintReport) Warnings() int {
+	return r.count(LintWarning)
+}
+
+func (r *LintReport) count(severity LintSeverity) int {
+	n := 0
+	for _, issue := range r.Issues {
+		if issue.Severity == severity && !issue.Fixed {
+			n++
+		}
+	}
+	return n
+}
+
+// Lint statically checks every suite in testsDir.
+//
+// Checks performed:
+//   - each case is valid JSON and conforms to schema/testcase.schema.json
+//   - $matrix templates expand without errors
+//   - case names pass testhelper.ValidateTestCaseName
+//   - no two cases in a suite share the same input
+//   - every $file target exists, and every non-case file is referenced
+//   - suites are not composed entirely of skipped cases
+//   - cases do not use the reserved timeout/setup/teardown fields
+//   - case files use canonical formatting (2-space indent, trailing newline)
+//
+// Returns an error only if the tests directory cannot be read.
+func Lint(testsDir string, opts LintOptions) (*LintReport, error) {
+	if opts.Pattern == "" {
+		opts.Pattern = "**/*.json"
+	}
+
+	entries, err := os.ReadDir(testsDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tests directory: %w", err)
+	}
+
+	report := &LintReport{Issues: []LintIssue{}}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		report.Suites++
+		if err := lintSuite(testsDir, entry.Name(), opts, report); err != nil {
+			return nil, err
+		}
+	}
+
+	return report, nil
+}
+
+// lintCase is a loaded case used for suite-level checks.
+type lintCase struct {
+	name  string
+	path  string
+	input string // canonical JSON of the raw input
+	skip  bool
+}
+
+// lintSuite lints a single suite directory, appending issues to report.
+func lintSuite(testsDir, suite string, opts LintOptions, report *LintReport) error {
+	suiteDir := filepath.Join(testsDir, suite)
+	files, err := findMatches(suiteDir, opts.Pattern)
+	if err != nil {
+		return err
+	}
+
+	rel := func(path string) string {
+		r, err := filepath.Rel(testsDir, path)
+		if err != nil {
+			return path
+		}
+		return filepath.ToSlash(r)
+	}
+	add := func(severity LintSeverity, rule, path, format string, args ...interface{}) *LintIssue {
+		report.Issues = append(report.Issues, LintIssue{
+			Severity: severity,
+			Rule:     rule,
+			Suite:    suite,
+			Path:     path,
+			Message:  fmt.Sprintf(format, args...),
+		})
+		return &report.Issues[len(report.Issues)-1]
+	}
+
+	caseFiles := make(map[string]bool, len(files))
+	referenced := make(map[string]bool)
+	var cases []lintCase
+
+	for _, path := range files {
+		report.Files++
+		caseFiles[path] = true
+		relPath := rel(path)
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		var raw map[string]interface{}
+		if err := json.Unmarshal(data, &raw); err != nil {
+			add(LintError, RuleInvalidJSON, relPath, "invalid JSON: %v", err)
+			continue
+		}
+
+		if canonical := canonicalJSON(data); !bytes.Equal(canonical, data) {
+			issue := add(LintWarning, RuleFormatting, relPath, "not canonically formatted (2-space indent, trailing newline)")
+			if opts.Fix {
+				if err := os.WriteFile(path, canonical, 0644); err != nil {
+					return fmt.Errorf("failed to fix %s: %w", relPath, err)
+				}
+				issue.Fixed = true
+			}
+		}
+
+		for _, field := range reservedFields {
+			if _, ok := raw[field]; ok {
+				add(LintWarning, RuleReservedField, relPath, "field %q is reserved for future use", field)
+			}
+		}
+
+		baseName := strings.TrimSuffix(filepath.Base(path), ".json")
+		docs := []testhelper.ExpandedCase{{Name: baseName, Data: data}}
+		if testhelper.IsCaseTemplate(data) {
+			expanded, err := testhelper.ExpandCaseTemplate(baseName, data)
+			if err != nil {
+				add(LintError, RuleMatrix, relPath, "%v", err)
+				continue
+			}
+			docs = expanded
+		}
+
+		for _, doc := range docs {
+			if err := testhelper.ValidateTestCaseName(doc.Name); err != nil {
+				add(LintError, RuleInvalidName, relPath, "%v", err)
+			}
+			if err := schema.ValidateTestCase(doc.Data); err != nil {
+				add(LintError, RuleSchema, relPath, "%s%s", casePrefix(doc.Name, baseName), schemaMessage(err))
+				continue
+			}
+
+			var parsed map[string]interface{}
+			if err := json.Unmarshal(doc.Data, &parsed); err != nil {
+				add(LintError, RuleInvalidJSON, relPath, "%s%v", casePrefix(doc.Name, baseName), err)
+				continue
+			}
+
+			for _, ref := range collectFileRefs(parsed) {
+				target := filepath.Join(filepath.Dir(path), filepath.FromSlash(ref))
+				referenced[filepath.Clean(target)] = true
+				if _, err := os.Stat(target); err != nil {
+					add(LintError, RuleMissingFile, relPath, "%s$file %q not found", casePrefix(doc.Name, baseName), ref)
+				}
+			}
+
+			inputJSON, _ := json.Marshal(parsed["input"])
+			skip, _ := parsed["skip"].(bool)
+			cases = append(cases, lintCase{name: doc.Name, path: relPath, input: string(inputJSON), skip: skip})
+		}
+	}
+
+	// Duplicate inputs within the suite.
+	byInput := make(map[string][]lintCase)
+	for _, c := range cases {
+		byInput[c.input] = append(byInput[c.input], c)
+	}
+	for _, c := range cases {
+		dups := byInput[c.input]
+		if len(dups) < 2 || dups[0].name != c.name {
+			continue
+		}
+		names := make([]string, len(dups))
+		for i, d := range dups {
+			names[i] = d.name
+		}
+		add(LintWarning, RuleDuplicateInput, c.path, "cases have identical input: %s", strings.Join(names, ", "))
+	}
+
+	// Suites containing only skipped cases never exercise anything.
+	if len(cases) > 0 {
+		allSkipped := true
+		for _, c := range cases {
+			if !c.skip {
+				allSkipped = false
+				break
+			}
+		}
+		if allSkipped {
+			add(LintWarning, RuleAllSkipped, "", "all %d case(s) are skipped", len(cases))
+		}
+	}
+
+	// Data files that no case references.
+	var unreferenced []string
+	err = filepath.Walk(suiteDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || caseFiles[path] || referenced[filepath.Clean(path)] {
+			return nil
+		}
+		unreferenced = append(unreferenced, path)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	sort.Strings(unreferenced)
+	for _, path := range unreferenced {
+		add(LintWarning, RuleUnreferencedFile, rel(path), "file is not referenced by any $file reference")
+	}
+
+	return nil
+}
+
+// canonicalJSON returns data re-indented with two spaces and a trailing newline.
+// Key order is preserved. Returns data unchanged if it is not valid JSON.
+func canonicalJSON(data []byte) []byte {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, bytes.TrimSpace(data), "", "  "); err != nil {
+		return data
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes()
+}
+
+// collectFileRefs returns all $file paths referenced within value.
+func collectFileRefs(value interface{}) []string {
+	var refs []string
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if ref, ok := v["$file"].(string); ok {
+			return []string{ref}
+		}
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			refs = append(refs, collectFileRefs(v[key])...)
+		}
+	case []interface{}:
+		for _, item := range v {
+			refs = append(refs, collectFileRefs(item)...)
+		}
+	}
+	return refs
+}
+
+// casePrefix returns a "case <name>: " prefix for expanded cases.
+func casePrefix(name, baseName string) string {
+	if name == baseName {
+		return ""
+	}
+	return fmt.Sprintf("case %q: ", name)
+}
+
+// schemaMessage condenses a multi-line schema validation error into one line.
+func schemaMessage(err error) string {
+	lines := strings.Split(err.Error(), "\n")
+	var details []string
+	for _, line := range lines[1:] {
+		line = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "- "))
+		if line != "" {
+			details = append(details, line)
+		}
+	}
+	if len(details) == 0 {
+		return err.Error()
+	}
+	return "schema violation: " + strings.Join(details, "; ")
+}
@@ -0,0 +1,154 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+func writeSuiteFile(t *testing.T, testsDir, rel, content string) string {
+	t.Helper()
+	path := filepath.Join(testsDir, filepath.FromSlash(rel))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func lintRules(report *LintReport) []string {
+	var rules []string
+	for _, issue := range report.Issues {
+		rules = append(rules, issue.Rule)
+	}
+	sort.Strings(rules)
+	return rules
+}
+
+func TestLint_CleanSuite_NoIssues(t *testing.T) {
+	t.Parallel()
+	testsDir := t.TempDir()
+	writeSuiteFile(t, testsDir, "math/add.json", "{\n  \"input\": {\n    \"a\": 1\n  },\n  \"output\": 2\n}\n")
+	writeSuiteFile(t, testsDir, "math/data.csv", "x\n1\n")
+	writeSuiteFile(t, testsDir, "math/sum.json", "{\n  \"input\": {\n    \"x\": {\n      \"$file\": \"data.csv\",\n      \"format\": \"csv\"\n    }\n  },\n  \"output\": 1\n}\n")
+
+	report, err := Lint(testsDir, LintOptions{})
+	if err != nil {
+		t.Fatalf("Lint() error = %v", err)
+	}
+	if len(report.Issues) != 0 {
+		t.Errorf("Issues = %+v, want none", report.Issues)
+	}
+	if report.Suites != 1 || report.Files != 2 {
+		t.Errorf("Suites = %d, Files = %d, want 1, 2", report.Suites, report.Files)
+	}
+}
+
+func TestLint_ReportsIssues(t *testing.T) {
+	t.Parallel()
+	testsDir := t.TempDir()
+	canonical := func(body string) string { return body + "\n" }
+	writeSuiteFile(t, testsDir, "s/a.json", canonical(`{
+  "input": {
+    "x": 1
+  },
+  "output": 1,
+  "skip": true,
+  "timeout": 5
+}`))
+	writeSuiteFile(t, testsDir, "s/b.json", canonical(`{
+  "input": {
+    "x": 1
+  },
+  "output": 2,
+  "skip": true
+}`))
+	writeSuiteFile(t, testsDir, "s/c.json", canonical(`{
+  "input": {
+    "y": {
+      "$file": "missing.bin"
+    }
+  },
+  "output": null
+}`))
+	writeSuiteFile(t, testsDir, "s/orphan.bin", "data")
+	writeSuiteFile(t, testsDir, "s/bad.json", `{`)
+
+	report, err := Lint(testsDir, LintOptions{})
+	if err != nil {
+		t.Fatalf("Lint() error = %v", err)
+	}
+
+	got := lintRules(report)
+	want := []string{RuleAllSkipped, RuleDuplicateInput, RuleInvalidJSON, RuleReservedField, RuleSchema, RuleUnreferencedFile}
+	sort.Strings(want)
+	if len(got) != len(want) {
+		t.Fatalf("rules = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("rules = %v, want %v", got, want)
+			break
+		}
+	}
+	if report.Errors() != 2 {
+		t.Errorf("Errors() = %d, want 2", report.Errors())
+	}
+}
+
+func TestLint_MissingFileReference(t *testing.T) {
+	t.Parallel()
+	testsDir := t.TempDir()
+	writeSuiteFile(t, testsDir, "s/a.json", "{\n  \"input\": {\n    \"y\": {\n      \"$file\": \"missing.bin\"\n    }\n  },\n  \"output\": 1\n}\n")
+
+	report, err := Lint(testsDir, LintOptions{})
+	if err != nil {
+		t.Fatalf("Lint() error = %v", err)
+	}
+	if rules := lintRules(report); len(rules) != 1 || rules[0] != RuleMissingFile {
+		t.Errorf("rules = %v, want [%s]", rules, RuleMissingFile)
+	}
+}
+
+func TestLint_Fix_RewritesFormatting(t *testing.T) {
+	t.Parallel()
+	testsDir := t.TempDir()
+	path := writeSuiteFile(t, testsDir, "s/a.json", `{"input":{"b":1,"a":2},"output":3}`)
+
+	report, err := Lint(testsDir, LintOptions{Fix: true})
+	if err != nil {
+		t.Fatalf("Lint() error = %v", err)
+	}
+	if len(report.Issues) != 1 || report.Issues[0].Rule != RuleFormatting || !report.Issues[0].Fixed {
+		t.Fatalf("Issues = %+v, want one fixed formatting issue", report.Issues)
+	}
+	if report.Warnings() != 0 {
+		t.Errorf("Warnings() = %d, want 0 after fix", report.Warnings())
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "{\n  \"input\": {\n    \"b\": 1,\n    \"a\": 2\n  },\n  \"output\": 3\n}\n"
+	if string(data) != want {
+		t.Errorf("fixed file =\n%s\nwant\n%s", data, want)
+	}
+}
+
+func TestLint_MatrixTemplate_ChecksExpandedCases(t *testing.T) {
+	t.Parallel()
+	testsDir := t.TempDir()
+	writeSuiteFile(t, testsDir, "s/q.json", "{\n  \"$matrix\": {\n    \"p\": [\n      1,\n      1\n    ]\n  },\n  \"input\": {},\n  \"output\": 1\n}\n")
+
+	report, err := Lint(testsDir, LintOptions{})
+	if err != nil {
+		t.Fatalf("Lint() error = %v", err)
+	}
+	if rules := lintRules(report); len(rules) != 1 || rules[0] != RuleMatrix {
+		t.Errorf("rules = %v, want [%s]", rules, RuleMatrix)
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
+
+	"github.com/AndreyAkinshin/structyl/pkg/testhelper"
 )
 
 // LoadTestSuite loads all test cases from a suite directory.
@@ -23,21 +25,20 @@ func LoadTestSuite(testsDir, suite, pattern string) ([]TestCase, error) {
 		return nil, err
 	}
 
+	// Cases in suite order, as in every harness.
+	testhelper.SortCaseFiles(matches)
 	var cases []TestCase
 	for _, path := range matches {
-		tc, err := LoadTestCase(path)
+		loaded, err := LoadTestCases(path)
 		if err != nil {
 			return nil, fmt.Errorf("test suite %q: %w (file: %s)", suite, err, path)
 		}
-		tc.Suite = suite
-		cases = append(cases, *tc)
+		for _, tc := range loaded {
+			tc.Suite = suite
+			cases = append(cases, tc)
+		}
 	}
 
-	// Sort by name for deterministic order
-	sort.Slice(cases, func(i, j int) bool {
-		return cases[i].Name < cases[j].Name
-	})
-
 	return cases, nil
 }
 
@@ -69,12 +70,56 @@ func LoadAllSuites(testsDir, pattern string) (map[string][]TestCase, error) {
 }
 
 // LoadTestCase loads a single test case from a JSON file.
+// Case templates (files with a "$matrix" field) expand into several cases
+// and must be loaded with LoadTestCases instead.
 func LoadTestCase(path string) (*TestCase, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, err
 	}
+	if testhelper.IsCaseTemplate(data) {
+		return nil, fmt.Errorf("file is a %s case template; use LoadTestCases", testhelper.MatrixKey)
+	}
+	return parseTestCase(data, strings.TrimSuffix(filepath.Base(path), ".json"), path)
+}
+
+// LoadTestCases loads all test cases defined by a JSON file.
+// A regular case file yields a single case. A case template (a file with a
+// top-level "$matrix" field) yields one case per matrix combination, named
+// "<file>[k1=v1,k2=v2]" in matrix order.
+func LoadTestCases(path string) ([]TestCase, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	baseName := strings.TrimSuffix(filepath.Base(path), ".json")
+	if !testhelper.IsCaseTemplate(data) {
+		tc, err := parseTestCase(data, baseName, path)
+		if err != nil {
+			return nil, err
+		}
+		return []TestCase{*tc}, nil
+	}
+
+	expanded, err := testhelper.ExpandCaseTemplate(baseName, data)
+	if err != nil {
+		return nil, err
+	}
+	cases := make([]TestCase, 0, len(expanded))
+	for _, c := range expanded {
+		tc, err := parseTestCase(c.Data, c.Name, path)
+		if err != nil {
+			return nil, fmt.Errorf("case %q: %w", c.Name, err)
+		}
+		cases = append(cases, *tc)
+	}
+	return cases, nil
+}
 
+// parseTestCase parses test case JSON read from path.
+// The path is used for $file resolution and recorded on the result.
+func parseTestCase(data []byte, name, path string) (*TestCase, error) {
 	var raw map[string]interface{}
 	if err := json.Unmarshal(data, &raw); err != nil {
 		return nil, fmt.Errorf("invalid JSON: %w", err)
@@ -92,7 +137,7 @@ func LoadTestCase(path string) (*TestCase, error) {
 
 	// Resolve $file references
 	baseDir := filepath.Dir(path)
-	input, err = resolveFileRefs(input, baseDir)
+	input, err := resolveFileRefs(input, baseDir)
 	if err != nil {
 		return nil, fmt.Errorf("input: %w", err)
 	}
@@ -106,11 +151,23 @@ func LoadTestCase(path string) (*TestCase, error) {
 		return nil, fmt.Errorf("\"input\" must be an object")
 	}
 
+	skip, _ := raw["skip"].(bool)
+	var tags []string
+	if rawTags, ok := raw["tags"].([]interface{}); ok {
+		for _, tag := range rawTags {
+			if s, ok := tag.(string); ok {
+				tags = append(tags, s)
+			}
+		}
+	}
+
 	return &TestCase{
-		Name:   strings.TrimSuffix(filepath.Base(path), ".json"),
+		Name:   name,
 		Path:   path,
 		Input:  inputMap,
 		Output: output,
+		Skip:   skip,
+		Tags:   tags,
 	}, nil
 }
 
@@ -185,8 +242,12 @@ func resolveFileRefs(value interface{}, baseDir string) (interface{}, error) {
 	switch v := value.(type) {
 	case map[string]interface{}:
 		// Check if this is a $file reference
-		if fileRef, ok := v["$file"].(string); ok {
-			return loadFileRef(fileRef, baseDir)
+		if _, ok := v["$file"]; ok {
+			ref, err := parseFileRef(v)
+			if err != nil {
+				return nil, err
+			}
+			return loadFileRef(ref, baseDir)
 		}
 
 		// Recursively resolve nested values
@@ -216,14 +277,15 @@ func resolveFileRefs(value interface{}, baseDir string) (interface{}, error) {
 	}
 }
 
-// loadFileRef loads a file referenced by $file.
-func loadFileRef(ref, baseDir string) (interface{}, error) {
+// loadFileRef loads a file referenced by $file and decodes it according to
+// the reference's encoding or format (see fileRef.decode).
+func loadFileRef(ref fileRef, baseDir string) (interface{}, error) {
 	// Security: prevent path traversal
-	if strings.Contains(ref, "..") {
-		return nil, fmt.Errorf("$file path contains \"..\": %s", ref)
+	if strings.Contains(ref.Path, "..") {
+		return nil, fmt.Errorf("$file path contains \"..\": %s", ref.Path)
 	}
 
-	path := filepath.Join(baseDir, ref)
+	path := filepath.Join(baseDir, ref.Path)
 
 	// Verify the resolved path is still within baseDir
 	absBase, err := filepath.Abs(baseDir)
@@ -235,21 +297,16 @@ func loadFileRef(ref, baseDir string) (interface{}, error) {
 		return nil, err
 	}
 	if !strings.HasPrefix(absPath, absBase) {
-		return nil, fmt.Errorf("$file path escapes test directory: %s", ref)
+		return nil, fmt.Errorf("$file path escapes test directory: %s", ref.Path)
 	}
 
 	data, err := os.ReadFile(path)
 	if err != nil {
-		return nil, fmt.Errorf("$file %q: %w", ref, err)
+		return nil, fmt.Errorf("$file %q: %w", ref.Path, err)
 	}
 
-	// If content is valid JSON, return parsed structure.
-	// Otherwise, return raw content as string (callers expecting binary
-	// data must handle encoding themselves).
-	var jsonValue interface{}
-	if err := json.Unmarshal(data, &jsonValue); err == nil {
-		return jsonValue, nil
+	if err := ref.verifyChecksum(data); err != nil {
+		return nil, err
 	}
-
-	return string(data), nil
+	return ref.decode(data)
 }
@@ -4,6 +4,8 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+
+	"github.com/AndreyAkinshin/structyl/pkg/testhelper"
 )
 
 func TestLoadTestSuite_ValidSuite_ReturnsTestCases(t *testing.T) {
@@ -270,7 +272,7 @@ func TestLoadFileRef_PathTraversal_ReturnsError(t *testing.T) {
 	t.Parallel()
 	tmpDir := t.TempDir()
 
-	_, err := loadFileRef("../escape.json", tmpDir)
+	_, err := loadFileRef(fileRef{Path: "../escape.json"}, tmpDir)
 	if err == nil {
 		t.Error("loadFileRef() expected error for path traversal")
 	}
@@ -286,7 +288,7 @@ func TestLoadFileRef_EscapesBaseDir_ReturnsError(t *testing.T) {
 
 	// Even without "..", try to escape via symlink-like path
 	// This test verifies the absolute path check
-	_, err := loadFileRef("../escape.txt", subDir)
+	_, err := loadFileRef(fileRef{Path: "../escape.txt"}, subDir)
 	if err == nil {
 		t.Error("loadFileRef() expected error for path escaping base directory")
 	}
@@ -300,7 +302,7 @@ func TestLoadFileRef_ValidJSONFile_ParsesAsJSON(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	result, err := loadFileRef("data.json", tmpDir)
+	result, err := loadFileRef(fileRef{Path: "data.json"}, tmpDir)
 	if err != nil {
 		t.Fatalf("loadFileRef() error = %v", err)
 	}
@@ -322,7 +324,7 @@ func TestLoadFileRef_NonJSONFile_ReturnsString(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	result, err := loadFileRef("data.txt", tmpDir)
+	result, err := loadFileRef(fileRef{Path: "data.txt"}, tmpDir)
 	if err != nil {
 		t.Fatalf("loadFileRef() error = %v", err)
 	}
@@ -340,7 +342,7 @@ func TestLoadFileRef_FileNotFound_ReturnsError(t *testing.T) {
 	t.Parallel()
 	tmpDir := t.TempDir()
 
-	_, err := loadFileRef("nonexistent.json", tmpDir)
+	_, err := loadFileRef(fileRef{Path: "nonexistent.json"}, tmpDir)
 	if err == nil {
 		t.Error("loadFileRef() expected error for missing file")
 	}
@@ -530,3 +532,114 @@ func TestFindMatches_DoubleStarPattern_FindsNestedFiles(t *testing.T) {
 		}
 	}
 }
+
+func TestLoadTestSuite_MatrixTemplate_ExpandsCases(t *testing.T) {
+	t.Parallel()
+	tmpDir := t.TempDir()
+	suiteDir := filepath.Join(tmpDir, "suite1")
+	if err := os.MkdirAll(suiteDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	template := `{
+		"$matrix": [{"n": 1, "$output": 2}, {"n": 2, "$output": 4}],
+		"input": {"n": {"$param": "n"}},
+		"output": 0
+	}`
+	if err := os.WriteFile(filepath.Join(suiteDir, "double.json"), []byte(template), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(suiteDir, "add.json"), []byte(`{"input": {}, "output": 1}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cases, err := LoadTestSuite(tmpDir, "suite1", "*.json")
+	if err != nil {
+		t.Fatalf("LoadTestSuite() error = %v", err)
+	}
+
+	want := []string{"add", "double[n=1]", "double[n=2]"}
+	if len(cases) != len(want) {
+		t.Fatalf("len(cases) = %d, want %d", len(cases), len(want))
+	}
+	for i, name := range want {
+		if cases[i].Name != name {
+			t.Errorf("cases[%d].Name = %q, want %q", i, cases[i].Name, name)
+		}
+	}
+	if cases[2].Input["n"] != 2.0 || cases[2].Output != 4.0 {
+		t.Errorf("cases[2] = %v -> %v, want n=2 -> 4", cases[2].Input, cases[2].Output)
+	}
+}
+
+func TestLoadTestSuite_OrderMatchesTesthelper(t *testing.T) {
+	t.Parallel()
+	tmpDir := t.TempDir()
+	suiteDir := filepath.Join(tmpDir, "suite1")
+	if err := os.MkdirAll(suiteDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"q.json":   `{"$matrix": {"n": [10, 100]}, "input": {"n": {"$param": "n"}}, "output": 0}`,
+		"q-b.json": `{"input": {}, "output": 1}`,
+		"a.json":   `{"input": {}, "output": 1}`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(suiteDir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Files by name without extension; a template's cases in matrix order.
+	want := []string{"a", "q[n=10]", "q[n=100]", "q-b"}
+	cases, err := LoadTestSuite(tmpDir, "suite1", "*.json")
+	if err != nil {
+		t.Fatalf("LoadTestSuite() error = %v", err)
+	}
+	harnessCases, err := testhelper.LoadTestSuiteFromDir(tmpDir, "suite1")
+	if err != nil {
+		t.Fatalf("testhelper.LoadTestSuiteFromDir() error = %v", err)
+	}
+	if len(cases) != len(want) || len(harnessCases) != len(want) {
+		t.Fatalf("got %d and %d cases, want %d", len(cases), len(harnessCases), len(want))
+	}
+	for i, name := range want {
+		if cases[i].Name != name || harnessCases[i].Name != name {
+			t.Errorf("cases[%d] = %q (testhelper %q), want %q", i, cases[i].Name, harnessCases[i].Name, name)
+		}
+	}
+}
+
+func TestLoadTestCase_MatrixTemplate_ReturnsError(t *testing.T) {
+	t.Parallel()
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "tpl.json")
+	if err := os.WriteFile(path, []byte(`{"$matrix": {"n": [1]}, "input": {}, "output": 1}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := LoadTestCase(path); err == nil {
+		t.Error("LoadTestCase() expected error for case template")
+	}
+}
+
+func TestLoadTestCase_SkipAndTags_Parsed(t *testing.T) {
+	t.Parallel()
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "tagged.json")
+	content := `{"input": {}, "output": 1, "skip": true, "tags": ["slow", "skip-ci"]}`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tc, err := LoadTestCase(path)
+	if err != nil {
+		t.Fatalf("LoadTestCase() error = %v", err)
+	}
+	if !tc.Skip {
+		t.Error("Skip = false, want true")
+	}
+	if len(tc.Tags) != 2 || tc.Tags[0] != "slow" || tc.Tags[1] != "skip-ci" {
+		t.Errorf("Tags = %v, want [slow skip-ci]", tc.Tags)
+	}
+}
@@ -0,0 +1,233 @@
+package tests
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/AndreyAkinshin/structyl/pkg/testhelper"
+)
+
+// MatrixStatus is the status of one case in one target of a conformance matrix.
+type MatrixStatus string
+
+const (
+	MatrixPass    MatrixStatus = "pass"    // Harness reported a match
+	MatrixFail    MatrixStatus = "fail"    // Harness reported an error or mismatch
+	MatrixSkip    MatrixStatus = "skip"    // Case is marked skip
+	MatrixMissing MatrixStatus = "missing" // Harness recorded no result for the case
+)
+
+// MatrixRow holds the results of one test case across targets.
+type MatrixRow struct {
+	ID      string                  `json:"id"`
+	Results map[string]MatrixStatus `json:"results"`
+	// Disagreement is true if the targets that ran the case disagree with
+	// each other: some pass while others fail, or all fail with different
+	// actual outputs.
+	Disagreement bool   `json:"disagreement"`
+	Reason       string `json:"reason,omitempty"`
+}
+
+// MatrixSummary counts the statuses of one target.
+type MatrixSummary struct {
+	Pass    int `json:"pass"`
+	Fail    int `json:"fail"`
+	Skip    int `json:"skip"`
+	Missing int `json:"missing"`
+}
+
+// Matrix is a cross-target conformance report: test case × target.
+type Matrix struct {
+	Targets       []string                 `json:"targets"`
+	Cases         []MatrixRow              `json:"cases"`
+	Summary       map[string]MatrixSummary `json:"summary"`
+	Disagreements int                      `json:"disagreements"`
+}
+
+// BuildMatrix builds a conformance matrix.
+//
+// caseIDs lists the cases expected to run (see [testhelper.TestCase.ID]);
+// results maps each target to the results its harness recorded. Cases that
+// appear only in results are included as well. Actual outputs of failing
+// targets are compared with each other using opts.
+func BuildMatrix(caseIDs []string, targets []string, results map[string]map[string]testhelper.CaseResult, opts testhelper.CompareOptions) *Matrix {
+	ids := make(map[string]bool, len(caseIDs))
+	for _, id := range caseIDs {
+		ids[id] = true
+	}
+	for _, target := range targets {
+		for id := range results[target] {
+			ids[id] = true
+		}
+	}
+
+	m := &Matrix{
+		Targets: targets,
+		Summary: make(map[string]MatrixSummary, len(targets)),
+	}
+	for _, id := range sortedSet(ids) {
+		row := MatrixRow{ID: id, Results: make(map[string]MatrixStatus, len(targets))}
+		for _, target := range targets {
+			status := MatrixMissing
+			if r, ok := results[target][id]; ok {
+				status = MatrixStatus(r.Status)
+			}
+			row.Results[target] = status
+
+			s := m.Summary[target]
+			switch status {
+			case MatrixPass:
+				s.Pass++
+			case MatrixFail:
+				s.Fail++
+			case MatrixSkip:
+				s.Skip++
+			default:
+				s.Missing++
+			}
+			m.Summary[target] = s
+		}
+		row.Disagreement, row.Reason = disagreement(id, targets, row.Results, results, opts)
+		if row.Disagreement {
+			m.Disagreements++
+		}
+		m.Cases = append(m.Cases, row)
+	}
+	return m
+}
+
+// Failures returns the number of failing cells in the matrix.
+func (m *Matrix) Failures() int {
+	n := 0
+	for _, s := range m.Summary {
+		n += s.Fail
+	}
+	return n
+}
+
+// disagreement reports whether the targets that ran a case disagree.
+func disagreement(id string, targets []string, statuses map[string]MatrixStatus, results map[string]map[string]testhelper.CaseResult, opts testhelper.CompareOptions) (bool, string) {
+	var passed, failed []string
+	for _, target := range targets {
+		switch statuses[target] {
+		case MatrixPass:
+			passed = append(passed, target)
+		case MatrixFail:
+			failed = append(failed, target)
+		}
+	}
+	if len(passed) > 0 && len(failed) > 0 {
+		return true, fmt.Sprintf("passes in %s, fails in %s", strings.Join(passed, ", "), strings.Join(failed, ", "))
+	}
+	if len(passed) > 0 || len(failed) < 2 {
+		return false, ""
+	}
+
+	// Every target fails: check whether they at least agree on the output.
+	ref := ""
+	for _, target := range failed {
+		actual := results[target][id].Actual
+		if actual == nil {
+			continue
+		}
+		if ref == "" {
+			ref = target
+			continue
+		}
+		equal, diff, err := compareActuals(results[ref][id].Actual, actual, opts)
+		if err != nil {
+			return true, fmt.Sprintf("%s and %s: %v", ref, target, err)
+		}
+		if !equal {
+			return true, fmt.Sprintf("%s and %s produce different outputs: %s", ref, target, diff)
+		}
+	}
+	return false, ""
+}
+
+// compareActuals compares two recorded outputs. Recorded outputs use string
+// forms for special floats, so a is compared as an expected value, and NaN
+// always matches NaN.
+func compareActuals(a, b interface{}, opts testhelper.CompareOptions) (bool, string, error) {
+	opts.NaNEqualsNaN = true
+	return testhelper.CompareE(a, decodeSpecialFloats(b), opts)
+}
+
+// Markdown renders the matrix as a Markdown table. Disagreeing cases are
+// marked with "⚠" and listed with their reasons below the table.
+func (m *Matrix) Markdown() string {
+	var b strings.Builder
+	b.WriteString("| Case |")
+	for _, target := range m.Targets {
+		fmt.Fprintf(&b, " %s |", target)
+	}
+	b.WriteString("\n| --- |")
+	for range m.Targets {
+		b.WriteString(" --- |")
+	}
+	b.WriteString("\n")
+
+	for _, row := range m.Cases {
+		id := "`" + row.ID + "`"
+		if row.Disagreement {
+			id = "⚠ " + id
+		}
+		fmt.Fprintf(&b, "| %s |", id)
+		for _, target := range m.Targets {
+			fmt.Fprintf(&b, " %s |", row.Results[target])
+		}
+		b.WriteString("\n")
+	}
+
+	if m.Disagreements > 0 {
+		b.WriteString("\n**Disagreements:**\n\n")
+		for _, row := range m.Cases {
+			if row.Disagreement {
+				fmt.Fprintf(&b, "- `%s`: %s\n", row.ID, row.Reason)
+			}
+		}
+	}
+	return b.String()
+}
+
+// decodeSpecialFloats replaces "NaN", "Infinity", and "-Infinity" strings in
+// a recorded output with their float values.
+func decodeSpecialFloats(v interface{}) interface{} {
+	switch val := v.(type) {
+	case string:
+		switch val {
+		case "NaN":
+			return math.NaN()
+		case "Infinity", "+Infinity":
+			return math.Inf(1)
+		case "-Infinity":
+			return math.Inf(-1)
+		}
+		return val
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		for i, item := range val {
+			out[i] = decodeSpecialFloats(item)
+		}
+		return out
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(val))
+		for k, item := range val {
+			out[k] = decodeSpecialFloats(item)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+func sortedSet(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
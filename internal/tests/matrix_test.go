@@ -0,0 +1,101 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/AndreyAkinshin/structyl/pkg/testhelper"
+)
+
+func TestBuildMatrix(t *testing.T) {
+	targets := []string{"cs", "py", "rs"}
+	results := map[string]map[string]testhelper.CaseResult{
+		"cs": {
+			"math/add":   {Status: testhelper.ResultPass, Actual: 3.0},
+			"math/div":   {Status: testhelper.ResultFail, Actual: 0.5},
+			"math/round": {Status: testhelper.ResultFail, Actual: 2.0},
+			"math/slow":  {Status: testhelper.ResultSkip},
+		},
+		"py": {
+			"math/add":   {Status: testhelper.ResultPass, Actual: 3.0},
+			"math/div":   {Status: testhelper.ResultPass, Actual: 0.25},
+			"math/round": {Status: testhelper.ResultFail, Actual: 2.0},
+			"math/slow":  {Status: testhelper.ResultSkip},
+		},
+		"rs": {
+			"math/add":   {Status: testhelper.ResultPass, Actual: 3.0},
+			"math/round": {Status: testhelper.ResultFail, Actual: 3.0},
+			"math/slow":  {Status: testhelper.ResultSkip},
+			"old/stale":  {Status: testhelper.ResultPass},
+		},
+	}
+	caseIDs := []string{"math/add", "math/div", "math/round", "math/slow"}
+
+	m := BuildMatrix(caseIDs, targets, results, testhelper.DefaultOptions())
+
+	var ids []string
+	for _, row := range m.Cases {
+		ids = append(ids, row.ID)
+	}
+	if got := strings.Join(ids, ","); got != "math/add,math/div,math/round,math/slow,old/stale" {
+		t.Errorf("case IDs = %s", got)
+	}
+
+	rows := make(map[string]MatrixRow)
+	for _, row := range m.Cases {
+		rows[row.ID] = row
+	}
+	if rows["math/div"].Results["rs"] != MatrixMissing {
+		t.Errorf("math/div in rs = %q, want missing", rows["math/div"].Results["rs"])
+	}
+	for id, want := range map[string]bool{
+		"math/add":   false, // all pass
+		"math/div":   true,  // pass vs fail
+		"math/round": true,  // all fail, different outputs
+		"math/slow":  false, // all skip
+		"old/stale":  false, // only one target ran
+	} {
+		if got := rows[id].Disagreement; got != want {
+			t.Errorf("%s disagreement = %v, want %v (%s)", id, got, want, rows[id].Reason)
+		}
+	}
+	if m.Disagreements != 2 {
+		t.Errorf("Disagreements = %d, want 2", m.Disagreements)
+	}
+	if want := (MatrixSummary{Pass: 1, Fail: 2, Skip: 1, Missing: 1}); m.Summary["cs"] != want {
+		t.Errorf("Summary[cs] = %+v, want %+v", m.Summary["cs"], want)
+	}
+	if m.Failures() != 4 {
+		t.Errorf("Failures() = %d, want 4", m.Failures())
+	}
+}
+
+func TestBuildMatrix_FailuresWithSameOutputAgree(t *testing.T) {
+	results := map[string]map[string]testhelper.CaseResult{
+		"go": {"s/c": {Status: testhelper.ResultFail, Actual: []interface{}{1.0, "NaN"}}},
+		"ts": {"s/c": {Status: testhelper.ResultFail, Actual: []interface{}{1.0, "NaN"}}},
+	}
+	m := BuildMatrix([]string{"s/c"}, []string{"go", "ts"}, results, testhelper.DefaultOptions())
+	if m.Cases[0].Disagreement {
+		t.Errorf("identical failing outputs reported as disagreement: %s", m.Cases[0].Reason)
+	}
+}
+
+func TestMatrix_Markdown(t *testing.T) {
+	results := map[string]map[string]testhelper.CaseResult{
+		"go": {"s/a": {Status: testhelper.ResultPass}, "s/b": {Status: testhelper.ResultPass}},
+		"py": {"s/a": {Status: testhelper.ResultPass}, "s/b": {Status: testhelper.ResultFail}},
+	}
+	md := BuildMatrix(nil, []string{"go", "py"}, results, testhelper.DefaultOptions()).Markdown()
+
+	for _, want := range []string{
+		"| Case | go | py |",
+		"| `s/a` | pass | pass |",
+		"| ⚠ `s/b` | pass | fail |",
+		"- `s/b`: passes in go, fails in py",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("Markdown() missing %q:\n%s", want, md)
+		}
+	}
+}
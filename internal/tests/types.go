@@ -8,6 +8,8 @@ type TestCase struct {
 	Path   string                 // Full path to the test file
 	Input  map[string]interface{} // Input data for the test
 	Output interface{}            // Expected output
+	Skip   bool                   // Whether the case is marked as skipped
+	Tags   []string               // Tags for selection (see testhelper.TagFilter)
 }
 
 // ComparisonConfig configures how test outputs are compared.
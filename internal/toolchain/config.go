@@ -8,30 +8,30 @@ import (
 
 // ToolchainsFile represents the .structyl/toolchains.json configuration file.
 type ToolchainsFile struct {
-	Schema            string                        `json:"$schema,omitempty"`
-	Version           string                        `json:"version"`
-	Commands          map[string]CommandMeta        `json:"commands,omitempty"`
-	AggregateCommands []string                      `json:"aggregateCommands,omitempty"`
-	Pipelines         map[string][]string           `json:"pipelines,omitempty"`
-	Toolchains        map[string]ToolchainFileEntry `json:"toolchains"`
+	Schema            string                        `json:"$schema,omitempty" jsonschema_description:"JSON Schema reference"`
+	Version           string                        `json:"version" jsonschema:"const=1.0" jsonschema_description:"Toolchains format version"`
+	Commands          map[string]CommandMeta        `json:"commands,omitempty" jsonschema_description:"Command definitions with descriptions"`
+	AggregateCommands []string                      `json:"aggregateCommands,omitempty" jsonschema_description:"Commands that should be aggregated across all targets"`
+	Pipelines         map[string][]string           `json:"pipelines,omitempty" jsonschema_description:"Named pipelines (sequences of commands)"`
+	Toolchains        map[string]ToolchainFileEntry `json:"toolchains" jsonschema_description:"Toolchain definitions"`
 }
 
 // CommandMeta contains metadata about a standard command.
 type CommandMeta struct {
-	Description string `json:"description"`
+	Description string `json:"description" jsonschema_description:"Human-readable description of the command"`
 }
 
 // ToolchainFileEntry represents a single toolchain configuration in the file.
 type ToolchainFileEntry struct {
-	Mise     *MiseConfig            `json:"mise,omitempty"`
-	Commands map[string]interface{} `json:"commands,omitempty"`
+	Mise     *MiseConfig            `json:"mise,omitempty" jsonschema_description:"mise tool configuration"`
+	Commands map[string]interface{} `json:"commands,omitempty" jsonschema:"ref=commandValue" jsonschema_description:"Command implementations for this toolchain"`
 }
 
 // MiseConfig represents the mise tool configuration for a toolchain.
 type MiseConfig struct {
-	PrimaryTool string            `json:"primary_tool,omitempty"`
-	Version     string            `json:"version,omitempty"`
-	ExtraTools  map[string]string `json:"extra_tools,omitempty"`
+	PrimaryTool string            `json:"primary_tool,omitempty" jsonschema:"required" jsonschema_description:"Primary tool name (e.g., rust, go, node)"`
+	Version     string            `json:"version,omitempty" jsonschema:"required" jsonschema_description:"Tool version (e.g., stable, 1.24, latest)"`
+	ExtraTools  map[string]string `json:"extra_tools,omitempty" jsonschema_description:"Additional tools to install"`
 }
 
 // LoadToolchains loads the toolchains configuration from projectRoot/.structyl/toolchains.json.
@@ -40,13 +40,28 @@ type MiseConfig struct {
 func LoadToolchains(projectRoot string) (*ToolchainsFile, error) {
 	defaults := GetDefaultToolchains()
 
+	loaded, err := ReadToolchainsFile(projectRoot)
+	if err != nil {
+		return nil, err
+	}
+	if loaded == nil {
+		// No file, return defaults
+		return defaults, nil
+	}
+
+	// Merge loaded config with defaults
+	return MergeToolchains(defaults, loaded), nil
+}
+
+// ReadToolchainsFile reads projectRoot/.structyl/toolchains.json as written,
+// without merging it with the defaults. Returns nil if the file doesn't exist.
+func ReadToolchainsFile(projectRoot string) (*ToolchainsFile, error) {
 	toolchainsPath := filepath.Join(projectRoot, ".structyl", "toolchains.json")
 
 	data, err := os.ReadFile(toolchainsPath)
 	if err != nil {
 		if os.IsNotExist(err) {
-			// No file, return defaults
-			return defaults, nil
+			return nil, nil
 		}
 		return nil, err
 	}
@@ -55,9 +70,7 @@ func LoadToolchains(projectRoot string) (*ToolchainsFile, error) {
 	if err := json.Unmarshal(data, &loaded); err != nil {
 		return nil, err
 	}
-
-	// Merge loaded config with defaults
-	return MergeToolchains(defaults, &loaded), nil
+	return &loaded, nil
 }
 
 // MergeToolchains performs a deep merge of the loaded configuration over the defaults.
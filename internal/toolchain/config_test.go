@@ -97,6 +97,33 @@ func TestLoadToolchains_ValidFile_MergesWithDefaults(t *testing.T) {
 	}
 }
 
+func TestReadToolchainsFile(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	result, err := ReadToolchainsFile(tmpDir)
+	if err != nil || result != nil {
+		t.Fatalf("ReadToolchainsFile() without file = %v, %v; want nil, nil", result, err)
+	}
+
+	structylDir := filepath.Join(tmpDir, ".structyl")
+	if err := os.MkdirAll(structylDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	toolchainsJSON := `{"version": "1.0", "toolchains": {"cargo": {"commands": {"build": "cargo build --locked"}}}}`
+	if err := os.WriteFile(filepath.Join(structylDir, "toolchains.json"), []byte(toolchainsJSON), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err = ReadToolchainsFile(tmpDir)
+	if err != nil {
+		t.Fatalf("ReadToolchainsFile() error = %v", err)
+	}
+	// Only the file's contents, not merged with the defaults
+	if len(result.Toolchains) != 1 || len(result.Toolchains["cargo"].Commands) != 1 {
+		t.Errorf("ReadToolchainsFile() = %+v, want only the cargo build command", result.Toolchains)
+	}
+}
+
 func TestLoadToolchains_InvalidJSON_ReturnsError(t *testing.T) {
 	tmpDir := t.TempDir()
 
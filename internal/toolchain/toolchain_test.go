@@ -134,6 +134,19 @@ func TestBuiltinToolchains_HaveStandardCommands(t *testing.T) {
 	}
 }
 
+// The deprecated fallback of GetFromConfig to Get is unreachable from a
+// configuration, and so not reported by 'structyl config migrate', only as
+// long as every built-in toolchain is also a default toolchain.
+func TestBuiltinToolchains_AreDefaults(t *testing.T) {
+	t.Parallel()
+	defaults := GetDefaultToolchains()
+	for _, name := range List() {
+		if _, ok := defaults.Toolchains[name]; !ok {
+			t.Errorf("built-in toolchain %q is not a default toolchain", name)
+		}
+	}
+}
+
 func TestGetFromConfig_WithLoadedConfig(t *testing.T) {
 	t.Parallel()
 	loaded := &ToolchainsFile{
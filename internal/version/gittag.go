@@ -0,0 +1,187 @@
+package version
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/AndreyAkinshin/structyl/internal/config"
+)
+
+// Resolve returns the project version from the configured version source:
+// the version file, or the version derived from git tags for "git:tag".
+func Resolve(projectRoot string, cfg *config.Config) (string, error) {
+	source := SourcePath(projectRoot, cfg.Version)
+	if config.IsGitTagSource(cfg.Version) {
+		source = config.VersionSourceGitTag
+	}
+	return ResolveSource(projectRoot, source, config.ReleaseTagFormat(cfg))
+}
+
+// ResolveTarget returns the version of an independently versioned target.
+func ResolveTarget(projectRoot, name string, t config.TargetConfig) (string, error) {
+	source := config.TargetVersionSource(name, t)
+	if source != config.VersionSourceGitTag {
+		source = filepath.Join(projectRoot, source)
+	}
+	return ResolveSource(projectRoot, source, config.TargetTagFormat(name, t))
+}
+
+// ResolveSource returns the version stored in the version file at source (an
+// absolute path), or derived from tags matching tagFormat if source is "git:tag".
+func ResolveSource(projectRoot, source, tagFormat string) (string, error) {
+	if source == config.VersionSourceGitTag {
+		return FromGitTag(projectRoot, tagFormat)
+	}
+	return Read(source)
+}
+
+// FromGitTag derives a version from the most recent tag reachable from HEAD
+// in the repository at dir that matches tagFormat (e.g., "v{version}").
+//
+// A tagged commit gets the tag's version. Any other commit gets a dev version
+// ordered by its distance from the tag (see DevVersion), so every commit has
+// a unique version that sorts after the previous release.
+func FromGitTag(dir, tagFormat string) (string, error) {
+	tag, err := latestTag(dir, tagFormat)
+	if err != nil {
+		return "", err
+	}
+
+	if tag == "" {
+		count, err := git(dir, "rev-list", "--count", "HEAD")
+		if err != nil {
+			return "", err
+		}
+		hash, err := git(dir, "rev-parse", "--short=7", "HEAD")
+		if err != nil {
+			return "", err
+		}
+		distance, _ := strconv.Atoi(count) // git prints a decimal count
+		return DevVersion("", distance, hash)
+	}
+
+	// git describe --long prints <tag>-<distance>-g<hash>.
+	desc, err := git(dir, "describe", "--tags", "--long", "--abbrev=7", "--match", tagPattern(tagFormat), "HEAD")
+	if err != nil {
+		return "", err
+	}
+	rest, hash, _ := cutLast(desc, "-g")
+	tag, count, _ := cutLast(rest, "-")
+	distance, err := strconv.Atoi(count)
+	if err != nil {
+		return "", fmt.Errorf("unexpected git describe output %q", desc)
+	}
+
+	base, ok := TagVersion(tag, tagFormat)
+	if !ok {
+		return "", fmt.Errorf("tag %q does not contain a valid version for tag format %q", tag, tagFormat)
+	}
+	if distance == 0 {
+		return base, nil
+	}
+	return DevVersion(base, distance, hash)
+}
+
+// LatestTagVersion returns the version of the most recent tag reachable from
+// HEAD that matches tagFormat, or "" if there is no such tag.
+func LatestTagVersion(dir, tagFormat string) (string, error) {
+	tag, err := latestTag(dir, tagFormat)
+	if err != nil || tag == "" {
+		return "", err
+	}
+	desc, err := git(dir, "describe", "--tags", "--abbrev=0", "--match", tagPattern(tagFormat), "HEAD")
+	if err != nil {
+		return "", err
+	}
+	v, ok := TagVersion(desc, tagFormat)
+	if !ok {
+		return "", fmt.Errorf("tag %q does not contain a valid version for tag format %q", desc, tagFormat)
+	}
+	return v, nil
+}
+
+// DevVersion returns the version of a commit distance commits after the
+// release base, with the abbreviated commit hash as build metadata.
+//
+// The dev version is a prerelease of the next minor version
+// (1.3.2 → 1.4.0-dev.12+g3f2a1c4). If base is itself a prerelease, it is
+// extended instead (1.4.0-rc.1 → 1.4.0-rc.1.dev.12+g3f2a1c4), so dev versions
+// always sort after base. An empty base means there is no release yet.
+func DevVersion(base string, distance int, hash string) (string, error) {
+	next := &Semver{Minor: 1}
+	if base != "" {
+		v, err := Parse(base)
+		if err != nil {
+			return "", err
+		}
+		next = &Semver{Major: v.Major, Minor: v.Minor + 1}
+		if v.Prerelease != "" {
+			next = &Semver{Major: v.Major, Minor: v.Minor, Patch: v.Patch, Prerelease: v.Prerelease + "."}
+		}
+	}
+	next.Prerelease += fmt.Sprintf("dev.%d", distance)
+	next.Build = "g" + hash
+	return next.String(), nil
+}
+
+// TagVersion extracts the version from a tag created with tagFormat.
+// Returns false if the tag does not match the format or the version is not
+// valid semver.
+func TagVersion(tag, tagFormat string) (string, bool) {
+	prefix, suffix, ok := strings.Cut(tagFormat, "{version}")
+	if !ok || !strings.HasPrefix(tag, prefix) || !strings.HasSuffix(tag, suffix) || len(tag) < len(prefix)+len(suffix) {
+		return "", false
+	}
+	v := tag[len(prefix) : len(tag)-len(suffix)]
+	if Validate(v) != nil {
+		return "", false
+	}
+	return v, true
+}
+
+// latestTag returns the most recent tag reachable from HEAD that matches
+// tagFormat, or "" if there is none.
+func latestTag(dir, tagFormat string) (string, error) {
+	// git describe fails without a matching tag, so check for one first.
+	tags, err := git(dir, "tag", "--list", "--merged", "HEAD", tagPattern(tagFormat))
+	if err != nil || tags == "" {
+		return "", err
+	}
+	return git(dir, "describe", "--tags", "--abbrev=0", "--match", tagPattern(tagFormat), "HEAD")
+}
+
+// tagPattern returns the glob matching tags created with tagFormat.
+// Versions start with a digit, which excludes unrelated tags sharing the prefix.
+func tagPattern(tagFormat string) string {
+	return strings.Replace(tagFormat, "{version}", "[0-9]*", 1)
+}
+
+// git runs a git command in dir and returns its trimmed output.
+func git(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if msg := strings.TrimSpace(stderr.String()); msg != "" && errors.As(err, &exitErr) {
+			return "", fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// cutLast slices s around the last instance of sep.
+func cutLast(s, sep string) (before, after string, found bool) {
+	if i := strings.LastIndex(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+	return s, "", false
+}
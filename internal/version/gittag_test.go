@@ -0,0 +1,140 @@
+package version
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+func TestDevVersion(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		base     string
+		distance int
+		want     string
+	}{
+		{"1.3.2", 12, "1.4.0-dev.12+g3f2a1c4"},
+		{"1.3.2+build.5", 1, "1.4.0-dev.1+g3f2a1c4"},
+		{"1.4.0-rc.1", 3, "1.4.0-rc.1.dev.3+g3f2a1c4"},
+		{"", 7, "0.1.0-dev.7+g3f2a1c4"},
+	}
+	for _, tt := range tests {
+		got, err := DevVersion(tt.base, tt.distance, "3f2a1c4")
+		if err != nil {
+			t.Errorf("DevVersion(%q) error = %v", tt.base, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("DevVersion(%q, %d) = %q, want %q", tt.base, tt.distance, got, tt.want)
+		}
+		if c, _ := Compare(got, tt.base); tt.base != "" && c <= 0 {
+			t.Errorf("DevVersion(%q) = %q does not sort after the base", tt.base, got)
+		}
+	}
+}
+
+func TestTagVersion(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		tag, format, want string
+		ok                bool
+	}{
+		{"v1.2.3", "v{version}", "1.2.3", true},
+		{"release-1.2.3-rc.1", "release-{version}", "1.2.3-rc.1", true},
+		{"pkg/1.2.3/final", "pkg/{version}/final", "1.2.3", true},
+		{"1.2.3", "v{version}", "", false},
+		{"v1.2", "v{version}", "", false},
+		{"vnext", "v{version}", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := TagVersion(tt.tag, tt.format)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("TagVersion(%q, %q) = %q, %v; want %q, %v", tt.tag, tt.format, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+// gitRepo creates a git repository with one commit.
+func gitRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	dir := t.TempDir()
+	runGit(t, dir, "init")
+	runGit(t, dir, "config", "user.email", "test@test.com")
+	runGit(t, dir, "config", "user.name", "Test")
+	runGit(t, dir, "config", "commit.gpgsign", "false")
+	gitCommit(t, dir)
+	return dir
+}
+
+func runGit(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v failed: %v\n%s", args, err, out)
+	}
+}
+
+// gitCommit creates an empty commit.
+func gitCommit(t *testing.T, dir string) {
+	t.Helper()
+	runGit(t, dir, "commit", "--allow-empty", "--no-gpg-sign", "-m", "commit")
+}
+
+func TestFromGitTag(t *testing.T) {
+	t.Parallel()
+	dir := gitRepo(t)
+	devVersion := regexp.MustCompile(`^(.+)\+g[0-9a-f]{7,}$`)
+
+	check := func(want string) {
+		t.Helper()
+		got, err := FromGitTag(dir, "v{version}")
+		if err != nil {
+			t.Fatalf("FromGitTag() error = %v", err)
+		}
+		if m := devVersion.FindStringSubmatch(got); m != nil {
+			got = m[1] // The commit hash varies between runs
+		}
+		if got != want {
+			t.Errorf("FromGitTag() = %q, want %q", got, want)
+		}
+		if Validate(got) != nil {
+			t.Errorf("FromGitTag() = %q is not valid semver", got)
+		}
+	}
+
+	check("0.1.0-dev.1")
+
+	runGit(t, dir, "tag", "v1.3.2")
+	check("1.3.2")
+
+	gitCommit(t, dir)
+	gitCommit(t, dir)
+	runGit(t, dir, "tag", "vnext") // Ignored: not a version
+	runGit(t, dir, "tag", "docs-2.0.0")
+	check("1.4.0-dev.2")
+
+	if v, err := LatestTagVersion(dir, "v{version}"); err != nil || v != "1.3.2" {
+		t.Errorf("LatestTagVersion() = %q, %v; want 1.3.2", v, err)
+	}
+}
+
+func TestFromGitTag_NotARepository(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	dir := t.TempDir()
+	// Keep git from discovering a repository above the temporary directory.
+	if err := os.MkdirAll(filepath.Join(dir, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GIT_CEILING_DIRECTORIES", dir)
+	if _, err := FromGitTag(filepath.Join(dir, "sub"), "v{version}"); err == nil {
+		t.Error("FromGitTag() outside a repository: expected error")
+	}
+}
@@ -0,0 +1,67 @@
+package version
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/AndreyAkinshin/structyl/internal/config"
+)
+
+// Preset describes where a toolchain's manifest stores the package version.
+type Preset struct {
+	File string // Manifest file name or glob, relative to the target directory
+	Key  string // Key path; the format is inferred from File's extension
+}
+
+// presets lists version file presets per toolchain, in order of preference.
+var presets = map[string][]Preset{
+	"cargo":    {{File: "Cargo.toml", Key: "package.version"}},
+	"npm":      {{File: "package.json", Key: "/version"}},
+	"pnpm":     {{File: "package.json", Key: "/version"}},
+	"yarn":     {{File: "package.json", Key: "/version"}},
+	"bun":      {{File: "package.json", Key: "/version"}},
+	"deno":     {{File: "deno.json", Key: "/version"}},
+	"composer": {{File: "composer.json", Key: "/version"}},
+	"python":   {{File: "pyproject.toml", Key: "project.version"}},
+	"uv":       {{File: "pyproject.toml", Key: "project.version"}},
+	"poetry": {
+		{File: "pyproject.toml", Key: "tool.poetry.version"},
+		{File: "pyproject.toml", Key: "project.version"},
+	},
+	"maven": {{File: "pom.xml", Key: "project/version"}},
+	"dotnet": {
+		{File: "Directory.Build.props", Key: "Project/PropertyGroup/Version"},
+		{File: "*.csproj", Key: "Project/PropertyGroup/Version"},
+		{File: "*.fsproj", Key: "Project/PropertyGroup/Version"},
+	},
+}
+
+// Presets returns the version file presets for a toolchain, or nil if it has none.
+func Presets(toolchain string) []Preset {
+	return presets[toolchain]
+}
+
+// DetectFile returns a version file rule for the target in dir (relative to
+// projectRoot) built with toolchain. The first preset whose file exists and
+// contains the key exactly once is used; a glob must match a single file.
+func DetectFile(projectRoot, dir, toolchain string) (config.VersionFileConfig, bool) {
+	for _, p := range presets[toolchain] {
+		matches, err := filepath.Glob(filepath.Join(projectRoot, dir, p.File))
+		if err != nil || len(matches) != 1 {
+			continue
+		}
+		f := config.VersionFileConfig{Path: matches[0], Key: p.Key}
+		data, err := os.ReadFile(f.Path)
+		if err != nil {
+			continue
+		}
+		if _, err := findKey(data, config.VersionFileFormat(f), f.Key, false); err != nil {
+			continue
+		}
+		if rel, err := filepath.Rel(projectRoot, f.Path); err == nil {
+			f.Path = filepath.ToSlash(rel)
+		}
+		return f, true
+	}
+	return config.VersionFileConfig{}, false
+}
@@ -3,16 +3,44 @@ package version
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"regexp"
 	"strings"
 
 	"github.com/AndreyAkinshin/structyl/internal/config"
 )
 
+// SourcePath returns the path of the project version file under projectRoot.
+// A nil cfg or empty source selects config.DefaultVersionSource.
+func SourcePath(projectRoot string, cfg *config.VersionConfig) string {
+	source := config.DefaultVersionSource
+	if cfg != nil && cfg.Source != "" {
+		source = cfg.Source
+	}
+	return filepath.Join(projectRoot, source)
+}
+
+// ResolveFiles returns a copy of files with paths resolved relative to
+// projectRoot.
+func ResolveFiles(projectRoot string, files []config.VersionFileConfig) []config.VersionFileConfig {
+	resolved := make([]config.VersionFileConfig, len(files))
+	for i, f := range files {
+		resolved[i] = f
+		resolved[i].Path = filepath.Join(projectRoot, f.Path)
+	}
+	return resolved
+}
+
 // Propagate updates version in all configured files.
 func Propagate(version string, files []config.VersionFileConfig) error {
 	for _, f := range files {
-		if err := UpdateFile(f.Path, f.Pattern, f.Replace, version, f.ReplaceAll); err != nil {
+		var err error
+		if f.Key != "" {
+			err = UpdateKey(f.Path, config.VersionFileFormat(f), f.Key, version, f.ReplaceAll)
+		} else {
+			err = UpdateFile(f.Path, f.Pattern, f.Replace, version, f.ReplaceAll)
+		}
+		if err != nil {
 			return fmt.Errorf("failed to update %s: %w", f.Path, err)
 		}
 	}
@@ -56,38 +84,112 @@ func UpdateFile(path, pattern, replace, version string, replaceAll bool) error {
 	return os.WriteFile(path, []byte(result), 0644)
 }
 
-// CheckConsistency verifies version is consistent across all files.
-func CheckConsistency(sourceVersion string, files []config.VersionFileConfig) ([]string, error) {
-	var inconsistencies []string
+// embeddedVersionRegex finds a semver-looking string inside a pattern match.
+var embeddedVersionRegex = regexp.MustCompile(`\d+\.\d+\.\d+(-[a-zA-Z0-9]+(\.[a-zA-Z0-9]+)*)?(\+[a-zA-Z0-9]+(\.[a-zA-Z0-9]+)*)?`)
+
+// FileStatus is the result of checking one version file against the project version.
+type FileStatus struct {
+	Path    string // Path as configured
+	Found   string // Version found in the file; empty if none could be extracted
+	Problem string // Why the file is inconsistent; empty if it is up to date
+}
 
+// OK reports whether the file is up to date.
+func (s FileStatus) OK() bool {
+	return s.Problem == ""
+}
+
+// CheckFiles checks each file against sourceVersion and returns one status
+// per file, in order.
+//
+// The version found in a file is taken from the capture group named "version"
+// if the pattern has one, and otherwise from the last semver-looking string
+// inside the match.
+func CheckFiles(sourceVersion string, files []config.VersionFileConfig) []FileStatus {
+	statuses := make([]FileStatus, 0, len(files))
 	for _, f := range files {
-		data, err := os.ReadFile(f.Path)
-		if err != nil {
-			inconsistencies = append(inconsistencies, fmt.Sprintf("%s: file not found", f.Path))
-			continue
-		}
+		statuses = append(statuses, checkFile(sourceVersion, f))
+	}
+	return statuses
+}
 
-		re, err := regexp.Compile(f.Pattern)
-		if err != nil {
-			inconsistencies = append(inconsistencies, fmt.Sprintf("%s: invalid pattern: %v", f.Path, err))
-			continue
-		}
+func checkFile(sourceVersion string, f config.VersionFileConfig) FileStatus {
+	status := FileStatus{Path: f.Path}
 
-		// Extract version from file
-		match := re.FindSubmatch(data)
-		if match == nil {
-			inconsistencies = append(inconsistencies, fmt.Sprintf("%s: pattern not matched", f.Path))
-			continue
-		}
+	data, err := os.ReadFile(f.Path)
+	if err != nil {
+		status.Problem = "file not found"
+		return status
+	}
+	if f.Key != "" {
+		return checkKey(status, data, sourceVersion, f)
+	}
+
+	re, err := regexp.Compile(f.Pattern)
+	if err != nil {
+		status.Problem = fmt.Sprintf("invalid pattern: %v", err)
+		return status
+	}
 
-		// Check if the file would need updating
-		replacement := strings.ReplaceAll(f.Replace, "{version}", sourceVersion)
-		result := re.ReplaceAllString(string(data), replacement)
+	match := re.FindSubmatchIndex(data)
+	if match == nil {
+		status.Problem = "pattern not matched"
+		return status
+	}
+	status.Found = extractVersion(re, data, match)
+
+	// Check if the file would need updating
+	replacement := strings.ReplaceAll(f.Replace, "{version}", sourceVersion)
+	if re.ReplaceAllString(string(data), replacement) != string(data) {
+		status.Problem = "version mismatch"
+	}
+	return status
+}
 
-		if result != string(data) {
-			inconsistencies = append(inconsistencies, fmt.Sprintf("%s: version mismatch", f.Path))
+// checkKey fills in status for a key-based version file.
+func checkKey(status FileStatus, data []byte, sourceVersion string, f config.VersionFileConfig) FileStatus {
+	spans, err := findKey(data, config.VersionFileFormat(f), f.Key, f.ReplaceAll)
+	if err != nil {
+		status.Problem = err.Error()
+		return status
+	}
+	for _, s := range spans {
+		if found := string(data[s.start:s.end]); found != sourceVersion {
+			status.Found = found
+			status.Problem = "version mismatch"
+			return status
 		}
 	}
+	status.Found = sourceVersion
+	return status
+}
+
+// extractVersion returns the version inside the first match of re, given its
+// submatch indices, or "" if there is none.
+func extractVersion(re *regexp.Regexp, data []byte, match []int) string {
+	if i := re.SubexpIndex("version"); i >= 0 && match[2*i] >= 0 {
+		return string(data[match[2*i]:match[2*i+1]])
+	}
+	found := embeddedVersionRegex.FindAll(data[match[0]:match[1]], -1)
+	if len(found) == 0 {
+		return ""
+	}
+	return string(found[len(found)-1])
+}
 
+// CheckConsistency verifies version is consistent across all files.
+// Each inconsistency is reported as "<path>: <problem>".
+func CheckConsistency(sourceVersion string, files []config.VersionFileConfig) ([]string, error) {
+	var inconsistencies []string
+	for _, s := range CheckFiles(sourceVersion, files) {
+		if s.OK() {
+			continue
+		}
+		if s.Found != "" {
+			inconsistencies = append(inconsistencies, fmt.Sprintf("%s: %s (found %s)", s.Path, s.Problem, s.Found))
+		} else {
+			inconsistencies = append(inconsistencies, fmt.Sprintf("%s: %s", s.Path, s.Problem))
+		}
+	}
 	return inconsistencies, nil
 }
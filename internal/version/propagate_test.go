@@ -405,3 +405,77 @@ func TestCheckConsistency_EmptyFiles_ReturnsEmpty(t *testing.T) {
 		t.Errorf("inconsistencies = %v, want empty", inconsistencies)
 	}
 }
+
+func TestSourcePath(t *testing.T) {
+	root := filepath.Join("project", "root")
+	if got, want := SourcePath(root, nil), filepath.Join(root, config.DefaultVersionSource); got != want {
+		t.Errorf("SourcePath(nil) = %q, want %q", got, want)
+	}
+	if got, want := SourcePath(root, &config.VersionConfig{Source: "VERSION"}), filepath.Join(root, "VERSION"); got != want {
+		t.Errorf("SourcePath(VERSION) = %q, want %q", got, want)
+	}
+}
+
+func TestResolveFiles(t *testing.T) {
+	files := []config.VersionFileConfig{{Path: "go/version.go", Pattern: "v", Replace: "{version}", ReplaceAll: true}}
+	resolved := ResolveFiles("/root", files)
+	if resolved[0].Path != filepath.Join("/root", "go/version.go") || !resolved[0].ReplaceAll || resolved[0].Pattern != "v" {
+		t.Errorf("ResolveFiles() = %+v", resolved[0])
+	}
+	if files[0].Path != "go/version.go" {
+		t.Error("ResolveFiles() modified its input")
+	}
+}
+
+func TestCheckFiles_ReportsFoundVersion(t *testing.T) {
+	tmpDir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(tmpDir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	cargo := write("Cargo.toml", "[package]\nname = \"demo\"\nversion = \"1.9.0\"\n\n[dependencies]\nserde = { version = \"1.0.0\" }\n")
+	pkg := write("package.json", `{"version": "2.0.0-rc.1"}`)
+	props := write("Directory.Build.props", "<Version>2.0.0</Version>")
+
+	files := []config.VersionFileConfig{
+		{Path: cargo, Pattern: `(name = "demo"[\s\S]*?)version = ".*?"`, Replace: `${1}version = "{version}"`},
+		{Path: pkg, Pattern: `"version": "(?P<version>[^"]*)"`, Replace: `"version": "{version}"`},
+		{Path: props, Pattern: `<Version>.*?</Version>`, Replace: `<Version>{version}</Version>`},
+	}
+
+	statuses := CheckFiles("2.0.0", files)
+	if len(statuses) != 3 {
+		t.Fatalf("len(statuses) = %d, want 3", len(statuses))
+	}
+	tests := []struct {
+		found string
+		ok    bool
+	}{
+		{"1.9.0", false},
+		{"2.0.0-rc.1", false},
+		{"2.0.0", true},
+	}
+	for i, tt := range tests {
+		if statuses[i].Found != tt.found || statuses[i].OK() != tt.ok {
+			t.Errorf("statuses[%d] = %+v, want found %q, ok %v", i, statuses[i], tt.found, tt.ok)
+		}
+	}
+}
+
+func TestCheckConsistency_Mismatch_IncludesFoundVersion(t *testing.T) {
+	tmpDir := t.TempDir()
+	filePath := filepath.Join(tmpDir, "package.json")
+	if err := os.WriteFile(filePath, []byte(`{"version": "1.0.0"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	inconsistencies, _ := CheckConsistency("2.0.0", []config.VersionFileConfig{
+		{Path: filePath, Pattern: `"version": "[\d.]+"`, Replace: `"version": "{version}"`},
+	})
+	if len(inconsistencies) != 1 || !strings.Contains(inconsistencies[0], "found 1.0.0") {
+		t.Errorf("inconsistencies = %v, want one mentioning 'found 1.0.0'", inconsistencies)
+	}
+}
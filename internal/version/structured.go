@@ -0,0 +1,406 @@
+package version
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/AndreyAkinshin/structyl/internal/config"
+)
+
+// span locates a value inside a file: data[start:end] is the value's text,
+// without surrounding quotes or tags.
+type span struct {
+	start, end int
+}
+
+// locator finds every value stored under key in a file of one format.
+type locator func(data []byte, key string) ([]span, error)
+
+var locators = map[string]locator{
+	config.VersionFormatTOML: locateTOML,
+	config.VersionFormatJSON: locateJSON,
+	config.VersionFormatXML:  locateXML,
+	config.VersionFormatYAML: locateYAML,
+}
+
+// errKeyNotFound is returned when a key is absent from a file.
+var errKeyNotFound = errors.New("key not found")
+
+// UpdateKey sets the value stored under key in a structured file to version.
+// Only the value's text is replaced, so formatting and comments are preserved.
+// If replaceAll is false (default), the key must occur exactly once.
+func UpdateKey(path, format, key, version string, replaceAll bool) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	result, err := setKey(data, format, key, version, replaceAll)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	if bytes.Equal(result, data) {
+		return nil // Already up to date
+	}
+	return os.WriteFile(path, result, 0644)
+}
+
+// ReadKey returns the value stored under key in a structured file.
+// The key must occur exactly once.
+func ReadKey(path, format, key string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	spans, err := findKey(data, format, key, false)
+	if err != nil {
+		return "", err
+	}
+	return string(data[spans[0].start:spans[0].end]), nil
+}
+
+// setKey returns data with every value under key replaced by version.
+func setKey(data []byte, format, key, version string, replaceAll bool) ([]byte, error) {
+	spans, err := findKey(data, format, key, replaceAll)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]byte, 0, len(data))
+	last := 0
+	for _, s := range spans {
+		result = append(result, data[last:s.start]...)
+		result = append(result, version...)
+		last = s.end
+	}
+	return append(result, data[last:]...), nil
+}
+
+// findKey locates the values under key, in file order.
+func findKey(data []byte, format, key string, replaceAll bool) ([]span, error) {
+	locate, ok := locators[format]
+	if !ok {
+		return nil, fmt.Errorf("unsupported format %q", format)
+	}
+	spans, err := locate(data, key)
+	if err != nil {
+		return nil, err
+	}
+	if len(spans) == 0 {
+		return nil, fmt.Errorf("%w: %s", errKeyNotFound, key)
+	}
+	if !replaceAll && len(spans) > 1 {
+		return nil, fmt.Errorf("key %s matched %d times (expected 1)", key, len(spans))
+	}
+	return spans, nil
+}
+
+// locateTOML finds a string value by dotted key path (e.g., "package.version").
+// The key may be split between a [table] header and a dotted key. Keys inside
+// inline tables and arrays of tables are not searched.
+func locateTOML(data []byte, key string) ([]span, error) {
+	want := splitTOMLKey(key)
+	var spans []span
+	var table []string
+	inArrayTable := false
+
+	offset := 0
+	lines := strings.SplitAfter(string(data), "\n")
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		lineStart := offset
+		offset += len(line)
+
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "" || strings.HasPrefix(trimmed, "#"):
+			continue
+		case strings.HasPrefix(trimmed, "[["):
+			inArrayTable = true
+			continue
+		case strings.HasPrefix(trimmed, "["):
+			end := strings.Index(trimmed, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("line %d: unterminated table header", i+1)
+			}
+			table = splitTOMLKey(trimmed[1:end])
+			inArrayTable = false
+			continue
+		}
+
+		eq := strings.Index(line, "=")
+		if eq < 0 {
+			continue
+		}
+		valueStart := eq + 1
+		for valueStart < len(line) && (line[valueStart] == ' ' || line[valueStart] == '\t') {
+			valueStart++
+		}
+
+		// Skip the remaining lines of a multi-line string.
+		rest := line[valueStart:]
+		if delim := rest[:min(3, len(rest))]; delim == `"""` || delim == "'''" {
+			if strings.Count(rest, delim) < 2 {
+				for i+1 < len(lines) {
+					i++
+					offset += len(lines[i])
+					if strings.Contains(lines[i], delim) {
+						break
+					}
+				}
+			}
+			continue
+		}
+
+		if inArrayTable || !equalKeys(append(append([]string(nil), table...), splitTOMLKey(line[:eq])...), want) {
+			continue
+		}
+		s, err := quotedValue(line, valueStart)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s: %w", i+1, key, err)
+		}
+		spans = append(spans, span{lineStart + s.start, lineStart + s.end})
+	}
+	return spans, nil
+}
+
+// splitTOMLKey splits a dotted TOML key into its parts, unquoting each part.
+func splitTOMLKey(key string) []string {
+	parts := strings.Split(key, ".")
+	for i, p := range parts {
+		parts[i] = strings.Trim(strings.TrimSpace(p), `"'`)
+	}
+	return parts
+}
+
+// quotedValue returns the span of the quoted string starting at line[pos].
+func quotedValue(line string, pos int) (span, error) {
+	if pos >= len(line) || (line[pos] != '"' && line[pos] != '\'') {
+		return span{}, errors.New("value is not a string")
+	}
+	quote := line[pos]
+	for i := pos + 1; i < len(line); i++ {
+		switch {
+		case line[i] == '\\' && quote == '"':
+			i++
+		case line[i] == quote:
+			return span{pos + 1, i}, nil
+		}
+	}
+	return span{}, errors.New("unterminated string")
+}
+
+// locateJSON finds a string value by JSON pointer (RFC 6901, e.g., "/version").
+func locateJSON(data []byte, pointer string) ([]span, error) {
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid JSON pointer %q: must start with \"/\"", pointer)
+	}
+	want := strings.Split(pointer[1:], "/")
+	for i, token := range want {
+		want[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+
+	var spans []span
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := walkJSON(dec, data, nil, want, &spans); err != nil {
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, fmt.Errorf("invalid JSON: %w", err)
+		}
+		return nil, err
+	}
+	return spans, nil
+}
+
+// walkJSON reads one JSON value from dec, recording string values whose path
+// equals want.
+func walkJSON(dec *json.Decoder, data []byte, path, want []string, spans *[]span) error {
+	// The value starts after the separator that precedes it.
+	start := int(dec.InputOffset())
+	for start < len(data) && strings.IndexByte(" \t\r\n:,", data[start]) >= 0 {
+		start++
+	}
+
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if _, isString := tok.(string); !isString && equalKeys(path, want) {
+		return fmt.Errorf("value at /%s is not a string", strings.Join(path, "/"))
+	}
+	switch t := tok.(type) {
+	case json.Delim:
+		switch t {
+		case '{':
+			for dec.More() {
+				keyTok, err := dec.Token()
+				if err != nil {
+					return err
+				}
+				if err := walkJSON(dec, data, append(path, keyTok.(string)), want, spans); err != nil {
+					return err
+				}
+			}
+		case '[':
+			for i := 0; dec.More(); i++ {
+				if err := walkJSON(dec, data, append(path, strconv.Itoa(i)), want, spans); err != nil {
+					return err
+				}
+			}
+		}
+		_, err := dec.Token() // closing delimiter
+		return err
+	case string:
+		if equalKeys(path, want) {
+			*spans = append(*spans, span{start + 1, int(dec.InputOffset()) - 1})
+		}
+	}
+	return nil
+}
+
+// locateXML finds the text of elements by slash-separated path from the root
+// element (e.g., "Project/PropertyGroup/Version"). Namespace prefixes are ignored.
+func locateXML(data []byte, path string) ([]span, error) {
+	want := strings.Split(strings.Trim(path, "/"), "/")
+
+	var spans []span
+	var stack []string
+	contentStart := -1
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		before := int(dec.InputOffset())
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid XML: %w", err)
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if contentStart >= 0 {
+				return nil, fmt.Errorf("element %s has child elements", path)
+			}
+			stack = append(stack, t.Name.Local)
+			if equalKeys(stack, want) {
+				contentStart = int(dec.InputOffset())
+			}
+		case xml.EndElement:
+			if contentStart >= 0 {
+				if before == contentStart && bytes.HasSuffix(data[:contentStart], []byte("/>")) {
+					return nil, fmt.Errorf("element %s is self-closing", path)
+				}
+				spans = append(spans, trimSpan(data, span{contentStart, before}))
+				contentStart = -1
+			}
+			stack = stack[:len(stack)-1]
+		}
+	}
+	return spans, nil
+}
+
+// locateYAML finds a scalar value by dotted key path (e.g., "app.version")
+// in the first document of a YAML file.
+func locateYAML(data []byte, key string) ([]span, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid YAML: %w", err)
+	}
+	if len(doc.Content) == 0 {
+		return nil, nil
+	}
+
+	node := doc.Content[0]
+	for _, part := range strings.Split(key, ".") {
+		if node.Kind != yaml.MappingNode {
+			return nil, nil
+		}
+		var next *yaml.Node
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == part {
+				next = node.Content[i+1]
+			}
+		}
+		if next == nil {
+			return nil, nil
+		}
+		node = next
+	}
+	if node.Kind != yaml.ScalarNode {
+		return nil, fmt.Errorf("value at %s is not a scalar", key)
+	}
+
+	lineStart := 0
+	for line := 1; line < node.Line; line++ {
+		lineStart += bytes.IndexByte(data[lineStart:], '\n') + 1
+	}
+	lineEnd := len(data)
+	if i := bytes.IndexByte(data[lineStart:], '\n'); i >= 0 {
+		lineEnd = lineStart + i
+	}
+	line := string(data[lineStart:lineEnd])
+
+	// Columns count characters, not bytes.
+	pos := 0
+	for col := 1; col < node.Column && pos < len(line); col++ {
+		_, size := utf8.DecodeRuneInString(line[pos:])
+		pos += size
+	}
+
+	var s span
+	switch node.Style {
+	case yaml.DoubleQuotedStyle, yaml.SingleQuotedStyle:
+		var err error
+		if s, err = quotedValue(line, pos); err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+	case 0, yaml.TaggedStyle:
+		end := len(line)
+		if i := strings.Index(line[pos:], " #"); i >= 0 {
+			end = pos + i
+		}
+		s = trimSpan([]byte(line), span{pos, end})
+	default:
+		return nil, fmt.Errorf("value at %s must be a single-line scalar", key)
+	}
+	return []span{{lineStart + s.start, lineStart + s.end}}, nil
+}
+
+// trimSpan shrinks s to exclude leading and trailing whitespace.
+func trimSpan(data []byte, s span) span {
+	for s.start < s.end && isSpace(data[s.start]) {
+		s.start++
+	}
+	for s.end > s.start && isSpace(data[s.end-1]) {
+		s.end--
+	}
+	return s
+}
+
+func isSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\r' || b == '\n'
+}
+
+func equalKeys(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
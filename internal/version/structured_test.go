@@ -0,0 +1,222 @@
+package version
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/AndreyAkinshin/structyl/internal/config"
+)
+
+const cargoToml = `# Workspace member
+[package]
+name = "demo"
+version = "1.2.3" # keep in sync
+description = """
+[dependencies]
+version = "9.9.9"
+"""
+
+[dependencies]
+serde = { version = "1.0.0", features = ["derive"] }
+
+[dev-dependencies.criterion]
+version = "0.5.1"
+`
+
+const packageJSON = `{
+  "name": "demo",
+  "version": "1.2.3",
+  "dependencies": {
+    "version": "^4.0.0"
+  },
+  "files": ["dist"]
+}
+`
+
+const csproj = `<Project Sdk="Microsoft.NET.Sdk">
+  <!-- Versioning -->
+  <PropertyGroup>
+    <TargetFramework>net8.0</TargetFramework>
+    <Version>1.2.3</Version>
+  </PropertyGroup>
+  <ItemGroup>
+    <PackageReference Include="Foo" Version="2.0.0" />
+  </ItemGroup>
+</Project>
+`
+
+const pomXML = `<?xml version="1.0" encoding="UTF-8"?>
+<project xmlns="http://maven.apache.org/POM/4.0.0">
+  <parent>
+    <version>7.0.0</version>
+  </parent>
+  <version>
+    1.2.3
+  </version>
+</project>
+`
+
+const chartYAML = `# Chart metadata
+apiVersion: v2
+name: demo
+app:
+  version: "1.2.3"  # quoted
+  image: demo
+version: 1.2.3 # plain
+`
+
+func TestSetKey_PreservesFormatting(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		key    string
+		input  string
+		want   string
+	}{
+		{"toml table", config.VersionFormatTOML, "package.version", cargoToml,
+			strings.Replace(cargoToml, `version = "1.2.3" # keep`, `version = "2.0.0" # keep`, 1)},
+		{"toml dotted table header", config.VersionFormatTOML, "dev-dependencies.criterion.version", cargoToml,
+			strings.Replace(cargoToml, `"0.5.1"`, `"2.0.0"`, 1)},
+		{"toml dotted key", config.VersionFormatTOML, "tool.poetry.version", "tool.poetry.version = '1.2.3'\n",
+			"tool.poetry.version = '2.0.0'\n"},
+		{"json pointer", config.VersionFormatJSON, "/version", packageJSON,
+			strings.Replace(packageJSON, `"version": "1.2.3"`, `"version": "2.0.0"`, 1)},
+		{"json nested pointer", config.VersionFormatJSON, "/dependencies/version", packageJSON,
+			strings.Replace(packageJSON, `"^4.0.0"`, `"2.0.0"`, 1)},
+		{"xml path", config.VersionFormatXML, "Project/PropertyGroup/Version", csproj,
+			strings.Replace(csproj, "<Version>1.2.3</Version>", "<Version>2.0.0</Version>", 1)},
+		{"xml namespaced with whitespace", config.VersionFormatXML, "project/version", pomXML,
+			strings.Replace(pomXML, "    1.2.3\n", "    2.0.0\n", 1)},
+		{"yaml quoted", config.VersionFormatYAML, "app.version", chartYAML,
+			strings.Replace(chartYAML, `"1.2.3"  # quoted`, `"2.0.0"  # quoted`, 1)},
+		{"yaml plain", config.VersionFormatYAML, "version", chartYAML,
+			strings.Replace(chartYAML, "version: 1.2.3 # plain", "version: 2.0.0 # plain", 1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := setKey([]byte(tt.input), tt.format, tt.key, "2.0.0", false)
+			if err != nil {
+				t.Fatalf("setKey() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("setKey() =\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetKey_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		key     string
+		input   string
+		wantErr string
+	}{
+		{"toml missing", config.VersionFormatTOML, "project.version", cargoToml, "key not found"},
+		{"toml inline table", config.VersionFormatTOML, "dependencies.serde.version", cargoToml, "key not found"},
+		{"toml not string", config.VersionFormatTOML, "version", "version = 1\n", "not a string"},
+		{"toml duplicate", config.VersionFormatTOML, "version", "version = \"1\"\nversion = \"2\"\n", "matched 2 times"},
+		{"json missing", config.VersionFormatJSON, "/dependencies/missing", packageJSON, "key not found"},
+		{"json not string", config.VersionFormatJSON, "/files", packageJSON, "not a string"},
+		{"json invalid", config.VersionFormatJSON, "/version", `{"version": }`, "invalid JSON"},
+		{"json relative pointer", config.VersionFormatJSON, "version", packageJSON, "must start with"},
+		{"xml missing", config.VersionFormatXML, "Project/Version", csproj, "key not found"},
+		{"xml has children", config.VersionFormatXML, "Project/PropertyGroup", csproj, "child elements"},
+		{"xml self-closing", config.VersionFormatXML, "a/v", "<a><v/></a>", "self-closing"},
+		{"yaml missing", config.VersionFormatYAML, "app.tag", chartYAML, "key not found"},
+		{"yaml mapping", config.VersionFormatYAML, "app", chartYAML, "not a scalar"},
+		{"yaml block scalar", config.VersionFormatYAML, "v", "v: |\n  1.2.3\n", "single-line"},
+		{"unknown format", "ini", "version", "version=1", "unsupported format"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := setKey([]byte(tt.input), tt.format, tt.key, "2.0.0", false)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("setKey() error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSetKey_ReplaceAll(t *testing.T) {
+	input := "<a><v>1</v><v>2</v></a>"
+	got, err := setKey([]byte(input), config.VersionFormatXML, "a/v", "3.0.0", true)
+	if err != nil {
+		t.Fatalf("setKey() error = %v", err)
+	}
+	if want := "<a><v>3.0.0</v><v>3.0.0</v></a>"; string(got) != want {
+		t.Errorf("setKey() = %s, want %s", got, want)
+	}
+}
+
+func TestPropagate_KeyBasedFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	cargo := filepath.Join(tmpDir, "Cargo.toml")
+	pkg := filepath.Join(tmpDir, "package.json")
+	if err := os.WriteFile(cargo, []byte(cargoToml), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(pkg, []byte(packageJSON), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	files := []config.VersionFileConfig{
+		{Path: cargo, Key: "package.version"},
+		{Path: pkg, Key: "/version"},
+	}
+	if got := CheckFiles("2.0.0", files); got[0].Found != "1.2.3" || got[0].OK() {
+		t.Errorf("CheckFiles() before propagation = %+v", got[0])
+	}
+	if err := Propagate("2.0.0", files); err != nil {
+		t.Fatalf("Propagate() error = %v", err)
+	}
+	for _, s := range CheckFiles("2.0.0", files) {
+		if !s.OK() || s.Found != "2.0.0" {
+			t.Errorf("CheckFiles() after propagation = %+v", s)
+		}
+	}
+	if v, err := ReadKey(cargo, config.VersionFormatTOML, "package.version"); err != nil || v != "2.0.0" {
+		t.Errorf("ReadKey() = %q, %v; want 2.0.0", v, err)
+	}
+}
+
+func TestDetectFile(t *testing.T) {
+	root := t.TempDir()
+	write := func(name, content string) {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("rs/Cargo.toml", cargoToml)
+	write("cs/Demo/Demo.csproj", csproj)
+	write("cs/Demo.csproj", csproj)
+	write("py/pyproject.toml", "[tool.poetry]\nname = \"demo\"\n\n[project]\nversion = \"1.2.3\"\n")
+	write("ts/package.json", `{"name": "demo", "private": true}`)
+
+	tests := []struct {
+		dir, toolchain string
+		want           config.VersionFileConfig
+		ok             bool
+	}{
+		{"rs", "cargo", config.VersionFileConfig{Path: "rs/Cargo.toml", Key: "package.version"}, true},
+		{"cs", "dotnet", config.VersionFileConfig{Path: "cs/Demo.csproj", Key: "Project/PropertyGroup/Version"}, true},
+		{"py", "poetry", config.VersionFileConfig{Path: "py/pyproject.toml", Key: "project.version"}, true},
+		{"ts", "npm", config.VersionFileConfig{}, false},
+		{"rs", "go", config.VersionFileConfig{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := DetectFile(root, tt.dir, tt.toolchain)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("DetectFile(%s, %s) = %+v, %v; want %+v, %v", tt.dir, tt.toolchain, got, ok, tt.want, tt.ok)
+		}
+	}
+}
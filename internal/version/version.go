@@ -165,6 +165,48 @@ func Bump(current, part string) (string, error) {
 	return v.String(), nil
 }
 
+// BumpPre bumps the specified part of the version like [Bump], using pre as
+// the prerelease identifier (e.g., "alpha", "rc").
+//
+// For "major", "minor", and "patch", the bumped version starts a prerelease
+// series: 1.2.3 → 1.3.0-rc.1 (minor, "rc"). For "prerelease", a version in
+// the same series is incremented (1.3.0-rc.1 → 1.3.0-rc.2); a version in
+// another series switches to pre (1.3.0-alpha.2 → 1.3.0-rc.1); and a release
+// bumps patch (1.2.3 → 1.2.4-rc.1). An empty pre behaves like [Bump].
+func BumpPre(current, part, pre string) (string, error) {
+	if pre == "" {
+		return Bump(current, part)
+	}
+	if err := Validate("0.0.0-" + pre); err != nil {
+		return "", fmt.Errorf("invalid prerelease identifier: %q", pre)
+	}
+	if part == "release" {
+		return "", fmt.Errorf("cannot combine a prerelease identifier with %q", part)
+	}
+
+	if part == "prerelease" {
+		v, err := Parse(current)
+		if err != nil {
+			return "", err
+		}
+		if v.Prerelease == pre || strings.HasPrefix(v.Prerelease, pre+".") {
+			return Bump(current, part)
+		}
+		if v.Prerelease == "" {
+			v.Patch++
+		}
+		v.Prerelease = pre + ".1"
+		v.Build = ""
+		return v.String(), nil
+	}
+
+	bumped, err := Bump(current, part)
+	if err != nil {
+		return "", err
+	}
+	return bumped + "-" + pre + ".1", nil
+}
+
 // bumpPrerelease increments a prerelease version.
 // Examples:
 //   - "alpha.1" → "alpha.2" (increments rightmost numeric part)
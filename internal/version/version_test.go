@@ -178,6 +178,52 @@ func TestBump_InvalidPart(t *testing.T) {
 	}
 }
 
+func TestBumpPre(t *testing.T) {
+	tests := []struct {
+		current string
+		part    string
+		pre     string
+		want    string
+	}{
+		{"1.2.3", "major", "rc", "2.0.0-rc.1"},
+		{"1.2.3", "minor", "beta", "1.3.0-beta.1"},
+		{"1.2.3", "patch", "alpha", "1.2.4-alpha.1"},
+		{"1.2.3", "prerelease", "beta", "1.2.4-beta.1"},
+		{"1.3.0-beta.1", "prerelease", "beta", "1.3.0-beta.2"},
+		{"1.3.0-beta", "prerelease", "beta", "1.3.0-beta.1"},
+		{"1.3.0-alpha.4", "prerelease", "rc", "1.3.0-rc.1"},
+		{"1.3.0-alpha.4+build", "prerelease", "rc", "1.3.0-rc.1"},
+		{"1.3.0-alpha.4", "prerelease", "", "1.3.0-alpha.5"}, // empty pre behaves like Bump
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.current+"/"+tt.part+"/"+tt.pre, func(t *testing.T) {
+			got, err := BumpPre(tt.current, tt.part, tt.pre)
+			if err != nil {
+				t.Fatalf("BumpPre() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("BumpPre() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBumpPre_Errors(t *testing.T) {
+	t.Parallel()
+	for _, tt := range []struct{ current, part, pre string }{
+		{"1.2.3", "minor", "bad id"},
+		{"1.2.3", "minor", "rc..1"},
+		{"1.2.3", "release", "rc"},
+		{"1.2.3", "bogus", "rc"},
+		{"v1.2.3", "prerelease", "rc"},
+	} {
+		if _, err := BumpPre(tt.current, tt.part, tt.pre); err == nil {
+			t.Errorf("BumpPre(%q, %q, %q) = nil error, want error", tt.current, tt.part, tt.pre)
+		}
+	}
+}
+
 func TestBump_InvalidVersion(t *testing.T) {
 	t.Parallel()
 	invalidVersions := []string{
@@ -0,0 +1,376 @@
+package testhelper
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// ErrDecode is returned when a test case input or output cannot be decoded
+// into the requested Go type.
+// Use errors.Is(err, ErrDecode) to check for this condition.
+var ErrDecode = errors.New("test case decode failed")
+
+// DecodeError indicates that part of a test case does not match the Go type
+// it was decoded into. It carries the JSON path of the offending value.
+type DecodeError struct {
+	Case   string // Test case ID (see [TestCase.ID])
+	Path   string // JSON path, e.g. "input.count" or "output[2].name"
+	Reason string // What went wrong, e.g. "missing required field"
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("decode test case %q: %s: %s", e.Case, e.Path, e.Reason)
+}
+
+// Is implements error matching for [errors.Is].
+// Returns true when target is [ErrDecode].
+func (e *DecodeError) Is(target error) bool {
+	return target == ErrDecode
+}
+
+// DecodeInput decodes tc.Input into a value of type T, typically a struct
+// with JSON tags:
+//
+//	type quantileInput struct {
+//	    X     []float64 `json:"x"`
+//	    P     float64   `json:"p"`
+//	    Count int       `json:"count"`
+//	    Label string    `json:"label,omitempty"`
+//	}
+//
+//	in, err := testhelper.DecodeInput[quantileInput](tc)
+//
+// Decoding is strict, so mistakes in test data are reported instead of
+// silently producing zero values:
+//
+//   - Every struct field is required unless its tag has "omitempty" (or
+//     "omitzero"); a missing field is an error.
+//   - A JSON key with no matching struct field is an error.
+//   - Integer fields accept only integral numbers: 3 decodes into an int, but
+//     3.5 is an error rather than being truncated. Integers must be exactly
+//     representable as float64 (|n| ≤ 2^53), since that is how the loader
+//     stores numbers. Use [json.Number] for fields that need the number's
+//     textual form.
+//
+// These rules apply recursively to nested structs, slices, arrays, and maps.
+// Types implementing [json.Unmarshaler] are decoded by their own method.
+//
+// The special float strings "NaN", "Infinity", and "-Infinity" cannot be
+// decoded into float fields; decode them into string or interface{} fields.
+//
+// Errors wrap [ErrDecode] as a [DecodeError] naming the JSON path, e.g.
+// `decode test case "math/basic": input.count: missing required field`.
+func DecodeInput[T any](tc TestCase) (T, error) {
+	var v T
+	err := decodeValue(tc.ID(), "input", tc.Input, &v)
+	return v, err
+}
+
+// DecodeOutput decodes tc.Output into a value of type T.
+// T may be any type that the JSON value can be decoded into, such as
+// float64, []float64, or a struct. The same strict rules as [DecodeInput]
+// apply.
+func DecodeOutput[T any](tc TestCase) (T, error) {
+	var v T
+	err := decodeValue(tc.ID(), "output", tc.Output, &v)
+	return v, err
+}
+
+// decodeValue decodes the generic JSON value into target, a non-nil pointer.
+func decodeValue(caseID, root string, value interface{}, target interface{}) error {
+	if err := checkShape(reflect.TypeOf(target).Elem(), value, root); err != nil {
+		err.Case = caseID
+		return err
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return &DecodeError{Case: caseID, Path: root, Reason: err.Error()}
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(target); err != nil {
+		path, reason := root, err.Error()
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			if typeErr.Field != "" {
+				path = root + "." + typeErr.Field
+			}
+			reason = fmt.Sprintf("cannot decode JSON %s into %s", typeErr.Value, typeErr.Type)
+		}
+		return &DecodeError{Case: caseID, Path: path, Reason: reason}
+	}
+	return nil
+}
+
+var (
+	jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	jsonMarshalerType   = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	jsonNumberType      = reflect.TypeOf(json.Number(""))
+)
+
+// customUnmarshal reports whether t decodes itself, in which case its
+// shape is not checked.
+func customUnmarshal(t reflect.Type) bool {
+	pt := reflect.PointerTo(t)
+	return t.Implements(jsonUnmarshalerType) || pt.Implements(jsonUnmarshalerType) ||
+		t.Implements(textUnmarshalerType) || pt.Implements(textUnmarshalerType)
+}
+
+// checkShape reports missing required fields, unknown fields, and
+// non-integral numbers for integer targets. The returned error has no Case set.
+func checkShape(t reflect.Type, value interface{}, path string) *DecodeError {
+	if value == nil || customUnmarshal(t) {
+		return nil
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return checkShape(t.Elem(), value, path)
+	case reflect.Struct:
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return nil // reported as a type error by encoding/json
+		}
+		return checkStruct(t, obj, path)
+	case reflect.Slice, reflect.Array:
+		arr, ok := value.([]interface{})
+		if !ok {
+			return nil
+		}
+		for i, item := range arr {
+			if err := checkShape(t.Elem(), item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		for _, key := range sortedKeys(obj) {
+			if err := checkShape(t.Elem(), obj[key], path+"."+key); err != nil {
+				return err
+			}
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if f, ok := value.(float64); ok && f != math.Trunc(f) {
+			return &DecodeError{Path: path, Reason: fmt.Sprintf("number %v is not an integer (target type %s)", f, t)}
+		}
+	case reflect.Float32, reflect.Float64:
+		if s, ok := value.(string); ok {
+			switch s {
+			case "NaN", "Infinity", "+Infinity", "-Infinity":
+				return &DecodeError{Path: path, Reason: fmt.Sprintf("special value %q cannot be decoded into %s; use string or interface{}", s, t)}
+			}
+		}
+	}
+	return nil
+}
+
+// checkStruct checks a JSON object against the fields of struct type t.
+func checkStruct(t reflect.Type, obj map[string]interface{}, path string) *DecodeError {
+	fields := jsonFields(t)
+	used := make(map[string]bool, len(obj))
+
+	for _, f := range fields {
+		key, ok := matchKey(obj, f.name)
+		if !ok {
+			if !f.optional {
+				return &DecodeError{Path: path + "." + f.name, Reason: "missing required field"}
+			}
+			continue
+		}
+		used[key] = true
+		if err := checkShape(f.typ, obj[key], path+"."+f.name); err != nil {
+			return err
+		}
+	}
+
+	for _, key := range sortedKeys(obj) {
+		if !used[key] {
+			return &DecodeError{Path: path + "." + key, Reason: fmt.Sprintf("unknown field (not in %s)", t)}
+		}
+	}
+	return nil
+}
+
+// matchKey finds the object key for a field name, preferring an exact match
+// and falling back to the case-insensitive match encoding/json performs.
+func matchKey(obj map[string]interface{}, name string) (string, bool) {
+	if _, ok := obj[name]; ok {
+		return name, true
+	}
+	for _, key := range sortedKeys(obj) {
+		if strings.EqualFold(key, name) {
+			return key, true
+		}
+	}
+	return "", false
+}
+
+// jsonField describes a struct field as seen by encoding/json.
+type jsonField struct {
+	name     string
+	index    []int
+	typ      reflect.Type
+	optional bool
+}
+
+// jsonFields lists the JSON-visible fields of struct type t, flattening
+// untagged embedded structs the way encoding/json does.
+func jsonFields(t reflect.Type) []jsonField {
+	var fields []jsonField
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag := sf.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		if sf.Anonymous && name == "" {
+			ft := sf.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				for _, f := range jsonFields(ft) {
+					f.index = append([]int{i}, f.index...)
+					fields = append(fields, f)
+				}
+				continue
+			}
+		}
+		if !sf.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = sf.Name
+		}
+		optional := strings.Contains(","+opts+",", ",omitempty,") || strings.Contains(","+opts+",", ",omitzero,")
+		fields = append(fields, jsonField{name: name, index: []int{i}, typ: sf.Type, optional: optional})
+	}
+	return fields
+}
+
+// toJSONValue converts a Go value into the generic form produced by JSON
+// unmarshaling (float64, string, bool, []interface{}, map[string]interface{},
+// nil) so it can be passed to [Compare]. Unlike a JSON round-trip, NaN and
+// infinities are preserved as float64 values.
+func toJSONValue(v reflect.Value) (interface{}, error) {
+	if !v.IsValid() {
+		return nil, nil
+	}
+	if v.Type().Implements(jsonMarshalerType) || v.Type() == jsonNumberType {
+		if v.Kind() == reflect.Pointer && v.IsNil() {
+			return nil, nil
+		}
+		data, err := json.Marshal(v.Interface())
+		if err != nil {
+			return nil, err
+		}
+		var generic interface{}
+		if err := json.Unmarshal(data, &generic); err != nil {
+			return nil, err
+		}
+		return generic, nil
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil, nil
+		}
+		return toJSONValue(v.Elem())
+	case reflect.Bool:
+		return v.Bool(), nil
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(v.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), nil
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil, nil
+		}
+		result := make([]interface{}, v.Len())
+		for i := range result {
+			item, err := toJSONValue(v.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			result[i] = item
+		}
+		return result, nil
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("unsupported map key type %s", v.Type().Key())
+		}
+		if v.IsNil() {
+			return nil, nil
+		}
+		result := make(map[string]interface{}, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			item, err := toJSONValue(iter.Value())
+			if err != nil {
+				return nil, err
+			}
+			result[iter.Key().String()] = item
+		}
+		return result, nil
+	case reflect.Struct:
+		result := make(map[string]interface{})
+		for _, f := range jsonFields(v.Type()) {
+			fv, err := v.FieldByIndexErr(f.index)
+			if err != nil {
+				continue // nil embedded pointer
+			}
+			if f.optional && isEmptyJSONValue(fv) {
+				continue
+			}
+			item, err := toJSONValue(fv)
+			if err != nil {
+				return nil, err
+			}
+			result[f.name] = item
+		}
+		return result, nil
+	default:
+		return nil, fmt.Errorf("unsupported type %s", v.Type())
+	}
+}
+
+// isEmptyJSONValue mirrors encoding/json's omitempty rules.
+func isEmptyJSONValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	default:
+		return v.IsZero()
+	}
+}
+
+// sortedKeys returns the keys of obj in sorted order, for deterministic errors.
+func sortedKeys(obj map[string]interface{}) []string {
+	keys := make([]string, 0, len(obj))
+	for key := range obj {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
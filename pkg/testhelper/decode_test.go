@@ -0,0 +1,151 @@
+package testhelper
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type decodePoint struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+type decodeInput struct {
+	Values []float64     `json:"values"`
+	Count  int           `json:"count"`
+	Label  string        `json:"label,omitempty"`
+	Points []decodePoint `json:"points,omitempty"`
+	Exact  json.Number   `json:"exact,omitempty"`
+}
+
+func mustCase(t *testing.T, data string) TestCase {
+	t.Helper()
+	tc, err := NewTestCaseFromJSONWithSuite([]byte(data), "basic", "math")
+	if err != nil {
+		t.Fatalf("NewTestCaseFromJSONWithSuite() error = %v", err)
+	}
+	return *tc
+}
+
+func TestDecodeInput_Struct(t *testing.T) {
+	t.Parallel()
+	tc := mustCase(t, `{"input": {"values": [1.5, 2], "count": 3, "points": [{"x": 1, "y": 2}], "exact": 12345678901}, "output": 0}`)
+
+	got, err := DecodeInput[decodeInput](tc)
+	if err != nil {
+		t.Fatalf("DecodeInput() error = %v", err)
+	}
+	want := decodeInput{
+		Values: []float64{1.5, 2},
+		Count:  3,
+		Points: []decodePoint{{X: 1, Y: 2}},
+		Exact:  "12345678901",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DecodeInput() = %+v, want %+v", got, want)
+	}
+}
+
+func TestDecodeInput_Errors(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		data     string
+		wantPath string
+		wantText string
+	}{
+		{"missing field", `{"input": {"values": []}, "output": 0}`, "input.count", "missing required field"},
+		{"unknown field", `{"input": {"values": [], "count": 1, "extra": true}, "output": 0}`, "input.extra", "unknown field"},
+		{"fractional integer", `{"input": {"values": [], "count": 2.5}, "output": 0}`, "input.count", "not an integer"},
+		{"nested missing field", `{"input": {"values": [], "count": 1, "points": [{"x": 1}]}, "output": 0}`, "input.points[0].y", "missing required field"},
+		{"type mismatch", `{"input": {"values": "abc", "count": 1}, "output": 0}`, "input.values", "cannot decode JSON string"},
+		{"special float", `{"input": {"values": ["NaN"], "count": 1}, "output": 0}`, "input.values[0]", "special value \"NaN\""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := DecodeInput[decodeInput](mustCase(t, tt.data))
+			if !errors.Is(err, ErrDecode) {
+				t.Fatalf("DecodeInput() error = %v, want ErrDecode", err)
+			}
+			var decodeErr *DecodeError
+			if !errors.As(err, &decodeErr) {
+				t.Fatalf("DecodeInput() error type = %T, want *DecodeError", err)
+			}
+			if decodeErr.Case != "math/basic" {
+				t.Errorf("Case = %q, want %q", decodeErr.Case, "math/basic")
+			}
+			if decodeErr.Path != tt.wantPath {
+				t.Errorf("Path = %q, want %q", decodeErr.Path, tt.wantPath)
+			}
+			if !strings.Contains(decodeErr.Reason, tt.wantText) {
+				t.Errorf("Reason = %q, want substring %q", decodeErr.Reason, tt.wantText)
+			}
+		})
+	}
+}
+
+func TestDecodeOutput(t *testing.T) {
+	t.Parallel()
+	tc := mustCase(t, `{"input": {}, "output": [{"x": 1, "y": 2}, {"x": 3, "y": 4}]}`)
+
+	got, err := DecodeOutput[[]decodePoint](tc)
+	if err != nil {
+		t.Fatalf("DecodeOutput() error = %v", err)
+	}
+	want := []decodePoint{{1, 2}, {3, 4}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DecodeOutput() = %+v, want %+v", got, want)
+	}
+
+	if _, err := DecodeOutput[int](mustCase(t, `{"input": {}, "output": 1.25}`)); !errors.Is(err, ErrDecode) {
+		t.Errorf("DecodeOutput[int](1.25) error = %v, want ErrDecode", err)
+	}
+}
+
+func TestToJSONValue(t *testing.T) {
+	t.Parallel()
+	type inner struct {
+		N int `json:"n"`
+	}
+	type result struct {
+		Mean   float64           `json:"mean"`
+		Counts []int             `json:"counts"`
+		Inner  *inner            `json:"inner"`
+		Extra  map[string]string `json:"extra,omitempty"`
+		Hidden string            `json:"-"`
+	}
+
+	got, err := toJSONValue(reflect.ValueOf(result{
+		Mean:   math.NaN(),
+		Counts: []int{1, 2},
+		Inner:  &inner{N: 7},
+		Hidden: "ignored",
+	}))
+	if err != nil {
+		t.Fatalf("toJSONValue() error = %v", err)
+	}
+
+	obj, ok := got.(map[string]interface{})
+	if !ok {
+		t.Fatalf("toJSONValue() = %T, want map", got)
+	}
+	if mean, ok := obj["mean"].(float64); !ok || !math.IsNaN(mean) {
+		t.Errorf("mean = %v, want NaN", obj["mean"])
+	}
+	if !reflect.DeepEqual(obj["counts"], []interface{}{1.0, 2.0}) {
+		t.Errorf("counts = %v, want [1 2]", obj["counts"])
+	}
+	if !reflect.DeepEqual(obj["inner"], map[string]interface{}{"n": 7.0}) {
+		t.Errorf("inner = %v, want map[n:7]", obj["inner"])
+	}
+	for _, key := range []string{"extra", "Hidden"} {
+		if _, ok := obj[key]; ok {
+			t.Errorf("key %q should be omitted", key)
+		}
+	}
+}
@@ -36,6 +36,7 @@
 //	[ErrTestCaseNotFound]    ← [TestCaseNotFoundError] (carries Name, Suite, ProjectRoot)
 //	[ErrInvalidSuiteName]    ← [InvalidSuiteNameError] (carries Name, Reason)
 //	[ErrInvalidTestCaseName] ← [InvalidTestCaseNameError] (carries Name, Reason)
+//	[ErrDecode]              ← [DecodeError] (carries Case, Path, Reason)
 //	[ErrEmptySuiteName]      (sentinel only)
 //	[ErrEmptyTestCaseName]   (sentinel only)
 //	[ErrFileReferenceNotSupported] (sentinel only)
@@ -172,8 +173,10 @@ import (
 //   - JSON null → Go nil
 //
 // Note: JSON does not distinguish integers from floats. All numbers in Input
-// and Output are unmarshaled as float64. Callers should convert as needed
-// (e.g., int(tc.Input["count"].(float64))).
+// and Output are unmarshaled as float64. Prefer [DecodeInput] and
+// [DecodeOutput] to decode into typed structs over manual conversion
+// (e.g., int(tc.Input["count"].(float64))). [RunSuite] runs a whole suite
+// with typed inputs and outputs.
 type TestCase struct {
 	// Name is the test case name (derived from filename).
 	Name string `json:"-"`
@@ -246,17 +249,18 @@ type TestCase struct {
 	// Skip marks the test as skipped if true.
 	Skip bool `json:"skip,omitempty"`
 
-	// Tags provides optional categorization for filtering or grouping tests.
-	// Unlike other TestCase fields, Tags has no built-in semantics in structyl.
-	// Language implementations MAY use tags to:
-	//   - Filter test execution (e.g., run only "slow" or "integration" tests)
-	//   - Group tests in output
-	//   - Skip tests based on environment capabilities
+	// Tags selects which cases run. "structyl test --tags slow,!flaky" exports
+	// the selection as [TagsEnvVar] (STRUCTYL_TEST_TAGS), and harnesses MUST
+	// run only the matching cases: a case with an excluded tag never runs,
+	// and if any tag is included, a case must have at least one of them.
+	// [RunSuite] applies the selection; custom loaders use [TagFilterFromEnv]
+	// and [FilterByTags]. Beyond selection, implementations MAY use tags to
+	// group tests in output.
 	//
 	// Tag values are free-form strings with no validation: empty strings,
-	// duplicates, and any characters are permitted. Establish conventions
-	// per-project. This permissive design is intentional to avoid constraining
-	// downstream tooling.
+	// duplicates, and any characters are permitted, although a tag containing
+	// a comma cannot be written in a selection. Establish conventions
+	// per-project.
 	//
 	// Recommended conventions (not enforced):
 	//   - Use lowercase, hyphen-separated names (e.g., "slow", "integration", "skip-ci")
@@ -759,16 +763,34 @@ func validateDeepType(path string, v interface{}) error {
 // internal test runner or iterate subdirectories manually.
 // See docs/specs/test-system.md for pattern support details.
 //
+// Case templates (files with a top-level "$matrix" field) are expanded into
+// one test case per parameter combination, named "<file>[k1=v1,k2=v2]".
+// Expanded cases appear in matrix order at the template file's position.
+// See [LoadTestCases] for details.
+//
 // Returns ErrEmptySuiteName or ErrInvalidSuiteName if the suite name is invalid.
 // Returns SuiteNotFoundError if the suite directory does not exist.
 // Returns an empty slice (not nil) if the suite exists but contains no JSON files.
 func LoadTestSuite(projectRoot, suite string) ([]TestCase, error) {
+	return loadSuite(projectRoot, filepath.Join(projectRoot, "tests"), suite)
+}
+
+// LoadTestSuiteFromDir loads all test cases for a suite from an explicit
+// tests directory (testsDir/suite/*.json) rather than a project root.
+// It behaves like [LoadTestSuite] otherwise; a missing suite directory
+// yields a SuiteNotFoundError whose Root is testsDir.
+func LoadTestSuiteFromDir(testsDir, suite string) ([]TestCase, error) {
+	return loadSuite(testsDir, testsDir, suite)
+}
+
+// loadSuite loads testsDir/suite/*.json, reporting root in not-found errors.
+func loadSuite(root, testsDir, suite string) ([]TestCase, error) {
 	if err := ValidateSuiteName(suite); err != nil {
 		return nil, err
 	}
-	suiteDir := filepath.Join(projectRoot, "tests", suite)
+	suiteDir := filepath.Join(testsDir, suite)
 	if _, err := os.Stat(suiteDir); os.IsNotExist(err) {
-		return nil, &SuiteNotFoundError{Root: projectRoot, Suite: suite}
+		return nil, &SuiteNotFoundError{Root: root, Suite: suite}
 	}
 
 	pattern := filepath.Join(suiteDir, "*.json")
@@ -779,21 +801,44 @@ func LoadTestSuite(projectRoot, suite string) ([]TestCase, error) {
 
 	// Sort files for deterministic ordering across platforms.
 	// filepath.Glob returns files in filesystem-dependent order.
-	sort.Strings(files)
+	SortCaseFiles(files)
 
 	cases := make([]TestCase, 0, len(files))
 	for _, f := range files {
-		tc, err := LoadTestCase(f)
+		loaded, err := loadTestCasesInternal(f, suite)
 		if err != nil {
 			return nil, fmt.Errorf("suite %q: %w", suite, err)
 		}
-		tc.Suite = suite
-		cases = append(cases, *tc)
+		cases = append(cases, loaded...)
 	}
 
 	return cases, nil
 }
 
+// LoadTestCases loads all test cases defined by a JSON file.
+// A regular test case file yields a single case, identical to [LoadTestCase].
+// A case template yields one case per combination of its "$matrix":
+//
+//	{
+//	  "$matrix": {"p": [0.5, 0.9], "n": [10, 100]},
+//	  "input": {"p": {"$param": "p"}, "n": {"$param": "n"}},
+//	  "output": 1.0
+//	}
+//
+// An object matrix is expanded as a cartesian grid (first parameter
+// outermost); an array matrix lists explicit rows, and a row may provide a
+// per-case expected value via "$output". Every {"$param": "name"} placeholder
+// is replaced by the parameter value.
+//
+// Expanded cases are named "<file>[k1=v1,k2=v2]" with parameters in
+// declaration order and values exactly as written in JSON (e.g.,
+// "quantile[p=0.5,n=10]"). Names are identical across all language harnesses.
+//
+// Like [LoadTestCase], this function does NOT set TestCase.Suite.
+func LoadTestCases(path string) ([]TestCase, error) {
+	return loadTestCasesInternal(path, "")
+}
+
 // LoadTestCase loads a single test case from a JSON file.
 // Returns an error if the file cannot be read, contains invalid JSON,
 // or is missing required fields (input and output).
@@ -810,6 +855,9 @@ func LoadTestSuite(projectRoot, suite string) ([]TestCase, error) {
 // empty ("") after loading. If your code requires suite information, use
 // [LoadTestCaseWithSuite] to explicitly set the suite, or [LoadTestSuite] to
 // load all cases from a suite directory (which sets Suite automatically).
+//
+// Returns [ErrCaseTemplate] if the file is a "$matrix" case template; use
+// [LoadTestCases] to expand templates.
 func LoadTestCase(path string) (*TestCase, error) {
 	return loadTestCaseInternal(path, "")
 }
@@ -831,6 +879,10 @@ func LoadTestCaseWithSuite(path, suite string) (*TestCase, error) {
 //
 // The test case is loaded from: {projectRoot}/tests/{suite}/{name}.json
 //
+// Expanded case names (e.g., "quantile[p=0.5,n=10]") are resolved by expanding
+// the template {projectRoot}/tests/{suite}/quantile.json when no file with the
+// exact name exists. See [LoadTestCases].
+//
 // Returns:
 //   - [ErrEmptySuiteName] or [ErrInvalidSuiteName] if suite validation fails
 //   - [ErrEmptyTestCaseName] or [ErrInvalidTestCaseName] if name validation fails
@@ -845,6 +897,13 @@ func LoadTestCaseByName(projectRoot, suite, name string) (*TestCase, error) {
 	}
 	path := filepath.Join(projectRoot, "tests", suite, name+".json")
 	tc, err := loadTestCaseInternal(path, suite)
+	if errors.Is(err, ErrTestCaseNotFound) {
+		if expanded, found, expErr := findExpandedCase(projectRoot, suite, name); expErr != nil {
+			return nil, expErr
+		} else if found {
+			return expanded, nil
+		}
+	}
 	if err != nil {
 		// Enhance TestCaseNotFoundError with suite and name context
 		var tcnfErr *TestCaseNotFoundError
@@ -985,6 +1044,91 @@ func validateInputFieldType(data []byte) error {
 
 // loadTestCaseInternal is the shared implementation for LoadTestCase and LoadTestCaseWithSuite.
 func loadTestCaseInternal(path, suite string) (*TestCase, error) {
+	data, err := readTestCaseFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if IsCaseTemplate(data) {
+		return nil, fmt.Errorf("%s: %w", filepath.Base(path), ErrCaseTemplate)
+	}
+	return parseTestCaseFile(data, path, strings.TrimSuffix(filepath.Base(path), ".json"), suite)
+}
+
+// loadTestCasesInternal is the shared implementation for LoadTestCases and LoadTestSuite.
+func loadTestCasesInternal(path, suite string) ([]TestCase, error) {
+	data, err := readTestCaseFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	baseName := strings.TrimSuffix(filepath.Base(path), ".json")
+	if !IsCaseTemplate(data) {
+		tc, err := parseTestCaseFile(data, path, baseName, suite)
+		if err != nil {
+			return nil, err
+		}
+		return []TestCase{*tc}, nil
+	}
+
+	expanded, err := ExpandCaseTemplate(baseName, data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	cases := make([]TestCase, 0, len(expanded))
+	for _, c := range expanded {
+		tc, err := parseTestCaseFile(c.Data, path, c.Name, suite)
+		if err != nil {
+			return nil, fmt.Errorf("%s: case %q: %w", filepath.Base(path), c.Name, err)
+		}
+		cases = append(cases, *tc)
+	}
+	return cases, nil
+}
+
+// findExpandedCase resolves an expanded case name such as "quantile[p=0.5]"
+// against the template file "quantile.json" in the suite directory.
+// Returns found=false if the name is not in expanded form, the template does
+// not exist, or the template does not produce the name.
+func findExpandedCase(projectRoot, suite, name string) (*TestCase, bool, error) {
+	baseName, ok := expandedBaseName(name)
+	if !ok {
+		return nil, false, nil
+	}
+	path := filepath.Join(projectRoot, "tests", suite, baseName+".json")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	if !IsCaseTemplate(data) {
+		return nil, false, nil
+	}
+	cases, err := loadTestCasesInternal(path, suite)
+	if err != nil {
+		return nil, false, err
+	}
+	for i := range cases {
+		if cases[i].Name == name {
+			return &cases[i], true, nil
+		}
+	}
+	return nil, false, nil
+}
+
+// expandedBaseName returns the template file name for an expanded case name
+// ("quantile[p=0.5]" → "quantile").
+func expandedBaseName(name string) (string, bool) {
+	idx := strings.IndexByte(name, '[')
+	if idx <= 0 || !strings.HasSuffix(name, "]") {
+		return "", false
+	}
+	return name[:idx], true
+}
+
+// readTestCaseFile reads a test case file, mapping a missing file to TestCaseNotFoundError.
+func readTestCaseFile(path string) ([]byte, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -992,7 +1136,12 @@ func loadTestCaseInternal(path, suite string) (*TestCase, error) {
 		}
 		return nil, err
 	}
+	return data, nil
+}
 
+// parseTestCaseFile validates and parses test case JSON read from path.
+// Errors are prefixed with the file's base name.
+func parseTestCaseFile(data []byte, path, name, suite string) (*TestCase, error) {
 	// Pre-validate input field type before unmarshaling into TestCase.
 	// JSON arrays silently unmarshal to nil when the target is map[string]interface{},
 	// so we must check the raw JSON to distinguish missing from wrong type.
@@ -1023,7 +1172,7 @@ func loadTestCaseInternal(path, suite string) (*TestCase, error) {
 		return nil, fmt.Errorf("%s: missing required field \"output\"", filepath.Base(path))
 	}
 
-	tc.Name = strings.TrimSuffix(filepath.Base(path), ".json")
+	tc.Name = name
 	if tc.Name == "" {
 		return nil, fmt.Errorf("%s: invalid filename (name cannot be empty)", filepath.Base(path))
 	}
@@ -1082,8 +1231,9 @@ func LoadAllSuites(projectRoot string) (map[string][]TestCase, error) {
 	return suites, nil
 }
 
-// FindProjectRoot walks up the directory tree to find .structyl/config.json.
-// It returns the directory containing .structyl/config.json.
+// FindProjectRoot walks up the directory tree to find .structyl/config.json,
+// or the project configuration file in another format (config.jsonc,
+// config.yaml, config.toml). It returns the directory containing .structyl.
 func FindProjectRoot() (string, error) {
 	cwd, err := os.Getwd()
 	if err != nil {
@@ -1097,8 +1247,7 @@ func FindProjectRootFrom(startDir string) (string, error) {
 	dir := startDir
 
 	for {
-		configPath := filepath.Join(dir, ".structyl", "config.json")
-		if _, err := os.Stat(configPath); err == nil {
+		if _, err := findConfigFile(filepath.Join(dir, ".structyl")); err == nil {
 			return dir, nil
 		}
 
@@ -1122,7 +1271,7 @@ type ProjectNotFoundError struct {
 }
 
 func (e *ProjectNotFoundError) Error() string {
-	return ".structyl/config.json not found (searched from " + e.StartDir + ")"
+	return ".structyl/config.* not found (searched from " + e.StartDir + ")"
 }
 
 // Is implements error matching for errors.Is().
@@ -1180,6 +1329,11 @@ func (e *TestCaseNotFoundError) Is(target error) bool {
 // Use embedded data instead, or use Structyl's internal test runner for $file support.
 var ErrFileReferenceNotSupported = errors.New("$file references not supported in pkg/testhelper; use internal/tests package or embed data directly in JSON")
 
+// ErrCaseTemplate is returned when a single test case is requested from a
+// "$matrix" case template, which defines several cases. Use [LoadTestCases]
+// or [LoadTestSuite] to load the expanded cases.
+var ErrCaseTemplate = errors.New("file is a $matrix case template; use LoadTestCases or LoadTestSuite to expand it")
+
 // ErrEmptySuiteName is returned when an empty suite name is provided.
 // Suite names must be non-empty strings corresponding to directory names.
 //
@@ -1357,6 +1511,10 @@ func ListSuites(projectRoot string) ([]string, error) {
 //
 // Only .json files are included in the result; other files are ignored.
 // The returned names do not include the .json extension.
+//
+// Case templates are reported by their expanded names (e.g.,
+// "quantile[p=0.5,n=10]"), matching the names produced by [LoadTestSuite].
+// Returns an error if a template cannot be expanded.
 func ListTestCases(projectRoot, suite string) ([]string, error) {
 	if err := ValidateSuiteName(suite); err != nil {
 		return nil, err
@@ -1377,8 +1535,24 @@ func ListTestCases(projectRoot, suite string) ([]string, error) {
 			continue
 		}
 		name := entry.Name()
-		if strings.HasSuffix(name, ".json") {
-			testCases = append(testCases, strings.TrimSuffix(name, ".json"))
+		if !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(suiteDir, name))
+		if err != nil {
+			return nil, err
+		}
+		baseName := strings.TrimSuffix(name, ".json")
+		if !IsCaseTemplate(data) {
+			testCases = append(testCases, baseName)
+			continue
+		}
+		expanded, err := ExpandCaseTemplate(baseName, data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		for _, c := range expanded {
+			testCases = append(testCases, c.Name)
 		}
 	}
 
@@ -1409,6 +1583,7 @@ func SuiteExists(projectRoot, suite string) bool {
 }
 
 // TestCaseExists checks if a specific test case exists.
+// Expanded case names from "$matrix" templates are recognized (see [LoadTestCases]).
 // Returns false for any error (including permission errors), not just "not found".
 // Use [LoadTestCase] for detailed error information, or [TestCaseExistsErr] to
 // distinguish "not found" from "permission denied" or other errors.
@@ -1424,8 +1599,11 @@ func TestCaseExists(projectRoot, suite, name string) bool {
 		return false
 	}
 	path := filepath.Join(projectRoot, "tests", suite, name+".json")
-	_, err := os.Stat(path)
-	return err == nil
+	if _, err := os.Stat(path); err == nil {
+		return true
+	}
+	_, found, err := findExpandedCase(projectRoot, suite, name)
+	return err == nil && found
 }
 
 // validatePathComponent checks if a path component is safe.
@@ -1495,6 +1673,7 @@ func SuiteExistsErr(projectRoot, suite string) (bool, error) {
 }
 
 // TestCaseExistsErr checks if a specific test case exists, returning detailed error information.
+// Expanded case names from "$matrix" templates are recognized (see [LoadTestCases]).
 // Returns (true, nil) if the test case exists, (false, nil) if it doesn't exist,
 // or (false, error) for other errors like permission denied or invalid input.
 // This variant is useful when callers need to distinguish "not found" from "access error"
@@ -1517,7 +1696,8 @@ func TestCaseExistsErr(projectRoot, suite, name string) (bool, error) {
 	_, err := os.Stat(path)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return false, nil
+			_, found, expErr := findExpandedCase(projectRoot, suite, name)
+			return found, expErr
 		}
 		return false, err
 	}
@@ -4093,3 +4093,86 @@ func TestTestCase_JSONRoundTrip(t *testing.T) {
 		})
 	}
 }
+
+func TestLoadTestSuite_MatrixTemplate(t *testing.T) {
+	tmpDir := t.TempDir()
+	suiteDir := filepath.Join(tmpDir, "tests", "stats")
+	os.MkdirAll(suiteDir, 0755)
+
+	template := `{
+		"$matrix": {"p": [0.5, 0.9], "n": [10]},
+		"input": {"p": {"$param": "p"}, "n": {"$param": "n"}},
+		"output": 1
+	}`
+	os.WriteFile(filepath.Join(suiteDir, "quantile.json"), []byte(template), 0644)
+	os.WriteFile(filepath.Join(suiteDir, "mean.json"), []byte(`{"input": {}, "output": 2}`), 0644)
+
+	cases, err := LoadTestSuite(tmpDir, "stats")
+	if err != nil {
+		t.Fatalf("LoadTestSuite() error = %v", err)
+	}
+
+	var names []string
+	for _, tc := range cases {
+		names = append(names, tc.Name)
+		if tc.Suite != "stats" {
+			t.Errorf("Suite = %q, want %q", tc.Suite, "stats")
+		}
+	}
+	want := []string{"mean", "quantile[p=0.5,n=10]", "quantile[p=0.9,n=10]"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("names = %v, want %v", names, want)
+	}
+
+	listed, err := ListTestCases(tmpDir, "stats")
+	if err != nil {
+		t.Fatalf("ListTestCases() error = %v", err)
+	}
+	if !reflect.DeepEqual(listed, want) {
+		t.Errorf("ListTestCases() = %v, want %v", listed, want)
+	}
+
+	tc, err := LoadTestCaseByName(tmpDir, "stats", "quantile[p=0.9,n=10]")
+	if err != nil {
+		t.Fatalf("LoadTestCaseByName() error = %v", err)
+	}
+	if tc.Input["p"] != 0.9 {
+		t.Errorf("Input[p] = %v, want 0.9", tc.Input["p"])
+	}
+	if !TestCaseExists(tmpDir, "stats", "quantile[p=0.5,n=10]") {
+		t.Error("TestCaseExists() = false for expanded case")
+	}
+	if TestCaseExists(tmpDir, "stats", "quantile[p=0.7,n=10]") {
+		t.Error("TestCaseExists() = true for missing expanded case")
+	}
+
+	_, err = LoadTestCase(filepath.Join(suiteDir, "quantile.json"))
+	if !errors.Is(err, ErrCaseTemplate) {
+		t.Errorf("LoadTestCase() error = %v, want ErrCaseTemplate", err)
+	}
+}
+
+func TestLoadTestSuiteFromDir(t *testing.T) {
+	testsDir := t.TempDir()
+	suiteDir := filepath.Join(testsDir, "fuzz")
+	if err := os.MkdirAll(suiteDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(suiteDir, "fuzz-0001.json"), []byte(`{"input": {"x": 1}, "output": 2}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cases, err := LoadTestSuiteFromDir(testsDir, "fuzz")
+	if err != nil {
+		t.Fatalf("LoadTestSuiteFromDir() error = %v", err)
+	}
+	if len(cases) != 1 || cases[0].ID() != "fuzz/fuzz-0001" {
+		t.Errorf("LoadTestSuiteFromDir() = %v, want fuzz/fuzz-0001", cases)
+	}
+
+	_, err = LoadTestSuiteFromDir(testsDir, "missing")
+	var notFound *SuiteNotFoundError
+	if !errors.As(err, &notFound) || notFound.Root != testsDir {
+		t.Errorf("LoadTestSuiteFromDir(missing) error = %v, want SuiteNotFoundError in %s", err, testsDir)
+	}
+}
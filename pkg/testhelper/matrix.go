@@ -0,0 +1,388 @@
+// Case templates: parametrized reference test cases.
+//
+// A case template is a regular test case file with a top-level "$matrix"
+// field. The matrix declares parameter values, and "$param" placeholders in
+// the template are substituted for each combination:
+//
+//	{
+//	  "$matrix": {"p": [0.5, 0.9], "n": [10, 100]},
+//	  "input": {"p": {"$param": "p"}, "n": {"$param": "n"}},
+//	  "output": 1.0
+//	}
+//
+// The matrix is either an object of parameter arrays (expanded as a cartesian
+// grid, first parameter outermost) or an array of explicit rows. A row may
+// carry a "$output" value that overrides the template output for that case,
+// which is how per-combination expected values are expressed.
+//
+// Expanded cases are named "<file>[k1=v1,k2=v2]" with parameters listed in
+// declaration order, e.g. "quantile[p=0.5,n=10]". Values appear exactly as
+// written in the JSON source, so names are deterministic across platforms and
+// across every language harness.
+//
+// Both loaders (internal/tests and this package) use this expansion so all
+// consumers observe the same expanded set.
+
+package testhelper
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Reserved keys used by case templates.
+const (
+	// MatrixKey is the top-level template field declaring parameter values.
+	MatrixKey = "$matrix"
+	// ParamKey is the placeholder key: {"$param": "name"}.
+	ParamKey = "$param"
+	// RowOutputKey overrides the template output for a single matrix row.
+	RowOutputKey = "$output"
+)
+
+// ExpandedCase is a single test case document expanded from a case template.
+type ExpandedCase struct {
+	// Name is the expanded case name (e.g., "quantile[p=0.5,n=10]").
+	Name string
+	// Data is the JSON document for the case with "$matrix" removed and all
+	// "$param" placeholders substituted.
+	Data []byte
+}
+
+// param is a single named parameter value within a combination.
+type param struct {
+	name  string
+	value interface{}
+}
+
+// IsCaseTemplate reports whether data is a case template (has a top-level "$matrix").
+// Returns false for invalid JSON; callers report parse errors through their
+// regular loading path.
+func IsCaseTemplate(data []byte) bool {
+	// Fast path: avoid a full parse for ordinary case files.
+	if !bytes.Contains(data, []byte(`"`+MatrixKey+`"`)) {
+		return false
+	}
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return false
+	}
+	_, ok := raw[MatrixKey]
+	return ok
+}
+
+// ExpandCaseTemplate expands a case template into concrete case documents.
+// The baseName is the template file name without extension and becomes the
+// prefix of every expanded case name. Cases are returned in matrix order.
+//
+// Returns an error if the matrix is malformed, a placeholder references an
+// unknown parameter, an expanded name is not a valid file-safe name, or two
+// combinations produce the same name.
+func ExpandCaseTemplate(baseName string, data []byte) ([]ExpandedCase, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var doc map[string]interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	matrixRaw, ok := raw[MatrixKey]
+	if !ok {
+		return nil, fmt.Errorf("missing %q field", MatrixKey)
+	}
+
+	combos, err := parseMatrix(matrixRaw)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", MatrixKey, err)
+	}
+	if len(combos) == 0 {
+		return nil, fmt.Errorf("%s: expands to no cases", MatrixKey)
+	}
+
+	delete(doc, MatrixKey)
+
+	seen := make(map[string]bool, len(combos))
+	cases := make([]ExpandedCase, 0, len(combos))
+	for i, combo := range combos {
+		name, err := caseName(baseName, combo.params)
+		if err != nil {
+			return nil, fmt.Errorf("%s[%d]: %w", MatrixKey, i, err)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("%s[%d]: duplicate expanded case name %q", MatrixKey, i, name)
+		}
+		seen[name] = true
+
+		values := make(map[string]interface{}, len(combo.params))
+		for _, p := range combo.params {
+			values[p.name] = p.value
+		}
+
+		expanded := make(map[string]interface{}, len(doc))
+		for key, val := range doc {
+			sub, err := substitute(val, values)
+			if err != nil {
+				return nil, fmt.Errorf("case %q: %s: %w", name, key, err)
+			}
+			expanded[key] = sub
+		}
+		if combo.hasOutput {
+			expanded["output"] = combo.output
+		}
+
+		caseData, err := json.Marshal(expanded)
+		if err != nil {
+			return nil, fmt.Errorf("case %q: %w", name, err)
+		}
+		cases = append(cases, ExpandedCase{Name: name, Data: caseData})
+	}
+
+	return cases, nil
+}
+
+// combination is one set of parameter values produced by the matrix.
+type combination struct {
+	params    []param
+	output    interface{}
+	hasOutput bool
+}
+
+// parseMatrix parses the "$matrix" value into combinations.
+func parseMatrix(raw json.RawMessage) ([]combination, error) {
+	trimmed := bytes.TrimSpace(raw)
+	if len(trimmed) == 0 {
+		return nil, errors.New("must be an object or an array")
+	}
+	switch trimmed[0] {
+	case '{':
+		return parseGrid(trimmed)
+	case '[':
+		return parseRows(trimmed)
+	default:
+		return nil, errors.New("must be an object of parameter arrays or an array of rows")
+	}
+}
+
+// parseGrid expands an object of parameter arrays as a cartesian product.
+func parseGrid(raw []byte) ([]combination, error) {
+	params, err := decodeOrdered(raw)
+	if err != nil {
+		return nil, err
+	}
+	if len(params) == 0 {
+		return nil, errors.New("must declare at least one parameter")
+	}
+
+	axes := make([][]interface{}, len(params))
+	for i, p := range params {
+		if strings.HasPrefix(p.name, "$") {
+			return nil, fmt.Errorf("parameter %q: names starting with \"$\" are reserved", p.name)
+		}
+		values, ok := p.value.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("parameter %q: must be an array of values", p.name)
+		}
+		if len(values) == 0 {
+			return nil, fmt.Errorf("parameter %q: must have at least one value", p.name)
+		}
+		axes[i] = values
+	}
+
+	combos := []combination{{}}
+	for i, axis := range axes {
+		next := make([]combination, 0, len(combos)*len(axis))
+		for _, c := range combos {
+			for _, v := range axis {
+				ps := make([]param, len(c.params), len(c.params)+1)
+				copy(ps, c.params)
+				ps = append(ps, param{name: params[i].name, value: v})
+				next = append(next, combination{params: ps})
+			}
+		}
+		combos = next
+	}
+	return combos, nil
+}
+
+// parseRows parses an array of explicit parameter rows.
+func parseRows(raw []byte) ([]combination, error) {
+	var rows []json.RawMessage
+	if err := json.Unmarshal(raw, &rows); err != nil {
+		return nil, err
+	}
+
+	combos := make([]combination, 0, len(rows))
+	for i, row := range rows {
+		trimmed := bytes.TrimSpace(row)
+		if len(trimmed) == 0 || trimmed[0] != '{' {
+			return nil, fmt.Errorf("row %d: must be an object", i)
+		}
+		fields, err := decodeOrdered(trimmed)
+		if err != nil {
+			return nil, fmt.Errorf("row %d: %w", i, err)
+		}
+
+		var c combination
+		for _, f := range fields {
+			switch {
+			case f.name == RowOutputKey:
+				c.output = f.value
+				c.hasOutput = true
+			case strings.HasPrefix(f.name, "$"):
+				return nil, fmt.Errorf("row %d: unknown reserved key %q", i, f.name)
+			default:
+				c.params = append(c.params, f)
+			}
+		}
+		if len(c.params) == 0 {
+			return nil, fmt.Errorf("row %d: must declare at least one parameter", i)
+		}
+		combos = append(combos, c)
+	}
+	return combos, nil
+}
+
+// decodeOrdered decodes a JSON object preserving key order.
+// Values are decoded with json.Number so literals survive unchanged.
+func decodeOrdered(raw []byte) ([]param, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '{' {
+		return nil, errors.New("must be an object")
+	}
+
+	var result []param
+	seen := make(map[string]bool)
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		key, ok := tok.(string)
+		if !ok {
+			return nil, errors.New("invalid object key")
+		}
+		var value interface{}
+		if err := dec.Decode(&value); err != nil {
+			return nil, fmt.Errorf("%q: %w", key, err)
+		}
+		if seen[key] {
+			return nil, fmt.Errorf("duplicate key %q", key)
+		}
+		seen[key] = true
+		result = append(result, param{name: key, value: value})
+	}
+	return result, nil
+}
+
+// substitute replaces {"$param": "name"} placeholders within value.
+func substitute(value interface{}, params map[string]interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if ref, ok := v[ParamKey]; ok {
+			name, isString := ref.(string)
+			if !isString || len(v) != 1 {
+				return nil, fmt.Errorf("placeholder must be an object with a single string %q key", ParamKey)
+			}
+			resolved, known := params[name]
+			if !known {
+				return nil, fmt.Errorf("unknown parameter %q", name)
+			}
+			return resolved, nil
+		}
+		result := make(map[string]interface{}, len(v))
+		for key, val := range v {
+			sub, err := substitute(val, params)
+			if err != nil {
+				return nil, err
+			}
+			result[key] = sub
+		}
+		return result, nil
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, val := range v {
+			sub, err := substitute(val, params)
+			if err != nil {
+				return nil, err
+			}
+			result[i] = sub
+		}
+		return result, nil
+	default:
+		return value, nil
+	}
+}
+
+// caseName builds the deterministic expanded name for a combination.
+func caseName(baseName string, params []param) (string, error) {
+	parts := make([]string, len(params))
+	for i, p := range params {
+		formatted, err := formatValue(p.value)
+		if err != nil {
+			return "", fmt.Errorf("parameter %q: %w", p.name, err)
+		}
+		parts[i] = p.name + "=" + formatted
+	}
+	name := baseName + "[" + strings.Join(parts, ",") + "]"
+
+	// Expanded names must remain usable as test identifiers and file names.
+	if strings.Contains(name, "..") || strings.ContainsAny(name, "/\\\x00") {
+		return "", fmt.Errorf("expanded name %q contains \"..\", a path separator, or a null byte", name)
+	}
+	return name, nil
+}
+
+// formatValue renders a parameter value for use in a case name.
+// Scalars render as their JSON literal (strings unquoted); arrays and objects
+// render as compact JSON.
+func formatValue(v interface{}) (string, error) {
+	switch val := v.(type) {
+	case string:
+		return val, nil
+	case json.Number:
+		return val.String(), nil
+	case bool:
+		if val {
+			return "true", nil
+		}
+		return "false", nil
+	case nil:
+		return "null", nil
+	default:
+		data, err := json.Marshal(val)
+		if err != nil {
+			return "", err
+		}
+		return string(data), nil
+	}
+}
+
+// SortCaseFiles sorts the paths of test case files into suite order: by file
+// name without the .json extension, then by path. The cases expanded from a
+// case template keep its position, in matrix order, so every loader lists
+// the cases of a suite in the same order.
+func SortCaseFiles(paths []string) {
+	sort.Slice(paths, func(i, j int) bool {
+		a := strings.TrimSuffix(filepath.Base(paths[i]), ".json")
+		b := strings.TrimSuffix(filepath.Base(paths[j]), ".json")
+		if a != b {
+			return a < b
+		}
+		return paths[i] < paths[j]
+	})
+}
@@ -0,0 +1,148 @@
+package testhelper
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func caseNames(cases []ExpandedCase) []string {
+	names := make([]string, len(cases))
+	for i, c := range cases {
+		names[i] = c.Name
+	}
+	return names
+}
+
+func decodeCase(t *testing.T, c ExpandedCase) map[string]interface{} {
+	t.Helper()
+	var doc map[string]interface{}
+	if err := json.Unmarshal(c.Data, &doc); err != nil {
+		t.Fatalf("case %q: invalid JSON: %v", c.Name, err)
+	}
+	return doc
+}
+
+func TestIsCaseTemplate(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		data string
+		want bool
+	}{
+		{"regular case", `{"input": {}, "output": 1}`, false},
+		{"template", `{"$matrix": {"n": [1]}, "input": {}, "output": 1}`, true},
+		{"nested matrix key", `{"input": {"$matrix": 1}, "output": 1}`, false},
+		{"invalid JSON", `{"$matrix": `, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := IsCaseTemplate([]byte(tt.data)); got != tt.want {
+				t.Errorf("IsCaseTemplate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpandCaseTemplate_Grid_CartesianInDeclarationOrder(t *testing.T) {
+	t.Parallel()
+	data := `{
+		"$matrix": {"p": [0.5, 0.9], "n": [10, 100]},
+		"input": {"p": {"$param": "p"}, "n": {"$param": "n"}, "data": [1, 2]},
+		"output": 1.0
+	}`
+
+	cases, err := ExpandCaseTemplate("quantile", []byte(data))
+	if err != nil {
+		t.Fatalf("ExpandCaseTemplate() error = %v", err)
+	}
+
+	want := []string{
+		"quantile[p=0.5,n=10]",
+		"quantile[p=0.5,n=100]",
+		"quantile[p=0.9,n=10]",
+		"quantile[p=0.9,n=100]",
+	}
+	if got := caseNames(cases); !reflect.DeepEqual(got, want) {
+		t.Errorf("names = %v, want %v", got, want)
+	}
+
+	doc := decodeCase(t, cases[1])
+	input := doc["input"].(map[string]interface{})
+	if input["p"] != 0.5 || input["n"] != 100.0 {
+		t.Errorf("input = %v, want p=0.5 n=100", input)
+	}
+	if _, ok := doc[MatrixKey]; ok {
+		t.Errorf("expanded case still contains %q", MatrixKey)
+	}
+}
+
+func TestExpandCaseTemplate_Rows_OutputOverride(t *testing.T) {
+	t.Parallel()
+	data := `{
+		"$matrix": [
+			{"x": 1, "$output": 2},
+			{"x": 3, "$output": 6}
+		],
+		"input": {"x": {"$param": "x"}},
+		"output": 0
+	}`
+
+	cases, err := ExpandCaseTemplate("double", []byte(data))
+	if err != nil {
+		t.Fatalf("ExpandCaseTemplate() error = %v", err)
+	}
+	if got := caseNames(cases); !reflect.DeepEqual(got, []string{"double[x=1]", "double[x=3]"}) {
+		t.Errorf("names = %v", got)
+	}
+	if got := decodeCase(t, cases[1])["output"]; got != 6.0 {
+		t.Errorf("output = %v, want 6", got)
+	}
+}
+
+func TestExpandCaseTemplate_PreservesNumericLiterals(t *testing.T) {
+	t.Parallel()
+	data := `{"$matrix": {"v": [1e3, 0.10, "abc", true]}, "input": {"v": {"$param": "v"}}, "output": 1}`
+
+	cases, err := ExpandCaseTemplate("lit", []byte(data))
+	if err != nil {
+		t.Fatalf("ExpandCaseTemplate() error = %v", err)
+	}
+	want := []string{"lit[v=1e3]", "lit[v=0.10]", "lit[v=abc]", "lit[v=true]"}
+	if got := caseNames(cases); !reflect.DeepEqual(got, want) {
+		t.Errorf("names = %v, want %v", got, want)
+	}
+}
+
+func TestExpandCaseTemplate_Errors(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		data    string
+		wantErr string
+	}{
+		{"unknown parameter", `{"$matrix": {"a": [1]}, "input": {"b": {"$param": "b"}}, "output": 1}`, `unknown parameter "b"`},
+		{"empty values", `{"$matrix": {"a": []}, "input": {}, "output": 1}`, "at least one value"},
+		{"values not array", `{"$matrix": {"a": 1}, "input": {}, "output": 1}`, "must be an array"},
+		{"scalar matrix", `{"$matrix": 1, "input": {}, "output": 1}`, "must be an object"},
+		{"empty rows", `{"$matrix": [], "input": {}, "output": 1}`, "no cases"},
+		{"duplicate rows", `{"$matrix": [{"a": 1}, {"a": 1}], "input": {}, "output": 1}`, "duplicate"},
+		{"unknown row key", `{"$matrix": [{"a": 1, "$skip": true}], "input": {}, "output": 1}`, `"$skip"`},
+		{"path separator", `{"$matrix": {"a": ["x/y"]}, "input": {}, "output": 1}`, "path separator"},
+		{"bad placeholder", `{"$matrix": {"a": [1]}, "input": {"a": {"$param": "a", "x": 1}}, "output": 1}`, "placeholder"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := ExpandCaseTemplate("base", []byte(tt.data))
+			if err == nil {
+				t.Fatal("ExpandCaseTemplate() expected error")
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %q, want substring %q", err.Error(), tt.wantErr)
+			}
+		})
+	}
+}
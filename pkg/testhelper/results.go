@@ -0,0 +1,145 @@
+package testhelper
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ResultsDirEnvVar is the environment variable naming the directory where
+// harnesses record per-case results.
+//
+// Structyl sets this variable when collecting results from several targets
+// (e.g., "structyl tests matrix"), pointing each target at its own
+// directory. Harnesses that see it write one result file per executed case
+// with [WriteCaseResult]; when it is unset, nothing is recorded.
+const ResultsDirEnvVar = "STRUCTYL_RESULTS_DIR"
+
+// TestsDirEnvVar is the environment variable that redirects harnesses to an
+// alternative tests directory.
+//
+// Structyl sets this variable when it feeds generated cases to every target
+// (e.g., "structyl tests fuzz"). Harnesses that see it load suites from
+// <dir>/<suite> instead of the project's tests directory, and skip suites
+// that do not exist there.
+const TestsDirEnvVar = "STRUCTYL_TESTS_DIR"
+
+// ResultStatus is the outcome of running a single test case.
+type ResultStatus string
+
+const (
+	// ResultPass means the actual output matched the expected output.
+	ResultPass ResultStatus = "pass"
+	// ResultFail means the case errored or the output did not match.
+	ResultFail ResultStatus = "fail"
+	// ResultSkip means the case was marked "skip" and not run.
+	ResultSkip ResultStatus = "skip"
+)
+
+// CaseResult is the recorded outcome of one test case in one harness.
+//
+// Results are stored as JSON files at <dir>/<suite>/<name>.json, so the path
+// of a result file determines the [TestCase.ID] it belongs to:
+//
+//	{"status": "fail", "actual": 2.5, "message": "$: expected 3, got 2.5"}
+//
+// Actual holds the output the implementation produced, in its JSON form
+// ("NaN", "Infinity", and "-Infinity" for special floats). It is omitted
+// when no output was produced (skipped cases, decode errors, errors from the
+// code under test).
+type CaseResult struct {
+	Status  ResultStatus `json:"status"`
+	Actual  interface{}  `json:"actual,omitempty"`
+	Message string       `json:"message,omitempty"`
+}
+
+// WriteCaseResult records the result of tc under dir.
+// Returns an error if tc has no Suite or Name, the result status is
+// unknown, or the file cannot be written.
+func WriteCaseResult(dir string, tc TestCase, result CaseResult) error {
+	if tc.Suite == "" || tc.Name == "" {
+		return fmt.Errorf("cannot record result for %q: suite and name are required", tc.ID())
+	}
+	if !isValidResultStatus(result.Status) {
+		return fmt.Errorf("cannot record result for %s: unknown status %q", tc.ID(), result.Status)
+	}
+	result.Actual = encodeSpecialFloats(result.Actual)
+
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return fmt.Errorf("cannot record result for %s: %w", tc.ID(), err)
+	}
+	suiteDir := filepath.Join(dir, tc.Suite)
+	if err := os.MkdirAll(suiteDir, 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(suiteDir, tc.Name+".json"), append(data, '\n'), 0644)
+}
+
+// ReadCaseResults reads every result recorded under dir, keyed by test case
+// ID ("suite/name"). A missing directory yields an empty map.
+func ReadCaseResults(dir string) (map[string]CaseResult, error) {
+	results := make(map[string]CaseResult)
+	files, err := filepath.Glob(filepath.Join(dir, "*", "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		var result CaseResult
+		if err := json.Unmarshal(data, &result); err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		if !isValidResultStatus(result.Status) {
+			return nil, fmt.Errorf("%s: unknown status %q", file, result.Status)
+		}
+		suite := filepath.Base(filepath.Dir(file))
+		name := strings.TrimSuffix(filepath.Base(file), ".json")
+		results[suite+"/"+name] = result
+	}
+	return results, nil
+}
+
+func isValidResultStatus(s ResultStatus) bool {
+	return s == ResultPass || s == ResultFail || s == ResultSkip
+}
+
+// encodeSpecialFloats replaces NaN and infinities in a generic JSON value
+// with their reference test string forms, which encoding/json cannot encode.
+func encodeSpecialFloats(v interface{}) interface{} {
+	switch val := v.(type) {
+	case float64:
+		switch {
+		case math.IsNaN(val):
+			return "NaN"
+		case math.IsInf(val, 1):
+			return "Infinity"
+		case math.IsInf(val, -1):
+			return "-Infinity"
+		}
+		return val
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		for i, item := range val {
+			out[i] = encodeSpecialFloats(item)
+		}
+		return out
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(val))
+		for k, item := range val {
+			out[k] = encodeSpecialFloats(item)
+		}
+		return out
+	default:
+		return v
+	}
+}
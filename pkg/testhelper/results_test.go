@@ -0,0 +1,79 @@
+package testhelper
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestWriteCaseResult_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	tc := TestCase{Suite: "stats", Name: "nan-mean"}
+	result := CaseResult{
+		Status:  ResultFail,
+		Actual:  []interface{}{math.NaN(), math.Inf(-1), 1.5},
+		Message: "mismatch",
+	}
+	if err := WriteCaseResult(dir, tc, result); err != nil {
+		t.Fatalf("WriteCaseResult() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "stats", "nan-mean.json")); err != nil {
+		t.Fatalf("result file not written: %v", err)
+	}
+
+	results, err := ReadCaseResults(dir)
+	if err != nil {
+		t.Fatalf("ReadCaseResults() error = %v", err)
+	}
+	got, ok := results["stats/nan-mean"]
+	if !ok {
+		t.Fatalf("ReadCaseResults() = %v, missing stats/nan-mean", results)
+	}
+	want := CaseResult{Status: ResultFail, Actual: []interface{}{"NaN", "-Infinity", 1.5}, Message: "mismatch"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadCaseResults() = %+v, want %+v", got, want)
+	}
+}
+
+func TestWriteCaseResult_Errors(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	if err := WriteCaseResult(dir, TestCase{Name: "x"}, CaseResult{Status: ResultPass}); err == nil {
+		t.Error("WriteCaseResult() without suite should return error")
+	}
+	if err := WriteCaseResult(dir, TestCase{Suite: "s", Name: "x"}, CaseResult{Status: "ok"}); err == nil {
+		t.Error("WriteCaseResult() with unknown status should return error")
+	}
+}
+
+func TestReadCaseResults_MissingDirectory(t *testing.T) {
+	t.Parallel()
+
+	results, err := ReadCaseResults(filepath.Join(t.TempDir(), "missing"))
+	if err != nil {
+		t.Fatalf("ReadCaseResults() error = %v", err)
+	}
+	if len(results) != 0 {
+		t.Errorf("ReadCaseResults() = %v, want empty", results)
+	}
+}
+
+func TestReadCaseResults_InvalidStatus(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "s"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "s", "x.json"), []byte(`{"status": "passed"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadCaseResults(dir); err == nil {
+		t.Error("ReadCaseResults() with unknown status should return error")
+	}
+}
@@ -0,0 +1,307 @@
+package testhelper
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// ComparisonEnvVar is the environment variable carrying the comparison
+// options of the project as JSON, in the form of the tests.comparison
+// section. structyl sets it for the commands it runs, with the selected
+// profile and included files applied.
+const ComparisonEnvVar = "STRUCTYL_COMPARISON"
+
+// comparisonSection is the tests.comparison section of the configuration.
+// Unset fields are nil.
+type comparisonSection struct {
+	FloatTolerance *float64 `json:"float_tolerance"`
+	ToleranceMode  string   `json:"tolerance_mode"`
+	NaNEqualsNaN   *bool    `json:"nan_equals_nan"`
+	ArrayOrder     string   `json:"array_order"`
+}
+
+// LoadCompareOptions returns the comparison options of the project: the
+// tests.comparison section from [ComparisonEnvVar] if it is set, or else from
+// the project's .structyl/config.json or config.jsonc. Fragments listed in
+// include are not read. Unset fields take the same defaults structyl itself
+// uses, which match [DefaultOptions] when the comparison section is absent.
+//
+// A project configured in YAML or TOML is only supported through
+// [ComparisonEnvVar], which structyl sets when it runs the tests.
+//
+// Returns an error if the configuration cannot be read or the configured
+// options are invalid (see [ValidateOptions]).
+func LoadCompareOptions(projectRoot string) (CompareOptions, error) {
+	var section *comparisonSection
+	if env := os.Getenv(ComparisonEnvVar); env != "" {
+		if err := json.Unmarshal([]byte(env), &section); err != nil {
+			return CompareOptions{}, fmt.Errorf("%s: %w", ComparisonEnvVar, err)
+		}
+	} else {
+		var err error
+		if section, err = readComparisonSection(projectRoot); err != nil {
+			return CompareOptions{}, err
+		}
+	}
+
+	opts := DefaultOptions()
+	if section != nil {
+		// As in structyl, nan_equals_nan is false when a comparison section
+		// omits it.
+		opts.NaNEqualsNaN = section.NaNEqualsNaN != nil && *section.NaNEqualsNaN
+		if section.FloatTolerance != nil {
+			opts.FloatTolerance = *section.FloatTolerance
+		}
+		if section.ToleranceMode != "" {
+			opts.ToleranceMode = section.ToleranceMode
+		}
+		if section.ArrayOrder != "" {
+			opts.ArrayOrder = section.ArrayOrder
+		}
+	}
+	if err := ValidateOptions(opts); err != nil {
+		return CompareOptions{}, fmt.Errorf("tests.comparison: %w", err)
+	}
+	return opts, nil
+}
+
+// readComparisonSection reads the tests.comparison section of the project's
+// configuration file. It returns nil if the section is absent.
+func readComparisonSection(projectRoot string) (*comparisonSection, error) {
+	path, err := findConfigFile(filepath.Join(projectRoot, ".structyl"))
+	if err != nil {
+		return nil, err
+	}
+	switch filepath.Ext(path) {
+	case ".json", ".jsonc":
+	default:
+		return nil, fmt.Errorf("cannot read tests.comparison from %s: run the tests with structyl, which sets %s", filepath.Base(path), ComparisonEnvVar)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cfg struct {
+		Tests *struct {
+			Comparison *comparisonSection `json:"comparison"`
+		} `json:"tests"`
+	}
+	if err := json.Unmarshal(stripJSONComments(data), &cfg); err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	if cfg.Tests == nil {
+		return nil, nil
+	}
+	return cfg.Tests.Comparison, nil
+}
+
+// configFileNames are the accepted names of the project configuration file
+// in the .structyl directory, as in structyl.
+var configFileNames = []string{"config.json", "config.jsonc", "config.yaml", "config.toml"}
+
+// findConfigFile returns the path of the project configuration file in dir,
+// the .structyl directory of a project. If there is none, the error
+// satisfies os.IsNotExist.
+func findConfigFile(dir string) (string, error) {
+	for _, name := range configFileNames {
+		path := filepath.Join(dir, name)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path, nil
+		}
+	}
+	return "", &os.PathError{Op: "find", Path: filepath.Join(dir, "config.*"), Err: os.ErrNotExist}
+}
+
+// stripJSONComments replaces the comments and trailing commas of JSONC data
+// with spaces.
+func stripJSONComments(data []byte) []byte {
+	out := bytes.Clone(data)
+	blank := func(from, to int) {
+		for i := from; i < to && i < len(out); i++ {
+			if out[i] != '\n' && out[i] != '\r' {
+				out[i] = ' '
+			}
+		}
+	}
+
+	lastComma := -1 // Offset of a comma not yet followed by a value
+	for i := 0; i < len(out); i++ {
+		switch c := out[i]; {
+		case c == '"':
+			lastComma = -1
+			for i++; i < len(out) && out[i] != '"'; i++ {
+				if out[i] == '\\' {
+					i++
+				}
+			}
+		case c == '/' && i+1 < len(out) && out[i+1] == '/':
+			end := bytes.IndexByte(out[i:], '\n')
+			if end < 0 {
+				end = len(out) - i
+			}
+			blank(i, i+end)
+			i += end - 1
+		case c == '/' && i+1 < len(out) && out[i+1] == '*':
+			end := bytes.Index(out[i+2:], []byte("*/"))
+			if end < 0 {
+				return out // Unterminated; left for the JSON parser to report
+			}
+			blank(i, i+2+end+2)
+			i += 2 + end + 1
+		case c == ',':
+			lastComma = i
+		case c == '}' || c == ']':
+			if lastComma >= 0 {
+				blank(lastComma, lastComma+1)
+			}
+			lastComma = -1
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+		default:
+			lastComma = -1
+		}
+	}
+	return out
+}
+
+// RunSuite runs every case of a reference test suite as a subtest of t.
+//
+// For each case, RunSuite decodes the input into I (see [DecodeInput]),
+// calls fn, and compares the result with the expected output using the
+// project's configured comparison options (see [LoadCompareOptions]):
+//
+//	type meanInput struct {
+//	    X []float64 `json:"x"`
+//	}
+//
+//	func TestMean(t *testing.T) {
+//	    testhelper.RunSuite(t, "mean", func(in meanInput) (float64, error) {
+//	        return stats.Mean(in.X)
+//	    })
+//	}
+//
+// The project root is found from the working directory with
+// [FindProjectRoot]. Cases marked "skip" are reported as skipped subtests.
+// The tag selection from [TagsEnvVar] (set by "structyl test --tags") is
+// applied before running; unselected cases are not run at all.
+//
+// The result of fn is converted to its JSON form before comparison, so O may
+// be any JSON-encodable type (numbers, strings, slices, maps, tagged
+// structs). NaN and infinities in the result are compared against the
+// "NaN", "Infinity", and "-Infinity" expected values.
+//
+// When [TestsDirEnvVar] is set, cases are loaded from that directory
+// instead, and the test is skipped if the suite does not exist there.
+// When [ResultsDirEnvVar] is set, the outcome of every selected case is
+// recorded there with [WriteCaseResult] for cross-target reports.
+//
+// Loading failures stop the test with t.Fatal. A decode failure, an error
+// from fn, or an output mismatch fails only the affected subtest.
+func RunSuite[I, O any](t *testing.T, suite string, fn func(I) (O, error)) {
+	t.Helper()
+	root, err := FindProjectRoot()
+	if err != nil {
+		t.Fatalf("RunSuite(%q): %v", suite, err)
+	}
+	runSuite(t, root, filepath.Join(root, "tests"), suite, fn)
+}
+
+// RunSuiteFromDir is like [RunSuite], but loads the cases from an explicit
+// tests directory (testsDir/suite/*.json), for projects whose tests
+// directory is not "tests". [TestsDirEnvVar] still takes precedence.
+func RunSuiteFromDir[I, O any](t *testing.T, testsDir, suite string, fn func(I) (O, error)) {
+	t.Helper()
+	root, err := FindProjectRoot()
+	if err != nil {
+		t.Fatalf("RunSuite(%q): %v", suite, err)
+	}
+	runSuite(t, root, testsDir, suite, fn)
+}
+
+func runSuite[I, O any](t *testing.T, root, testsDir, suite string, fn func(I) (O, error)) {
+	t.Helper()
+	opts, err := LoadCompareOptions(root)
+	if err != nil {
+		t.Fatalf("RunSuite(%q): %v", suite, err)
+	}
+	filter, err := TagFilterFromEnv()
+	if err != nil {
+		t.Fatalf("RunSuite(%q): %v", suite, err)
+	}
+	var cases []TestCase
+	if override := os.Getenv(TestsDirEnvVar); override != "" {
+		cases, err = LoadTestSuiteFromDir(override, suite)
+		var notFound *SuiteNotFoundError
+		if errors.As(err, &notFound) {
+			t.Skipf("RunSuite(%q): suite not in %s", suite, override)
+		}
+	} else {
+		cases, err = loadSuite(root, testsDir, suite)
+	}
+	if err != nil {
+		t.Fatalf("RunSuite(%q): %v", suite, err)
+	}
+
+	resultsDir := os.Getenv(ResultsDirEnvVar)
+	for _, tc := range FilterByTags(cases, filter) {
+		t.Run(tc.Name, func(t *testing.T) {
+			if tc.Skip {
+				recordResult(t, resultsDir, tc, CaseResult{Status: ResultSkip})
+				t.Skip("skipped by test case")
+			}
+			actual, err := runCase(tc, opts, fn)
+			result := CaseResult{Status: ResultPass, Actual: actual}
+			if err != nil {
+				result.Status = ResultFail
+				result.Message = err.Error()
+				t.Error(err)
+			}
+			recordResult(t, resultsDir, tc, result)
+		})
+	}
+}
+
+// runCase decodes, runs, and checks a single test case.
+// Returns the JSON form of the actual output, if one was produced, along
+// with any error.
+func runCase[I, O any](tc TestCase, opts CompareOptions, fn func(I) (O, error)) (interface{}, error) {
+	input, err := DecodeInput[I](tc)
+	if err != nil {
+		return nil, err
+	}
+
+	actual, err := fn(input)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", tc.ID(), err)
+	}
+
+	generic, err := toJSONValue(reflect.ValueOf(actual))
+	if err != nil {
+		return nil, fmt.Errorf("%s: cannot convert result of type %T: %w", tc.ID(), actual, err)
+	}
+
+	equal, diff, err := CompareE(tc.Output, generic, opts)
+	if err != nil {
+		return generic, err
+	}
+	if !equal {
+		return generic, fmt.Errorf("%s: output mismatch: %s", tc.ID(), diff)
+	}
+	return generic, nil
+}
+
+// recordResult writes a case result when results collection is enabled.
+func recordResult(t *testing.T, dir string, tc TestCase, result CaseResult) {
+	t.Helper()
+	if dir == "" {
+		return
+	}
+	if err := WriteCaseResult(dir, tc, result); err != nil {
+		t.Errorf("recording result: %v", err)
+	}
+}
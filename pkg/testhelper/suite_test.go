@@ -0,0 +1,304 @@
+package testhelper
+
+import (
+	"errors"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// createSuiteProject creates a project with the given config and test cases
+// in suite "mean".
+func createSuiteProject(t *testing.T, config string, cases map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, ".structyl"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, ".structyl", "config.json"), []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	suiteDir := filepath.Join(root, "tests", "mean")
+	if err := os.MkdirAll(suiteDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, data := range cases {
+		if err := os.WriteFile(filepath.Join(suiteDir, name+".json"), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func TestLoadCompareOptions(t *testing.T) {
+	t.Parallel()
+
+	root := createSuiteProject(t, `{"project": {"name": "p"}}`, nil)
+	opts, err := LoadCompareOptions(root)
+	if err != nil {
+		t.Fatalf("LoadCompareOptions() error = %v", err)
+	}
+	if opts != DefaultOptions() {
+		t.Errorf("LoadCompareOptions() = %v, want defaults", opts)
+	}
+
+	root = createSuiteProject(t, `{"project": {"name": "p"}, "tests": {"comparison": {"float_tolerance": 0.01, "tolerance_mode": "absolute", "array_order": "unordered"}}}`, nil)
+	opts, err = LoadCompareOptions(root)
+	if err != nil {
+		t.Fatalf("LoadCompareOptions() error = %v", err)
+	}
+	want := CompareOptions{FloatTolerance: 0.01, ToleranceMode: ToleranceModeAbsolute, ArrayOrder: ArrayOrderUnordered}
+	if opts != want {
+		t.Errorf("LoadCompareOptions() = %v, want %v", opts, want)
+	}
+
+	root = createSuiteProject(t, `{"project": {"name": "p"}, "tests": {"comparison": {"tolerance_mode": "fuzzy"}}}`, nil)
+	if _, err := LoadCompareOptions(root); err == nil {
+		t.Error("LoadCompareOptions() with invalid mode should return error")
+	}
+}
+
+func TestLoadCompareOptions_JSONC(t *testing.T) {
+	t.Parallel()
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, ".structyl"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	jsonc := `{
+		// Loose comparison for the benchmark suites
+		"tests": {"comparison": {"float_tolerance": 0.5, /* absolute */ "tolerance_mode": "absolute",},},
+	}`
+	if err := os.WriteFile(filepath.Join(root, ".structyl", "config.jsonc"), []byte(jsonc), 0644); err != nil {
+		t.Fatal(err)
+	}
+	opts, err := LoadCompareOptions(root)
+	if err != nil {
+		t.Fatalf("LoadCompareOptions() error = %v", err)
+	}
+	want := CompareOptions{FloatTolerance: 0.5, ToleranceMode: ToleranceModeAbsolute, ArrayOrder: ArrayOrderStrict}
+	if opts != want {
+		t.Errorf("LoadCompareOptions() = %v, want %v", opts, want)
+	}
+}
+
+func TestLoadCompareOptions_EnvVar(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, ".structyl"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, ".structyl", "config.yaml"), []byte("project:\n  name: p\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// YAML is not read directly.
+	if _, err := LoadCompareOptions(root); err == nil || !strings.Contains(err.Error(), ComparisonEnvVar) {
+		t.Errorf("LoadCompareOptions() error = %v, want a hint to %s", err, ComparisonEnvVar)
+	}
+
+	t.Setenv(ComparisonEnvVar, `{"float_tolerance": 2, "tolerance_mode": "ulp", "nan_equals_nan": true}`)
+	opts, err := LoadCompareOptions(root)
+	if err != nil {
+		t.Fatalf("LoadCompareOptions() error = %v", err)
+	}
+	want := CompareOptions{FloatTolerance: 2, ToleranceMode: ToleranceModeULP, NaNEqualsNaN: true, ArrayOrder: ArrayOrderStrict}
+	if opts != want {
+		t.Errorf("LoadCompareOptions() = %v, want %v", opts, want)
+	}
+
+	t.Setenv(ComparisonEnvVar, `{"float_tolerance": `)
+	if _, err := LoadCompareOptions(root); err == nil {
+		t.Error("LoadCompareOptions() with invalid JSON in the environment should return an error")
+	}
+}
+
+// TestPackageImports_StandardLibraryOnly keeps the package usable without
+// pulling in structyl's internal packages and their dependencies.
+func TestPackageImports_StandardLibraryOnly(t *testing.T) {
+	t.Parallel()
+	files, err := filepath.Glob("*.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fset := token.NewFileSet()
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+		f, err := parser.ParseFile(fset, file, nil, parser.ImportsOnly)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, imp := range f.Imports {
+			path := strings.Trim(imp.Path.Value, `"`)
+			if first, _, _ := strings.Cut(path, "/"); strings.Contains(first, ".") {
+				t.Errorf("%s imports %s, want the standard library only", file, path)
+			}
+		}
+	}
+}
+
+type meanInput struct {
+	X []float64 `json:"x"`
+}
+
+func mean(in meanInput) (float64, error) {
+	if len(in.X) == 0 {
+		return math.NaN(), nil
+	}
+	sum := 0.0
+	for _, x := range in.X {
+		sum += x
+	}
+	return sum / float64(len(in.X)), nil
+}
+
+func TestRunCase(t *testing.T) {
+	t.Parallel()
+	opts := DefaultOptions()
+
+	tests := []struct {
+		name    string
+		data    string
+		fn      func(meanInput) (float64, error)
+		wantErr string
+	}{
+		{"match", `{"input": {"x": [1, 2, 3]}, "output": 2}`, mean, ""},
+		{"NaN result", `{"input": {"x": []}, "output": "NaN"}`, mean, ""},
+		{"mismatch", `{"input": {"x": [1, 2, 3]}, "output": 5}`, mean, "output mismatch"},
+		{"decode error", `{"input": {"y": [1]}, "output": 1}`, mean, "missing required field"},
+		{"fn error", `{"input": {"x": [1]}, "output": 1}`, func(meanInput) (float64, error) {
+			return 0, errors.New("boom")
+		}, "boom"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := runCase(mustCase(t, tt.data), opts, tt.fn)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("runCase() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("runCase() error = %v, want substring %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRunSuite_RunsSelectedCases(t *testing.T) {
+	root := createSuiteProject(t, `{"project": {"name": "p"}}`, map[string]string{
+		"basic":   `{"input": {"x": [1, 3]}, "output": 2}`,
+		"skipped": `{"input": {"x": [1]}, "output": 100, "skip": true}`,
+		"slow":    `{"input": {"x": [1]}, "output": 100, "tags": ["slow"]}`,
+	})
+	t.Setenv(TagsEnvVar, "!slow")
+
+	var ran []float64
+	withWorkingDir(t, root, func() {
+		RunSuite(t, "mean", func(in meanInput) (float64, error) {
+			m, err := mean(in)
+			ran = append(ran, m)
+			return m, err
+		})
+	})
+
+	if len(ran) != 1 || ran[0] != 2 {
+		t.Errorf("RunSuite ran cases with results %v, want only basic", ran)
+	}
+}
+
+func TestRunSuite_RecordsResults(t *testing.T) {
+	root := createSuiteProject(t, `{"project": {"name": "p"}}`, map[string]string{
+		"basic":   `{"input": {"x": [1, 3]}, "output": 2}`,
+		"skipped": `{"input": {"x": [1]}, "output": 100, "skip": true}`,
+	})
+	resultsDir := t.TempDir()
+	t.Setenv(ResultsDirEnvVar, resultsDir)
+
+	withWorkingDir(t, root, func() {
+		RunSuite(t, "mean", func(in meanInput) (float64, error) {
+			return mean(in)
+		})
+	})
+
+	results, err := ReadCaseResults(resultsDir)
+	if err != nil {
+		t.Fatalf("ReadCaseResults() error = %v", err)
+	}
+	if got := results["mean/basic"]; got.Status != ResultPass || got.Actual != 2.0 {
+		t.Errorf("mean/basic result = %+v, want pass with actual 2", got)
+	}
+	if got := results["mean/skipped"]; got.Status != ResultSkip {
+		t.Errorf("mean/skipped result = %+v, want skip", got)
+	}
+}
+
+func TestRunSuite_TestsDirOverride(t *testing.T) {
+	root := createSuiteProject(t, `{"project": {"name": "p"}}`, map[string]string{
+		"basic": `{"input": {"x": [1, 3]}, "output": 2}`,
+	})
+	override := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(override, "mean"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(override, "mean", "fuzz-0001.json"), []byte(`{"input": {"x": [2, 4]}, "output": 3}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(TestsDirEnvVar, override)
+
+	var ran []float64
+	withWorkingDir(t, root, func() {
+		RunSuite(t, "mean", func(in meanInput) (float64, error) {
+			m, err := mean(in)
+			ran = append(ran, m)
+			return m, err
+		})
+	})
+	if len(ran) != 1 || ran[0] != 3 {
+		t.Errorf("RunSuite ran cases with results %v, want only the override case", ran)
+	}
+
+	// Suites absent from the override directory are skipped, not failed.
+	var skipped bool
+	withWorkingDir(t, root, func() {
+		t.Run("missing", func(t *testing.T) {
+			defer func() { skipped = t.Skipped() }()
+			RunSuite(t, "median", func(in meanInput) (float64, error) {
+				return mean(in)
+			})
+		})
+	})
+	if !skipped {
+		t.Error("RunSuite did not skip a suite missing from the override directory")
+	}
+}
+
+func TestRunSuiteFromDir_ExpandsCaseTemplates(t *testing.T) {
+	root := createSuiteProject(t, `{"project": {"name": "p"}}`, nil)
+	testsDir := filepath.Join(root, "reference")
+	if err := os.MkdirAll(filepath.Join(testsDir, "scale"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	template := `{"$matrix": [{"k": 2, "$output": 4}, {"k": 3, "$output": 6}], "input": {"x": 2, "k": {"$param": "k"}}}`
+	if err := os.WriteFile(filepath.Join(testsDir, "scale", "scale.json"), []byte(template), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	withWorkingDir(t, root, func() {
+		RunSuiteFromDir(t, testsDir, "scale", func(in map[string]interface{}) (interface{}, error) {
+			names = append(names, fmt.Sprint(in["k"]))
+			return in["x"].(float64) * in["k"].(float64), nil
+		})
+	})
+	if strings.Join(names, ",") != "2,3" {
+		t.Errorf("RunSuiteFromDir ran cases with k = %v, want 2,3", names)
+	}
+}
@@ -0,0 +1,149 @@
+package testhelper
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// TagsEnvVar is the environment variable carrying the tag selection expression.
+//
+// Structyl sets this variable when invoked with the global --tags flag
+// (e.g., "structyl test --tags slow,!skip-ci"), so every language harness
+// launched by structyl observes the same selection. Harnesses read it with
+// [TagFilterFromEnv] (Go) or by parsing the same syntax in their language.
+const TagsEnvVar = "STRUCTYL_TEST_TAGS"
+
+// ErrInvalidTagFilter is returned when a tag selection expression is malformed.
+//
+// Use [errors.Is] to check for this error type:
+//
+//	if errors.Is(err, testhelper.ErrInvalidTagFilter) {
+//	    // handle malformed --tags expression
+//	}
+var ErrInvalidTagFilter = errors.New("invalid tag filter")
+
+// TagFilter selects test cases by their Tags.
+//
+// A filter is parsed from a comma-separated expression of terms:
+//
+//   - "tag" includes cases that have the tag
+//   - "!tag" excludes cases that have the tag
+//
+// Selection rules:
+//
+//   - Exclusions take precedence: a case with any excluded tag is never selected.
+//   - If the expression has at least one inclusion term, a case is selected
+//     only if it has at least one included tag (terms are OR-ed).
+//   - If the expression has only exclusion terms, every case without an
+//     excluded tag is selected.
+//   - The zero value (empty expression) selects every case.
+//
+// Examples:
+//
+//	"slow"             → cases tagged "slow"
+//	"!skip-ci"         → all cases except those tagged "skip-ci"
+//	"slow,!skip-ci"    → cases tagged "slow" that are not tagged "skip-ci"
+//	"unit,integration" → cases tagged "unit" or "integration"
+//
+// Tag matching is exact and case-sensitive, consistent with [TestCase.TagsContain].
+//
+// TagFilter is immutable after parsing and safe for concurrent use.
+type TagFilter struct {
+	include []string
+	exclude []string
+}
+
+// ParseTagFilter parses a tag selection expression such as "slow,!skip-ci".
+// Whitespace around terms is ignored. An empty or whitespace-only expression
+// yields the zero TagFilter, which selects every case.
+//
+// Returns an error wrapping [ErrInvalidTagFilter] if a term is empty
+// (e.g., "a,,b" or a bare "!").
+func ParseTagFilter(expr string) (TagFilter, error) {
+	var f TagFilter
+	if strings.TrimSpace(expr) == "" {
+		return f, nil
+	}
+
+	for _, term := range strings.Split(expr, ",") {
+		term = strings.TrimSpace(term)
+		exclude := strings.HasPrefix(term, "!")
+		if exclude {
+			term = strings.TrimSpace(strings.TrimPrefix(term, "!"))
+		}
+		if term == "" {
+			return TagFilter{}, fmt.Errorf("%w %q: empty tag", ErrInvalidTagFilter, expr)
+		}
+		if exclude {
+			f.exclude = append(f.exclude, term)
+		} else {
+			f.include = append(f.include, term)
+		}
+	}
+	return f, nil
+}
+
+// TagFilterFromEnv parses the tag selection from the [TagsEnvVar] environment
+// variable. An unset or empty variable yields the zero TagFilter.
+func TagFilterFromEnv() (TagFilter, error) {
+	f, err := ParseTagFilter(os.Getenv(TagsEnvVar))
+	if err != nil {
+		return TagFilter{}, fmt.Errorf("%s: %w", TagsEnvVar, err)
+	}
+	return f, nil
+}
+
+// IsEmpty reports whether the filter selects every case.
+func (f TagFilter) IsEmpty() bool {
+	return len(f.include) == 0 && len(f.exclude) == 0
+}
+
+// Match reports whether a case with the given tags is selected by the filter.
+func (f TagFilter) Match(tags []string) bool {
+	for _, tag := range tags {
+		for _, ex := range f.exclude {
+			if tag == ex {
+				return false
+			}
+		}
+	}
+	if len(f.include) == 0 {
+		return true
+	}
+	for _, tag := range tags {
+		for _, in := range f.include {
+			if tag == in {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// String returns the canonical expression for the filter (inclusions first,
+// then exclusions, in their original order). Parsing the result with
+// [ParseTagFilter] yields an equivalent filter.
+func (f TagFilter) String() string {
+	terms := make([]string, 0, len(f.include)+len(f.exclude))
+	terms = append(terms, f.include...)
+	for _, ex := range f.exclude {
+		terms = append(terms, "!"+ex)
+	}
+	return strings.Join(terms, ",")
+}
+
+// FilterByTags returns the cases selected by the filter, preserving order.
+// Returns an empty slice (not nil) if no case matches. The input slice is
+// not modified; returned cases share Input/Output references with the input
+// (see [TestCase.Clone] for copy semantics).
+func FilterByTags(cases []TestCase, f TagFilter) []TestCase {
+	result := make([]TestCase, 0, len(cases))
+	for _, tc := range cases {
+		if f.Match(tc.Tags) {
+			result = append(result, tc)
+		}
+	}
+	return result
+}
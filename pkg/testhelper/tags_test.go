@@ -0,0 +1,110 @@
+package testhelper
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseTagFilter(t *testing.T) {
+	tests := []struct {
+		expr    string
+		want    string
+		wantErr bool
+	}{
+		{"", "", false},
+		{"   ", "", false},
+		{"slow", "slow", false},
+		{" slow , !skip-ci ", "slow,!skip-ci", false},
+		{"!a,b,!c", "b,!a,!c", false},
+		{"a,,b", "", true},
+		{"!", "", true},
+		{"a,", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			f, err := ParseTagFilter(tt.expr)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidTagFilter) {
+					t.Errorf("ParseTagFilter(%q) error = %v, want ErrInvalidTagFilter", tt.expr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseTagFilter(%q) error = %v", tt.expr, err)
+			}
+			if got := f.String(); got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTagFilter_Match(t *testing.T) {
+	tests := []struct {
+		expr string
+		tags []string
+		want bool
+	}{
+		{"", nil, true},
+		{"", []string{"slow"}, true},
+		{"slow", []string{"slow"}, true},
+		{"slow", []string{"fast"}, false},
+		{"slow", nil, false},
+		{"!skip-ci", nil, true},
+		{"!skip-ci", []string{"skip-ci"}, false},
+		{"slow,!skip-ci", []string{"slow"}, true},
+		{"slow,!skip-ci", []string{"slow", "skip-ci"}, false},
+		{"unit,integration", []string{"integration"}, true},
+		{"Slow", []string{"slow"}, false},
+	}
+	for _, tt := range tests {
+		f, err := ParseTagFilter(tt.expr)
+		if err != nil {
+			t.Fatalf("ParseTagFilter(%q) error = %v", tt.expr, err)
+		}
+		if got := f.Match(tt.tags); got != tt.want {
+			t.Errorf("ParseTagFilter(%q).Match(%v) = %v, want %v", tt.expr, tt.tags, got, tt.want)
+		}
+	}
+}
+
+func TestFilterByTags(t *testing.T) {
+	cases := []TestCase{
+		{Name: "a", Tags: []string{"slow"}},
+		{Name: "b"},
+		{Name: "c", Tags: []string{"slow", "skip-ci"}},
+	}
+	f, _ := ParseTagFilter("slow,!skip-ci")
+
+	got := FilterByTags(cases, f)
+	if len(got) != 1 || got[0].Name != "a" {
+		t.Errorf("FilterByTags() = %v, want [a]", got)
+	}
+
+	none, _ := ParseTagFilter("missing")
+	if got := FilterByTags(cases, none); got == nil || len(got) != 0 {
+		t.Errorf("FilterByTags() = %#v, want empty non-nil slice", got)
+	}
+}
+
+func TestTagFilterFromEnv(t *testing.T) {
+	t.Setenv(TagsEnvVar, "slow,!skip-ci")
+	f, err := TagFilterFromEnv()
+	if err != nil {
+		t.Fatalf("TagFilterFromEnv() error = %v", err)
+	}
+	if f.String() != "slow,!skip-ci" {
+		t.Errorf("String() = %q", f.String())
+	}
+
+	t.Setenv(TagsEnvVar, "a,,b")
+	if _, err := TagFilterFromEnv(); !errors.Is(err, ErrInvalidTagFilter) {
+		t.Errorf("TagFilterFromEnv() error = %v, want ErrInvalidTagFilter", err)
+	}
+
+	t.Setenv(TagsEnvVar, "")
+	f, err = TagFilterFromEnv()
+	if err != nil || !f.IsEmpty() {
+		t.Errorf("TagFilterFromEnv() = %v, %v; want empty filter", f, err)
+	}
+}